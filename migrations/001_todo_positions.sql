@@ -0,0 +1,26 @@
+-- Upgrades a database created from an earlier db.schema; new databases get
+-- all of this from db.schema itself. Run the scripts in order, each of them
+-- once, in one transaction:
+--
+--     psql -1 -f migrations/001_todo_positions.sql todos
+--
+-- Existing todos keep their order by id: every user's list gets evenly spaced
+-- keys of up to four digits, the way lib.PositionsBetween rebalances a list.
+
+ALTER TABLE users_todos ADD COLUMN position VARCHAR(255) COLLATE "C" NOT NULL DEFAULT '';
+
+WITH keys AS (
+    SELECT user_id, todo_id,
+        ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY todo_id) * 14776336 / (COUNT(*) OVER (PARTITION BY user_id) + 1) AS value,
+        '0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz' AS digits
+    FROM users_todos
+)
+UPDATE users_todos SET position = RTRIM(
+    SUBSTR(keys.digits, (keys.value / 238328 % 62 + 1)::INT, 1) ||
+    SUBSTR(keys.digits, (keys.value / 3844 % 62 + 1)::INT, 1) ||
+    SUBSTR(keys.digits, (keys.value / 62 % 62 + 1)::INT, 1) ||
+    SUBSTR(keys.digits, (keys.value % 62 + 1)::INT, 1), '0')
+FROM keys
+WHERE users_todos.user_id = keys.user_id AND users_todos.todo_id = keys.todo_id;
+
+CREATE INDEX users_todos_position_idx ON users_todos (user_id, position);
@@ -0,0 +1,5 @@
+-- Adds Markdown notes to todos. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/002_todo_notes.sql todos
+
+ALTER TABLE todos ADD COLUMN notes TEXT NOT NULL DEFAULT '';
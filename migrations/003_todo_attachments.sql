@@ -0,0 +1,16 @@
+-- Adds todo attachments. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/003_todo_attachments.sql todos
+
+CREATE TABLE todo_attachments (
+    id SERIAL PRIMARY KEY,
+    todo_id INT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX todo_attachments_todo_id_idx ON todo_attachments (todo_id);
@@ -0,0 +1,28 @@
+-- Adds todo comments and the activity timeline. Run it once, in one
+-- transaction:
+--
+--     psql -1 -f migrations/004_todo_comments.sql todos
+
+CREATE TABLE todo_comments (
+    id SERIAL PRIMARY KEY,
+    todo_id INT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    edited_at TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX todo_comments_todo_id_idx ON todo_comments (todo_id, created_at);
+
+CREATE TABLE todo_changes (
+    id SERIAL PRIMARY KEY,
+    todo_id INT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    field VARCHAR(50) NOT NULL,
+    old_value TEXT NOT NULL,
+    new_value TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX todo_changes_todo_id_idx ON todo_changes (todo_id, created_at);
@@ -0,0 +1,8 @@
+-- Adds roles to the users a todo is shared with. Run it once, in one
+-- transaction:
+--
+--     psql -1 -f migrations/005_todo_sharing.sql todos
+--
+-- Todos were only linked to their owner before, so existing links are owners.
+
+ALTER TABLE users_todos ADD COLUMN role VARCHAR(10) NOT NULL DEFAULT 'owner' CHECK (role IN ('viewer', 'editor', 'owner'));
@@ -0,0 +1,50 @@
+-- Adds workspaces with their members, invitations and projects. Run it once,
+-- in one transaction:
+--
+--     psql -1 -f migrations/006_workspaces.sql todos
+--
+-- Existing todos stay in their owner's personal space.
+
+CREATE TABLE workspaces (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE workspace_members (
+    workspace_id INT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(10) NOT NULL DEFAULT 'viewer' CHECK (role IN ('viewer', 'editor', 'owner')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX workspace_members_user_id_idx ON workspace_members (user_id);
+
+CREATE TABLE workspace_invitations (
+    id SERIAL PRIMARY KEY,
+    workspace_id INT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(10) NOT NULL CHECK (role IN ('viewer', 'editor', 'owner')),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    invited_by INT REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE projects (
+    id SERIAL PRIMARY KEY,
+    workspace_id INT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX projects_workspace_id_idx ON projects (workspace_id);
+
+ALTER TABLE todos
+    ADD COLUMN workspace_id INT REFERENCES workspaces(id) ON DELETE CASCADE,
+    ADD COLUMN project_id INT REFERENCES projects(id) ON DELETE SET NULL;
+
+CREATE INDEX todos_workspace_id_idx ON todos (workspace_id);
+CREATE INDEX todos_project_id_idx ON todos (project_id);
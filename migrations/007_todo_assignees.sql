@@ -0,0 +1,13 @@
+-- Adds todo assignees. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/007_todo_assignees.sql todos
+
+CREATE TABLE todo_assignees (
+    todo_id INT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    assigned_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (todo_id, user_id)
+);
+
+CREATE INDEX todo_assignees_user_id_idx ON todo_assignees (user_id);
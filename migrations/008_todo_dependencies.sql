@@ -0,0 +1,13 @@
+-- Adds todo dependencies. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/008_todo_dependencies.sql todos
+
+CREATE TABLE todo_dependencies (
+    todo_id INT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    blocked_by_id INT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (todo_id, blocked_by_id),
+    CHECK (todo_id <> blocked_by_id)
+);
+
+CREATE INDEX todo_dependencies_blocked_by_id_idx ON todo_dependencies (blocked_by_id);
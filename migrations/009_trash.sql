@@ -0,0 +1,7 @@
+-- Adds the trash for deleted todos. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/009_trash.sql todos
+
+ALTER TABLE todos ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX todos_deleted_at_idx ON todos (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,18 @@
+-- Adds todo revisions. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/010_todo_revisions.sql todos
+--
+-- Todos changed before it ran have no history; their first revision is the
+-- next change.
+
+CREATE TABLE todo_revisions (
+    id SERIAL PRIMARY KEY,
+    todo_id INT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore')),
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX todo_revisions_todo_id_idx ON todo_revisions (todo_id, id);
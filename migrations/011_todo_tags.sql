@@ -0,0 +1,11 @@
+-- Adds todo tags. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/011_todo_tags.sql todos
+
+CREATE TABLE todo_tags (
+    todo_id INT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    tag VARCHAR(50) NOT NULL,
+    PRIMARY KEY (todo_id, tag)
+);
+
+CREATE INDEX todo_tags_tag_idx ON todo_tags (tag);
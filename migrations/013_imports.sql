@@ -0,0 +1,21 @@
+-- Adds todo priorities and imports. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/013_imports.sql todos
+
+ALTER TABLE todos ADD COLUMN priority SMALLINT NOT NULL DEFAULT 0 CHECK (priority BETWEEN 0 AND 4);
+
+CREATE TABLE imports (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id INT REFERENCES workspaces(id) ON DELETE CASCADE,
+    format VARCHAR(10) NOT NULL,
+    file_name VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(10) NOT NULL CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    created INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    errors JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
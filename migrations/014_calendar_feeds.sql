@@ -0,0 +1,12 @@
+-- Adds iCalendar feed subscriptions. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/014_calendar_feeds.sql todos
+
+CREATE TABLE calendar_feeds (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id INT REFERENCES workspaces(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX calendar_feeds_user_workspace_idx ON calendar_feeds (user_id, COALESCE(workspace_id, 0));
@@ -0,0 +1,63 @@
+-- Adds CalDAV sync with app passwords. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/015_caldav.sql todos
+--
+-- Changes are recorded from now on; a client syncing for the first time
+-- lists every todo anyway.
+
+CREATE TABLE app_passwords (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    password_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP
+);
+
+CREATE INDEX app_passwords_user_id_idx ON app_passwords (user_id);
+
+CREATE TABLE caldav_resources (
+    todo_id INT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    uid VARCHAR(255) NOT NULL
+);
+
+CREATE SEQUENCE todo_sync_seq;
+
+CREATE TABLE todo_sync (
+    todo_id INT PRIMARY KEY,
+    sync_id BIGINT NOT NULL
+);
+
+CREATE INDEX todo_sync_sync_id_idx ON todo_sync (sync_id);
+
+CREATE OR REPLACE FUNCTION record_todo_sync()
+RETURNS TRIGGER AS $$
+DECLARE
+    changed JSONB;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := to_jsonb(OLD);
+    ELSE
+        changed := to_jsonb(NEW);
+    END IF;
+    INSERT INTO todo_sync (todo_id, sync_id) VALUES ((changed ->> TG_ARGV[0])::INT, nextval('todo_sync_seq'))
+    ON CONFLICT (todo_id) DO UPDATE SET sync_id = EXCLUDED.sync_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER todos_sync
+AFTER INSERT OR UPDATE OR DELETE ON todos
+FOR EACH ROW
+EXECUTE FUNCTION record_todo_sync('id');
+
+CREATE TRIGGER todo_tags_sync
+AFTER INSERT OR DELETE ON todo_tags
+FOR EACH ROW
+EXECUTE FUNCTION record_todo_sync('todo_id');
+
+CREATE TRIGGER users_todos_sync
+AFTER INSERT OR DELETE ON users_todos
+FOR EACH ROW
+EXECUTE FUNCTION record_todo_sync('todo_id');
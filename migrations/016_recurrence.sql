@@ -0,0 +1,5 @@
+-- Adds recurrence rules to todos. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/016_recurrence.sql todos
+
+ALTER TABLE todos ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,14 @@
+-- Adds saved filters. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/018_saved_filters.sql todos
+
+CREATE TABLE saved_filters (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    query TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX saved_filters_user_id_idx ON saved_filters (user_id);
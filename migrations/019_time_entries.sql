@@ -0,0 +1,16 @@
+-- Adds time tracking. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/019_time_entries.sql todos
+
+CREATE TABLE time_entries (
+    id SERIAL PRIMARY KEY,
+    todo_id INT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ CHECK (ended_at >= started_at),
+    note VARCHAR(1000) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX time_entries_todo_id_idx ON time_entries (todo_id);
+CREATE INDEX time_entries_user_id_started_at_idx ON time_entries (user_id, started_at);
+CREATE UNIQUE INDEX time_entries_running_idx ON time_entries (user_id) WHERE ended_at IS NULL;
@@ -0,0 +1,28 @@
+-- Adds completed_at to todos for completion statistics. Run it once, in one
+-- transaction:
+--
+--     psql -1 -f migrations/020_completed_at.sql todos
+--
+-- Todos completed before it ran take their last update as completion time.
+
+ALTER TABLE todos ADD COLUMN completed_at TIMESTAMPTZ;
+UPDATE todos SET completed_at = updated_at WHERE completed;
+
+CREATE INDEX todos_completed_at_idx ON todos (completed_at) WHERE completed_at IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION update_completed_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NOT NEW.completed THEN
+        NEW.completed_at = NULL;
+    ELSIF TG_OP = 'INSERT' OR NOT OLD.completed THEN
+        NEW.completed_at = COALESCE(NEW.completed_at, CURRENT_TIMESTAMP);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER set_completed_at
+BEFORE INSERT OR UPDATE OF completed ON todos
+FOR EACH ROW
+EXECUTE FUNCTION update_completed_at_column();
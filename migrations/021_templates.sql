@@ -0,0 +1,13 @@
+-- Adds todo templates. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/021_templates.sql todos
+
+CREATE TABLE templates (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    items JSONB NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX templates_user_id_idx ON templates (user_id);
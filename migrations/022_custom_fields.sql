@@ -0,0 +1,34 @@
+-- Adds custom fields to the todos of projects. Run it once, in one
+-- transaction:
+--
+--     psql -1 -f migrations/022_custom_fields.sql todos
+
+CREATE TABLE custom_fields (
+    id SERIAL PRIMARY KEY,
+    project_id INT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    key VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('text', 'number', 'date', 'select', 'multi_select', 'url')),
+    options TEXT[] NOT NULL DEFAULT '{}',
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (project_id, key)
+);
+
+ALTER TABLE todos ADD COLUMN custom_fields JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX todos_custom_fields_idx ON todos USING GIN (custom_fields jsonb_path_ops);
+
+CREATE OR REPLACE FUNCTION clear_custom_fields_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.custom_fields = '{}';
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER clear_custom_fields
+BEFORE UPDATE OF project_id ON todos
+FOR EACH ROW
+WHEN (OLD.project_id IS DISTINCT FROM NEW.project_id)
+EXECUTE FUNCTION clear_custom_fields_column();
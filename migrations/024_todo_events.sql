@@ -0,0 +1,16 @@
+-- Adds the todo event log behind the change streams. Run it once, in one
+-- transaction:
+--
+--     psql -1 -f migrations/024_todo_events.sql todos
+
+CREATE TABLE todo_events (
+    id BIGSERIAL PRIMARY KEY,
+    todo_id INT NOT NULL,
+    workspace_id INT,
+    type VARCHAR(20) NOT NULL,
+    todo JSONB NOT NULL,
+    user_ids INT[] NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX todo_events_created_at_idx ON todo_events (created_at);
@@ -0,0 +1,52 @@
+-- Records the projects and users of todo changes for CalDAV sync and takes
+-- sync ids from transaction ids. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/025_todo_sync_scopes.sql todos
+--
+-- Todos changed before it ran get the projects and users they have now. Sync
+-- tokens issued before it ran are no longer accepted, so clients sync again
+-- from scratch.
+
+ALTER TABLE todo_sync
+    ADD COLUMN project_ids INT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN user_ids INT[] NOT NULL DEFAULT '{}';
+
+UPDATE todo_sync SET sync_id = txid_current(),
+    project_ids = ARRAY(SELECT CASE WHEN t.workspace_id IS NULL THEN 0 ELSE t.project_id END FROM todos t WHERE t.id = todo_sync.todo_id AND (t.workspace_id IS NULL OR t.project_id IS NOT NULL)),
+    user_ids = ARRAY(SELECT ut.user_id FROM users_todos ut WHERE ut.todo_id = todo_sync.todo_id);
+
+CREATE OR REPLACE FUNCTION record_todo_sync()
+RETURNS TRIGGER AS $$
+DECLARE
+    changed JSONB;
+    version JSONB;
+    placed_in INT[] := '{}';
+    shared_with INT[] := '{}';
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := to_jsonb(OLD);
+    ELSE
+        changed := to_jsonb(NEW);
+    END IF;
+    FOREACH version IN ARRAY ARRAY[to_jsonb(OLD), to_jsonb(NEW)] LOOP
+        CONTINUE WHEN version IS NULL;
+        IF TG_TABLE_NAME = 'todos' THEN
+            IF version ->> 'workspace_id' IS NULL THEN
+                placed_in := placed_in || 0;
+            ELSIF version ->> 'project_id' IS NOT NULL THEN
+                placed_in := placed_in || (version ->> 'project_id')::INT;
+            END IF;
+        ELSIF TG_TABLE_NAME = 'users_todos' THEN
+            shared_with := shared_with || (version ->> 'user_id')::INT;
+        END IF;
+    END LOOP;
+    INSERT INTO todo_sync (todo_id, sync_id, project_ids, user_ids)
+    VALUES ((changed ->> TG_ARGV[0])::INT, txid_current(), placed_in, shared_with)
+    ON CONFLICT (todo_id) DO UPDATE SET sync_id = EXCLUDED.sync_id,
+        project_ids = ARRAY(SELECT DISTINCT UNNEST(todo_sync.project_ids || EXCLUDED.project_ids)),
+        user_ids = ARRAY(SELECT DISTINCT UNNEST(todo_sync.user_ids || EXCLUDED.user_ids));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP SEQUENCE todo_sync_seq;
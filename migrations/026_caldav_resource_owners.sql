@@ -0,0 +1,21 @@
+-- Makes the names CalDAV clients give todos unique per user instead of across
+-- all users. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/026_caldav_resource_owners.sql todos
+--
+-- Names go to an owner of their todo. The names of purged todos, whose owners
+-- are gone, are dropped, and names of the form {id}.ics, which always name
+-- the todo of that id now, become the default name of their todo.
+
+ALTER TABLE caldav_resources ADD COLUMN user_id INT REFERENCES users(id) ON DELETE CASCADE;
+
+UPDATE caldav_resources SET user_id = (SELECT MIN(ut.user_id) FROM users_todos ut WHERE ut.todo_id = caldav_resources.todo_id AND ut.role = 'owner');
+
+DELETE FROM caldav_resources WHERE user_id IS NULL;
+
+UPDATE caldav_resources SET name = todo_id || '.ics' WHERE name ~ '^[0-9]+\.ics$';
+
+ALTER TABLE caldav_resources
+    ALTER COLUMN user_id SET NOT NULL,
+    DROP CONSTRAINT caldav_resources_name_key,
+    ADD UNIQUE (user_id, name);
@@ -0,0 +1,22 @@
+-- Moves list positions out of users_todos into their own table. Run it once,
+-- in one transaction:
+--
+--     psql -1 -f migrations/027_todo_positions_per_user.sql todos
+--
+-- Members of a workspace can then order project todos they have no
+-- users_todos row for. Existing positions are kept as they are.
+
+CREATE TABLE todo_positions (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    todo_id INT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    position VARCHAR(255) COLLATE "C" NOT NULL,
+    PRIMARY KEY (user_id, todo_id)
+);
+
+INSERT INTO todo_positions (user_id, todo_id, position)
+SELECT user_id, todo_id, position FROM users_todos;
+
+CREATE INDEX todo_positions_position_idx ON todo_positions (user_id, position);
+
+DROP INDEX users_todos_position_idx;
+ALTER TABLE users_todos DROP COLUMN position;
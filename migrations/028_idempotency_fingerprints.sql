@@ -0,0 +1,6 @@
+-- Stores the fingerprint of an idempotent request with its response, as the
+-- body is hashed while the request runs. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/028_idempotency_fingerprints.sql todos
+
+ALTER TABLE idempotency_keys ALTER COLUMN fingerprint DROP NOT NULL;
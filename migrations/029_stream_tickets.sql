@@ -0,0 +1,12 @@
+-- Adds the single-use tickets that open event streams in place of a JWT in
+-- the URL. Run it once, in one transaction:
+--
+--     psql -1 -f migrations/029_stream_tickets.sql todos
+
+CREATE TABLE stream_tickets (
+    token_hash CHAR(64) PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX stream_tickets_expires_at_idx ON stream_tickets (expires_at);
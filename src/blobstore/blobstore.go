@@ -0,0 +1,44 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps the contents of uploaded files. Keys are slash separated paths
+// chosen by the caller.
+type Store interface {
+	// Put writes the contents of r under key. size is the number of bytes r
+	// will yield, or -1 when it is not known up front.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get returns length bytes of the blob starting at offset. A negative
+	// length reads to the end of the blob.
+	Get(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var store Store
+
+func GetStore() Store {
+	return store
+}
+
+func InitStore(s Store) {
+	store = s
+}
+
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStore(t *testing.T) {
+	store := &LocalStore{Root: t.TempDir()}
+	ctx := context.Background()
+
+	err := store.Put(ctx, "todos/1/abc", strings.NewReader("hello world"), -1)
+	assert.NoError(t, err)
+
+	type testCase struct {
+		name     string
+		offset   int64
+		length   int64
+		expected string
+	}
+	tests := []testCase{
+		{name: "Whole blob", offset: 0, length: -1, expected: "hello world"},
+		{name: "Prefix", offset: 0, length: 5, expected: "hello"},
+		{name: "Middle", offset: 6, length: 3, expected: "wor"},
+		{name: "Suffix", offset: 6, length: -1, expected: "world"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reader, err := store.Get(ctx, "todos/1/abc", tc.offset, tc.length)
+			assert.NoError(t, err)
+			defer reader.Close()
+			data, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(data))
+		})
+	}
+
+	assert.NoError(t, store.Delete(ctx, "todos/1/abc"))
+	_, err = store.Get(ctx, "todos/1/abc", 0, -1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, store.Delete(ctx, "todos/1/abc"), ErrNotFound)
+}
+
+func TestLocalStoreRejectsUnsafeKeys(t *testing.T) {
+	store := &LocalStore{Root: t.TempDir()}
+	for _, key := range []string{"", "/etc/passwd", "../outside", "todos/../../outside", "todos//1"} {
+		err := store.Put(context.Background(), key, strings.NewReader("x"), 1)
+		assert.Error(t, err, key)
+	}
+}
@@ -0,0 +1,204 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/src/models"
+)
+
+// csvHeader names the columns like the fields the CSV import reads.
+var csvHeader = []string{"task_name", "completed", "due_date", "notes", "priority", "tags"}
+
+type csvEncoder struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func newCSVEncoder(w io.Writer) Encoder {
+	return &csvEncoder{writer: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) Encode(todo *models.Todo) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	dueDate := ""
+	switch {
+	case todo.DueAllDay:
+		dueDate = todo.DueDate.Format("2006-01-02")
+	case !todo.DueDate.IsZero():
+		dueDate = todo.DueDate.UTC().Format(time.RFC3339)
+	}
+	return e.writer.Write([]string{
+		todo.TaskName,
+		strconv.FormatBool(todo.Completed),
+		dueDate,
+		todo.Notes,
+		strconv.Itoa(todo.Priority),
+		strings.Join(todo.Tags, ","),
+	})
+}
+
+func (e *csvEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.headerWritten {
+		return nil
+	}
+	e.headerWritten = true
+	return e.writer.Write(csvHeader)
+}
+
+// jsonEncoder writes a JSON array like GET /todos returns, one todo per
+// line.
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func newJSONEncoder(w io.Writer) Encoder {
+	return &jsonEncoder{w: w}
+}
+
+func (e *jsonEncoder) Encode(todo *models.Todo) error {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+	separator := ",\n"
+	if e.count == 0 {
+		separator = "[\n"
+	}
+	e.count++
+	_, err = fmt.Fprintf(e.w, "%s%s", separator, data)
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	if e.count == 0 {
+		_, err := io.WriteString(e.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n]\n")
+	return err
+}
+
+type ndjsonEncoder struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONEncoder(w io.Writer) Encoder {
+	return &ndjsonEncoder{encoder: json.NewEncoder(w)}
+}
+
+func (e *ndjsonEncoder) Encode(todo *models.Todo) error {
+	return e.encoder.Encode(todo)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// markdownEncoder writes a checklist. Notes follow their item, indented so
+// they stay part of it.
+type markdownEncoder struct {
+	w io.Writer
+}
+
+func newMarkdownEncoder(w io.Writer) Encoder {
+	return &markdownEncoder{w: w}
+}
+
+func (e *markdownEncoder) Encode(todo *models.Todo) error {
+	var line strings.Builder
+	line.WriteString("- [ ] ")
+	if todo.Completed {
+		line.Reset()
+		line.WriteString("- [x] ")
+	}
+	line.WriteString(singleLine(todo.TaskName))
+	if !todo.DueDate.IsZero() {
+		line.WriteString(" (due " + todo.DueDate.UTC().Format("2006-01-02") + ")")
+	}
+	for _, tag := range todo.Tags {
+		line.WriteString(" #" + tag)
+	}
+	line.WriteString("\n")
+	if notes := strings.TrimSpace(todo.Notes); notes != "" {
+		for _, note := range strings.Split(notes, "\n") {
+			line.WriteString(strings.TrimRight("  "+note, " ") + "\n")
+		}
+	}
+	_, err := io.WriteString(e.w, line.String())
+	return err
+}
+
+func (e *markdownEncoder) Close() error {
+	return nil
+}
+
+// todoTxtEncoder writes the todo.txt format the todo.txt import reads. Tags
+// are written as projects and notes are left out.
+type todoTxtEncoder struct {
+	w io.Writer
+}
+
+func newTodoTxtEncoder(w io.Writer) Encoder {
+	return &todoTxtEncoder{w: w}
+}
+
+func (e *todoTxtEncoder) Encode(todo *models.Todo) error {
+	words := []string{}
+	priority := todoTxtPriority(todo.Priority)
+	if todo.Completed {
+		words = append(words, "x")
+	} else if priority != "" {
+		words = append(words, "("+priority+")")
+	}
+	words = append(words, singleLine(todo.TaskName))
+	for _, tag := range todo.Tags {
+		words = append(words, "+"+tag)
+	}
+	if !todo.DueDate.IsZero() {
+		words = append(words, "due:"+todo.DueDate.UTC().Format("2006-01-02"))
+	}
+	// Completed tasks lose their leading priority, so it is kept as a key.
+	if todo.Completed && priority != "" {
+		words = append(words, "pri:"+priority)
+	}
+	_, err := io.WriteString(e.w, strings.Join(words, " ")+"\n")
+	return err
+}
+
+func (e *todoTxtEncoder) Close() error {
+	return nil
+}
+
+func todoTxtPriority(priority int) string {
+	switch priority {
+	case 4:
+		return "A"
+	case 3:
+		return "B"
+	case 2:
+		return "C"
+	case 1:
+		return "D"
+	}
+	return ""
+}
+
+func singleLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,54 @@
+// Package exporter writes todos in the formats they can be downloaded in.
+// Encoders write one todo at a time so exports never have to be held in
+// memory as a whole. Time reports are written as CSV by WriteTimeReportCSV.
+package exporter
+
+import (
+	"errors"
+	"io"
+	"todo-list/src/models"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Encoder writes todos to an export.
+type Encoder interface {
+	Encode(todo *models.Todo) error
+	// Close writes whatever the format needs after the last todo. It does
+	// not close the underlying writer.
+	Close() error
+}
+
+type format struct {
+	contentType string
+	extension   string
+	newEncoder  func(w io.Writer) Encoder
+}
+
+var formats = map[string]format{
+	models.ExportCSV:      {"text/csv; charset=utf-8", "csv", newCSVEncoder},
+	models.ExportJSON:     {"application/json", "json", newJSONEncoder},
+	models.ExportNDJSON:   {"application/x-ndjson", "ndjson", newNDJSONEncoder},
+	models.ExportMarkdown: {"text/markdown; charset=utf-8", "md", newMarkdownEncoder},
+	models.ExportTodoTxt:  {"text/plain; charset=utf-8", "txt", newTodoTxtEncoder},
+	models.ExportICal:     {"text/calendar; charset=utf-8", "ics", newICalEncoder},
+}
+
+// NewEncoder returns an encoder writing the given format to w.
+func NewEncoder(exportFormat string, w io.Writer) (Encoder, error) {
+	f, ok := formats[exportFormat]
+	if !ok {
+		return nil, ErrUnknownFormat
+	}
+	return f.newEncoder(w), nil
+}
+
+// ContentType returns the media type of a format, or "" for unknown formats.
+func ContentType(exportFormat string) string {
+	return formats[exportFormat].contentType
+}
+
+// Extension returns the file name extension of a format, without the dot.
+func Extension(exportFormat string) string {
+	return formats[exportFormat].extension
+}
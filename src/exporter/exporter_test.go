@@ -0,0 +1,198 @@
+package exporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/importer"
+	"todo-list/src/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var exportedTodos = []*models.Todo{
+	{ID: 1, TaskName: "Buy milk", DueDate: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), DueAllDay: true, Notes: "2 liters\nOat is fine", Priority: 2, Tags: []string{"shopping", "home"}, Role: "owner"},
+	{ID: 2, TaskName: "File taxes", Completed: true, Priority: 4, Role: "owner"},
+	{ID: 3, TaskName: "Water plants", Role: "owner"},
+}
+
+func export(t *testing.T, format string, todos []*models.Todo) string {
+	var out bytes.Buffer
+	encoder, err := NewEncoder(format, &out)
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	for _, todo := range todos {
+		if err := encoder.Encode(todo); err != nil {
+			t.Fatalf("Failed to encode todo: %v", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		t.Fatalf("Failed to close encoder: %v", err)
+	}
+	return out.String()
+}
+
+func TestEncoders(t *testing.T) {
+	type testCase struct {
+		format   string
+		todos    []*models.Todo
+		expected string
+	}
+
+	tests := []testCase{
+		{
+			format: models.ExportCSV,
+			todos:  exportedTodos,
+			expected: "task_name,completed,due_date,notes,priority,tags\n" +
+				"Buy milk,false,2024-12-01,\"2 liters\nOat is fine\",2,\"shopping,home\"\n" +
+				"File taxes,true,,,4,\n" +
+				"Water plants,false,,,0,\n",
+		},
+		{
+			format:   models.ExportCSV,
+			expected: "task_name,completed,due_date,notes,priority,tags\n",
+		},
+		{
+			format:   models.ExportJSON,
+			todos:    exportedTodos[2:],
+			expected: "[\n{\"id\":3,\"task_name\":\"Water plants\",\"completed\":false,\"due_date\":\"0001-01-01T00:00:00Z\",\"created_at\":\"0001-01-01T00:00:00Z\",\"updated_at\":\"0001-01-01T00:00:00Z\",\"role\":\"owner\",\"notes\":\"\",\"blocked\":false}\n]\n",
+		},
+		{
+			format:   models.ExportJSON,
+			expected: "[]\n",
+		},
+		{
+			format: models.ExportMarkdown,
+			todos:  exportedTodos,
+			expected: "- [ ] Buy milk (due 2024-12-01) #shopping #home\n" +
+				"  2 liters\n" +
+				"  Oat is fine\n" +
+				"- [x] File taxes\n" +
+				"- [ ] Water plants\n",
+		},
+		{
+			format: models.ExportTodoTxt,
+			todos:  exportedTodos,
+			expected: "(C) Buy milk +shopping +home due:2024-12-01\n" +
+				"x File taxes pri:A\n" +
+				"Water plants\n",
+		},
+		{
+			format: models.ExportICal,
+			todos: []*models.Todo{
+				{ID: 1, TaskName: "Buy milk, eggs", DueDate: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), DueAllDay: true, UpdatedAt: time.Date(2024, 11, 30, 8, 0, 0, 0, time.UTC), Notes: "A rather long note; long enough that the line has to be folded\nover two lines", Priority: 2, Tags: []string{"shopping", "home"}},
+				{ID: 2, TaskName: "File taxes", Completed: true, UpdatedAt: time.Date(2024, 11, 30, 8, 0, 0, 0, time.UTC)},
+			},
+			expected: "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//todo-list//todos//EN\r\nCALSCALE:GREGORIAN\r\nX-WR-CALNAME:Todos\r\n" +
+				"BEGIN:VTODO\r\nUID:todo-1@todo-list\r\nDTSTAMP:20241130T080000Z\r\nLAST-MODIFIED:20241130T080000Z\r\n" +
+				"SUMMARY:Buy milk\\, eggs\r\n" +
+				"DESCRIPTION:A rather long note\\; long enough that the line has to be folded\r\n \\nover two lines\r\n" +
+				"DUE;VALUE=DATE:20241201\r\nSTATUS:NEEDS-ACTION\r\nPRIORITY:5\r\nCATEGORIES:shopping,home\r\nEND:VTODO\r\n" +
+				"END:VCALENDAR\r\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			assert.Equal(t, tc.expected, export(t, tc.format, tc.todos))
+		})
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := NewEncoder("xlsx", &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+	assert.Equal(t, "", ContentType("xlsx"))
+	assert.Equal(t, "text/csv; charset=utf-8", ContentType(models.ExportCSV))
+	assert.Equal(t, "md", Extension(models.ExportMarkdown))
+}
+
+func TestICalResource(t *testing.T) {
+	resource := string(ICalResource(&models.Todo{ID: 7, TaskName: "Someday", UpdatedAt: time.Date(2024, 11, 30, 8, 0, 0, 0, time.UTC)}, "abc-123"))
+	assert.Equal(t, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//todo-list//todos//EN\r\nCALSCALE:GREGORIAN\r\nX-WR-CALNAME:Todos\r\n"+
+		"BEGIN:VTODO\r\nUID:abc-123\r\nDTSTAMP:20241130T080000Z\r\nLAST-MODIFIED:20241130T080000Z\r\nSUMMARY:Someday\r\nSTATUS:NEEDS-ACTION\r\nEND:VTODO\r\n"+
+		"END:VCALENDAR\r\n", resource)
+}
+
+func TestICalEvents(t *testing.T) {
+	var out bytes.Buffer
+	encoder := NewICalEncoder(&out, true)
+	assert.NoError(t, encoder.Encode(&models.Todo{ID: 7, TaskName: "Call mom", DueDate: time.Date(2024, 12, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600)), UpdatedAt: time.Date(2024, 11, 30, 8, 0, 0, 0, time.UTC)}))
+	assert.NoError(t, encoder.Encode(&models.Todo{ID: 8, TaskName: "Vacation", DueDate: time.Date(2024, 12, 23, 0, 0, 0, 0, time.UTC), DueAllDay: true, UpdatedAt: time.Date(2024, 11, 30, 8, 0, 0, 0, time.UTC)}))
+	assert.NoError(t, encoder.Close())
+	assert.Contains(t, out.String(), "BEGIN:VEVENT\r\nUID:todo-7@todo-list\r\nDTSTAMP:20241130T080000Z\r\nLAST-MODIFIED:20241130T080000Z\r\nSUMMARY:Call mom\r\nDTSTART:20241201T083000Z\r\nTRANSP:TRANSPARENT\r\nEND:VEVENT\r\n")
+	assert.Contains(t, out.String(), "SUMMARY:Vacation\r\nDTSTART;VALUE=DATE:20241223\r\nTRANSP:TRANSPARENT\r\n")
+	for _, line := range strings.Split(out.String(), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+}
+
+// TestRoundTrip imports every export that has an import format of the same
+// name and expects the user editable fields back.
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []string{models.ExportCSV, models.ExportJSON, models.ExportNDJSON, models.ExportTodoTxt} {
+		t.Run(format, func(t *testing.T) {
+			rows, err := importer.Parse(format, bytes.NewBufferString(export(t, format, exportedTodos)), nil)
+			assert.NoError(t, err)
+			if !assert.Len(t, rows, len(exportedTodos)) {
+				return
+			}
+			for i, row := range rows {
+				assert.Empty(t, row.Errors)
+				want := exportedTodos[i]
+				got := row.Todo
+				assert.Equal(t, want.TaskName, got.TaskName)
+				assert.Equal(t, want.Completed, got.Completed)
+				assert.True(t, want.DueDate.Equal(got.DueDate), "due date %v, want %v", got.DueDate, want.DueDate)
+				assert.Equal(t, want.DueAllDay, got.DueAllDay)
+				assert.Equal(t, want.Priority, got.Priority)
+				assert.Equal(t, want.Tags, got.Tags)
+				// todo.txt has no room for notes.
+				if format != models.ExportTodoTxt {
+					assert.Equal(t, want.Notes, got.Notes)
+				}
+			}
+		})
+	}
+}
+
+func TestICalRoundTrip(t *testing.T) {
+	todos := []*models.Todo{
+		{ID: 1, TaskName: "Buy milk, eggs; bread", DueDate: time.Date(2024, 12, 1, 9, 30, 0, 0, time.UTC), Notes: "Ünïcode notes " + strings.Repeat("é", 60) + "\nsecond line", Priority: 4, Tags: []string{"shopping", "a,b"}, Recurrence: "FREQ=WEEKLY;BYDAY=SA"},
+		{ID: 2, TaskName: "File taxes", Completed: true, DueDate: time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC), DueAllDay: true, Priority: 1},
+	}
+	rows, err := importer.Parse(models.ImportICal, bytes.NewBufferString(export(t, models.ExportICal, todos)), nil)
+	assert.NoError(t, err)
+	if !assert.Len(t, rows, len(todos)) {
+		return
+	}
+	for i, row := range rows {
+		assert.Empty(t, row.Errors)
+		assert.Equal(t, todos[i].TaskName, row.Todo.TaskName)
+		assert.Equal(t, todos[i].Notes, row.Todo.Notes)
+		assert.Equal(t, todos[i].Completed, row.Todo.Completed)
+		assert.True(t, todos[i].DueDate.Equal(row.Todo.DueDate))
+		assert.Equal(t, todos[i].DueAllDay, row.Todo.DueAllDay)
+		assert.Equal(t, todos[i].Priority, row.Todo.Priority)
+		assert.Equal(t, todos[i].Tags, row.Todo.Tags)
+		assert.Equal(t, todos[i].Recurrence, row.Todo.Recurrence)
+	}
+}
+
+func TestWriteTimeReportCSV(t *testing.T) {
+	report := &models.TimeReport{
+		GroupBy: []string{models.TimeByDay, models.TimeByProject, models.TimeByTag},
+		Rows: []*models.TimeReportRow{
+			{Day: "2024-11-04", Seconds: 1800},
+			{Day: "2024-11-04", ProjectID: 2, ProjectName: "Website, v2", Tag: "billable", Seconds: 4500},
+		},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, WriteTimeReportCSV(&out, report))
+	assert.Equal(t, "day,project_id,project,tag,seconds,hours\n"+
+		"2024-11-04,,,,1800,0.50\n"+
+		"2024-11-04,2,\"Website, v2\",billable,4500,1.25\n", out.String())
+}
@@ -0,0 +1,193 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"todo-list/src/models"
+)
+
+// icalTime is the UTC form of an iCalendar DATE-TIME. Times are always
+// written in UTC, which every client shows in its own time zone, so the
+// calendar needs no VTIMEZONE components.
+const icalTime = "20060102T150405Z"
+
+// icalDate is an iCalendar DATE, which all-day todos are due on.
+const icalDate = "20060102"
+
+// icalEncoder writes an RFC 5545 calendar with one VTODO, or VEVENT when
+// asEvents is set, per todo with a due date. Todos without one are skipped.
+type icalEncoder struct {
+	w        io.Writer
+	asEvents bool
+	// uid overrides the UID made from the todo id. When it is set todos
+	// without a due date are written too.
+	uid           string
+	headerWritten bool
+	err           error
+}
+
+// NewICalEncoder returns an encoder writing an iCalendar file. Calendar apps
+// that do not show tasks can subscribe with asEvents to see due dates as
+// events instead.
+func NewICalEncoder(w io.Writer, asEvents bool) Encoder {
+	return &icalEncoder{w: w, asEvents: asEvents}
+}
+
+func newICalEncoder(w io.Writer) Encoder {
+	return NewICalEncoder(w, false)
+}
+
+// ICalResource writes a todo as a calendar of its own with the given UID, the
+// way CalDAV serves todos. Todos without a due date have no DUE.
+func ICalResource(todo *models.Todo, uid string) []byte {
+	var out bytes.Buffer
+	encoder := &icalEncoder{w: &out, uid: uid}
+	encoder.Encode(todo)
+	encoder.Close()
+	return out.Bytes()
+}
+
+func (e *icalEncoder) Encode(todo *models.Todo) error {
+	e.writeHeader()
+	if todo.DueDate.IsZero() && e.uid == "" {
+		return e.err
+	}
+	uid := e.uid
+	if uid == "" {
+		uid = fmt.Sprintf("todo-%d@todo-list", todo.ID)
+	}
+
+	stamp := todo.UpdatedAt
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	component := "VTODO"
+	if e.asEvents {
+		component = "VEVENT"
+	}
+	e.writeLine("BEGIN:" + component)
+	e.writeLine("UID:" + escapeICalText(uid))
+	e.writeLine("DTSTAMP:" + stamp.UTC().Format(icalTime))
+	if !todo.CreatedAt.IsZero() {
+		e.writeLine("CREATED:" + todo.CreatedAt.UTC().Format(icalTime))
+	}
+	if !todo.UpdatedAt.IsZero() {
+		e.writeLine("LAST-MODIFIED:" + todo.UpdatedAt.UTC().Format(icalTime))
+	}
+	e.writeLine("SUMMARY:" + escapeICalText(todo.TaskName))
+	if todo.Notes != "" {
+		e.writeLine("DESCRIPTION:" + escapeICalText(todo.Notes))
+	}
+	if e.asEvents {
+		// Transparent events leave free/busy alone.
+		e.writeDate("DTSTART", todo)
+		e.writeLine("TRANSP:TRANSPARENT")
+	} else {
+		if !todo.DueDate.IsZero() {
+			// Repeating todos need a DTSTART for their RRULE to count from.
+			if todo.Recurrence != "" {
+				e.writeDate("DTSTART", todo)
+			}
+			e.writeDate("DUE", todo)
+		}
+		if todo.Completed {
+			e.writeLine("STATUS:COMPLETED")
+		} else {
+			e.writeLine("STATUS:NEEDS-ACTION")
+		}
+	}
+	if todo.Recurrence != "" && !todo.DueDate.IsZero() {
+		e.writeLine("RRULE:" + todo.Recurrence)
+	}
+	if priority := icalPriority(todo.Priority); priority != 0 {
+		e.writeLine(fmt.Sprintf("PRIORITY:%d", priority))
+	}
+	if len(todo.Tags) > 0 {
+		tags := make([]string, len(todo.Tags))
+		for i, tag := range todo.Tags {
+			tags[i] = escapeICalText(tag)
+		}
+		e.writeLine("CATEGORIES:" + strings.Join(tags, ","))
+	}
+	e.writeLine("END:" + component)
+	return e.err
+}
+
+func (e *icalEncoder) Close() error {
+	e.writeHeader()
+	e.writeLine("END:VCALENDAR")
+	return e.err
+}
+
+func (e *icalEncoder) writeHeader() {
+	if e.headerWritten {
+		return
+	}
+	e.headerWritten = true
+	e.writeLine("BEGIN:VCALENDAR")
+	e.writeLine("VERSION:2.0")
+	e.writeLine("PRODID:-//todo-list//todos//EN")
+	e.writeLine("CALSCALE:GREGORIAN")
+	e.writeLine("X-WR-CALNAME:Todos")
+}
+
+// writeDate writes the todo's due date as property: a DATE for all-day todos
+// and a UTC DATE-TIME otherwise.
+func (e *icalEncoder) writeDate(property string, todo *models.Todo) {
+	if todo.DueAllDay {
+		e.writeLine(property + ";VALUE=DATE:" + todo.DueDate.Format(icalDate))
+		return
+	}
+	e.writeLine(property + ":" + todo.DueDate.UTC().Format(icalTime))
+}
+
+// writeLine ends a content line with CRLF, folding it so no line is longer
+// than 75 octets without splitting a UTF-8 sequence.
+func (e *icalEncoder) writeLine(line string) {
+	if e.err != nil {
+		return
+	}
+	var folded strings.Builder
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		folded.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// Continuation lines start with the folding space.
+		limit = 74
+	}
+	folded.WriteString(line + "\r\n")
+	_, e.err = io.WriteString(e.w, folded.String())
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+var icalTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeICalText(value string) string {
+	return icalTextEscaper.Replace(value)
+}
+
+// icalPriority maps priorities onto the iCalendar scale, where 1 is the
+// highest, 9 the lowest and 0 undefined.
+func icalPriority(priority int) int {
+	switch priority {
+	case 4:
+		return 1
+	case 3:
+		return 3
+	case 2:
+		return 5
+	case 1:
+		return 9
+	}
+	return 0
+}
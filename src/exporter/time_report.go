@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"todo-list/src/models"
+)
+
+// WriteTimeReportCSV writes a time report with a column per grouping, in the
+// order it was grouped by, followed by the seconds and the hours rounded to
+// two decimals for invoices.
+func WriteTimeReportCSV(w io.Writer, report *models.TimeReport) error {
+	writer := csv.NewWriter(w)
+	header := []string{}
+	for _, group := range report.GroupBy {
+		switch group {
+		case models.TimeByProject:
+			header = append(header, "project_id", "project")
+		default:
+			header = append(header, group)
+		}
+	}
+	if err := writer.Write(append(header, "seconds", "hours")); err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		record := []string{}
+		for _, group := range report.GroupBy {
+			switch group {
+			case models.TimeByProject:
+				projectID := ""
+				if row.ProjectID != 0 {
+					projectID = strconv.Itoa(row.ProjectID)
+				}
+				record = append(record, projectID, row.ProjectName)
+			case models.TimeByTag:
+				record = append(record, row.Tag)
+			case models.TimeByDay:
+				record = append(record, row.Day)
+			}
+		}
+		record = append(record, strconv.FormatInt(row.Seconds, 10), strconv.FormatFloat(float64(row.Seconds)/3600, 'f', 2, 64))
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
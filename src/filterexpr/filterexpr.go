@@ -0,0 +1,408 @@
+// Package filterexpr reads filter expressions such as
+//
+//	due < +3d and tag:work and not completed
+//
+// into a models.FilterNode. Terms are joined with "and" and "or", which must
+// be written out, negated with "not" and grouped with parentheses; "and"
+// binds tighter than "or". A term is either a flag (completed, blocked,
+// recurring, overdue), a tag written as #work, a quoted string to look for in
+// the name and notes, or a field compared with a value:
+//
+//	tag:work  project:12  project:none  assignee:3  assignee:none  text:"call bob"
+//	priority >= 2  due <= tomorrow  due:none  created > -1w  updated < -12h
+//
+// Dates are today, tomorrow, yesterday, an ISO date like 2024-05-01 or an
+// offset from today in days, weeks, months or years (+3d, -1w, +2m, +1y).
+// Offsets in hours (+2h) and "now" name an instant rather than a day.
+// Parsing only builds the tree; stores compile it into SQL.
+package filterexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+)
+
+// maxDepth bounds how deeply parentheses and "not" may nest.
+const maxDepth = 32
+
+// Error points at the token a filter could not be read at. Start and End are
+// character offsets into the text.
+type Error struct {
+	Message string `json:"error"`
+	Token   string `json:"token"`
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at character %d", e.Message, e.Start+1)
+}
+
+func errorAt(t token, runes []rune, format string, args ...any) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...), Token: t.source(runes), Start: t.start, End: t.end}
+}
+
+// parser walks the tokens of one expression.
+type parser struct {
+	runes  []rune
+	tokens []token
+	pos    int
+	depth  int
+	now    time.Time
+}
+
+// Parse reads a filter expression. now is the current time in the user's
+// timezone; relative dates are resolved against it, and days start at its
+// midnight. Errors are of type *Error.
+func Parse(text string, now time.Time) (models.FilterNode, error) {
+	runes := []rune(text)
+	tokens, err := lex(runes)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{runes: runes, tokens: tokens, now: now}
+	if p.peek().kind == tokenEOF {
+		return nil, p.errorAt(p.peek(), "The filter is empty")
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokenClose {
+		return nil, p.errorAt(t, "Unexpected \")\"")
+	} else if t.kind != tokenEOF {
+		return nil, p.errorAt(t, "Expected \"and\" or \"or\" before %q", t.source(p.runes))
+	}
+	return node, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorAt(t token, format string, args ...any) *Error {
+	return errorAt(t, p.runes, format, args...)
+}
+
+func (p *parser) parseOr() (models.FilterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = models.FilterOr{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (models.FilterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = models.FilterAnd{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (models.FilterNode, error) {
+	t := p.peek()
+	if !t.is("not") && t.kind != tokenOpen {
+		return p.parseTerm()
+	}
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, p.errorAt(t, "The filter is nested too deeply")
+	}
+
+	p.next()
+	if t.kind == tokenWord {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return models.FilterNot{Operand: operand}, nil
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenClose {
+		if p.peek().kind == tokenEOF {
+			return nil, p.errorAt(t, "Missing \")\"")
+		}
+		return nil, p.errorAt(p.peek(), "Expected \"and\", \"or\" or \")\" before %q", p.peek().source(p.runes))
+	}
+	p.next()
+	return node, nil
+}
+
+// flags are the terms that stand on their own.
+var flags = map[string]models.FilterFlag{
+	"completed": {Name: models.FilterCompleted},
+	"blocked":   {Name: models.FilterBlocked},
+	"recurring": {Name: models.FilterRecurring},
+}
+
+func (p *parser) parseTerm() (models.FilterNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return models.FilterText{Text: t.text}, nil
+	case tokenWord:
+	case tokenEOF:
+		return nil, p.errorAt(t, "Expected a filter at the end")
+	default:
+		return nil, p.errorAt(t, "Expected a filter before %q", t.source(p.runes))
+	}
+
+	name := strings.ToLower(t.text)
+	if name == "and" || name == "or" {
+		return nil, p.errorAt(t, "Expected a filter before %q", t.text)
+	}
+	if strings.HasPrefix(name, "#") && len(name) > 1 {
+		return models.FilterTag{Tag: name[1:]}, nil
+	}
+	if flag, ok := flags[name]; ok {
+		return flag, nil
+	}
+	switch name {
+	case "overdue":
+		overdue := models.FilterDate{Field: models.FilterDue, Range: models.DueRange{To: p.now}}
+		return models.FilterAnd{Left: overdue, Right: models.FilterNot{Operand: flags["completed"]}}, nil
+	case "tag", "project", "assignee", "text":
+		return p.parseMatch(t, name)
+	case "priority":
+		return p.parsePriority(t)
+	case "due", "created", "updated":
+		return p.parseDate(t, name)
+	}
+	return nil, p.errorAt(t, "Unknown field %q", t.text)
+}
+
+// operator reads the operator after field, which must be one of allowed.
+func (p *parser) operator(field token, allowed ...string) (token, error) {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		if len(allowed) == len(operators) {
+			return t, p.errorAt(field, "Expected a comparison such as \"<\" after %q", field.text)
+		}
+		return t, p.errorAt(field, "Expected \":\" after %q", field.text)
+	}
+	for _, operator := range allowed {
+		if t.text == operator {
+			p.next()
+			return t, nil
+		}
+	}
+	return t, p.errorAt(t, "%q can not be compared with %q", field.text, t.text)
+}
+
+// value reads the value after operator.
+func (p *parser) value(operator token) (token, error) {
+	t := p.peek()
+	if t.kind != tokenWord && t.kind != tokenString || t.is("and") || t.is("or") {
+		return t, p.errorAt(operator, "Expected a value after %q", operator.text)
+	}
+	return p.next(), nil
+}
+
+// negate wraps node in a FilterNot for the != operator.
+func negate(operator token, node models.FilterNode) models.FilterNode {
+	if operator.text == "!=" {
+		return models.FilterNot{Operand: node}
+	}
+	return node
+}
+
+// parseMatch reads tag, project, assignee and text, which match with ":" or
+// "=" and not at all with "!=".
+func (p *parser) parseMatch(field token, name string) (models.FilterNode, error) {
+	operator, err := p.operator(field, ":", "=", "!=")
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.value(operator)
+	if err != nil {
+		return nil, err
+	}
+
+	var node models.FilterNode
+	switch name {
+	case "tag":
+		tag := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(value.text), "#"))
+		if tag == "" {
+			return nil, p.errorAt(value, "Expected a tag")
+		}
+		node = models.FilterTag{Tag: tag}
+	case "text":
+		node = models.FilterText{Text: value.text}
+	case "project", "assignee":
+		id := 0
+		if !value.is("none") {
+			id, err = strconv.Atoi(value.text)
+			if err != nil || id <= 0 {
+				return nil, p.errorAt(value, "Expected %s id or \"none\"", map[string]string{"project": "a project", "assignee": "a user"}[name])
+			}
+		}
+		if name == "project" {
+			node = models.FilterProject{ProjectID: id}
+		} else {
+			node = models.FilterAssignee{UserID: id}
+		}
+	}
+	return negate(operator, node), nil
+}
+
+func (p *parser) parsePriority(field token) (models.FilterNode, error) {
+	operator, err := p.operator(field, operators...)
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.value(operator)
+	if err != nil {
+		return nil, err
+	}
+	priority, err := strconv.Atoi(value.text)
+	if err != nil || priority < 0 || priority > 4 {
+		return nil, p.errorAt(value, "Priority must be a number from 0 to 4")
+	}
+	op := operator.text
+	if op == ":" {
+		op = "="
+	}
+	return models.FilterPriority{Operator: op, Value: priority}, nil
+}
+
+// parseDate reads a comparison of a date field. Comparing with a day covers
+// all of it, so "due = today" is the range from midnight to midnight and
+// "due <= tomorrow" ends at the midnight after tomorrow. "due:none" matches
+// todos without a due date.
+func (p *parser) parseDate(field token, name string) (models.FilterNode, error) {
+	operator, err := p.operator(field, operators...)
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.value(operator)
+	if err != nil {
+		return nil, err
+	}
+	op := operator.text
+	if op == ":" {
+		op = "="
+	}
+
+	if value.is("none") {
+		if name != "due" {
+			return nil, p.errorAt(value, "Only due dates can be \"none\"")
+		}
+		if op != "=" && op != "!=" {
+			return nil, p.errorAt(operator, "Use \":\" or \"!=\" to compare with \"none\"")
+		}
+		return negate(operator, models.FilterFlag{Name: models.FilterNoDueDate}), nil
+	}
+
+	day, instant, ok := p.readDate(value.text)
+	if !ok {
+		return nil, p.errorAt(value, "Invalid date %q, use a date such as 2024-05-01, today or +3d", value.text)
+	}
+	date := func(r models.DueRange) models.FilterNode {
+		return models.FilterDate{Field: name, Range: r}
+	}
+	if !instant {
+		next := day.AddDate(0, 0, 1)
+		switch op {
+		case "=":
+			return date(models.DueRange{From: day, To: next}), nil
+		case "!=":
+			return models.FilterOr{Left: date(models.DueRange{To: day}), Right: date(models.DueRange{From: next})}, nil
+		case "<":
+			return date(models.DueRange{To: day}), nil
+		case "<=":
+			return date(models.DueRange{To: next}), nil
+		case ">":
+			return date(models.DueRange{From: next}), nil
+		default:
+			return date(models.DueRange{From: day}), nil
+		}
+	}
+	// Postgres keeps microseconds, so one is the smallest step past an
+	// instant.
+	switch op {
+	case "<":
+		return date(models.DueRange{To: day}), nil
+	case "<=":
+		return date(models.DueRange{To: day.Add(time.Microsecond)}), nil
+	case ">":
+		return date(models.DueRange{From: day.Add(time.Microsecond)}), nil
+	case ">=":
+		return date(models.DueRange{From: day}), nil
+	}
+	return nil, p.errorAt(operator, "Use <, <=, > or >= to compare with a time")
+}
+
+// readDate reads a date value. It returns the start of the day it names, or
+// the instant for "now" and offsets in hours.
+func (p *parser) readDate(text string) (time.Time, bool, bool) {
+	today := lib.StartOfDay(p.now)
+	switch strings.ToLower(text) {
+	case "today":
+		return today, false, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), false, true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), false, true
+	case "now":
+		return p.now, true, true
+	}
+	if date, err := time.ParseInLocation("2006-01-02", text, p.now.Location()); err == nil {
+		return date, false, true
+	}
+
+	offset := strings.ToLower(text)
+	if len(offset) < 2 {
+		return time.Time{}, false, false
+	}
+	unit := offset[len(offset)-1]
+	amount, err := strconv.Atoi(offset[:len(offset)-1])
+	if err != nil || amount > 10000 || amount < -10000 {
+		return time.Time{}, false, false
+	}
+	switch unit {
+	case 'h':
+		return p.now.Add(time.Duration(amount) * time.Hour), true, true
+	case 'd':
+		return today.AddDate(0, 0, amount), false, true
+	case 'w':
+		return today.AddDate(0, 0, 7*amount), false, true
+	case 'm':
+		return today.AddDate(0, amount, 0), false, true
+	case 'y':
+		return today.AddDate(amount, 0, 0), false, true
+	}
+	return time.Time{}, false, false
+}
@@ -0,0 +1,122 @@
+package filterexpr
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+	// A Wednesday afternoon in Berlin.
+	now := time.Date(2024, 11, 27, 15, 4, 5, 0, berlin)
+	day := func(d int) time.Time {
+		return time.Date(2024, 11, d, 0, 0, 0, 0, berlin)
+	}
+	due := func(r models.DueRange) models.FilterNode {
+		return models.FilterDate{Field: models.FilterDue, Range: r}
+	}
+	completed := models.FilterFlag{Name: models.FilterCompleted}
+
+	tests := []struct {
+		text     string
+		expected models.FilterNode
+	}{
+		{
+			text: "due < +3d and tag:work and not completed",
+			expected: models.FilterAnd{
+				Left:  models.FilterAnd{Left: due(models.DueRange{To: day(30)}), Right: models.FilterTag{Tag: "work"}},
+				Right: models.FilterNot{Operand: completed},
+			},
+		},
+		{
+			text: "#home or #errands and priority >= 2",
+			expected: models.FilterOr{
+				Left:  models.FilterTag{Tag: "home"},
+				Right: models.FilterAnd{Left: models.FilterTag{Tag: "errands"}, Right: models.FilterPriority{Operator: ">=", Value: 2}},
+			},
+		},
+		{
+			text: "(#home OR #errands) AND NOT blocked",
+			expected: models.FilterAnd{
+				Left:  models.FilterOr{Left: models.FilterTag{Tag: "home"}, Right: models.FilterTag{Tag: "errands"}},
+				Right: models.FilterNot{Operand: models.FilterFlag{Name: models.FilterBlocked}},
+			},
+		},
+		{text: "due:today", expected: due(models.DueRange{From: day(27), To: day(28)})},
+		{text: "due <= tomorrow", expected: due(models.DueRange{To: day(29)})},
+		{text: "due > yesterday", expected: due(models.DueRange{From: day(27)})},
+		{text: "due >= 2024-12-01", expected: due(models.DueRange{From: time.Date(2024, 12, 1, 0, 0, 0, 0, berlin)})},
+		{text: "due != today", expected: models.FilterOr{Left: due(models.DueRange{To: day(27)}), Right: due(models.DueRange{From: day(28)})}},
+		{text: "due < +2h", expected: due(models.DueRange{To: now.Add(2 * time.Hour)})},
+		{text: "due <= now", expected: due(models.DueRange{To: now.Add(time.Microsecond)})},
+		{text: "due:none", expected: models.FilterFlag{Name: models.FilterNoDueDate}},
+		{text: "due != none", expected: models.FilterNot{Operand: models.FilterFlag{Name: models.FilterNoDueDate}}},
+		{text: "created > -1w", expected: models.FilterDate{Field: models.FilterCreated, Range: models.DueRange{From: day(21)}}},
+		{text: "updated >= -1m", expected: models.FilterDate{Field: models.FilterUpdated, Range: models.DueRange{From: time.Date(2024, 10, 27, 0, 0, 0, 0, berlin)}}},
+		{text: "overdue", expected: models.FilterAnd{Left: due(models.DueRange{To: now}), Right: models.FilterNot{Operand: completed}}},
+		{text: "project:12 and assignee:none", expected: models.FilterAnd{Left: models.FilterProject{ProjectID: 12}, Right: models.FilterAssignee{}}},
+		{text: "project != none", expected: models.FilterNot{Operand: models.FilterProject{}}},
+		{text: `text:"call bob" or "Pay \"rent\""`, expected: models.FilterOr{Left: models.FilterText{Text: "call bob"}, Right: models.FilterText{Text: `Pay "rent"`}}},
+		{text: "tag = #Work", expected: models.FilterTag{Tag: "work"}},
+		{text: "recurring and priority:4", expected: models.FilterAnd{Left: models.FilterFlag{Name: models.FilterRecurring}, Right: models.FilterPriority{Operator: "=", Value: 4}}},
+		{text: "not not completed", expected: models.FilterNot{Operand: models.FilterNot{Operand: completed}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			node, err := Parse(tt.text, now)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			assert.Equal(t, tt.expected, node)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	now := time.Date(2024, 11, 27, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		text     string
+		expected Error
+	}{
+		{text: "", expected: Error{Message: "The filter is empty"}},
+		{text: "   ", expected: Error{Message: "The filter is empty", Start: 3, End: 3}},
+		{text: "dew < today", expected: Error{Message: `Unknown field "dew"`, Token: "dew", End: 3}},
+		{text: "tag:work completed", expected: Error{Message: `Expected "and" or "or" before "completed"`, Token: "completed", Start: 9, End: 18}},
+		{text: "(tag:work or completed", expected: Error{Message: `Missing ")"`, Token: "(", End: 1}},
+		{text: "completed)", expected: Error{Message: `Unexpected ")"`, Token: ")", Start: 9, End: 10}},
+		{text: "completed and", expected: Error{Message: "Expected a filter at the end", Start: 13, End: 13}},
+		{text: "and completed", expected: Error{Message: `Expected a filter before "and"`, Token: "and", End: 3}},
+		{text: "due < someday", expected: Error{Message: `Invalid date "someday", use a date such as 2024-05-01, today or +3d`, Token: "someday", Start: 6, End: 13}},
+		{text: "due = now", expected: Error{Message: "Use <, <=, > or >= to compare with a time", Token: "=", Start: 4, End: 5}},
+		{text: "due", expected: Error{Message: `Expected a comparison such as "<" after "due"`, Token: "due", End: 3}},
+		{text: "tag", expected: Error{Message: `Expected ":" after "tag"`, Token: "tag", End: 3}},
+		{text: "tag < work", expected: Error{Message: `"tag" can not be compared with "<"`, Token: "<", Start: 4, End: 5}},
+		{text: "tag:", expected: Error{Message: `Expected a value after ":"`, Token: ":", Start: 3, End: 4}},
+		{text: "priority > 7", expected: Error{Message: "Priority must be a number from 0 to 4", Token: "7", Start: 11, End: 12}},
+		{text: "project:inbox", expected: Error{Message: `Expected a project id or "none"`, Token: "inbox", Start: 8, End: 13}},
+		{text: "created:none", expected: Error{Message: `Only due dates can be "none"`, Token: "none", Start: 8, End: 12}},
+		{text: `text:"milk`, expected: Error{Message: "Missing closing quote", Token: `"milk`, Start: 5, End: 10}},
+		{text: "priority ! 2", expected: Error{Message: `Unexpected "!", did you mean "!="?`, Token: "!", Start: 9, End: 10}},
+		{text: "tag:café and überfällig", expected: Error{Message: `Unknown field "überfällig"`, Token: "überfällig", Start: 13, End: 23}},
+		{text: strings.Repeat("(", 40) + "completed" + strings.Repeat(")", 40), expected: Error{Message: "The filter is nested too deeply", Token: "(", Start: 32, End: 33}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			_, err := Parse(tt.text, now)
+			parseErr, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("Expected an *Error, got %v", err)
+			}
+			assert.Equal(t, tt.expected, *parseErr)
+		})
+	}
+}
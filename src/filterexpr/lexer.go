@@ -0,0 +1,113 @@
+package filterexpr
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenOpen
+	tokenClose
+)
+
+// token is a token of the text and its character offsets. The text of a
+// quoted string is its unquoted content.
+type token struct {
+	kind  tokenKind
+	text  string
+	start int
+	end   int
+}
+
+// source is how the token was written, for error messages.
+func (t token) source(runes []rune) string {
+	return string(runes[t.start:t.end])
+}
+
+// is reports whether t is the word keyword, in any case.
+func (t token) is(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+// operators lists the comparison operators, two-character ones first.
+var operators = []string{"<=", ">=", "!=", "<", ">", "=", ":"}
+
+// lex splits the text into tokens, ending with a tokenEOF.
+func lex(runes []rune) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "(", start: i, end: i + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")", start: i, end: i + 1})
+			i++
+		case r == '"':
+			t, err := lexString(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+			i = t.end
+		case isOperator(r):
+			operator := lexOperator(runes, i)
+			if operator == "" {
+				return nil, errorAt(token{text: string(r), start: i, end: i + 1}, runes, "Unexpected %q, did you mean \"!=\"?", string(r))
+			}
+			end := i + len([]rune(operator))
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, start: i, end: end})
+			i = end
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !isOperator(runes[i]) && !strings.ContainsRune(`()"`, runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[start:i]), start: start, end: i})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, start: len(runes), end: len(runes)}), nil
+}
+
+func isOperator(r rune) bool {
+	return strings.ContainsRune("<>=!:", r)
+}
+
+func lexOperator(runes []rune, i int) string {
+	for _, operator := range operators {
+		end := i + len(operator)
+		if end <= len(runes) && string(runes[i:end]) == operator {
+			return operator
+		}
+	}
+	return ""
+}
+
+// lexString reads the quoted string starting at i. A backslash escapes the
+// character after it.
+func lexString(runes []rune, start int) (token, error) {
+	var text strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				text.WriteRune(runes[i])
+			}
+		case '"':
+			return token{kind: tokenString, text: text.String(), start: start, end: i + 1}, nil
+		default:
+			text.WriteRune(runes[i])
+		}
+	}
+	return token{}, errorAt(token{start: start, end: len(runes)}, runes, "Missing closing quote")
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateAppPasswordHandler creates a password for signing in to CalDAV with
+// the user's email. The password is only returned here.
+func CreateAppPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	appPassword := models.AppPassword{}
+	err := json.NewDecoder(r.Body).Decode(&appPassword)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+	errors := validations.ValidateAppPassword(&appPassword)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	password, err := newSecretToken()
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Can not create app password"}, http.StatusInternalServerError)
+		return
+	}
+	newAppPassword, err := stores.GetStore().CreateAppPassword(&models.AppPassword{UserID: user.ID, Name: appPassword.Name, Password: password})
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create app password\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	// The secret is only shown here, so the response must not be kept for
+	// idempotent retries or by caches.
+	w.Header().Set("Cache-Control", "no-store")
+	utility.WriteJsonData(w, newAppPassword, http.StatusCreated)
+}
+
+func GetAppPasswordsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	appPasswords, err := stores.GetStore().GetAppPasswords(user.ID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get app passwords\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, appPasswords, http.StatusOK)
+}
+
+// DeleteAppPasswordHandler revokes an app password; clients using it are
+// signed out of CalDAV.
+func DeleteAppPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	appPasswordID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid app password id"}, http.StatusBadRequest)
+		return
+	}
+
+	err = stores.GetStore().DeleteAppPassword(appPasswordID, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "App password not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not delete app password\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, map[string]string{"message": "App password revoked"}, http.StatusOK)
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateAppPasswordHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	createdAt := time.Date(2024, 11, 30, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(mockStore *stores.MockStore)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Create App Password",
+			body: `{"name": "Phone"}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("CreateAppPassword", mock.MatchedBy(func(appPassword *models.AppPassword) bool {
+					return appPassword.UserID == 1 && appPassword.Name == "Phone" && len(appPassword.Password) == 43
+				})).Return(&models.AppPassword{ID: 3, UserID: 1, Name: "Phone", Password: "app-password", CreatedAt: createdAt}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id": 3, "name": "Phone", "password": "app-password", "created_at": "2024-11-30T08:00:00Z"}`,
+		},
+		{
+			name:           "Missing Name",
+			body:           `{"name": ""}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Payload",
+			body:           `{"name":`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error": "Invalid request payload"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tt.mockSetup(mockStore)
+			stores.InitStore(mockStore)
+
+			req, _ := http.NewRequest("POST", "/app-passwords", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+*token)
+			recorder := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/app-passwords", CreateAppPasswordHandler).Methods("POST")
+			r.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, recorder.Body.String())
+			}
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetAppPasswordsHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	createdAt := time.Date(2024, 11, 30, 8, 0, 0, 0, time.UTC)
+
+	mockStore := stores.InitMockStore()
+	mockAuthenticatedUser(mockStore)
+	mockStore.On("GetAppPasswords", 1).Return([]*models.AppPassword{{ID: 3, UserID: 1, Name: "Phone", CreatedAt: createdAt, LastUsedAt: &createdAt}}, nil)
+	stores.InitStore(mockStore)
+
+	req, _ := http.NewRequest("GET", "/app-passwords", nil)
+	req.Header.Set("Authorization", "Bearer "+*token)
+	recorder := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/app-passwords", GetAppPasswordsHandler).Methods("GET")
+	r.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	appPasswords := []map[string]interface{}{}
+	if err := json.NewDecoder(recorder.Body).Decode(&appPasswords); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+	assert.Equal(t, []map[string]interface{}{{"id": float64(3), "name": "Phone", "created_at": "2024-11-30T08:00:00Z", "last_used_at": "2024-11-30T08:00:00Z"}}, appPasswords)
+
+	mockStore.AssertExpectations(t)
+}
+
+func TestDeleteAppPasswordHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	mockStore := stores.InitMockStore()
+	mockAuthenticatedUser(mockStore)
+	mockStore.On("DeleteAppPassword", 3, 1).Return(nil).Once()
+	mockStore.On("DeleteAppPassword", 3, 1).Return(sql.ErrNoRows).Once()
+	stores.InitStore(mockStore)
+
+	r := mux.NewRouter()
+	r.HandleFunc("/app-passwords/{id:[0-9]+}", DeleteAppPasswordHandler).Methods("DELETE")
+
+	req, _ := http.NewRequest("DELETE", "/app-passwords/3", nil)
+	req.Header.Set("Authorization", "Bearer "+*token)
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"message": "App password revoked"}`, recorder.Body.String())
+
+	req, _ = http.NewRequest("DELETE", "/app-passwords/3", nil)
+	req.Header.Set("Authorization", "Bearer "+*token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.JSONEq(t, `{"error": "App password not found"}`, recorder.Body.String())
+
+	mockStore.AssertExpectations(t)
+}
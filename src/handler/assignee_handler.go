@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"todo-list/src/models"
+	"todo-list/src/notifications"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+
+	"github.com/gorilla/mux"
+)
+
+// SetAssigneesHandler replaces the assignees of a todo. Every assignee must
+// be able to see the todo in its workspace.
+func SetAssigneesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	assignment := models.Assignment{}
+	err = json.NewDecoder(r.Body).Decode(&assignment)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	role, err := stores.GetStore().GetTodoRole(todoID, user.ID, workspaceID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Todo not found"}, http.StatusNotFound)
+		return
+	}
+	if !models.RoleAtLeast(role, models.RoleEditor) {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("This action requires the %s role", models.RoleEditor)}, http.StatusForbidden)
+		return
+	}
+
+	if assignment.AssigneeID != 0 {
+		assignment.AssigneeIDs = append(assignment.AssigneeIDs, assignment.AssigneeID)
+	}
+	errors := validations.ValidateAssignment(&assignment)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+	assigneeIDs := uniqueIDs(assignment.AssigneeIDs)
+
+	for _, assigneeID := range assigneeIDs {
+		_, err := stores.GetStore().GetTodoRole(todoID, assigneeID, workspaceID)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"AssigneeIDs": fmt.Sprintf("User %d can not access this todo", assigneeID)}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	changes, err := stores.GetStore().SetAssignees(todoID, assigneeIDs, user.ID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not assign todo\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	for _, assignee := range changes.Assigned {
+		notifications.NotifyAssignment(&notifications.AssignmentEvent{Todo: changes.Todo, Assignee: assignee, AssignedBy: user, Assigned: true})
+	}
+	for _, assignee := range changes.Unassigned {
+		notifications.NotifyAssignment(&notifications.AssignmentEvent{Todo: changes.Todo, Assignee: assignee, AssignedBy: user, Assigned: false})
+	}
+
+	changes.Todo.Role = role
+	prepareTodo(changes.Todo, false)
+	utility.WriteJsonData(w, changes.Todo, http.StatusOK)
+}
+
+// uniqueIDs returns ids sorted with duplicates removed.
+func uniqueIDs(ids []int) []int {
+	sorted := append([]int{}, ids...)
+	sort.Ints(sorted)
+	unique := []int{}
+	for i, id := range sorted {
+		if i == 0 || id != sorted[i-1] {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
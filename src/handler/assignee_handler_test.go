@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/notifications"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+)
+
+func TestSetAssigneesHandler(t *testing.T) {
+	type testCase struct {
+		name           string
+		payload        string
+		expectedBody   interface{}
+		expectedStatus int
+		expectedEvents []string
+		mockReturn     func(*stores.MockStore)
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	bob := &models.User{ID: 2, UserName: "bob", Email: "bob@mail.com"}
+	carol := &models.User{ID: 3, UserName: "carol", Email: "carol@mail.com"}
+
+	tests := []testCase{
+		{
+			name:           "Assign Several Users",
+			payload:        `{"assignee_id": 2, "assignee_ids": [3, 2]}`,
+			expectedBody:   &models.Todo{ID: 5, TaskName: "Ship it", Role: "editor", AssigneeIDs: []int{2, 3}},
+			expectedStatus: http.StatusOK,
+			expectedEvents: []string{"assigned bob", "unassigned carol"},
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("editor", nil)
+				mockStore.On("GetTodoRole", 5, 2, 0).Return("viewer", nil)
+				mockStore.On("GetTodoRole", 5, 3, 0).Return("editor", nil)
+				mockStore.On("SetAssignees", 5, []int{2, 3}, 1).Return(&models.AssigneeChanges{
+					Todo:       &models.Todo{ID: 5, TaskName: "Ship it", AssigneeIDs: []int{2, 3}},
+					Assigned:   []*models.User{bob},
+					Unassigned: []*models.User{carol},
+				}, nil)
+			},
+		},
+		{
+			name:           "Assign User Without Access",
+			payload:        `{"assignee_id": 7}`,
+			expectedBody:   map[string]string{"AssigneeIDs": "User 7 can not access this todo"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+				mockStore.On("GetTodoRole", 5, 7, 0).Return("", sql.ErrNoRows)
+			},
+		},
+		{
+			name:           "Assign Invalid User Id",
+			payload:        `{"assignee_ids": [-1]}`,
+			expectedBody:   map[string]string{"AssigneeIDs[0]": "Must be a valid user id"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+			},
+		},
+		{
+			name:           "Assign As Viewer",
+			payload:        `{"assignee_id": 1}`,
+			expectedBody:   map[string]string{"error": "This action requires the editor role"},
+			expectedStatus: http.StatusForbidden,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+			},
+		},
+	}
+
+	var events []string
+	notifications.OnAssignment(func(event *notifications.AssignmentEvent) {
+		if event.Assigned {
+			events = append(events, "assigned "+event.Assignee.UserName)
+		} else {
+			events = append(events, "unassigned "+event.Assignee.UserName)
+		}
+	})
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tc.mockReturn(mockStore)
+			stores.InitStore(mockStore)
+
+			events = []string{}
+
+			req, err := http.NewRequest("PUT", "/todos/5/assignees", strings.NewReader(tc.payload))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+
+			r := mux.NewRouter()
+			r.HandleFunc("/todos/{id:[0-9]+}/assignees", SetAssigneesHandler).Methods("PUT")
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+
+			decoded := reflect.New(reflect.TypeOf(tc.expectedBody)).Interface()
+			if err := json.NewDecoder(recorder.Body).Decode(decoded); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			if got := reflect.ValueOf(decoded).Elem().Interface(); !reflect.DeepEqual(got, tc.expectedBody) {
+				t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", got, tc.expectedBody)
+			}
+			if tc.expectedEvents != nil && !reflect.DeepEqual(events, tc.expectedEvents) {
+				t.Errorf("Unexpected notifications: got %v want %v", events, tc.expectedEvents)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"bufio"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"todo-list/src/blobstore"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+
+	"github.com/gorilla/mux"
+)
+
+const maxAttachmentSize = 10 << 20
+
+// allowedAttachmentTypes is checked against the type sniffed from the file
+// contents, not the type or extension the client claims.
+var allowedAttachmentTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+	// Office documents (docx, xlsx, ...) are zip archives.
+	"application/zip": true,
+}
+
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func CreateAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleEditor) {
+		return
+	}
+
+	// Leave room for the multipart headers around the file itself.
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Expected a multipart/form-data body"}, http.StatusBadRequest)
+		return
+	}
+
+	var part *multipart.Part
+	for {
+		part, err = reader.NextPart()
+		if err == io.EOF {
+			utility.WriteJsonData(w, map[string]string{"error": "The file field is required"}, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			writeUploadError(w, err)
+			return
+		}
+		if part.FormName() == "file" {
+			break
+		}
+		part.Close()
+	}
+	defer part.Close()
+
+	buffered := bufio.NewReaderSize(part, 512)
+	head, err := buffered.Peek(512)
+	if err != nil && err != io.EOF {
+		writeUploadError(w, err)
+		return
+	}
+	contentType := http.DetectContentType(head)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !allowedAttachmentTypes[mediaType] {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("File type %s is not allowed", mediaType)}, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	storageKey, err := newStorageKey(todoID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Can not store attachment"}, http.StatusInternalServerError)
+		return
+	}
+
+	counter := &countingReader{Reader: io.LimitReader(buffered, maxAttachmentSize+1)}
+	err = blobstore.GetStore().Put(r.Context(), storageKey, counter, -1)
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+	if counter.n > maxAttachmentSize {
+		deleteBlob(r, storageKey)
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("File is larger than %d bytes", maxAttachmentSize)}, http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	attachment, err := stores.GetStore().CreateAttachment(&models.Attachment{
+		TodoID:      todoID,
+		UserID:      user.ID,
+		FileName:    attachmentFileName(part.FileName()),
+		ContentType: contentType,
+		Size:        counter.n,
+		StorageKey:  storageKey,
+	})
+	if err != nil {
+		deleteBlob(r, storageKey)
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not save attachment\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, attachment, http.StatusCreated)
+}
+
+func GetAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleViewer) {
+		return
+	}
+
+	attachments, err := stores.GetStore().GetAttachments(todoID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get attachments\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, attachments, http.StatusOK)
+}
+
+func DownloadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := findAttachment(w, r, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	offset, length, partial, ok := parseRange(r.Header.Get("Range"), attachment.Size)
+	if !ok {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", attachment.Size))
+		utility.WriteJsonData(w, map[string]string{"error": "Requested range not satisfiable"}, http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	blob, err := blobstore.GetStore().Get(r.Context(), attachment.StorageKey, offset, length)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			utility.WriteJsonData(w, map[string]string{"error": "Attachment not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": "Can not read attachment"}, http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if partial {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, attachment.Size))
+		w.WriteHeader(http.StatusPartialContent)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	io.Copy(w, blob)
+}
+
+func DeleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := findAttachment(w, r, models.RoleEditor)
+	if !ok {
+		return
+	}
+
+	err := stores.GetStore().DeleteAttachment(attachment.ID, attachment.TodoID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Can not delete attachment"}, http.StatusInternalServerError)
+		return
+	}
+	deleteBlob(r, attachment.StorageKey)
+
+	utility.WriteJsonData(w, map[string]string{"message": "Attachment deleted successfully. ID: " + strconv.Itoa(attachment.ID)}, http.StatusOK)
+}
+
+// findAttachment loads the attachment named in the URL after checking the
+// requesting user holds minimumRole on its todo. It writes the error response
+// on failure.
+func findAttachment(w http.ResponseWriter, r *http.Request, minimumRole string) (*models.Attachment, bool) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return nil, false
+	}
+	attachmentID, err := strconv.Atoi(vars["attachmentID"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return nil, false
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if !authorizeTodo(w, r, todoID, user.ID, minimumRole) {
+		return nil, false
+	}
+
+	attachment, err := stores.GetStore().GetAttachment(attachmentID, todoID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Attachment not found"}, http.StatusNotFound)
+			return nil, false
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get attachment\n%v", err)}, http.StatusInternalServerError)
+		return nil, false
+	}
+	return attachment, true
+}
+
+// deleteAttachmentBlobs removes the stored files of attachments whose rows
+// are already gone, e.g. after their todo was purged from the trash.
+func deleteAttachmentBlobs(r *http.Request, attachments []*models.Attachment) {
+	for _, attachment := range attachments {
+		deleteBlob(r, attachment.StorageKey)
+	}
+}
+
+func deleteBlob(r *http.Request, storageKey string) {
+	err := blobstore.GetStore().Delete(r.Context(), storageKey)
+	if err != nil && !errors.Is(err, blobstore.ErrNotFound) {
+		log.Printf("Can not delete blob %s: %v", storageKey, err)
+	}
+}
+
+func writeUploadError(w http.ResponseWriter, err error) {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("File is larger than %d bytes", maxAttachmentSize)}, http.StatusRequestEntityTooLarge)
+		return
+	}
+	utility.WriteJsonData(w, map[string]string{"error": "Can not read upload"}, http.StatusBadRequest)
+}
+
+func newStorageKey(todoID int) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("todos/%d/%s", todoID, hex.EncodeToString(random)), nil
+}
+
+func attachmentFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		return "attachment"
+	}
+	return name
+}
+
+// parseRange interprets a single byte range request. Multiple ranges, other
+// units and malformed headers fall back to the whole file, as RFC 9110
+// allows; ok is false only for a well-formed but unsatisfiable range.
+func parseRange(header string, size int64) (offset int64, length int64, partial bool, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, size, false, true
+	}
+	startText, endText, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, size, false, true
+	}
+
+	if startText == "" {
+		suffix, err := strconv.ParseInt(endText, 10, 64)
+		if err != nil || suffix < 0 {
+			return 0, size, false, true
+		}
+		if suffix == 0 || size == 0 {
+			return 0, 0, false, false
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return size - suffix, suffix, true, true
+	}
+
+	start, err := strconv.ParseInt(startText, 10, 64)
+	if err != nil || start < 0 {
+		return 0, size, false, true
+	}
+	end := size - 1
+	if endText != "" {
+		end, err = strconv.ParseInt(endText, 10, 64)
+		if err != nil || end < start {
+			return 0, size, false, true
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	if start >= size {
+		return 0, 0, false, false
+	}
+	return start, end - start + 1, true, true
+}
@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/blobstore"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func attachmentRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/todos/{id:[0-9]+}/attachments", GetAttachmentsHandler).Methods("GET")
+	r.HandleFunc("/todos/{id:[0-9]+}/attachments", CreateAttachmentHandler).Methods("POST")
+	r.HandleFunc("/todos/{id:[0-9]+}/attachments/{attachmentID:[0-9]+}", DownloadAttachmentHandler).Methods("GET")
+	r.HandleFunc("/todos/{id:[0-9]+}/attachments/{attachmentID:[0-9]+}", DeleteAttachmentHandler).Methods("DELETE")
+	return r
+}
+
+func multipartBody(t *testing.T, fileName string, content []byte) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("comment", "ignored")
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write(content)
+	writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+func mockAuthenticatedUser(mockStore *stores.MockStore) {
+	mockStore.On("GetUser", &models.User{
+		Email:    "test@mail.com",
+		Password: "password",
+	}).Return(&models.User{ID: 1, Email: "test@mail.com", Password: "password"}, nil)
+}
+
+func TestCreateAttachmentHandler(t *testing.T) {
+	type testCase struct {
+		name           string
+		fileName       string
+		content        []byte
+		expectedStatus int
+		expectedError  string
+		mockReturn     func(*stores.MockStore)
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	tests := []testCase{
+		{
+			name:           "Upload PNG",
+			fileName:       "screenshot.png",
+			content:        pngHeader,
+			expectedStatus: http.StatusCreated,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("editor", nil)
+				mockStore.On("CreateAttachment", mock.MatchedBy(func(a *models.Attachment) bool {
+					return a.TodoID == 5 && a.UserID == 1 && a.FileName == "screenshot.png" && a.ContentType == "image/png" && a.Size == int64(len(pngHeader)) && strings.HasPrefix(a.StorageKey, "todos/5/")
+				})).Return(&models.Attachment{ID: 7, TodoID: 5, UserID: 1, FileName: "screenshot.png", ContentType: "image/png", Size: int64(len(pngHeader))}, nil)
+			},
+		},
+		{
+			name:           "Disguised HTML",
+			fileName:       "innocent.png",
+			content:        []byte("<html><script>alert(1)</script></html>"),
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedError:  "File type text/html is not allowed",
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("editor", nil)
+			},
+		},
+		{
+			name:           "Too Large",
+			fileName:       "big.txt",
+			content:        bytes.Repeat([]byte("a"), maxAttachmentSize+1),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedError:  "File is larger than 10485760 bytes",
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("editor", nil)
+			},
+		},
+		{
+			name:           "Todo Not Owned",
+			fileName:       "screenshot.png",
+			content:        pngHeader,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Todo not found",
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("", sql.ErrNoRows)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			blobstore.InitStore(&blobstore.LocalStore{Root: root})
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tc.mockReturn(mockStore)
+			stores.InitStore(mockStore)
+
+			body, contentType := multipartBody(t, tc.fileName, tc.content)
+			req, err := http.NewRequest("POST", "/todos/5/attachments", body)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+			req.Header.Set("Content-Type", contentType)
+
+			recorder := httptest.NewRecorder()
+			attachmentRouter().ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+			if tc.expectedError != "" {
+				var decodedErrorBody map[string]string
+				if err := json.NewDecoder(recorder.Body).Decode(&decodedErrorBody); err != nil {
+					t.Fatalf("Failed to decode response body: %v", err)
+				}
+				assert.Equal(t, tc.expectedError, decodedErrorBody["error"])
+				// Nothing may be left behind for rejected uploads.
+				entries, _ := os.ReadDir(filepath.Join(root, "todos", "5"))
+				assert.Empty(t, entries)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDownloadAttachmentHandler(t *testing.T) {
+	type testCase struct {
+		name            string
+		rangeHeader     string
+		expectedStatus  int
+		expectedBody    string
+		expectedRange   string
+		expectedLength  string
+		attachmentFound bool
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	tests := []testCase{
+		{name: "Whole File", expectedStatus: http.StatusOK, expectedBody: "hello world", expectedLength: "11", attachmentFound: true},
+		{name: "Byte Range", rangeHeader: "bytes=0-4", expectedStatus: http.StatusPartialContent, expectedBody: "hello", expectedRange: "bytes 0-4/11", expectedLength: "5", attachmentFound: true},
+		{name: "Open Ended Range", rangeHeader: "bytes=6-", expectedStatus: http.StatusPartialContent, expectedBody: "world", expectedRange: "bytes 6-10/11", expectedLength: "5", attachmentFound: true},
+		{name: "Suffix Range", rangeHeader: "bytes=-3", expectedStatus: http.StatusPartialContent, expectedBody: "rld", expectedRange: "bytes 8-10/11", expectedLength: "3", attachmentFound: true},
+		{name: "Multiple Ranges Fall Back To Whole File", rangeHeader: "bytes=0-1,3-4", expectedStatus: http.StatusOK, expectedBody: "hello world", expectedLength: "11", attachmentFound: true},
+		{name: "Unsatisfiable Range", rangeHeader: "bytes=20-", expectedStatus: http.StatusRequestedRangeNotSatisfiable, expectedRange: "bytes */11", attachmentFound: true},
+		{name: "Missing Attachment", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			blobs := &blobstore.LocalStore{Root: t.TempDir()}
+			blobs.Put(context.Background(), "todos/5/abc", strings.NewReader("hello world"), -1)
+			blobstore.InitStore(blobs)
+
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			mockStore.On("GetTodoRole", 5, 1, 0).Return("editor", nil)
+			if tc.attachmentFound {
+				mockStore.On("GetAttachment", 7, 5).Return(&models.Attachment{ID: 7, TodoID: 5, FileName: "notes.txt", ContentType: "text/plain; charset=utf-8", Size: 11, StorageKey: "todos/5/abc", CreatedAt: time.Now()}, nil)
+			} else {
+				mockStore.On("GetAttachment", 7, 5).Return((*models.Attachment)(nil), sql.ErrNoRows)
+			}
+			stores.InitStore(mockStore)
+
+			req, err := http.NewRequest("GET", "/todos/5/attachments/7", nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+			if tc.rangeHeader != "" {
+				req.Header.Set("Range", tc.rangeHeader)
+			}
+
+			recorder := httptest.NewRecorder()
+			attachmentRouter().ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+			if tc.expectedBody != "" {
+				body, _ := io.ReadAll(recorder.Body)
+				assert.Equal(t, tc.expectedBody, string(body))
+				assert.Equal(t, tc.expectedLength, recorder.Header().Get("Content-Length"))
+				assert.Equal(t, "attachment; filename=notes.txt", recorder.Header().Get("Content-Disposition"))
+				assert.Equal(t, "bytes", recorder.Header().Get("Accept-Ranges"))
+			}
+			assert.Equal(t, tc.expectedRange, recorder.Header().Get("Content-Range"))
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteAttachmentHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	blobs := &blobstore.LocalStore{Root: t.TempDir()}
+	blobs.Put(context.Background(), "todos/5/abc", strings.NewReader("hello world"), -1)
+	blobstore.InitStore(blobs)
+
+	mockStore := stores.InitMockStore()
+	mockAuthenticatedUser(mockStore)
+	mockStore.On("GetTodoRole", 5, 1, 0).Return("editor", nil)
+	mockStore.On("GetAttachment", 7, 5).Return(&models.Attachment{ID: 7, TodoID: 5, StorageKey: "todos/5/abc"}, nil)
+	mockStore.On("DeleteAttachment", 7, 5).Return(nil)
+	stores.InitStore(mockStore)
+
+	req, err := http.NewRequest("DELETE", "/todos/5/attachments/7", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+*token)
+
+	recorder := httptest.NewRecorder()
+	attachmentRouter().ServeHTTP(recorder, req)
+
+	if status := recorder.Code; status != http.StatusOK {
+		t.Errorf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	_, err = blobs.Get(context.Background(), "todos/5/abc", 0, -1)
+	assert.ErrorIs(t, err, blobstore.ErrNotFound)
+
+	mockStore.AssertExpectations(t)
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+
+	"github.com/gorilla/mux"
+)
+
+// workspaceHeader selects the active workspace for routes that are not mounted
+// under the /workspaces/{workspaceID} prefix.
+const workspaceHeader = "X-Workspace-ID"
+
+// authenticateUser resolves the user behind the request's bearer token. When
+// that fails it writes the error response and returns false.
+func authenticateUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user, message := requestUser(r)
+	if user == nil {
+		utility.WriteJsonData(w, map[string]string{"error": message}, http.StatusUnauthorized)
+		return nil, false
+	}
+	return user, true
+}
+
+// requestUser resolves the user behind the request's bearer token without
+// writing a response. When that fails it returns nil and the reason.
+func requestUser(r *http.Request) (*models.User, string) {
+	jwtToken, err := utility.ExtractTokenFromHeader(r)
+	if err != nil {
+		return nil, "Invalid token"
+	}
+	claims, err := lib.ValidateJWT(jwtToken)
+	if err != nil {
+		return nil, "Invalid token"
+	}
+	user := &models.User{}
+	user.Email = claims["email"].(string)
+	user.Password = claims["password"].(string)
+	user, err = stores.GetStore().GetUser(user)
+
+	if err != nil {
+		return nil, "User not found"
+	}
+	return user, ""
+}
+
+// activeWorkspace returns the workspace the request works in, taken from the
+// path prefix or the X-Workspace-ID header, after checking the user belongs to
+// it. Without either the personal space, 0, is used. When the workspace can
+// not be used it writes the error response and returns false.
+func activeWorkspace(w http.ResponseWriter, r *http.Request, userID int) (int, bool) {
+	value := mux.Vars(r)["workspaceID"]
+	header := r.Header.Get(workspaceHeader)
+	if value == "" {
+		value = header
+	} else if header != "" && header != value {
+		utility.WriteJsonData(w, map[string]string{"error": workspaceHeader + " does not match the workspace in the path"}, http.StatusBadRequest)
+		return 0, false
+	}
+	if value == "" {
+		return 0, true
+	}
+
+	workspaceID, err := strconv.Atoi(value)
+	if err != nil || workspaceID <= 0 {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid workspace id"}, http.StatusBadRequest)
+		return 0, false
+	}
+	_, err = stores.GetStore().GetWorkspace(workspaceID, userID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Workspace not found"}, http.StatusNotFound)
+		return 0, false
+	}
+	return workspaceID, true
+}
+
+// authorizeWorkspace checks that the user holds at least minimumRole in the
+// workspace. When they do not it writes the error response and returns false.
+func authorizeWorkspace(w http.ResponseWriter, workspaceID int, userID int, minimumRole string) (*models.Workspace, bool) {
+	workspace, err := stores.GetStore().GetWorkspace(workspaceID, userID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Workspace not found"}, http.StatusNotFound)
+		return nil, false
+	}
+	if !models.RoleAtLeast(workspace.Role, minimumRole) {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("This action requires the %s role", minimumRole)}, http.StatusForbidden)
+		return nil, false
+	}
+	return workspace, true
+}
+
+// authorizeTodo checks that the user holds at least minimumRole on the todo in
+// the request's active workspace. When they do not it writes the error
+// response and returns false.
+func authorizeTodo(w http.ResponseWriter, r *http.Request, todoID int, userID int, minimumRole string) bool {
+	workspaceID, ok := activeWorkspace(w, r, userID)
+	if !ok {
+		return false
+	}
+	role, err := stores.GetStore().GetTodoRole(todoID, userID, workspaceID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Todo not found"}, http.StatusNotFound)
+		return false
+	}
+	if !models.RoleAtLeast(role, minimumRole) {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("This action requires the %s role", minimumRole)}, http.StatusForbidden)
+		return false
+	}
+	return true
+}
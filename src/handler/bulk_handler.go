@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+)
+
+// BulkTodosHandler applies a list of operations to many todos in one
+// transaction and reports the outcome per todo. A request that was rolled
+// back as a whole answers 409 with the result of every item tried.
+func BulkTodosHandler(w http.ResponseWriter, r *http.Request) {
+	request := models.BulkRequest{}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	for i := range request.Operations {
+		request.Operations[i].Tags = normalizeTags(request.Operations[i].Tags)
+	}
+	errors := validations.ValidateBulk(&request)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	for i := range request.Operations {
+		operation := &request.Operations[i]
+		if operation.Query == "" {
+			continue
+		}
+		expression, ok := filterExpression(w, operation.Query, user)
+		if !ok {
+			return
+		}
+		if operation.Filter == nil {
+			operation.Filter = &models.TodoFilter{}
+		}
+		operation.Filter.Expression = expression
+	}
+
+	for i, operation := range request.Operations {
+		if operation.Op != models.BulkMove || *operation.ProjectID == 0 {
+			continue
+		}
+		if workspaceID == 0 {
+			utility.WriteJsonData(w, map[string]string{fmt.Sprintf("Operations[%d].ProjectID", i): "Project not found"}, http.StatusBadRequest)
+			return
+		}
+		if _, ok := authorizeWorkspace(w, workspaceID, user.ID, models.RoleEditor); !ok {
+			return
+		}
+		_, err = stores.GetStore().GetProject(*operation.ProjectID, workspaceID)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{fmt.Sprintf("Operations[%d].ProjectID", i): "Project not found"}, http.StatusBadRequest)
+			return
+		}
+		// Moved todos lose their custom field values, so they could never
+		// hold the required ones.
+		fields, err := stores.GetStore().GetCustomFields(*operation.ProjectID)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get custom fields\n%v", err)}, http.StatusInternalServerError)
+			return
+		}
+		for _, field := range fields {
+			if field.Required {
+				utility.WriteJsonData(w, map[string]string{fmt.Sprintf("Operations[%d].ProjectID", i): "Todos can not be moved into a project with required custom fields"}, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	response, err := stores.GetStore().BulkUpdateTodos(&request, user.ID, workspaceID)
+	if err == stores.ErrBulkTooLarge {
+		utility.WriteJsonData(w, map[string]string{"error": "A bulk request can change at most 500 todos"}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not apply bulk operations\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if !response.Committed {
+		status = http.StatusConflict
+	}
+	utility.WriteJsonData(w, response, status)
+}
+
+// normalizeTags lower cases tags and strips a leading "#", dropping the ones
+// left empty.
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBulkTodosHandler(t *testing.T) {
+	type testCase struct {
+		name           string
+		url            string
+		payload        string
+		expectedBody   interface{}
+		expectedStatus int
+		mockReturn     func(*stores.MockStore)
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	projectID := 4
+
+	tests := []testCase{
+		{
+			name:    "Complete Todos",
+			url:     "/todos/bulk",
+			payload: `{"operations": [{"op": "complete", "ids": [1, 2]}]}`,
+			expectedBody: models.BulkResponse{Committed: true, Results: []*models.BulkResult{
+				{Operation: 0, TodoID: 1, Status: "ok"},
+				{Operation: 0, TodoID: 2, Status: "ok"},
+			}},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("BulkUpdateTodos", &models.BulkRequest{Operations: []models.BulkOperation{{Op: "complete", IDs: []int{1, 2}}}}, 1, 0).
+					Return(&models.BulkResponse{Committed: true, Results: []*models.BulkResult{
+						{Operation: 0, TodoID: 1, Status: "ok"},
+						{Operation: 0, TodoID: 2, Status: "ok"},
+					}}, nil)
+			},
+		},
+		{
+			name:    "Tags Are Normalized",
+			url:     "/todos/bulk",
+			payload: `{"operations": [{"op": "tag", "filter": {"completed": false, "tag": "inbox"}, "tags": ["#Work", " urgent "]}], "mode": "best_effort"}`,
+			expectedBody: models.BulkResponse{Committed: true, Results: []*models.BulkResult{
+				{Operation: 0, TodoID: 3, Status: "ok"},
+			}},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("BulkUpdateTodos", mock.MatchedBy(func(request *models.BulkRequest) bool {
+					return reflect.DeepEqual(request.Operations[0].Tags, []string{"work", "urgent"}) && request.Mode == "best_effort"
+				}), 1, 0).Return(&models.BulkResponse{Committed: true, Results: []*models.BulkResult{
+					{Operation: 0, TodoID: 3, Status: "ok"},
+				}}, nil)
+			},
+		},
+		{
+			name:    "All Or Nothing Rolled Back",
+			url:     "/todos/bulk",
+			payload: `{"operations": [{"op": "delete", "ids": [1, 2]}]}`,
+			expectedBody: models.BulkResponse{Committed: false, Results: []*models.BulkResult{
+				{Operation: 0, TodoID: 1, Status: "rolled_back"},
+				{Operation: 0, TodoID: 2, Status: "failed", Error: "this action requires the owner role"},
+			}},
+			expectedStatus: http.StatusConflict,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("BulkUpdateTodos", mock.Anything, 1, 0).Return(&models.BulkResponse{Committed: false, Results: []*models.BulkResult{
+					{Operation: 0, TodoID: 1, Status: "rolled_back"},
+					{Operation: 0, TodoID: 2, Status: "failed", Error: "this action requires the owner role"},
+				}}, nil)
+			},
+		},
+		{
+			name:           "Move To Project In Workspace",
+			url:            "/workspaces/3/todos/bulk",
+			payload:        `{"operations": [{"op": "move", "ids": [5], "project_id": 4}]}`,
+			expectedBody:   models.BulkResponse{Committed: true, Results: []*models.BulkResult{{Operation: 0, TodoID: 5, Status: "ok"}}},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 3, 1).Return(&models.Workspace{ID: 3, Name: "Team", Role: "editor"}, nil)
+				mockStore.On("GetProject", 4, 3).Return(&models.Project{ID: 4, WorkspaceID: 3, Name: "Launch"}, nil)
+				mockStore.On("GetCustomFields", 4).Return([]*models.CustomField{{Key: "points", Type: models.FieldNumber}}, nil)
+				mockStore.On("BulkUpdateTodos", &models.BulkRequest{Operations: []models.BulkOperation{{Op: "move", IDs: []int{5}, ProjectID: &projectID}}}, 1, 3).
+					Return(&models.BulkResponse{Committed: true, Results: []*models.BulkResult{{Operation: 0, TodoID: 5, Status: "ok"}}}, nil)
+			},
+		},
+		{
+			name:           "Move To Project With Required Field",
+			url:            "/workspaces/3/todos/bulk",
+			payload:        `{"operations": [{"op": "move", "ids": [5], "project_id": 4}]}`,
+			expectedBody:   map[string]string{"Operations[0].ProjectID": "Todos can not be moved into a project with required custom fields"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 3, 1).Return(&models.Workspace{ID: 3, Name: "Team", Role: "editor"}, nil)
+				mockStore.On("GetProject", 4, 3).Return(&models.Project{ID: 4, WorkspaceID: 3, Name: "Launch"}, nil)
+				mockStore.On("GetCustomFields", 4).Return([]*models.CustomField{{Key: "points", Type: models.FieldNumber, Required: true}}, nil)
+			},
+		},
+		{
+			name:           "Move To Unknown Project",
+			url:            "/todos/bulk",
+			payload:        `{"operations": [{"op": "move", "ids": [5], "project_id": 4}]}`,
+			expectedBody:   map[string]string{"Operations[0].ProjectID": "Project not found"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn:     func(mockStore *stores.MockStore) {},
+		},
+		{
+			name:    "Filter Expression",
+			url:     "/todos/bulk",
+			payload: `{"operations": [{"op": "complete", "query": "tag:work"}]}`,
+			expectedBody: models.BulkResponse{Committed: true, Results: []*models.BulkResult{
+				{Operation: 0, TodoID: 3, Status: "ok"},
+			}},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("BulkUpdateTodos", &models.BulkRequest{Operations: []models.BulkOperation{
+					{Op: "complete", Query: "tag:work", Filter: &models.TodoFilter{Expression: models.FilterTag{Tag: "work"}}},
+				}}, 1, 0).Return(&models.BulkResponse{Committed: true, Results: []*models.BulkResult{
+					{Operation: 0, TodoID: 3, Status: "ok"},
+				}}, nil)
+			},
+		},
+		{
+			name:           "Invalid Filter Expression",
+			url:            "/todos/bulk",
+			payload:        `{"operations": [{"op": "complete", "query": "tag:work and ("}]}`,
+			expectedBody:   map[string]interface{}{"error": "Expected a filter at the end", "token": "", "start": float64(14), "end": float64(14)},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn:     func(mockStore *stores.MockStore) {},
+		},
+		{
+			name:    "Invalid Operations",
+			url:     "/todos/bulk",
+			payload: `{"operations": [{"op": "archive", "ids": [1]}, {"op": "tag", "ids": [1], "filter": {}}]}`,
+			expectedBody: map[string]string{
+				"Operations[0].Op":   "Must be one of: complete, uncomplete, delete, move, tag, untag, set_due_date",
+				"Operations[1]":      "Either ids or a filter or query is required",
+				"Operations[1].Tags": "This field is required",
+			},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn:     func(mockStore *stores.MockStore) {},
+		},
+		{
+			name:           "Too Many Todos",
+			url:            "/todos/bulk",
+			payload:        `{"operations": [{"op": "complete", "filter": {}}]}`,
+			expectedBody:   map[string]string{"error": "A bulk request can change at most 500 todos"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("BulkUpdateTodos", mock.Anything, 1, 0).Return((*models.BulkResponse)(nil), stores.ErrBulkTooLarge)
+			},
+		},
+		{
+			name:           "Store Error",
+			url:            "/todos/bulk",
+			payload:        `{"operations": [{"op": "uncomplete", "ids": [1]}]}`,
+			expectedBody:   map[string]string{"error": "Can not apply bulk operations\nconnection lost"},
+			expectedStatus: http.StatusInternalServerError,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("BulkUpdateTodos", mock.Anything, 1, 0).Return((*models.BulkResponse)(nil), errors.New("connection lost"))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tc.mockReturn(mockStore)
+			stores.InitStore(mockStore)
+
+			req, err := http.NewRequest("POST", tc.url, strings.NewReader(tc.payload))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+
+			r := mux.NewRouter()
+			r.HandleFunc("/todos/bulk", BulkTodosHandler).Methods("POST")
+			r.HandleFunc("/workspaces/{workspaceID:[0-9]+}/todos/bulk", BulkTodosHandler).Methods("POST")
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+
+			decoded := reflect.New(reflect.TypeOf(tc.expectedBody)).Interface()
+			if err := json.NewDecoder(recorder.Body).Decode(decoded); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			if got := reflect.ValueOf(decoded).Elem().Interface(); !reflect.DeepEqual(got, tc.expectedBody) {
+				t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", got, tc.expectedBody)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
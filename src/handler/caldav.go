@@ -0,0 +1,625 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"todo-list/src/exporter"
+	"todo-list/src/importer"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+)
+
+// The CalDAV server lives under calDAVRoot:
+//
+//	/caldav/principal/                    the signed in user
+//	/caldav/calendars/                    their calendar home
+//	/caldav/calendars/personal/           the personal space
+//	/caldav/calendars/project-{id}/       a project of one of their workspaces
+//	/caldav/calendars/{calendar}/{name}   one todo as a VTODO resource
+//
+// Todos are named {id}.ics unless a client created them under a name of its
+// own. Clients sign in with their email and an app password.
+const (
+	calDAVRoot      = "/caldav/"
+	calDAVPrincipal = calDAVRoot + "principal/"
+	calDAVHome      = calDAVRoot + "calendars/"
+	personalCalDAV  = "personal"
+	// syncTokenPrefix makes the numbers from GetSyncToken the URIs RFC 6578
+	// asks sync tokens to be.
+	syncTokenPrefix       = "http://todo-list/ns/sync/txid/"
+	maxCalDAVResourceSize = 1 << 20
+)
+
+const (
+	davNamespace            = "DAV:"
+	calDAVNamespace         = "urn:ietf:params:xml:ns:caldav"
+	calendarServerNamespace = "http://calendarserver.org/ns/"
+)
+
+var defaultCalDAVName = regexp.MustCompile(`^([0-9]+)\.ics$`)
+
+// calendar is a CalDAV collection: the personal space or a project. role is
+// what the user may do with it as a whole.
+type calendar struct {
+	name        string
+	displayName string
+	workspaceID int
+	projectID   int
+	role        string
+}
+
+func (c *calendar) href() string {
+	return calDAVHome + c.name + "/"
+}
+
+// calendarObject is a todo as a resource of a calendar, with its
+// iCalendar data.
+type calendarObject struct {
+	todo *models.Todo
+	name string
+	uid  string
+	data []byte
+}
+
+func (o *calendarObject) etag() string {
+	sum := sha256.Sum256(o.data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// CalDAVHandler serves the CalDAV server for native task apps. Calendars
+// hold VTODOs only and can not be created or removed over CalDAV; they follow
+// the user's projects.
+func CalDAVHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Allow", "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	user := calDAVUser(w, r)
+	if user == nil {
+		return
+	}
+
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(calDAVRoot, "/")), "/"), "/")
+	switch {
+	case segments[0] == "":
+		serveCalDAVCollection(w, r, calDAVRoot, rootProps(), func() ([]*davResponse, error) {
+			return []*davResponse{
+				{href: calDAVPrincipal, props: principalProps(user)},
+				{href: calDAVHome, props: homeProps()},
+			}, nil
+		})
+	case segments[0] == "principal" && len(segments) == 1:
+		serveCalDAVCollection(w, r, calDAVPrincipal, principalProps(user), nil)
+	case segments[0] == "calendars" && len(segments) == 1:
+		serveCalDAVCollection(w, r, calDAVHome, homeProps(), func() ([]*davResponse, error) {
+			calendars, err := userCalendars(user)
+			if err != nil {
+				return nil, err
+			}
+			responses := []*davResponse{}
+			for _, cal := range calendars {
+				props, err := calendarProps(cal)
+				if err != nil {
+					return nil, err
+				}
+				responses = append(responses, &davResponse{href: cal.href(), props: props})
+			}
+			return responses, nil
+		})
+	case segments[0] == "calendars" && len(segments) <= 3:
+		cal, err := findCalendar(user, segments[1])
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get calendar\n%v", err)}, http.StatusInternalServerError)
+			return
+		}
+		if cal == nil {
+			utility.WriteJsonData(w, map[string]string{"error": "Calendar not found"}, http.StatusNotFound)
+			return
+		}
+		if len(segments) == 2 {
+			serveCalendar(w, r, user, cal)
+			return
+		}
+		serveCalendarObject(w, r, user, cal, segments[2])
+	default:
+		utility.WriteJsonData(w, map[string]string{"error": "Not found"}, http.StatusNotFound)
+	}
+}
+
+// calDAVUser signs the request in with HTTP Basic auth and an app password.
+// When that fails it writes the challenge and returns nil.
+func calDAVUser(w http.ResponseWriter, r *http.Request) *models.User {
+	email, password, ok := r.BasicAuth()
+	if ok {
+		user, err := stores.GetStore().AuthenticateAppPassword(email, password)
+		if err == nil {
+			return user
+		}
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="todo-list CalDAV", charset="UTF-8"`)
+	utility.WriteJsonData(w, map[string]string{"error": "Sign in with your email and an app password"}, http.StatusUnauthorized)
+	return nil
+}
+
+// serveCalDAVCollection answers PROPFIND on the collections above the
+// calendars. children lists the members for Depth 1.
+func serveCalDAVCollection(w http.ResponseWriter, r *http.Request, href string, props []davProp, children func() ([]*davResponse, error)) {
+	if r.Method != "PROPFIND" {
+		w.Header().Set("Allow", "OPTIONS, PROPFIND")
+		utility.WriteJsonData(w, map[string]string{"error": "Method not allowed"}, http.StatusMethodNotAllowed)
+		return
+	}
+	request, ok := readDAVRequest(w, r)
+	if !ok {
+		return
+	}
+
+	responses := []*davResponse{{href: href, props: props}}
+	if children != nil && r.Header.Get("Depth") != "0" {
+		members, err := children()
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not list collection\n%v", err)}, http.StatusInternalServerError)
+			return
+		}
+		responses = append(responses, members...)
+	}
+	writeMultistatus(w, request, responses, "")
+}
+
+func serveCalendar(w http.ResponseWriter, r *http.Request, user *models.User, cal *calendar) {
+	switch r.Method {
+	case "PROPFIND":
+		request, ok := readDAVRequest(w, r)
+		if !ok {
+			return
+		}
+		props, err := calendarProps(cal)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get calendar\n%v", err)}, http.StatusInternalServerError)
+			return
+		}
+		responses := []*davResponse{{href: cal.href(), props: props}}
+		if r.Header.Get("Depth") != "0" {
+			objects, err := calendarObjects(user, cal, nil)
+			if err != nil {
+				utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get todos\n%v", err)}, http.StatusInternalServerError)
+				return
+			}
+			for _, object := range objects {
+				responses = append(responses, &davResponse{href: cal.href() + object.name, props: objectProps(object)})
+			}
+		}
+		writeMultistatus(w, request, responses, "")
+	case "REPORT":
+		request, ok := readDAVRequest(w, r)
+		if !ok {
+			return
+		}
+		serveCalendarReport(w, user, cal, request)
+	case "MKCALENDAR", "MKCOL":
+		utility.WriteJsonData(w, map[string]string{"error": "Calendars are created by adding projects"}, http.StatusForbidden)
+	default:
+		w.Header().Set("Allow", "OPTIONS, PROPFIND, REPORT")
+		utility.WriteJsonData(w, map[string]string{"error": "Method not allowed"}, http.StatusMethodNotAllowed)
+	}
+}
+
+func serveCalendarReport(w http.ResponseWriter, user *models.User, cal *calendar, request *davRequest) {
+	switch request.XMLName {
+	case xml.Name{Space: calDAVNamespace, Local: "calendar-query"}:
+		// Only the component filter is applied. Time range and property
+		// filters are not, so clients can get more todos than they asked for.
+		responses := []*davResponse{}
+		if request.Filter == nil || request.Filter.matchesVTODO() {
+			objects, err := calendarObjects(user, cal, nil)
+			if err != nil {
+				utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get todos\n%v", err)}, http.StatusInternalServerError)
+				return
+			}
+			for _, object := range objects {
+				responses = append(responses, &davResponse{href: cal.href() + object.name, props: objectProps(object)})
+			}
+		}
+		writeMultistatus(w, request, responses, "")
+	case xml.Name{Space: calDAVNamespace, Local: "calendar-multiget"}:
+		responses := []*davResponse{}
+		for _, href := range request.Hrefs {
+			if parsed, err := url.Parse(href); err == nil {
+				href = parsed.Path
+			}
+			response := &davResponse{href: href, status: http.StatusNotFound}
+			if name, ok := objectName(cal, href); ok {
+				object, err := findCalendarObject(user, cal, name)
+				if err != nil {
+					utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get todo\n%v", err)}, http.StatusInternalServerError)
+					return
+				}
+				if object != nil {
+					response = &davResponse{href: cal.href() + object.name, props: objectProps(object)}
+				}
+			}
+			responses = append(responses, response)
+		}
+		writeMultistatus(w, request, responses, "")
+	case xml.Name{Space: davNamespace, Local: "sync-collection"}:
+		serveSyncCollection(w, user, cal, request)
+	default:
+		writeDAVError(w, http.StatusForbidden, xml.Name{Space: davNamespace, Local: "supported-report"})
+	}
+}
+
+// serveSyncCollection answers RFC 6578 sync-collection reports: every todo
+// for an empty token, otherwise the todos changed since it that the calendar
+// may have held, with the ones no longer in it reported as 404.
+func serveSyncCollection(w http.ResponseWriter, user *models.User, cal *calendar, request *davRequest) {
+	token, err := stores.GetStore().GetSyncToken()
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not sync\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	var changedIDs []int
+	if request.SyncToken != "" {
+		since, err := strconv.ParseInt(strings.TrimPrefix(request.SyncToken, syncTokenPrefix), 10, 64)
+		if err != nil || !strings.HasPrefix(request.SyncToken, syncTokenPrefix) || since < 0 || since > token {
+			writeDAVError(w, http.StatusForbidden, xml.Name{Space: davNamespace, Local: "valid-sync-token"})
+			return
+		}
+		changedIDs, err = stores.GetStore().GetChangedTodoIDs(since, user.ID, cal.projectID)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not sync\n%v", err)}, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	responses := []*davResponse{}
+	if request.SyncToken == "" || len(changedIDs) > 0 {
+		objects, err := calendarObjects(user, cal, changedIDs)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not sync\n%v", err)}, http.StatusInternalServerError)
+			return
+		}
+		present := map[int]bool{}
+		for _, object := range objects {
+			present[object.todo.ID] = true
+			responses = append(responses, &davResponse{href: cal.href() + object.name, props: objectProps(object)})
+		}
+
+		removedIDs := []int{}
+		for _, todoID := range changedIDs {
+			if !present[todoID] {
+				removedIDs = append(removedIDs, todoID)
+			}
+		}
+		names, _, err := resourceNames(removedIDs)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not sync\n%v", err)}, http.StatusInternalServerError)
+			return
+		}
+		for _, todoID := range removedIDs {
+			responses = append(responses, &davResponse{href: cal.href() + names[todoID], status: http.StatusNotFound})
+		}
+	}
+	writeMultistatus(w, request, responses, syncTokenPrefix+strconv.FormatInt(token, 10))
+}
+
+func serveCalendarObject(w http.ResponseWriter, r *http.Request, user *models.User, cal *calendar, name string) {
+	object, err := findCalendarObject(user, cal, name)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get todo\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	if object == nil && r.Method != http.MethodPut {
+		utility.WriteJsonData(w, map[string]string{"error": "Todo not found"}, http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("ETag", object.etag())
+		w.Header().Set("Last-Modified", object.todo.UpdatedAt.UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.Itoa(len(object.data)))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(object.data)
+		}
+	case "PROPFIND":
+		request, ok := readDAVRequest(w, r)
+		if !ok {
+			return
+		}
+		writeMultistatus(w, request, []*davResponse{{href: cal.href() + object.name, props: objectProps(object)}}, "")
+	case http.MethodPut:
+		putCalendarObject(w, r, user, cal, name, object)
+	case http.MethodDelete:
+		if !calDAVPreconditions(w, r, object) {
+			return
+		}
+		if !calDAVTodoRole(w, user, cal, object.todo.ID, models.RoleOwner) {
+			return
+		}
+		err := stores.GetStore().DeleteTodo(object.todo.ID, user.ID)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not delete todo\n%v", err)}, http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND")
+		utility.WriteJsonData(w, map[string]string{"error": "Method not allowed"}, http.StatusMethodNotAllowed)
+	}
+}
+
+// putCalendarObject creates a todo under the name the client chose, or
+// updates the one it names. Like PUT /todos/{id}, updates leave tags alone.
+func putCalendarObject(w http.ResponseWriter, r *http.Request, user *models.User, cal *calendar, name string, object *calendarObject) {
+	if !calDAVPreconditions(w, r, object) {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxCalDAVResourceSize)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "The resource is too large"}, http.StatusRequestEntityTooLarge)
+		return
+	}
+	row, uid, err := importer.ParseICalTodo(bytes.NewReader(body))
+	if err != nil || len(row.Errors) > 0 {
+		writeDAVError(w, http.StatusForbidden, xml.Name{Space: calDAVNamespace, Local: "valid-calendar-data"})
+		return
+	}
+	todo := row.Todo
+	todo.Tags = normalizeTags(todo.Tags)
+	errors := validations.ValidateTodo(todo)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	if object != nil {
+		if uid != object.uid {
+			writeDAVError(w, http.StatusForbidden, xml.Name{Space: calDAVNamespace, Local: "no-uid-conflict"})
+			return
+		}
+		if !calDAVTodoRole(w, user, cal, object.todo.ID, models.RoleEditor) {
+			return
+		}
+		_, err := stores.GetStore().UpdateTodo(todo, object.todo.ID, user.ID, false)
+		if blocked, ok := err.(*stores.BlockedError); ok {
+			utility.WriteJsonData(w, map[string]interface{}{"error": "This todo is blocked by open todos", "blocked_by": blocked.BlockerIDs}, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not update todo\n%v", err)}, http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if !models.RoleAtLeast(cal.role, models.RoleEditor) {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("This action requires the %s role", models.RoleEditor)}, http.StatusForbidden)
+		return
+	}
+	// {id}.ics names the todo of that id, so a client can not give it to a
+	// new one.
+	if defaultCalDAVName.MatchString(name) {
+		utility.WriteJsonData(w, map[string]string{"error": "Names of the form {id}.ics are kept for existing todos"}, http.StatusForbidden)
+		return
+	}
+	todo.WorkspaceID = cal.workspaceID
+	todo.ProjectID = cal.projectID
+	if !checkNewTodoCustomFields(w, todo) {
+		return
+	}
+	_, err = stores.GetStore().CreateCalDAVTodo(todo, user.ID, &models.CalDAVResource{UserID: user.ID, Name: name, UID: uid})
+	if err == stores.ErrCalDAVNameTaken {
+		utility.WriteJsonData(w, map[string]string{"error": "Another of your todos already has this name"}, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create todo\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// calDAVPreconditions applies If-Match and If-None-Match, which clients send
+// so they do not overwrite changes they have not seen. When they fail it
+// writes the error response and returns false.
+func calDAVPreconditions(w http.ResponseWriter, r *http.Request, object *calendarObject) bool {
+	ifMatch := r.Header.Get("If-Match")
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	failed := false
+	if ifNoneMatch == "*" && object != nil {
+		failed = true
+	}
+	if ifMatch != "" && (object == nil || (ifMatch != "*" && !etagListContains(ifMatch, object.etag()))) {
+		failed = true
+	}
+	if failed {
+		utility.WriteJsonData(w, map[string]string{"error": "The todo was changed since it was last read"}, http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+func etagListContains(list string, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// calDAVTodoRole checks that the user holds at least minimumRole on a todo of
+// the calendar. When they do not it writes the error response and returns
+// false.
+func calDAVTodoRole(w http.ResponseWriter, user *models.User, cal *calendar, todoID int, minimumRole string) bool {
+	role, err := stores.GetStore().GetTodoRole(todoID, user.ID, cal.workspaceID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Todo not found"}, http.StatusNotFound)
+		return false
+	}
+	if !models.RoleAtLeast(role, minimumRole) {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("This action requires the %s role", minimumRole)}, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// userCalendars lists the personal space and every project of the user's
+// workspaces.
+func userCalendars(user *models.User) ([]*calendar, error) {
+	projects, err := projectCalendars(user, 0)
+	if err != nil {
+		return nil, err
+	}
+	return append([]*calendar{{name: personalCalDAV, displayName: "Personal", role: models.RoleOwner}}, projects...), nil
+}
+
+// projectCalendars lists the projects of the user's workspaces, only the one
+// of projectID unless it is 0.
+func projectCalendars(user *models.User, projectID int) ([]*calendar, error) {
+	projects, err := stores.GetStore().GetMemberProjects(user.ID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	calendars := []*calendar{}
+	for _, project := range projects {
+		calendars = append(calendars, &calendar{
+			name:        fmt.Sprintf("project-%d", project.ID),
+			displayName: project.WorkspaceName + " / " + project.Name,
+			workspaceID: project.WorkspaceID,
+			projectID:   project.ID,
+			role:        project.Role,
+		})
+	}
+	return calendars, nil
+}
+
+// findCalendar returns nil when the user has no calendar of that name.
+func findCalendar(user *models.User, name string) (*calendar, error) {
+	if name == personalCalDAV {
+		return &calendar{name: personalCalDAV, displayName: "Personal", role: models.RoleOwner}, nil
+	}
+	projectID, err := strconv.Atoi(strings.TrimPrefix(name, "project-"))
+	if err != nil || projectID <= 0 || name != fmt.Sprintf("project-%d", projectID) {
+		return nil, nil
+	}
+	calendars, err := projectCalendars(user, projectID)
+	if err != nil || len(calendars) == 0 {
+		return nil, err
+	}
+	return calendars[0], nil
+}
+
+// calendarObjects returns the todos of the calendar, only the ones in
+// todoIDs unless it is nil.
+func calendarObjects(user *models.User, cal *calendar, todoIDs []int) ([]*calendarObject, error) {
+	todos := []*models.Todo{}
+	err := stores.GetStore().ExportTodos(user.ID, cal.workspaceID, &models.TodoFilter{ProjectID: cal.projectID, IDs: todoIDs}, func(todo *models.Todo) error {
+		todos = append(todos, todo)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	names, uids, err := resourceNames(ids)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]*calendarObject, len(todos))
+	for i, todo := range todos {
+		objects[i] = &calendarObject{todo: todo, name: names[todo.ID], uid: uids[todo.ID]}
+		objects[i].data = exporter.ICalResource(todo, objects[i].uid)
+	}
+	return objects, nil
+}
+
+// resourceNames returns the resource name and UID of every todo, the ones a
+// client gave it or the defaults.
+func resourceNames(todoIDs []int) (map[int]string, map[int]string, error) {
+	names := map[int]string{}
+	uids := map[int]string{}
+	if len(todoIDs) == 0 {
+		return names, uids, nil
+	}
+	for _, todoID := range todoIDs {
+		names[todoID] = fmt.Sprintf("%d.ics", todoID)
+		uids[todoID] = fmt.Sprintf("todo-%d@todo-list", todoID)
+	}
+	resources, err := stores.GetStore().GetCalDAVResources(todoIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, resource := range resources {
+		names[resource.TodoID] = resource.Name
+		uids[resource.TodoID] = resource.UID
+	}
+	return names, uids, nil
+}
+
+// findCalendarObject returns nil when the calendar has no todo of that name.
+// {id}.ics always names the todo of that id; other names are looked up among
+// the ones clients gave todos, where several users may have used the same.
+func findCalendarObject(user *models.User, cal *calendar, name string) (*calendarObject, error) {
+	todoIDs := []int{}
+	if match := defaultCalDAVName.FindStringSubmatch(name); match != nil {
+		todoID, _ := strconv.Atoi(match[1])
+		todoIDs = append(todoIDs, todoID)
+	} else {
+		resources, err := stores.GetStore().GetCalDAVResourcesByName(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resources {
+			todoIDs = append(todoIDs, resource.TodoID)
+		}
+	}
+	if len(todoIDs) == 0 {
+		return nil, nil
+	}
+
+	objects, err := calendarObjects(user, cal, todoIDs)
+	if err != nil {
+		return nil, err
+	}
+	// A todo a client named is only found under that name.
+	for _, object := range objects {
+		if object.name == name {
+			return object, nil
+		}
+	}
+	return nil, nil
+}
+
+// objectName returns the resource name of an href inside the calendar.
+func objectName(cal *calendar, href string) (string, bool) {
+	name, ok := strings.CutPrefix(href, cal.href())
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,554 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// davStore keeps todos in memory for the CalDAV tests, which need a store
+// that remembers what a client wrote. Calls it does not implement fall
+// through to the embedded mock and fail the test.
+type davStore struct {
+	*stores.MockStore
+	todos     map[int]*models.Todo
+	trashed   map[int]bool
+	resources map[int]*models.CalDAVResource
+	syncIDs   map[int]int64
+	placedIn  map[int][]int
+	owners    map[int]int
+	lastSync  int64
+	nextID    int
+}
+
+func newDAVStore() *davStore {
+	store := &davStore{
+		MockStore: stores.InitMockStore(),
+		todos:     map[int]*models.Todo{},
+		trashed:   map[int]bool{},
+		resources: map[int]*models.CalDAVResource{},
+		syncIDs:   map[int]int64{},
+		placedIn:  map[int][]int{},
+		owners:    map[int]int{},
+		nextID:    1,
+	}
+	dueDate := time.Date(2024, 12, 1, 9, 0, 0, 0, time.UTC)
+	store.CreateTodo(&models.Todo{TaskName: "Water the plants", DueDate: dueDate}, 1)
+	store.CreateTodo(&models.Todo{TaskName: "Write launch plan", WorkspaceID: 4, ProjectID: 7}, 1)
+	store.CreateTodo(&models.Todo{TaskName: "Bring snacks", WorkspaceID: 5, ProjectID: 8}, 1)
+	return store
+}
+
+// touch records a change to a todo the way the todo_sync trigger does.
+func (s *davStore) touch(todoID int) {
+	s.lastSync++
+	s.syncIDs[todoID] = s.lastSync
+	if todo := s.todos[todoID]; todo.WorkspaceID == 0 {
+		s.placedIn[todoID] = append(s.placedIn[todoID], 0)
+	} else if todo.ProjectID != 0 {
+		s.placedIn[todoID] = append(s.placedIn[todoID], todo.ProjectID)
+	}
+	s.todos[todoID].UpdatedAt = time.Date(2024, 11, 30, 8, 0, 0, 0, time.UTC).Add(time.Duration(s.lastSync) * time.Minute)
+}
+
+func (s *davStore) AuthenticateAppPassword(email string, password string) (*models.User, error) {
+	if password != "app-password" {
+		return nil, sql.ErrNoRows
+	}
+	switch email {
+	case "test@mail.com":
+		return &models.User{ID: 1, UserName: "tester", Email: email}, nil
+	case "other@mail.com":
+		return &models.User{ID: 2, UserName: "other", Email: email}, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *davStore) GetMemberProjects(userID int, projectID int) ([]*models.MemberProject, error) {
+	projects := []*models.MemberProject{
+		{Project: models.Project{ID: 7, WorkspaceID: 4, Name: "Launch"}, WorkspaceName: "Acme", Role: models.RoleEditor},
+		{Project: models.Project{ID: 8, WorkspaceID: 5, Name: "Party"}, WorkspaceName: "Guests", Role: models.RoleViewer},
+	}
+	if projectID == 0 {
+		return projects, nil
+	}
+	for _, project := range projects {
+		if project.ID == projectID {
+			return []*models.MemberProject{project}, nil
+		}
+	}
+	return []*models.MemberProject{}, nil
+}
+
+func (s *davStore) GetCustomFields(projectID int) ([]*models.CustomField, error) {
+	if projectID == 7 {
+		return []*models.CustomField{{ProjectID: 7, Key: "points", Type: models.FieldNumber, Required: true}}, nil
+	}
+	return []*models.CustomField{}, nil
+}
+
+func (s *davStore) ExportTodos(userID int, workspaceID int, filter *models.TodoFilter, each func(*models.Todo) error) error {
+	ids := []int{}
+	for id := range s.todos {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		todo := *s.todos[id]
+		if s.trashed[id] || todo.WorkspaceID != workspaceID || (filter.ProjectID != 0 && todo.ProjectID != filter.ProjectID) || s.owners[id] != userID {
+			continue
+		}
+		if filter.IDs != nil && !containsID(filter.IDs, id) {
+			continue
+		}
+		if err := each(&todo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *davStore) CreateTodo(todo *models.Todo, userID int) (*models.Todo, error) {
+	newTodo := *todo
+	newTodo.ID = s.nextID
+	s.nextID++
+	s.todos[newTodo.ID] = &newTodo
+	s.owners[newTodo.ID] = userID
+	s.touch(newTodo.ID)
+	return &newTodo, nil
+}
+
+func (s *davStore) UpdateTodo(todo *models.Todo, todoID int, userID int, allowBlocked bool) (*models.Todo, error) {
+	existing := s.todos[todoID]
+	existing.TaskName = todo.TaskName
+	existing.Completed = todo.Completed
+	existing.DueDate = todo.DueDate
+	existing.Notes = todo.Notes
+	existing.Priority = todo.Priority
+	s.touch(todoID)
+	return existing, nil
+}
+
+func (s *davStore) DeleteTodo(todoID int, userID int) error {
+	s.trashed[todoID] = true
+	s.touch(todoID)
+	return nil
+}
+
+func (s *davStore) GetTodoRole(todoID int, userID int, workspaceID int) (string, error) {
+	if s.todos[todoID].WorkspaceID == 5 {
+		return models.RoleViewer, nil
+	}
+	return models.RoleOwner, nil
+}
+
+func (s *davStore) CreateCalDAVTodo(todo *models.Todo, userID int, resource *models.CalDAVResource) (*models.Todo, error) {
+	for _, existing := range s.resources {
+		if existing.UserID == userID && existing.Name == resource.Name {
+			return nil, stores.ErrCalDAVNameTaken
+		}
+	}
+	newTodo, _ := s.CreateTodo(todo, userID)
+	created := *resource
+	created.TodoID = newTodo.ID
+	s.resources[newTodo.ID] = &created
+	return newTodo, nil
+}
+
+func (s *davStore) GetCalDAVResources(todoIDs []int) ([]*models.CalDAVResource, error) {
+	resources := []*models.CalDAVResource{}
+	for _, todoID := range todoIDs {
+		if resource, ok := s.resources[todoID]; ok {
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
+}
+
+func (s *davStore) GetCalDAVResourcesByName(name string) ([]*models.CalDAVResource, error) {
+	resources := []*models.CalDAVResource{}
+	for _, resource := range s.resources {
+		if resource.Name == name {
+			resources = append(resources, resource)
+		}
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].TodoID < resources[j].TodoID })
+	return resources, nil
+}
+
+// GetSyncToken returns the next sync id, as no change is ever in progress.
+func (s *davStore) GetSyncToken() (int64, error) {
+	return s.lastSync + 1, nil
+}
+
+func (s *davStore) GetChangedTodoIDs(since int64, userID int, projectID int) ([]int, error) {
+	todoIDs := []int{}
+	for todoID, syncID := range s.syncIDs {
+		if syncID >= since && containsID(s.placedIn[todoID], projectID) && (projectID != 0 || s.owners[todoID] == userID) {
+			todoIDs = append(todoIDs, todoID)
+		}
+	}
+	sort.Ints(todoIDs)
+	return todoIDs, nil
+}
+
+func containsID(ids []int, id int) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// davClient is a minimal CalDAV client, doing what task apps do.
+type davClient struct {
+	t        *testing.T
+	server   *httptest.Server
+	email    string
+	password string
+}
+
+type davMultistatusBody struct {
+	Responses []davResponseBody `xml:"DAV: response"`
+	SyncToken string            `xml:"DAV: sync-token"`
+}
+
+type davResponseBody struct {
+	Href      string `xml:"DAV: href"`
+	Status    string `xml:"DAV: status"`
+	Propstats []struct {
+		Prop struct {
+			Props []davPropBody `xml:",any"`
+		} `xml:"DAV: prop"`
+		Status string `xml:"DAV: status"`
+	} `xml:"DAV: propstat"`
+}
+
+type davPropBody struct {
+	XMLName xml.Name
+	Inner   string   `xml:",innerxml"`
+	Text    string   `xml:",chardata"`
+	Hrefs   []string `xml:"DAV: href"`
+}
+
+// prop returns a property found on the response, or nil.
+func (r davResponseBody) prop(local string) *davPropBody {
+	for _, propstat := range r.Propstats {
+		if !strings.Contains(propstat.Status, "200") {
+			continue
+		}
+		for _, prop := range propstat.Prop.Props {
+			if prop.XMLName.Local == local {
+				return &prop
+			}
+		}
+	}
+	return nil
+}
+
+func (c *davClient) do(method string, path string, body string, headers map[string]string) *http.Response {
+	req, err := http.NewRequest(method, c.server.URL+path, strings.NewReader(body))
+	require.NoError(c.t, err)
+	if c.password != "" {
+		email := c.email
+		if email == "" {
+			email = "test@mail.com"
+		}
+		req.SetBasicAuth(email, c.password)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := c.server.Client().Do(req)
+	require.NoError(c.t, err)
+	return resp
+}
+
+func (c *davClient) multistatus(method string, path string, depth string, body string) *davMultistatusBody {
+	resp := c.do(method, path, body, map[string]string{"Depth": depth, "Content-Type": "application/xml"})
+	defer resp.Body.Close()
+	require.Equal(c.t, http.StatusMultiStatus, resp.StatusCode)
+	multistatus := &davMultistatusBody{}
+	require.NoError(c.t, xml.NewDecoder(resp.Body).Decode(multistatus))
+	return multistatus
+}
+
+func (c *davClient) body(resp *http.Response) string {
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(c.t, err)
+	return string(data)
+}
+
+func (c *davClient) sync(path string, token string) *davMultistatusBody {
+	return c.multistatus("REPORT", path, "0", `<?xml version="1.0"?>
+<D:sync-collection xmlns:D="DAV:"><D:sync-token>`+token+`</D:sync-token><D:sync-level>1</D:sync-level><D:prop><D:getetag/></D:prop></D:sync-collection>`)
+}
+
+func davHrefs(multistatus *davMultistatusBody) []string {
+	hrefs := []string{}
+	for _, response := range multistatus.Responses {
+		hrefs = append(hrefs, response.Href)
+	}
+	return hrefs
+}
+
+func vtodo(uid string, summary string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Client//EN\r\nBEGIN:VTODO\r\nUID:" + uid + "\r\nDTSTAMP:20241130T080000Z\r\n" +
+		"SUMMARY:" + summary + "\r\nDUE;TZID=Europe/Berlin:20241202T100000\r\nPRIORITY:1\r\nCATEGORIES:Groceries\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+}
+
+func TestCalDAV(t *testing.T) {
+	store := newDAVStore()
+	stores.InitStore(store)
+	r := mux.NewRouter()
+	r.PathPrefix("/caldav/").HandlerFunc(CalDAVHandler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	client := &davClient{t: t, server: server, password: "app-password"}
+
+	t.Run("Options Without Auth", func(t *testing.T) {
+		anonymous := &davClient{t: t, server: server}
+		resp := anonymous.do("OPTIONS", "/caldav/", "", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("DAV"), "calendar-access")
+	})
+
+	t.Run("Requires App Password", func(t *testing.T) {
+		for _, password := range []string{"", "wrong"} {
+			resp := (&davClient{t: t, server: server, password: password}).do("PROPFIND", "/caldav/", "", nil)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			assert.Contains(t, resp.Header.Get("WWW-Authenticate"), "Basic")
+		}
+	})
+
+	t.Run("Discovery", func(t *testing.T) {
+		root := client.multistatus("PROPFIND", "/caldav/", "0", `<D:propfind xmlns:D="DAV:"><D:prop><D:current-user-principal/></D:prop></D:propfind>`)
+		require.Len(t, root.Responses, 1)
+		assert.Equal(t, []string{"/caldav/principal/"}, root.Responses[0].prop("current-user-principal").Hrefs)
+
+		principal := client.multistatus("PROPFIND", "/caldav/principal/", "0", `<D:propfind xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav" xmlns:A="http://apple.com/ns/ical/">`+
+			`<D:prop><C:calendar-home-set/><D:displayname/><A:calendar-color/></D:prop></D:propfind>`)
+		require.Len(t, principal.Responses, 1)
+		assert.Equal(t, []string{"/caldav/calendars/"}, principal.Responses[0].prop("calendar-home-set").Hrefs)
+		assert.Equal(t, "tester", principal.Responses[0].prop("displayname").Text)
+		assert.Nil(t, principal.Responses[0].prop("calendar-color"))
+		assert.Contains(t, principal.Responses[0].Propstats[1].Status, "404")
+
+		home := client.multistatus("PROPFIND", "/caldav/calendars/", "1", `<D:propfind xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">`+
+			`<D:prop><D:resourcetype/><D:displayname/><C:supported-calendar-component-set/><D:current-user-privilege-set/></D:prop></D:propfind>`)
+		assert.Equal(t, []string{"/caldav/calendars/", "/caldav/calendars/personal/", "/caldav/calendars/project-7/", "/caldav/calendars/project-8/"}, davHrefs(home))
+		launch := home.Responses[2]
+		assert.Equal(t, "Acme / Launch", launch.prop("displayname").Text)
+		assert.Contains(t, launch.prop("resourcetype").Inner, "calendar")
+		assert.Contains(t, launch.prop("supported-calendar-component-set").Inner, `name="VTODO"`)
+		assert.Contains(t, launch.prop("current-user-privilege-set").Inner, "write")
+		assert.NotContains(t, home.Responses[3].prop("current-user-privilege-set").Inner, "write")
+	})
+
+	t.Run("List Calendar", func(t *testing.T) {
+		personal := client.multistatus("PROPFIND", "/caldav/calendars/personal/", "1", `<D:propfind xmlns:D="DAV:"><D:prop><D:getetag/></D:prop></D:propfind>`)
+		assert.Equal(t, []string{"/caldav/calendars/personal/", "/caldav/calendars/personal/1.ics"}, davHrefs(personal))
+		assert.NotEmpty(t, personal.Responses[1].prop("getetag").Text)
+	})
+
+	var token string
+	var etag string
+	t.Run("Create", func(t *testing.T) {
+		resp := client.do("PUT", "/caldav/calendars/personal/ABC-123.ics", vtodo("ABC-123", "Buy oat milk"), map[string]string{"If-None-Match": "*", "Content-Type": "text/calendar"})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		created := store.todos[4]
+		assert.Equal(t, "Buy oat milk", created.TaskName)
+		assert.Equal(t, time.Date(2024, 12, 2, 9, 0, 0, 0, time.UTC), created.DueDate)
+		assert.Equal(t, 4, created.Priority)
+		assert.Equal(t, []string{"groceries"}, created.Tags)
+
+		resp = client.do("PUT", "/caldav/calendars/personal/ABC-123.ics", vtodo("ABC-123", "Buy oat milk"), map[string]string{"If-None-Match": "*"})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+		resp = client.do("PUT", "/caldav/calendars/personal/broken.ics", "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Contains(t, client.body(resp), "valid-calendar-data")
+
+		resp = client.do("PUT", "/caldav/calendars/project-8/XYZ.ics", vtodo("XYZ", "Bring cups please"), nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		// The Launch project requires a custom field iCalendar has no place for.
+		resp = client.do("PUT", "/caldav/calendars/project-7/XYZ.ics", vtodo("XYZ", "Draft the launch plan"), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, client.body(resp), "CustomFields.points")
+	})
+
+	t.Run("Multiget", func(t *testing.T) {
+		multiget := client.multistatus("REPORT", "/caldav/calendars/personal/", "1", `<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">`+
+			`<D:prop><D:getetag/><C:calendar-data/></D:prop>`+
+			`<D:href>/caldav/calendars/personal/ABC-123.ics</D:href><D:href>/caldav/calendars/personal/1.ics</D:href><D:href>/caldav/calendars/personal/missing.ics</D:href>`+
+			`</C:calendar-multiget>`)
+		require.Len(t, multiget.Responses, 3)
+		assert.Contains(t, multiget.Responses[0].prop("calendar-data").Text, "UID:ABC-123\r\n")
+		assert.Contains(t, multiget.Responses[0].prop("calendar-data").Text, "SUMMARY:Buy oat milk\r\n")
+		assert.Contains(t, multiget.Responses[1].prop("calendar-data").Text, "UID:todo-1@todo-list\r\n")
+		assert.Equal(t, "/caldav/calendars/personal/missing.ics", multiget.Responses[2].Href)
+		assert.Contains(t, multiget.Responses[2].Status, "404")
+	})
+
+	t.Run("Initial Sync", func(t *testing.T) {
+		initial := client.sync("/caldav/calendars/personal/", "")
+		assert.Equal(t, []string{"/caldav/calendars/personal/1.ics", "/caldav/calendars/personal/ABC-123.ics"}, davHrefs(initial))
+		assert.True(t, strings.HasPrefix(initial.SyncToken, syncTokenPrefix))
+		token = initial.SyncToken
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		resp := client.do("GET", "/caldav/calendars/personal/ABC-123.ics", "", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/calendar; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Contains(t, client.body(resp), "UID:ABC-123\r\n")
+		etag = resp.Header.Get("ETag")
+		require.NotEmpty(t, etag)
+
+		resp = client.do("PUT", "/caldav/calendars/personal/ABC-123.ics", vtodo("ABC-123", "Buy soy milk"), map[string]string{"If-Match": `"stale"`})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+		resp = client.do("PUT", "/caldav/calendars/personal/ABC-123.ics", vtodo("OTHER", "Buy soy milk"), map[string]string{"If-Match": etag})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Contains(t, client.body(resp), "no-uid-conflict")
+
+		resp = client.do("PUT", "/caldav/calendars/personal/ABC-123.ics", vtodo("ABC-123", "Buy soy milk"), map[string]string{"If-Match": etag})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "Buy soy milk", store.todos[4].TaskName)
+
+		resp = client.do("HEAD", "/caldav/calendars/personal/ABC-123.ics", "", nil)
+		resp.Body.Close()
+		assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+		etag = resp.Header.Get("ETag")
+	})
+
+	t.Run("Sync Changes", func(t *testing.T) {
+		changes := client.sync("/caldav/calendars/personal/", token)
+		require.Equal(t, []string{"/caldav/calendars/personal/ABC-123.ics"}, davHrefs(changes))
+		assert.Equal(t, etag, changes.Responses[0].prop("getetag").Text)
+		token = changes.SyncToken
+
+		unchanged := client.sync("/caldav/calendars/personal/", token)
+		assert.Empty(t, unchanged.Responses)
+		assert.Equal(t, token, unchanged.SyncToken)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		resp := client.do("DELETE", "/caldav/calendars/personal/1.ics", "", map[string]string{"If-Match": `"stale"`})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+		resp = client.do("DELETE", "/caldav/calendars/personal/1.ics", "", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.True(t, store.trashed[1])
+
+		resp = client.do("GET", "/caldav/calendars/personal/1.ics", "", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		changes := client.sync("/caldav/calendars/personal/", token)
+		require.Equal(t, []string{"/caldav/calendars/personal/1.ics"}, davHrefs(changes))
+		assert.Contains(t, changes.Responses[0].Status, "404")
+	})
+
+	t.Run("Sync Leaves Out Other Calendars", func(t *testing.T) {
+		before := client.sync("/caldav/calendars/personal/", "")
+		// Another user's personal todo and a todo of another calendar change.
+		store.CreateTodo(&models.Todo{TaskName: "Call the landlord"}, 2)
+		store.touch(2)
+
+		changes := client.sync("/caldav/calendars/personal/", before.SyncToken)
+		assert.Empty(t, changes.Responses)
+		launch := client.sync("/caldav/calendars/project-7/", before.SyncToken)
+		assert.Equal(t, []string{"/caldav/calendars/project-7/2.ics"}, davHrefs(launch))
+	})
+
+	t.Run("Invalid Sync Token", func(t *testing.T) {
+		for _, invalid := range []string{"garbage", syncTokenPrefix + "999"} {
+			resp := client.do("REPORT", "/caldav/calendars/personal/", `<D:sync-collection xmlns:D="DAV:"><D:sync-token>`+invalid+`</D:sync-token><D:prop><D:getetag/></D:prop></D:sync-collection>`, nil)
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+			assert.Contains(t, client.body(resp), "valid-sync-token")
+		}
+	})
+
+	t.Run("Calendar Query", func(t *testing.T) {
+		query := func(component string) *davMultistatusBody {
+			return client.multistatus("REPORT", "/caldav/calendars/personal/", "1", `<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">`+
+				`<D:prop><D:getetag/></D:prop><C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="`+component+`"/></C:comp-filter></C:filter></C:calendar-query>`)
+		}
+		assert.Equal(t, []string{"/caldav/calendars/personal/ABC-123.ics"}, davHrefs(query("VTODO")))
+		assert.Empty(t, query("VEVENT").Responses)
+	})
+
+	t.Run("Todos Stay In Their Calendar", func(t *testing.T) {
+		resp := client.do("GET", "/caldav/calendars/personal/2.ics", "", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp = client.do("GET", "/caldav/calendars/project-7/2.ics", "", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, client.body(resp), "SUMMARY:Write launch plan\r\n")
+
+		resp = client.do("PROPFIND", "/caldav/calendars/project-99/", "", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Names Are Per User", func(t *testing.T) {
+		other := &davClient{t: t, server: server, email: "other@mail.com", password: "app-password"}
+		resp := other.do("PUT", "/caldav/calendars/personal/ABC-123.ics", vtodo("ABC-123", "Walk the dog"), map[string]string{"If-None-Match": "*"})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = other.do("GET", "/caldav/calendars/personal/ABC-123.ics", "", nil)
+		assert.Contains(t, other.body(resp), "SUMMARY:Walk the dog\r\n")
+		resp = client.do("GET", "/caldav/calendars/personal/ABC-123.ics", "", nil)
+		assert.Contains(t, client.body(resp), "SUMMARY:Buy soy milk\r\n")
+
+		// {id}.ics can not be taken from the todo of that id.
+		count := len(store.todos)
+		resp = other.do("PUT", "/caldav/calendars/personal/4.ics", vtodo("SQUAT", "Not yours"), nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Len(t, store.todos, count)
+		resp = client.do("GET", "/caldav/calendars/personal/4.ics", "", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		// A trashed todo keeps its name, so a new todo can not take it.
+		resp = client.do("PUT", "/caldav/calendars/personal/LATER.ics", vtodo("LATER", "Read the paper"), nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp = client.do("DELETE", "/caldav/calendars/personal/LATER.ics", "", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		count = len(store.todos)
+		resp = client.do("PUT", "/caldav/calendars/personal/LATER.ics", vtodo("LATER", "Read the paper"), nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		assert.Len(t, store.todos, count)
+	})
+}
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+)
+
+// davRequest is the body of a PROPFIND or REPORT. Only the parts of the
+// reports this server supports are read.
+type davRequest struct {
+	XMLName   xml.Name
+	AllProp   *struct{}  `xml:"DAV: allprop"`
+	PropName  *struct{}  `xml:"DAV: propname"`
+	Prop      *davNames  `xml:"DAV: prop"`
+	Hrefs     []string   `xml:"DAV: href"`
+	SyncToken string     `xml:"DAV: sync-token"`
+	Filter    *davFilter `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+type davNames struct {
+	Names []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+type davFilter struct {
+	CompFilter davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type davCompFilter struct {
+	Name        string          `xml:"name,attr"`
+	CompFilters []davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+// matchesVTODO reports whether a calendar-query filter lets VTODOs through.
+func (f *davFilter) matchesVTODO() bool {
+	if !strings.EqualFold(f.CompFilter.Name, "VCALENDAR") {
+		return false
+	}
+	if len(f.CompFilter.CompFilters) == 0 {
+		return true
+	}
+	for _, filter := range f.CompFilter.CompFilters {
+		if strings.EqualFold(filter.Name, "VTODO") {
+			return true
+		}
+	}
+	return false
+}
+
+// davProp is a property with its value as XML. Values may use the D, C and CS
+// prefixes writeMultistatus declares. explicit properties, such as
+// calendar-data, are left out of allprop.
+type davProp struct {
+	name     xml.Name
+	value    string
+	explicit bool
+}
+
+// davResponse is one response of a multistatus, either with properties or,
+// for members that are gone, with only a status.
+type davResponse struct {
+	href   string
+	props  []davProp
+	status int
+}
+
+// readDAVRequest reads a PROPFIND or REPORT body; an empty body asks for all
+// properties. When the body can not be read it writes the error response and
+// returns false.
+func readDAVRequest(w http.ResponseWriter, r *http.Request) (*davRequest, bool) {
+	request := &davRequest{}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCalDAVResourceSize))
+	if err == nil && strings.TrimSpace(string(body)) != "" {
+		err = xml.Unmarshal(body, request)
+	}
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid XML body"}, http.StatusBadRequest)
+		return nil, false
+	}
+	if request.Prop == nil && request.PropName == nil {
+		request.AllProp = &struct{}{}
+	}
+	return request, true
+}
+
+// writeMultistatus answers with the properties request asks for. syncToken
+// is only set for sync-collection reports.
+func writeMultistatus(w http.ResponseWriter, request *davRequest, responses []*davResponse, syncToken string) {
+	var body strings.Builder
+	body.WriteString(xml.Header)
+	body.WriteString(`<D:multistatus xmlns:D="DAV:" xmlns:C="` + calDAVNamespace + `" xmlns:CS="` + calendarServerNamespace + `">`)
+	for _, response := range responses {
+		body.WriteString("<D:response>" + hrefXML(response.href))
+		if response.status != 0 {
+			body.WriteString("<D:status>" + statusLine(response.status) + "</D:status></D:response>")
+			continue
+		}
+
+		found, missing := selectProps(response.props, request)
+		if len(found) > 0 {
+			body.WriteString("<D:propstat><D:prop>")
+			for _, prop := range found {
+				body.WriteString(propXML(prop.name, prop.value))
+			}
+			body.WriteString("</D:prop><D:status>" + statusLine(http.StatusOK) + "</D:status></D:propstat>")
+		}
+		if len(missing) > 0 {
+			body.WriteString("<D:propstat><D:prop>")
+			for _, name := range missing {
+				body.WriteString(propXML(name, ""))
+			}
+			body.WriteString("</D:prop><D:status>" + statusLine(http.StatusNotFound) + "</D:status></D:propstat>")
+		}
+		body.WriteString("</D:response>")
+	}
+	if syncToken != "" {
+		body.WriteString("<D:sync-token>" + xmlText(syncToken) + "</D:sync-token>")
+	}
+	body.WriteString("</D:multistatus>")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, body.String())
+}
+
+// selectProps splits the properties asked for into the ones the resource has
+// and the ones it does not.
+func selectProps(props []davProp, request *davRequest) ([]davProp, []xml.Name) {
+	found := []davProp{}
+	missing := []xml.Name{}
+	switch {
+	case request.Prop != nil:
+		for _, requested := range request.Prop.Names {
+			ok := false
+			for _, prop := range props {
+				if prop.name == requested.XMLName {
+					found = append(found, prop)
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				missing = append(missing, requested.XMLName)
+			}
+		}
+	case request.PropName != nil:
+		for _, prop := range props {
+			found = append(found, davProp{name: prop.name})
+		}
+	default:
+		for _, prop := range props {
+			if !prop.explicit {
+				found = append(found, prop)
+			}
+		}
+	}
+	return found, missing
+}
+
+func writeDAVError(w http.ResponseWriter, status int, precondition xml.Name) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	io.WriteString(w, xml.Header+`<D:error xmlns:D="DAV:">`+propXML(precondition, "")+"</D:error>")
+}
+
+func propXML(name xml.Name, value string) string {
+	return fmt.Sprintf(`<%s xmlns="%s">%s</%s>`, name.Local, xmlText(name.Space), value, name.Local)
+}
+
+func hrefXML(href string) string {
+	return "<D:href>" + xmlText((&url.URL{Path: href}).EscapedPath()) + "</D:href>"
+}
+
+func statusLine(status int) string {
+	return "HTTP/1.1 " + strconv.Itoa(status) + " " + http.StatusText(status)
+}
+
+func xmlText(value string) string {
+	var text strings.Builder
+	xml.EscapeText(&text, []byte(value))
+	return text.String()
+}
+
+func dav(local string) xml.Name {
+	return xml.Name{Space: davNamespace, Local: local}
+}
+
+func calDAV(local string) xml.Name {
+	return xml.Name{Space: calDAVNamespace, Local: local}
+}
+
+func rootProps() []davProp {
+	return []davProp{
+		{name: dav("resourcetype"), value: "<D:collection/>"},
+		{name: dav("current-user-principal"), value: hrefXML(calDAVPrincipal)},
+	}
+}
+
+func principalProps(user *models.User) []davProp {
+	return []davProp{
+		{name: dav("resourcetype"), value: "<D:principal/>"},
+		{name: dav("displayname"), value: xmlText(user.UserName)},
+		{name: dav("current-user-principal"), value: hrefXML(calDAVPrincipal)},
+		{name: dav("principal-URL"), value: hrefXML(calDAVPrincipal)},
+		{name: calDAV("calendar-home-set"), value: hrefXML(calDAVHome)},
+		{name: calDAV("calendar-user-address-set"), value: "<D:href>" + xmlText("mailto:"+user.Email) + "</D:href>"},
+	}
+}
+
+func homeProps() []davProp {
+	return []davProp{
+		{name: dav("resourcetype"), value: "<D:collection/>"},
+		{name: dav("displayname"), value: "Calendars"},
+		{name: dav("current-user-principal"), value: hrefXML(calDAVPrincipal)},
+		{name: dav("owner"), value: hrefXML(calDAVPrincipal)},
+	}
+}
+
+// calendarProps describes a calendar. Its sync token doubles as the CTag
+// older clients poll to see whether anything changed.
+func calendarProps(cal *calendar) ([]davProp, error) {
+	token, err := stores.GetStore().GetSyncToken()
+	if err != nil {
+		return nil, err
+	}
+	syncToken := xmlText(syncTokenPrefix + strconv.FormatInt(token, 10))
+	privileges := "<D:privilege><D:read/></D:privilege>"
+	if models.RoleAtLeast(cal.role, models.RoleEditor) {
+		privileges += "<D:privilege><D:write/></D:privilege><D:privilege><D:write-content/></D:privilege>" +
+			"<D:privilege><D:bind/></D:privilege><D:privilege><D:unbind/></D:privilege>"
+	}
+	return []davProp{
+		{name: dav("resourcetype"), value: "<D:collection/><C:calendar/>"},
+		{name: dav("displayname"), value: xmlText(cal.displayName)},
+		{name: dav("current-user-principal"), value: hrefXML(calDAVPrincipal)},
+		{name: dav("owner"), value: hrefXML(calDAVPrincipal)},
+		{name: dav("current-user-privilege-set"), value: privileges},
+		{name: dav("supported-report-set"), value: "<D:supported-report><D:report><C:calendar-query/></D:report></D:supported-report>" +
+			"<D:supported-report><D:report><C:calendar-multiget/></D:report></D:supported-report>" +
+			"<D:supported-report><D:report><D:sync-collection/></D:report></D:supported-report>"},
+		{name: dav("sync-token"), value: syncToken},
+		{name: xml.Name{Space: calendarServerNamespace, Local: "getctag"}, value: syncToken},
+		{name: calDAV("supported-calendar-component-set"), value: `<C:comp name="VTODO"/>`},
+	}, nil
+}
+
+func objectProps(object *calendarObject) []davProp {
+	return []davProp{
+		{name: dav("resourcetype")},
+		{name: dav("getetag"), value: xmlText(object.etag())},
+		{name: dav("getcontenttype"), value: "text/calendar; charset=utf-8; component=vtodo"},
+		{name: dav("getcontentlength"), value: strconv.Itoa(len(object.data))},
+		{name: dav("getlastmodified"), value: object.todo.UpdatedAt.UTC().Format(http.TimeFormat)},
+		{name: calDAV("calendar-data"), value: xmlText(string(object.data)), explicit: true},
+	}
+}
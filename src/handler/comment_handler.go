@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+
+	"github.com/gorilla/mux"
+)
+
+func prepareComment(comment *models.Comment, renderHTML bool) {
+	if renderHTML && comment.Body != "" {
+		comment.BodyHTML = lib.RenderMarkdown(comment.Body)
+	}
+}
+
+func GetCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	renderHTML, ok := renderHTMLRequested(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleViewer) {
+		return
+	}
+
+	comments, err := stores.GetStore().GetComments(todoID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get comments\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	for _, comment := range comments {
+		prepareComment(comment, renderHTML)
+	}
+
+	utility.WriteJsonData(w, comments, http.StatusOK)
+}
+
+func CreateCommentHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	comment := models.Comment{}
+	err = json.NewDecoder(r.Body).Decode(&comment)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleViewer) {
+		return
+	}
+
+	errors := validations.ValidateComment(&comment)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	newComment, err := stores.GetStore().CreateComment(&models.Comment{TodoID: todoID, UserID: user.ID, Body: comment.Body})
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create comment\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, newComment, http.StatusCreated)
+}
+
+func UpdateCommentHandler(w http.ResponseWriter, r *http.Request) {
+	update := models.Comment{}
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	comment, ok := findOwnComment(w, r, "edit")
+	if !ok {
+		return
+	}
+
+	update.TodoID = comment.TodoID
+	update.UserID = comment.UserID
+	errors := validations.ValidateComment(&update)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	updatedComment, err := stores.GetStore().UpdateComment(comment.ID, update.Body)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not update comment\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, updatedComment, http.StatusOK)
+}
+
+func DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	comment, ok := findOwnComment(w, r, "delete")
+	if !ok {
+		return
+	}
+
+	err := stores.GetStore().DeleteComment(comment.ID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Can not delete comment"}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, map[string]string{"message": "Comment deleted successfully. ID: " + strconv.Itoa(comment.ID)}, http.StatusOK)
+}
+
+// findOwnComment loads the comment named in the URL and makes sure the
+// requesting user wrote it and can still see its todo. It writes the error
+// response on failure.
+func findOwnComment(w http.ResponseWriter, r *http.Request, action string) (*models.Comment, bool) {
+	vars := mux.Vars(r)
+	commentID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return nil, false
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	comment, err := stores.GetStore().GetComment(commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Comment not found"}, http.StatusNotFound)
+			return nil, false
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get comment\n%v", err)}, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return nil, false
+	}
+	_, err = stores.GetStore().GetTodoRole(comment.TodoID, user.ID, workspaceID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Comment not found"}, http.StatusNotFound)
+		return nil, false
+	}
+	if comment.UserID != user.ID {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Only the author can %s this comment", action)}, http.StatusForbidden)
+		return nil, false
+	}
+	return comment, true
+}
+
+func GetActivityHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleViewer) {
+		return
+	}
+
+	comments, err := stores.GetStore().GetComments(todoID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get activity\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	changes, err := stores.GetStore().GetTodoChanges(todoID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get activity\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, mergeActivity(comments, changes), http.StatusOK)
+}
+
+// mergeActivity interleaves comments and field changes into one timeline,
+// oldest first. Entries at the same instant keep comments before changes.
+func mergeActivity(comments []*models.Comment, changes []*models.TodoChange) []*models.Activity {
+	activity := make([]*models.Activity, 0, len(comments)+len(changes))
+	for _, comment := range comments {
+		activity = append(activity, &models.Activity{Type: "comment", At: comment.CreatedAt, UserID: comment.UserID, Comment: comment})
+	}
+	for _, change := range changes {
+		activity = append(activity, &models.Activity{Type: "change", At: change.CreatedAt, UserID: change.UserID, Change: change})
+	}
+	sort.SliceStable(activity, func(i, j int) bool {
+		return activity[i].At.Before(activity[j].At)
+	})
+	return activity
+}
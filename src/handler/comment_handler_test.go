@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+)
+
+func commentRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/todos/{id:[0-9]+}/comments", GetCommentsHandler).Methods("GET")
+	r.HandleFunc("/todos/{id:[0-9]+}/comments", CreateCommentHandler).Methods("POST")
+	r.HandleFunc("/todos/{id:[0-9]+}/activity", GetActivityHandler).Methods("GET")
+	r.HandleFunc("/comments/{id:[0-9]+}", UpdateCommentHandler).Methods("PATCH")
+	r.HandleFunc("/comments/{id:[0-9]+}", DeleteCommentHandler).Methods("DELETE")
+	return r
+}
+
+func TestCommentHandlers(t *testing.T) {
+	type testCase struct {
+		name           string
+		method         string
+		url            string
+		payload        string
+		expectedBody   interface{}
+		expectedStatus int
+		mockReturn     func(*stores.MockStore)
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC()
+
+	tests := []testCase{
+		{
+			name:           "Create Comment",
+			method:         "POST",
+			url:            "/todos/5/comments",
+			payload:        `{"body": "Looks **good**", "user_id": 99}`,
+			expectedBody:   &models.Comment{ID: 3, TodoID: 5, UserID: 1, Body: "Looks **good**", CreatedAt: createdAt},
+			expectedStatus: http.StatusCreated,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+				mockStore.On("CreateComment", &models.Comment{TodoID: 5, UserID: 1, Body: "Looks **good**"}).Return(&models.Comment{ID: 3, TodoID: 5, UserID: 1, Body: "Looks **good**", CreatedAt: createdAt}, nil)
+			},
+		},
+		{
+			name:           "Create Empty Comment",
+			method:         "POST",
+			url:            "/todos/5/comments",
+			payload:        `{"body": ""}`,
+			expectedBody:   map[string]string{"Body": "This field is required"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+			},
+		},
+		{
+			name:           "Comment On Inaccessible Todo",
+			method:         "POST",
+			url:            "/todos/5/comments",
+			payload:        `{"body": "hi"}`,
+			expectedBody:   map[string]string{"error": "Todo not found"},
+			expectedStatus: http.StatusNotFound,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("", sql.ErrNoRows)
+			},
+		},
+		{
+			name:           "List Rendered Comments",
+			method:         "GET",
+			url:            "/todos/5/comments?render=html",
+			expectedBody:   []*models.Comment{{ID: 3, TodoID: 5, UserID: 1, Body: "Looks **good**", BodyHTML: "<p>Looks <strong>good</strong></p>\n", CreatedAt: createdAt}},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+				mockStore.On("GetComments", 5).Return([]*models.Comment{{ID: 3, TodoID: 5, UserID: 1, Body: "Looks **good**", CreatedAt: createdAt}}, nil)
+			},
+		},
+		{
+			name:           "Edit Own Comment",
+			method:         "PATCH",
+			url:            "/comments/3",
+			payload:        `{"body": "Edited"}`,
+			expectedBody:   &models.Comment{ID: 3, TodoID: 5, UserID: 1, Body: "Edited", CreatedAt: createdAt},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetComment", 3).Return(&models.Comment{ID: 3, TodoID: 5, UserID: 1, Body: "Looks good", CreatedAt: createdAt}, nil)
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+				mockStore.On("UpdateComment", 3, "Edited").Return(&models.Comment{ID: 3, TodoID: 5, UserID: 1, Body: "Edited", CreatedAt: createdAt}, nil)
+			},
+		},
+		{
+			name:           "Edit Someone Elses Comment",
+			method:         "PATCH",
+			url:            "/comments/3",
+			payload:        `{"body": "Edited"}`,
+			expectedBody:   map[string]string{"error": "Only the author can edit this comment"},
+			expectedStatus: http.StatusForbidden,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetComment", 3).Return(&models.Comment{ID: 3, TodoID: 5, UserID: 2, Body: "Looks good", CreatedAt: createdAt}, nil)
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+			},
+		},
+		{
+			name:           "Delete Someone Elses Comment",
+			method:         "DELETE",
+			url:            "/comments/3",
+			expectedBody:   map[string]string{"error": "Only the author can delete this comment"},
+			expectedStatus: http.StatusForbidden,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetComment", 3).Return(&models.Comment{ID: 3, TodoID: 5, UserID: 2, Body: "Looks good", CreatedAt: createdAt}, nil)
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+			},
+		},
+		{
+			name:           "Delete Own Comment",
+			method:         "DELETE",
+			url:            "/comments/3",
+			expectedBody:   map[string]string{"message": "Comment deleted successfully. ID: 3"},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetComment", 3).Return(&models.Comment{ID: 3, TodoID: 5, UserID: 1, Body: "Looks good", CreatedAt: createdAt}, nil)
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+				mockStore.On("DeleteComment", 3).Return(nil)
+			},
+		},
+		{
+			name:           "Delete Missing Comment",
+			method:         "DELETE",
+			url:            "/comments/3",
+			expectedBody:   map[string]string{"error": "Comment not found"},
+			expectedStatus: http.StatusNotFound,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetComment", 3).Return((*models.Comment)(nil), sql.ErrNoRows)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tc.mockReturn(mockStore)
+			stores.InitStore(mockStore)
+
+			req, err := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.payload))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+
+			recorder := httptest.NewRecorder()
+			commentRouter().ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+
+			decoded := reflect.New(reflect.TypeOf(tc.expectedBody)).Interface()
+			if err := json.NewDecoder(recorder.Body).Decode(decoded); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			if got := reflect.ValueOf(decoded).Elem().Interface(); !reflect.DeepEqual(got, tc.expectedBody) {
+				t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", got, tc.expectedBody)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetActivityHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	start := time.Date(2024, 11, 30, 12, 0, 0, 0, time.UTC).UTC()
+
+	mockStore := stores.InitMockStore()
+	mockAuthenticatedUser(mockStore)
+	mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+	mockStore.On("GetComments", 5).Return([]*models.Comment{
+		{ID: 1, TodoID: 5, UserID: 1, Body: "first", CreatedAt: start},
+		{ID: 2, TodoID: 5, UserID: 2, Body: "third", CreatedAt: start.Add(2 * time.Minute)},
+	}, nil)
+	mockStore.On("GetTodoChanges", 5).Return([]*models.TodoChange{
+		{ID: 1, TodoID: 5, UserID: 2, Field: "completed", OldValue: "false", NewValue: "true", CreatedAt: start.Add(time.Minute)},
+	}, nil)
+	stores.InitStore(mockStore)
+
+	req, err := http.NewRequest("GET", "/todos/5/activity", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+*token)
+
+	recorder := httptest.NewRecorder()
+	commentRouter().ServeHTTP(recorder, req)
+
+	if status := recorder.Code; status != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var activity []*models.Activity
+	if err := json.NewDecoder(recorder.Body).Decode(&activity); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+	types := []string{}
+	for _, entry := range activity {
+		types = append(types, entry.Type)
+	}
+	if !reflect.DeepEqual(types, []string{"comment", "change", "comment"}) {
+		t.Errorf("Handler returned unexpected timeline: %v", types)
+	}
+	if activity[1].Change.Field != "completed" || activity[2].Comment.Body != "third" {
+		t.Errorf("Handler returned unexpected entries: %+v %+v", activity[1].Change, activity[2].Comment)
+	}
+
+	mockStore.AssertExpectations(t)
+}
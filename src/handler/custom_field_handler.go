@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+
+	"github.com/gorilla/mux"
+)
+
+// projectFields authenticates the user, checks they hold at least role in
+// the workspace of the path and that the project of the path belongs to it.
+func projectFields(w http.ResponseWriter, r *http.Request, role string) (int, bool) {
+	vars := mux.Vars(r)
+	workspaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return 0, false
+	}
+	projectID, err := strconv.Atoi(vars["projectID"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return 0, false
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return 0, false
+	}
+	if _, ok := authorizeWorkspace(w, workspaceID, user.ID, role); !ok {
+		return 0, false
+	}
+	if _, err := stores.GetStore().GetProject(projectID, workspaceID); err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Project not found"}, http.StatusNotFound)
+		return 0, false
+	}
+	return projectID, true
+}
+
+func GetCustomFieldsHandler(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFields(w, r, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	fields, err := stores.GetStore().GetCustomFields(projectID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get custom fields\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, fields, http.StatusOK)
+}
+
+func CreateCustomFieldHandler(w http.ResponseWriter, r *http.Request) {
+	field := &models.CustomField{}
+	err := json.NewDecoder(r.Body).Decode(field)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	projectID, ok := projectFields(w, r, models.RoleEditor)
+	if !ok {
+		return
+	}
+
+	errors := validations.ValidateCustomField(field)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+	field.ProjectID = projectID
+
+	newField, err := stores.GetStore().CreateCustomField(field)
+	if err != nil {
+		if err == stores.ErrCustomFieldKeyTaken {
+			utility.WriteJsonData(w, map[string]string{"error": err.Error()}, http.StatusConflict)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create custom field\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, newField, http.StatusCreated)
+}
+
+// UpdateCustomFieldHandler replaces the definition of a field. Changes that
+// values todos already hold would not pass are refused with 409, so clear or
+// change those values first.
+func UpdateCustomFieldHandler(w http.ResponseWriter, r *http.Request) {
+	fieldID, err := strconv.Atoi(mux.Vars(r)["fieldID"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+	field := &models.CustomField{}
+	err = json.NewDecoder(r.Body).Decode(field)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	projectID, ok := projectFields(w, r, models.RoleEditor)
+	if !ok {
+		return
+	}
+
+	errors := validations.ValidateCustomField(field)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+	field.ID = fieldID
+	field.ProjectID = projectID
+
+	updatedField, err := stores.GetStore().UpdateCustomField(field)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			utility.WriteJsonData(w, map[string]string{"error": "Custom field not found"}, http.StatusNotFound)
+		case stores.ErrCustomFieldKeyChanged:
+			utility.WriteJsonData(w, map[string]string{"error": err.Error()}, http.StatusBadRequest)
+		case stores.ErrCustomFieldInUse:
+			utility.WriteJsonData(w, map[string]string{"error": err.Error()}, http.StatusConflict)
+		default:
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not update custom field\n%v", err)}, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	utility.WriteJsonData(w, updatedField, http.StatusOK)
+}
+
+// DeleteCustomFieldHandler removes a field and the values todos hold for it.
+func DeleteCustomFieldHandler(w http.ResponseWriter, r *http.Request) {
+	fieldID, err := strconv.Atoi(mux.Vars(r)["fieldID"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	projectID, ok := projectFields(w, r, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	err = stores.GetStore().DeleteCustomField(fieldID, projectID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Custom field not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not delete custom field\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, map[string]string{"message": "Custom field deleted"}, http.StatusOK)
+}
+
+// checkCustomFields drops the null custom field values of todo and checks
+// the rest against the fields of its project.
+func checkCustomFields(w http.ResponseWriter, todo *models.Todo, fields []*models.CustomField) bool {
+	for key, value := range todo.CustomFields {
+		if value == nil {
+			delete(todo.CustomFields, key)
+		}
+	}
+	errors := validations.ValidateCustomFieldValues(todo.CustomFields, fields)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// checkNewTodoCustomFields checks the custom field values of a todo about to
+// be created against the fields of the project it goes in, if any.
+func checkNewTodoCustomFields(w http.ResponseWriter, todo *models.Todo) bool {
+	var fields []*models.CustomField
+	if todo.ProjectID != 0 {
+		var err error
+		fields, err = stores.GetStore().GetCustomFields(todo.ProjectID)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get custom fields\n%v", err)}, http.StatusInternalServerError)
+			return false
+		}
+	}
+	return checkCustomFields(w, todo, fields)
+}
+
+// customFieldFilter reads ?project_id and the custom field parameters of
+// GET /todos into filter: ?field.<key>=<value> keeps the todos holding that
+// value and ?sort=field.<key> or ?sort=-field.<key> orders them by it.
+// Filtering needs project_id, whose fields tell how to read each value.
+func customFieldFilter(w http.ResponseWriter, r *http.Request, filter *models.TodoFilter, workspaceID int) bool {
+	query := r.URL.Query()
+	if projectID := query.Get("project_id"); projectID != "" {
+		var err error
+		filter.ProjectID, err = strconv.Atoi(projectID)
+		if err != nil || filter.ProjectID <= 0 {
+			utility.WriteJsonData(w, map[string]string{"error": "project_id must be a project id"}, http.StatusBadRequest)
+			return false
+		}
+	}
+
+	if order := query.Get("sort"); order != "" {
+		key := strings.TrimPrefix(order, "-")
+		if !strings.HasPrefix(key, "field.") || strings.TrimPrefix(key, "field.") == "" {
+			utility.WriteJsonData(w, map[string]string{"error": "sort must be field.<key> or -field.<key>"}, http.StatusBadRequest)
+			return false
+		}
+		filter.SortField = strings.TrimPrefix(key, "field.")
+		filter.SortDescending = strings.HasPrefix(order, "-")
+	}
+
+	keys := []string{}
+	for parameter := range query {
+		if strings.HasPrefix(parameter, "field.") {
+			keys = append(keys, strings.TrimPrefix(parameter, "field."))
+		}
+	}
+	if len(keys) == 0 {
+		return true
+	}
+	sort.Strings(keys)
+	if filter.ProjectID == 0 {
+		utility.WriteJsonData(w, map[string]string{"error": "Filtering by custom fields needs a project_id"}, http.StatusBadRequest)
+		return false
+	}
+	if _, err := stores.GetStore().GetProject(filter.ProjectID, workspaceID); err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Project not found"}, http.StatusBadRequest)
+		return false
+	}
+	fields, err := stores.GetStore().GetCustomFields(filter.ProjectID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get custom fields\n%v", err)}, http.StatusInternalServerError)
+		return false
+	}
+	byKey := map[string]*models.CustomField{}
+	for _, field := range fields {
+		byKey[field.Key] = field
+	}
+
+	for _, key := range keys {
+		field, ok := byKey[key]
+		if !ok {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("The project has no field %s", key)}, http.StatusBadRequest)
+			return false
+		}
+		for _, raw := range query["field."+key] {
+			value, ok := customFieldValue(field, raw)
+			if !ok {
+				utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("field.%s must be a %s value", key, field.Type)}, http.StatusBadRequest)
+				return false
+			}
+			filter.Fields = append(filter.Fields, models.CustomFieldMatch{Key: key, Value: value})
+		}
+	}
+	return true
+}
+
+// customFieldValue reads a query parameter as a value of field, in the form
+// todos store it. A multi-select value matches todos that include the option.
+func customFieldValue(field *models.CustomField, raw string) (any, bool) {
+	switch field.Type {
+	case models.FieldNumber:
+		number, err := strconv.ParseFloat(raw, 64)
+		return number, err == nil
+	case models.FieldDate:
+		_, err := time.Parse("2006-01-02", raw)
+		return raw, err == nil
+	case models.FieldMultiSelect:
+		return []string{raw}, true
+	default:
+		return raw, true
+	}
+}
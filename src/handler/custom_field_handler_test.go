@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomFieldHandlers(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	createdAt := time.Date(2024, 12, 2, 9, 0, 0, 0, time.UTC)
+	stage := &models.CustomField{ID: 4, ProjectID: 2, Key: "stage", Name: "Stage", Type: models.FieldSelect, Options: []string{"lead", "won", "lost"}, CreatedAt: createdAt}
+	estimate := &models.CustomField{ID: 5, ProjectID: 2, Key: "estimate", Name: "Estimate", Type: models.FieldNumber, Required: true, CreatedAt: createdAt}
+	stageJSON := map[string]interface{}{"id": float64(4), "project_id": float64(2), "key": "stage", "name": "Stage", "type": "select", "options": []interface{}{"lead", "won", "lost"}, "required": false, "created_at": "2024-12-02T09:00:00Z"}
+	inWorkspace := func(role string) func(mockStore *stores.MockStore) {
+		return func(mockStore *stores.MockStore) {
+			mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Role: role}, nil)
+			mockStore.On("GetProject", 2, 4).Return(&models.Project{ID: 2, WorkspaceID: 4, Name: "Sales"}, nil)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		header         string
+		body           string
+		mockSetup      func(mockStore *stores.MockStore)
+		expectedStatus int
+		expectedBody   interface{}
+	}{
+		{
+			name:   "List",
+			method: "GET",
+			url:    "/workspaces/4/projects/2/fields",
+			mockSetup: func(mockStore *stores.MockStore) {
+				inWorkspace("viewer")(mockStore)
+				mockStore.On("GetCustomFields", 2).Return([]*models.CustomField{stage}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []interface{}{stageJSON},
+		},
+		{
+			name:   "Create",
+			method: "POST",
+			url:    "/workspaces/4/projects/2/fields",
+			body:   `{"key": "stage", "name": "Stage", "type": "select", "options": ["lead", "won", "lost"]}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				inWorkspace("editor")(mockStore)
+				mockStore.On("CreateCustomField", &models.CustomField{ProjectID: 2, Key: "stage", Name: "Stage", Type: "select", Options: stage.Options}).Return(stage, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   stageJSON,
+		},
+		{
+			name:           "Create Invalid",
+			method:         "POST",
+			url:            "/workspaces/4/projects/2/fields",
+			body:           `{"key": "Deal Stage", "name": "Stage", "type": "select"}`,
+			mockSetup:      inWorkspace("editor"),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"Key":     "Must start with a lower case letter followed by lower case letters, digits or underscores",
+				"Options": "Select fields need at least one option",
+			},
+		},
+		{
+			name:   "Create Taken Key",
+			method: "POST",
+			url:    "/workspaces/4/projects/2/fields",
+			body:   `{"key": "stage", "name": "Stage", "type": "text"}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				inWorkspace("editor")(mockStore)
+				mockStore.On("CreateCustomField", &models.CustomField{ProjectID: 2, Key: "stage", Name: "Stage", Type: "text"}).Return((*models.CustomField)(nil), stores.ErrCustomFieldKeyTaken)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   map[string]interface{}{"error": "the project already has a field with this key"},
+		},
+		{
+			name:   "Create In Other Workspace",
+			method: "POST",
+			url:    "/workspaces/4/projects/3/fields",
+			body:   `{"key": "stage", "name": "Stage", "type": "text"}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Role: "owner"}, nil)
+				mockStore.On("GetProject", 3, 4).Return((*models.Project)(nil), sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   map[string]interface{}{"error": "Project not found"},
+		},
+		{
+			name:   "Update In Use",
+			method: "PUT",
+			url:    "/workspaces/4/projects/2/fields/4",
+			body:   `{"key": "stage", "name": "Stage", "type": "select", "options": ["lead", "won"]}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				inWorkspace("editor")(mockStore)
+				mockStore.On("UpdateCustomField", &models.CustomField{ID: 4, ProjectID: 2, Key: "stage", Name: "Stage", Type: "select", Options: []string{"lead", "won"}}).Return((*models.CustomField)(nil), stores.ErrCustomFieldInUse)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   map[string]interface{}{"error": "todos hold values the change would make invalid"},
+		},
+		{
+			name:   "Delete As Editor",
+			method: "DELETE",
+			url:    "/workspaces/4/projects/2/fields/4",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Role: "editor"}, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   map[string]interface{}{"error": "This action requires the owner role"},
+		},
+		{
+			name:   "Delete",
+			method: "DELETE",
+			url:    "/workspaces/4/projects/2/fields/4",
+			mockSetup: func(mockStore *stores.MockStore) {
+				inWorkspace("owner")(mockStore)
+				mockStore.On("DeleteCustomField", 4, 2).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"message": "Custom field deleted"},
+		},
+		{
+			name:   "Create Todo With Invalid Values",
+			method: "POST",
+			url:    "/todos",
+			header: "4",
+			body:   `{"task_name": "Close the deal", "project_id": 2, "custom_fields": {"stage": "maybe", "customer": "Acme"}}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				inWorkspace("editor")(mockStore)
+				mockStore.On("GetCustomFields", 2).Return([]*models.CustomField{stage, estimate}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"CustomFields.stage":    "Must be one of: lead, won, lost",
+				"CustomFields.estimate": "This field is required",
+				"CustomFields.customer": "Unknown field",
+			},
+		},
+		{
+			name:   "Update Todo Values",
+			method: "PUT",
+			url:    "/todos/9",
+			body:   `{"task_name": "Close the deal", "custom_fields": {"stage": "won", "estimate": 3, "customer": null}}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 9, 1, 0).Return("editor", nil)
+				mockStore.On("GetTodoCustomFields", 9).Return([]*models.CustomField{stage, estimate}, nil)
+				mockStore.On("UpdateTodo", &models.Todo{TaskName: "Close the deal", CustomFields: map[string]any{"stage": "won", "estimate": float64(3)}}, 9, 1, false).
+					Return(&models.Todo{ID: 9, TaskName: "Close the deal", ProjectID: 2, CustomFields: map[string]any{"stage": "won", "estimate": float64(3)}}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody: map[string]interface{}{"id": float64(9), "task_name": "Close the deal", "project_id": float64(2), "completed": false, "blocked": false, "notes": "",
+				"custom_fields": map[string]interface{}{"stage": "won", "estimate": float64(3)},
+				"due_date":      "0001-01-01T00:00:00Z", "created_at": "0001-01-01T00:00:00Z", "updated_at": "0001-01-01T00:00:00Z"},
+		},
+		{
+			name:   "Filter And Sort Todos",
+			method: "GET",
+			url:    "/todos?project_id=2&field.stage=won&field.estimate=3&sort=-field.estimate",
+			header: "4",
+			mockSetup: func(mockStore *stores.MockStore) {
+				inWorkspace("viewer")(mockStore)
+				mockStore.On("GetCustomFields", 2).Return([]*models.CustomField{stage, estimate}, nil)
+				mockStore.On("GetTodos", 1, 4, &models.TodoFilter{
+					ProjectID:      2,
+					Fields:         []models.CustomFieldMatch{{Key: "estimate", Value: float64(3)}, {Key: "stage", Value: "won"}},
+					SortField:      "estimate",
+					SortDescending: true,
+				}).Return([]*models.Todo{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []interface{}{},
+		},
+		{
+			name:   "Filter With Invalid Value",
+			method: "GET",
+			url:    "/todos?project_id=2&field.estimate=soon",
+			header: "4",
+			mockSetup: func(mockStore *stores.MockStore) {
+				inWorkspace("viewer")(mockStore)
+				mockStore.On("GetCustomFields", 2).Return([]*models.CustomField{stage, estimate}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"error": "field.estimate must be a number value"},
+		},
+		{
+			name:           "Filter Without Project",
+			method:         "GET",
+			url:            "/todos?field.stage=won",
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"error": "Filtering by custom fields needs a project_id"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tt.mockSetup(mockStore)
+			stores.InitStore(mockStore)
+
+			req, _ := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+*token)
+			if tt.header != "" {
+				req.Header.Set("X-Workspace-ID", tt.header)
+			}
+			recorder := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/workspaces/{id:[0-9]+}/projects/{projectID:[0-9]+}/fields", GetCustomFieldsHandler).Methods("GET")
+			r.HandleFunc("/workspaces/{id:[0-9]+}/projects/{projectID:[0-9]+}/fields", CreateCustomFieldHandler).Methods("POST")
+			r.HandleFunc("/workspaces/{id:[0-9]+}/projects/{projectID:[0-9]+}/fields/{fieldID:[0-9]+}", UpdateCustomFieldHandler).Methods("PUT")
+			r.HandleFunc("/workspaces/{id:[0-9]+}/projects/{projectID:[0-9]+}/fields/{fieldID:[0-9]+}", DeleteCustomFieldHandler).Methods("DELETE")
+			r.HandleFunc("/todos", GetTodosHandler).Methods("GET")
+			r.HandleFunc("/todos", CreateTodoHandler).Methods("POST")
+			r.HandleFunc("/todos/{id:[0-9]+}", UpdateTodoHandler).Methods("PUT")
+			r.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			var body interface{}
+			if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			assert.Equal(t, tt.expectedBody, body)
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
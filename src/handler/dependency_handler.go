@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+
+	"github.com/gorilla/mux"
+)
+
+// AddDependencyHandler makes a todo wait for another one. The caller needs to
+// edit the blocked todo and see the blocking one.
+func AddDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	dependency := models.Dependency{}
+	err = json.NewDecoder(r.Body).Decode(&dependency)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	role, err := stores.GetStore().GetTodoRole(todoID, user.ID, workspaceID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Todo not found"}, http.StatusNotFound)
+		return
+	}
+	if !models.RoleAtLeast(role, models.RoleEditor) {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("This action requires the %s role", models.RoleEditor)}, http.StatusForbidden)
+		return
+	}
+
+	if dependency.BlockedBy == todoID {
+		utility.WriteJsonData(w, map[string]string{"BlockedBy": "A todo can not block itself"}, http.StatusBadRequest)
+		return
+	}
+	_, err = stores.GetStore().GetTodoRole(dependency.BlockedBy, user.ID, workspaceID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"BlockedBy": "Todo not found"}, http.StatusBadRequest)
+		return
+	}
+
+	err = stores.GetStore().AddDependency(todoID, dependency.BlockedBy)
+	if err == stores.ErrDependencyCycle {
+		utility.WriteJsonData(w, map[string]string{"error": "The dependency would create a cycle"}, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not add dependency\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, dependency, http.StatusCreated)
+}
+
+func RemoveDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+	blockedByID, err := strconv.Atoi(vars["blockerID"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleEditor) {
+		return
+	}
+
+	err = stores.GetStore().RemoveDependency(todoID, blockedByID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Dependency not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not remove dependency\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, map[string]string{"message": "Dependency deleted successfully. ID: " + vars["blockerID"]}, http.StatusOK)
+}
+
+// GetDependencyGraphHandler returns every todo the given one transitively
+// waits for or blocks, with the edges between them.
+func GetDependencyGraphHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+	_, err = stores.GetStore().GetTodoRole(todoID, user.ID, workspaceID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Todo not found"}, http.StatusNotFound)
+		return
+	}
+
+	graph, err := stores.GetStore().GetDependencyGraph(todoID, user.ID, workspaceID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get dependency graph\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, graph, http.StatusOK)
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDependencyHandlers(t *testing.T) {
+	type testCase struct {
+		name           string
+		method         string
+		url            string
+		payload        string
+		expectedBody   interface{}
+		expectedStatus int
+		mockReturn     func(*stores.MockStore)
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	tests := []testCase{
+		{
+			name:           "Add Dependency",
+			method:         "POST",
+			url:            "/todos/5/dependencies",
+			payload:        `{"blocked_by": 3}`,
+			expectedBody:   models.Dependency{BlockedBy: 3},
+			expectedStatus: http.StatusCreated,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("editor", nil)
+				mockStore.On("GetTodoRole", 3, 1, 0).Return("viewer", nil)
+				mockStore.On("AddDependency", 5, 3).Return(nil)
+			},
+		},
+		{
+			name:           "Add Dependency Creating A Cycle",
+			method:         "POST",
+			url:            "/todos/5/dependencies",
+			payload:        `{"blocked_by": 3}`,
+			expectedBody:   map[string]string{"error": "The dependency would create a cycle"},
+			expectedStatus: http.StatusConflict,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+				mockStore.On("GetTodoRole", 3, 1, 0).Return("owner", nil)
+				mockStore.On("AddDependency", 5, 3).Return(stores.ErrDependencyCycle)
+			},
+		},
+		{
+			name:           "Add Dependency On Itself",
+			method:         "POST",
+			url:            "/todos/5/dependencies",
+			payload:        `{"blocked_by": 5}`,
+			expectedBody:   map[string]string{"BlockedBy": "A todo can not block itself"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+			},
+		},
+		{
+			name:           "Add Dependency On Invisible Todo",
+			method:         "POST",
+			url:            "/todos/5/dependencies",
+			payload:        `{"blocked_by": 9}`,
+			expectedBody:   map[string]string{"BlockedBy": "Todo not found"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+				mockStore.On("GetTodoRole", 9, 1, 0).Return("", sql.ErrNoRows)
+			},
+		},
+		{
+			name:           "Add Dependency As Viewer",
+			method:         "POST",
+			url:            "/todos/5/dependencies",
+			payload:        `{"blocked_by": 3}`,
+			expectedBody:   map[string]string{"error": "This action requires the editor role"},
+			expectedStatus: http.StatusForbidden,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+			},
+		},
+		{
+			name:           "Remove Dependency",
+			method:         "DELETE",
+			url:            "/todos/5/dependencies/3",
+			expectedBody:   map[string]string{"message": "Dependency deleted successfully. ID: 3"},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("editor", nil)
+				mockStore.On("RemoveDependency", 5, 3).Return(nil)
+			},
+		},
+		{
+			name:   "Get Dependency Graph",
+			method: "GET",
+			url:    "/todos/5/graph",
+			expectedBody: models.DependencyGraph{
+				Nodes: []*models.GraphNode{{ID: 3, TaskName: "Design", Blocked: false}, {ID: 5, TaskName: "Build", Blocked: true}, {ID: 8, Hidden: true}},
+				Edges: []*models.GraphEdge{{TodoID: 5, BlockedBy: 3}, {TodoID: 8, BlockedBy: 5}},
+			},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+				mockStore.On("GetDependencyGraph", 5, 1, 0).Return(&models.DependencyGraph{
+					Nodes: []*models.GraphNode{{ID: 3, TaskName: "Design", Blocked: false}, {ID: 5, TaskName: "Build", Blocked: true}, {ID: 8, Hidden: true}},
+					Edges: []*models.GraphEdge{{TodoID: 5, BlockedBy: 3}, {TodoID: 8, BlockedBy: 5}},
+				}, nil)
+			},
+		},
+		{
+			name:           "Complete Blocked Todo",
+			method:         "PUT",
+			url:            "/todos/5",
+			payload:        `{"task_name": "Build", "completed": true, "due_date": "2024-11-30T23:59:59Z"}`,
+			expectedBody:   map[string]interface{}{"error": "This todo is blocked by open todos", "blocked_by": []interface{}{float64(3)}},
+			expectedStatus: http.StatusConflict,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("editor", nil)
+				mockStore.On("UpdateTodo", mock.Anything, 5, 1, false).Return((*models.Todo)(nil), &stores.BlockedError{BlockerIDs: []int{3}})
+			},
+		},
+		{
+			name:           "Force Complete Blocked Todo",
+			method:         "PUT",
+			url:            "/todos/5?force=true",
+			payload:        `{"task_name": "Build", "completed": true, "due_date": "2024-11-30T23:59:59Z"}`,
+			expectedBody:   models.Todo{ID: 5, TaskName: "Build", Completed: true},
+			expectedStatus: http.StatusCreated,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("editor", nil)
+				mockStore.On("UpdateTodo", mock.Anything, 5, 1, true).Return(&models.Todo{ID: 5, TaskName: "Build", Completed: true}, nil)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tc.mockReturn(mockStore)
+			stores.InitStore(mockStore)
+
+			req, err := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.payload))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+
+			r := mux.NewRouter()
+			r.HandleFunc("/todos/{id:[0-9]+}", UpdateTodoHandler).Methods("PUT")
+			r.HandleFunc("/todos/{id:[0-9]+}/dependencies", AddDependencyHandler).Methods("POST")
+			r.HandleFunc("/todos/{id:[0-9]+}/dependencies/{blockerID:[0-9]+}", RemoveDependencyHandler).Methods("DELETE")
+			r.HandleFunc("/todos/{id:[0-9]+}/graph", GetDependencyGraphHandler).Methods("GET")
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+
+			decoded := reflect.New(reflect.TypeOf(tc.expectedBody)).Interface()
+			if err := json.NewDecoder(recorder.Body).Decode(decoded); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			if got := reflect.ValueOf(decoded).Elem().Interface(); !reflect.DeepEqual(got, tc.expectedBody) {
+				t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", got, tc.expectedBody)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"todo-list/src/exporter"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+)
+
+// GetExportHandler downloads the todos GET /todos lists, with the same
+// filters, as ?format=csv, json (the default), ndjson, markdown, todotxt or
+// ical. Todos are written as they are read, so the response is streamed. A
+// failure before anything reached the client is answered with an error;
+// after that it can only cut the download short.
+func GetExportHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = models.ExportJSON
+	}
+	if exporter.ContentType(format) == "" {
+		utility.WriteJsonData(w, map[string]string{"error": "format must be one of: csv, json, ndjson, markdown, todotxt, ical"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+	filter, ok := todoFilter(w, r, user, workspaceID)
+	if !ok {
+		return
+	}
+
+	sent := &sentWriter{w: w}
+	out := bufio.NewWriter(sent)
+	encoder, err := exporter.NewEncoder(format, out)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	fileName := fmt.Sprintf("todos-%s.%s", time.Now().UTC().Format("2006-01-02"), exporter.Extension(format))
+	w.Header().Set("Content-Type", exporter.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+
+	err = stores.GetStore().ExportTodos(user.ID, workspaceID, filter, encoder.Encode)
+	if err == nil {
+		err = encoder.Close()
+	}
+	if err != nil && !sent.sent {
+		w.Header().Del("Content-Disposition")
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not export todos\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		log.Printf("Can not export todos for user %d: %v", user.ID, err)
+	}
+}
+
+// sentWriter records whether anything was written to the response yet.
+type sentWriter struct {
+	w    http.ResponseWriter
+	sent bool
+}
+
+func (s *sentWriter) Write(p []byte) (int, error) {
+	s.sent = true
+	return s.w.Write(p)
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetExportHandler(t *testing.T) {
+	type testCase struct {
+		name                string
+		url                 string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+		expectedAttachment  bool
+		mockReturn          func(*stores.MockStore)
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	dueDate := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	todos := []*models.Todo{
+		{ID: 1, TaskName: "Buy milk", DueDate: dueDate, Priority: 2, Tags: []string{"home"}},
+		{ID: 2, TaskName: "Clean garage", Completed: true, Tags: []string{"home"}},
+	}
+	manyTodos := []*models.Todo{}
+	for i := 0; i < 500; i++ {
+		manyTodos = append(manyTodos, &models.Todo{TaskName: fmt.Sprintf("Task number %d", i)})
+	}
+
+	tests := []testCase{
+		{
+			name:                "CSV Filtered By Tag",
+			url:                 "/todos/export?format=csv&tag=%23Home",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "task_name,completed,due_date,notes,priority,tags\nBuy milk,false,2024-12-01T00:00:00Z,,2,home\nClean garage,true,,,0,home\n",
+			expectedAttachment:  true,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("ExportTodos", 1, 0, &models.TodoFilter{Tag: "home"}).Return(todos, nil)
+			},
+		},
+		{
+			name:                "JSON By Default",
+			url:                 "/todos/export",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        "[]\n",
+			expectedAttachment:  true,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("ExportTodos", 1, 0, &models.TodoFilter{}).Return([]*models.Todo{}, nil)
+			},
+		},
+		{
+			name:                "Unknown Format",
+			url:                 "/todos/export?format=xlsx",
+			expectedStatus:      http.StatusBadRequest,
+			expectedContentType: "application/json",
+			expectedBody:        "{\"error\":\"format must be one of: csv, json, ndjson, markdown, todotxt, ical\"}\n",
+			mockReturn:          func(mockStore *stores.MockStore) {},
+		},
+		{
+			name:                "Store Error Before Output",
+			url:                 "/todos/export?format=markdown",
+			expectedStatus:      http.StatusInternalServerError,
+			expectedContentType: "application/json",
+			expectedBody:        "{\"error\":\"Can not export todos\\nsome db error\"}\n",
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("ExportTodos", 1, 0, &models.TodoFilter{}).Return(todos, errors.New("some db error"))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			if tc.expectedStatus != http.StatusBadRequest {
+				mockAuthenticatedUser(mockStore)
+			}
+			tc.mockReturn(mockStore)
+			stores.InitStore(mockStore)
+
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+
+			r := mux.NewRouter()
+			r.HandleFunc("/todos/export", GetExportHandler).Methods("GET")
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+			assert.Equal(t, tc.expectedContentType, recorder.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedBody, recorder.Body.String())
+			if tc.expectedAttachment {
+				assert.Regexp(t, `^attachment; filename="todos-\d{4}-\d{2}-\d{2}\.(csv|json)"$`, recorder.Header().Get("Content-Disposition"))
+			} else {
+				assert.Empty(t, recorder.Header().Get("Content-Disposition"))
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Store Error After Output", func(t *testing.T) {
+		mockStore := stores.InitMockStore()
+		mockAuthenticatedUser(mockStore)
+		mockStore.On("ExportTodos", 1, 0, &models.TodoFilter{}).Return(manyTodos, errors.New("some db error"))
+		stores.InitStore(mockStore)
+
+		req, _ := http.NewRequest("GET", "/todos/export?format=todotxt", nil)
+		req.Header.Set("Authorization", "Bearer "+*token)
+		recorder := httptest.NewRecorder()
+		GetExportHandler(recorder, req)
+
+		// The download has started, so it is only cut short.
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.True(t, strings.HasPrefix(recorder.Body.String(), "Task number 0\n"))
+		assert.Less(t, strings.Count(recorder.Body.String(), "\n"), len(manyTodos))
+
+		mockStore.AssertExpectations(t)
+	})
+}
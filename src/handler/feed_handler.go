@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"todo-list/src/exporter"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateCalendarFeedHandler creates the private iCalendar feed of the todos
+// in the active workspace. The feed URL works without a token, so it is only
+// returned here; creating the feed again gives a new URL and revokes the old
+// one.
+func CreateCalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Can not create calendar feed"}, http.StatusInternalServerError)
+		return
+	}
+	feed, err := stores.GetStore().CreateCalendarFeed(&models.CalendarFeed{UserID: user.ID, WorkspaceID: workspaceID, Token: token})
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create calendar feed\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	feed.URL = fmt.Sprintf("/feeds/%s.ics", token)
+
+	// The secret is only shown here, so the response must not be kept for
+	// idempotent retries or by caches.
+	w.Header().Set("Cache-Control", "no-store")
+	utility.WriteJsonData(w, feed, http.StatusCreated)
+}
+
+// DeleteCalendarFeedHandler revokes the calendar feed of the active
+// workspace.
+func DeleteCalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	err := stores.GetStore().DeleteCalendarFeed(user.ID, workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Calendar feed not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not revoke calendar feed\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, map[string]string{"message": "Calendar feed revoked"}, http.StatusOK)
+}
+
+// GetCalendarFeedHandler serves a calendar feed to calendar apps, which can
+// not send a JWT; the token in the URL is the credential. The feed has a
+// VTODO for every todo with a due date that the owner can still see, or a
+// VEVENT with ?type=event for apps that do not show tasks.
+func GetCalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	asEvents := false
+	switch r.URL.Query().Get("type") {
+	case "", "todo":
+	case "event":
+		asEvents = true
+	default:
+		utility.WriteJsonData(w, map[string]string{"error": "type must be todo or event"}, http.StatusBadRequest)
+		return
+	}
+
+	feed, err := stores.GetStore().GetCalendarFeed(mux.Vars(r)["token"])
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Calendar feed not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get calendar feed\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	sent := &sentWriter{w: w}
+	out := bufio.NewWriter(sent)
+	encoder := exporter.NewICalEncoder(out, asEvents)
+	w.Header().Set("Content-Type", exporter.ContentType(models.ExportICal))
+	w.Header().Set("Cache-Control", "private, max-age=300")
+
+	err = stores.GetStore().ExportTodos(feed.UserID, feed.WorkspaceID, &models.TodoFilter{}, encoder.Encode)
+	if err == nil {
+		err = encoder.Close()
+	}
+	if err != nil && !sent.sent {
+		w.Header().Del("Cache-Control")
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get calendar feed\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		log.Printf("Can not serve calendar feed of user %d: %v", feed.UserID, err)
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateCalendarFeedHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	createdAt := time.Date(2024, 11, 30, 8, 0, 0, 0, time.UTC)
+
+	var feedToken string
+	mockStore := stores.InitMockStore()
+	mockAuthenticatedUser(mockStore)
+	mockStore.On("CreateCalendarFeed", mock.MatchedBy(func(feed *models.CalendarFeed) bool {
+		feedToken = feed.Token
+		return feed.UserID == 1 && feed.WorkspaceID == 0 && len(feed.Token) == 43
+	})).Return(&models.CalendarFeed{UserID: 1, CreatedAt: createdAt}, nil)
+	stores.InitStore(mockStore)
+
+	req, _ := http.NewRequest("POST", "/feed", nil)
+	req.Header.Set("Authorization", "Bearer "+*token)
+	recorder := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.HandleFunc("/feed", CreateCalendarFeedHandler).Methods("POST")
+	r.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+	feed := map[string]interface{}{}
+	if err := json.NewDecoder(recorder.Body).Decode(&feed); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+	assert.Equal(t, map[string]interface{}{"url": "/feeds/" + feedToken + ".ics", "created_at": "2024-11-30T08:00:00Z"}, feed)
+
+	mockStore.AssertExpectations(t)
+}
+
+func TestDeleteCalendarFeedHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	mockStore := stores.InitMockStore()
+	mockAuthenticatedUser(mockStore)
+	mockStore.On("DeleteCalendarFeed", 1, 0).Return(nil).Once()
+	mockStore.On("DeleteCalendarFeed", 1, 0).Return(sql.ErrNoRows).Once()
+	stores.InitStore(mockStore)
+
+	r := mux.NewRouter()
+	r.HandleFunc("/feed", DeleteCalendarFeedHandler).Methods("DELETE")
+
+	req, _ := http.NewRequest("DELETE", "/feed", nil)
+	req.Header.Set("Authorization", "Bearer "+*token)
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"message": "Calendar feed revoked"}`, recorder.Body.String())
+
+	req, _ = http.NewRequest("DELETE", "/feed", nil)
+	req.Header.Set("Authorization", "Bearer "+*token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.JSONEq(t, `{"error": "Calendar feed not found"}`, recorder.Body.String())
+
+	mockStore.AssertExpectations(t)
+}
+
+func TestGetCalendarFeedHandler(t *testing.T) {
+	type testCase struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedParts  []string
+		mockReturn     func(*stores.MockStore)
+	}
+
+	updatedAt := time.Date(2024, 11, 30, 8, 0, 0, 0, time.UTC)
+	todos := []*models.Todo{
+		{ID: 5, TaskName: "Buy milk", DueDate: time.Date(2024, 12, 1, 9, 0, 0, 0, time.UTC), UpdatedAt: updatedAt},
+		{ID: 6, TaskName: "Someday", UpdatedAt: updatedAt},
+	}
+
+	tests := []testCase{
+		{
+			name:           "Todos",
+			url:            "/feeds/secret-token.ics",
+			expectedStatus: http.StatusOK,
+			expectedParts:  []string{"BEGIN:VCALENDAR\r\n", "BEGIN:VTODO\r\nUID:todo-5@todo-list\r\n", "DUE:20241201T090000Z\r\n", "END:VCALENDAR\r\n"},
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetCalendarFeed", "secret-token").Return(&models.CalendarFeed{UserID: 1, WorkspaceID: 4}, nil)
+				mockStore.On("ExportTodos", 1, 4, &models.TodoFilter{}).Return(todos, nil)
+			},
+		},
+		{
+			name:           "Events",
+			url:            "/feeds/secret-token.ics?type=event",
+			expectedStatus: http.StatusOK,
+			expectedParts:  []string{"BEGIN:VEVENT\r\nUID:todo-5@todo-list\r\n", "DTSTART:20241201T090000Z\r\n"},
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetCalendarFeed", "secret-token").Return(&models.CalendarFeed{UserID: 1}, nil)
+				mockStore.On("ExportTodos", 1, 0, &models.TodoFilter{}).Return(todos, nil)
+			},
+		},
+		{
+			name:           "Revoked Token",
+			url:            "/feeds/old-token.ics",
+			expectedStatus: http.StatusNotFound,
+			expectedParts:  []string{`{"error":"Calendar feed not found"}`},
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetCalendarFeed", "old-token").Return((*models.CalendarFeed)(nil), sql.ErrNoRows)
+			},
+		},
+		{
+			name:           "Unknown Type",
+			url:            "/feeds/secret-token.ics?type=journal",
+			expectedStatus: http.StatusBadRequest,
+			expectedParts:  []string{`{"error":"type must be todo or event"}`},
+			mockReturn:     func(mockStore *stores.MockStore) {},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			tc.mockReturn(mockStore)
+			stores.InitStore(mockStore)
+
+			// Calendar apps send no Authorization header.
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			r := mux.NewRouter()
+			r.HandleFunc("/feeds/{token:[A-Za-z0-9_-]+}.ics", GetCalendarFeedHandler).Methods("GET")
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+			for _, part := range tc.expectedParts {
+				assert.Contains(t, recorder.Body.String(), part)
+			}
+			if tc.expectedStatus == http.StatusOK {
+				assert.Equal(t, "text/calendar; charset=utf-8", recorder.Header().Get("Content-Type"))
+				assert.Equal(t, 1, strings.Count(recorder.Body.String(), "UID:"))
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+)
+
+const (
+	idempotencyHeader = "Idempotency-Key"
+	// replayedHeader marks a response that was stored by an earlier request
+	// with the same idempotency key.
+	replayedHeader    = "Idempotent-Replayed"
+	maxIdempotencyKey = 255
+	// maxIdempotentBody bounds the request body hashed for the fingerprint.
+	// It leaves room for the largest attachment upload.
+	maxIdempotentBody = maxAttachmentSize + 1<<20
+)
+
+// Idempotency is a middleware making POST and PATCH requests that carry an
+// Idempotency-Key header safe to retry. The first request with a key runs and
+// its response is stored for models.IdempotencyKeyTTL; retries get that
+// response back without running the handler again. Reusing a key for a
+// different request is refused with 422 and a retry arriving while the first
+// request still runs gets 409. Keys are scoped to the authenticated user, so
+// requests without a valid token are passed through untouched.
+//
+// The body is hashed as the handler reads it rather than held in memory, so
+// the fingerprint is only known once the request is done; a retry arriving
+// while the first still runs gets 409 whatever its body. Responses marked
+// Cache-Control: no-store carry something shown only once, such as a secret,
+// and are never stored; retries of them get 409 instead.
+func Idempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKey)}, http.StatusBadRequest)
+			return
+		}
+		user, _ := requestUser(r)
+		if user == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		hash := fingerprintHash(r)
+		body := http.MaxBytesReader(w, r.Body, maxIdempotentBody)
+
+		storedKey, claimed, err := stores.GetStore().ClaimIdempotencyKey(user.ID, key, time.Now().Add(-models.IdempotencyKeyTTL))
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not check idempotency key\n%v", err)}, http.StatusInternalServerError)
+			return
+		}
+		if !claimed {
+			replayResponse(w, storedKey, hash, body)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		completed := false
+		defer func() {
+			if !completed {
+				if err := stores.GetStore().ReleaseIdempotencyKey(user.ID, key); err != nil {
+					log.Printf("Can not release idempotency key %q: %v", key, err)
+				}
+			}
+		}()
+		r.Body = io.NopCloser(io.TeeReader(body, hash))
+		next.ServeHTTP(recorder, r)
+
+		// Server errors are not stored, so a retry gets to run the request
+		// again instead of replaying the failure.
+		if recorder.status() >= http.StatusInternalServerError {
+			return
+		}
+		// Whatever the handler left unread still counts, or a retry with a
+		// different tail would be taken for the same request.
+		fingerprint, err := finishFingerprint(hash, body)
+		if err != nil {
+			log.Printf("Can not read request body for idempotency key %q: %v", key, err)
+			return
+		}
+		responseBody := recorder.body.Bytes()
+		if shownOnce(w.Header()) {
+			responseBody = nil
+		}
+		err = stores.GetStore().SaveIdempotentResponse(&models.IdempotencyKey{
+			UserID:      user.ID,
+			Key:         key,
+			Fingerprint: fingerprint,
+			StatusCode:  recorder.status(),
+			Header:      w.Header().Clone(),
+			Body:        responseBody,
+		})
+		if err != nil {
+			log.Printf("Can not save response for idempotency key %q: %v", key, err)
+			return
+		}
+		completed = true
+	})
+}
+
+// fingerprintHash starts the hash identifying a request by its method,
+// target, workspace header and body. The body is added by finishFingerprint.
+func fingerprintHash(r *http.Request) hash.Hash {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n%s\n", r.Method, r.URL.RequestURI(), r.Header.Get(workspaceHeader))
+	return hash
+}
+
+// finishFingerprint adds the rest of body to hash and returns the request's
+// fingerprint.
+func finishFingerprint(hash hash.Hash, body io.Reader) (string, error) {
+	if _, err := io.Copy(hash, body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// shownOnce reports whether a response may not be kept for replay.
+func shownOnce(header http.Header) bool {
+	return header.Get("Cache-Control") == "no-store"
+}
+
+// replayResponse answers a request whose idempotency key is already held by
+// another request, hashing its body to tell whether it is a retry.
+func replayResponse(w http.ResponseWriter, storedKey *models.IdempotencyKey, hash hash.Hash, body io.Reader) {
+	if storedKey.StatusCode == 0 {
+		w.Header().Set("Retry-After", "1")
+		utility.WriteJsonData(w, map[string]string{"error": "A request with this Idempotency-Key is still in progress"}, http.StatusConflict)
+		return
+	}
+	fingerprint, err := finishFingerprint(hash, body)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			utility.WriteJsonData(w, map[string]string{"error": "Request body is too large"}, http.StatusRequestEntityTooLarge)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": "Can not read request body"}, http.StatusBadRequest)
+		return
+	}
+	if storedKey.Fingerprint != fingerprint {
+		utility.WriteJsonData(w, map[string]string{"error": "Idempotency-Key was already used for a different request"}, http.StatusUnprocessableEntity)
+		return
+	}
+	if shownOnce(storedKey.Header) {
+		utility.WriteJsonData(w, map[string]string{"error": "The response to this request is only shown once and was not kept"}, http.StatusConflict)
+		return
+	}
+
+	for name, values := range storedKey.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(replayedHeader, "true")
+	w.WriteHeader(storedKey.StatusCode)
+	w.Write(storedKey.Body)
+}
+
+// responseRecorder passes a response through while keeping a copy of its
+// status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (recorder *responseRecorder) WriteHeader(statusCode int) {
+	if recorder.statusCode == 0 {
+		recorder.statusCode = statusCode
+	}
+	recorder.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	if recorder.statusCode == 0 {
+		recorder.statusCode = http.StatusOK
+	}
+	recorder.body.Write(data)
+	return recorder.ResponseWriter.Write(data)
+}
+
+func (recorder *responseRecorder) status() int {
+	if recorder.statusCode == 0 {
+		return http.StatusOK
+	}
+	return recorder.statusCode
+}
@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIdempotency(t *testing.T) {
+	type testCase struct {
+		name            string
+		method          string
+		key             string
+		token           bool
+		handlerStatus   int
+		expectedStatus  int
+		expectedBody    string
+		expectedHandled bool
+		expectedHeader  map[string]string
+		shownOnce       bool
+		skipBody        bool
+		mockReturn      func(*stores.MockStore, string)
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	payload := `{"task_name": "Buy milk"}`
+	created := `{"id":7}`
+
+	tests := []testCase{
+		{
+			name:            "First Request Stores Response",
+			method:          "POST",
+			key:             "abc",
+			token:           true,
+			handlerStatus:   http.StatusCreated,
+			expectedStatus:  http.StatusCreated,
+			expectedBody:    created,
+			expectedHandled: true,
+			mockReturn: func(mockStore *stores.MockStore, fingerprint string) {
+				mockAuthenticatedUser(mockStore)
+				mockStore.On("ClaimIdempotencyKey", 1, "abc", mock.MatchedBy(func(expiredBefore time.Time) bool {
+					return time.Since(expiredBefore) >= models.IdempotencyKeyTTL
+				})).Return((*models.IdempotencyKey)(nil), true, nil)
+				mockStore.On("SaveIdempotentResponse", mock.MatchedBy(func(key *models.IdempotencyKey) bool {
+					return key.UserID == 1 && key.Key == "abc" && key.Fingerprint == fingerprint && key.StatusCode == http.StatusCreated &&
+						string(key.Body) == created && key.Header.Get("Content-Type") == "application/json"
+				})).Return(nil)
+			},
+		},
+		{
+			name:            "Unread Body Counts Towards Fingerprint",
+			method:          "POST",
+			key:             "abc",
+			token:           true,
+			skipBody:        true,
+			handlerStatus:   http.StatusCreated,
+			expectedStatus:  http.StatusCreated,
+			expectedBody:    created,
+			expectedHandled: true,
+			mockReturn: func(mockStore *stores.MockStore, fingerprint string) {
+				mockAuthenticatedUser(mockStore)
+				mockStore.On("ClaimIdempotencyKey", 1, "abc", mock.Anything).Return((*models.IdempotencyKey)(nil), true, nil)
+				mockStore.On("SaveIdempotentResponse", mock.MatchedBy(func(key *models.IdempotencyKey) bool {
+					return key.Fingerprint == fingerprint
+				})).Return(nil)
+			},
+		},
+		{
+			name:            "Response Shown Once Is Not Kept",
+			method:          "POST",
+			key:             "abc",
+			token:           true,
+			shownOnce:       true,
+			handlerStatus:   http.StatusCreated,
+			expectedStatus:  http.StatusCreated,
+			expectedBody:    created,
+			expectedHandled: true,
+			mockReturn: func(mockStore *stores.MockStore, fingerprint string) {
+				mockAuthenticatedUser(mockStore)
+				mockStore.On("ClaimIdempotencyKey", 1, "abc", mock.Anything).Return((*models.IdempotencyKey)(nil), true, nil)
+				mockStore.On("SaveIdempotentResponse", mock.MatchedBy(func(key *models.IdempotencyKey) bool {
+					return key.Fingerprint == fingerprint && key.StatusCode == http.StatusCreated && key.Body == nil
+				})).Return(nil)
+			},
+		},
+		{
+			name:            "Retry Of Response Shown Once",
+			method:          "POST",
+			key:             "abc",
+			token:           true,
+			expectedStatus:  http.StatusConflict,
+			expectedBody:    `{"error":"The response to this request is only shown once and was not kept"}`,
+			expectedHandled: false,
+			mockReturn: func(mockStore *stores.MockStore, fingerprint string) {
+				mockAuthenticatedUser(mockStore)
+				mockStore.On("ClaimIdempotencyKey", 1, "abc", mock.Anything).Return(&models.IdempotencyKey{
+					UserID:      1,
+					Key:         "abc",
+					Fingerprint: fingerprint,
+					StatusCode:  http.StatusCreated,
+					Header:      http.Header{"Content-Type": {"application/json"}, "Cache-Control": {"no-store"}},
+				}, false, nil)
+			},
+		},
+		{
+			name:            "Retry Replays Response",
+			method:          "POST",
+			key:             "abc",
+			token:           true,
+			expectedStatus:  http.StatusCreated,
+			expectedBody:    created,
+			expectedHandled: false,
+			expectedHeader:  map[string]string{"Content-Type": "application/json", "Idempotent-Replayed": "true"},
+			mockReturn: func(mockStore *stores.MockStore, fingerprint string) {
+				mockAuthenticatedUser(mockStore)
+				mockStore.On("ClaimIdempotencyKey", 1, "abc", mock.Anything).Return(&models.IdempotencyKey{
+					UserID:      1,
+					Key:         "abc",
+					Fingerprint: fingerprint,
+					StatusCode:  http.StatusCreated,
+					Header:      http.Header{"Content-Type": {"application/json"}},
+					Body:        []byte(created),
+				}, false, nil)
+			},
+		},
+		{
+			name:            "Key Reused With Different Body",
+			method:          "POST",
+			key:             "abc",
+			token:           true,
+			expectedStatus:  http.StatusUnprocessableEntity,
+			expectedBody:    `{"error":"Idempotency-Key was already used for a different request"}`,
+			expectedHandled: false,
+			mockReturn: func(mockStore *stores.MockStore, fingerprint string) {
+				mockAuthenticatedUser(mockStore)
+				mockStore.On("ClaimIdempotencyKey", 1, "abc", mock.Anything).Return(&models.IdempotencyKey{
+					UserID: 1, Key: "abc", Fingerprint: "other", StatusCode: http.StatusCreated,
+				}, false, nil)
+			},
+		},
+		{
+			name:            "Request Still In Progress",
+			method:          "PATCH",
+			key:             "abc",
+			token:           true,
+			expectedStatus:  http.StatusConflict,
+			expectedBody:    `{"error":"A request with this Idempotency-Key is still in progress"}`,
+			expectedHandled: false,
+			expectedHeader:  map[string]string{"Retry-After": "1"},
+			mockReturn: func(mockStore *stores.MockStore, fingerprint string) {
+				mockAuthenticatedUser(mockStore)
+				mockStore.On("ClaimIdempotencyKey", 1, "abc", mock.Anything).Return(&models.IdempotencyKey{
+					UserID: 1, Key: "abc", Fingerprint: fingerprint,
+				}, false, nil)
+			},
+		},
+		{
+			name:            "Server Error Releases Key",
+			method:          "POST",
+			key:             "abc",
+			token:           true,
+			handlerStatus:   http.StatusInternalServerError,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedBody:    created,
+			expectedHandled: true,
+			mockReturn: func(mockStore *stores.MockStore, fingerprint string) {
+				mockAuthenticatedUser(mockStore)
+				mockStore.On("ClaimIdempotencyKey", 1, "abc", mock.Anything).Return((*models.IdempotencyKey)(nil), true, nil)
+				mockStore.On("ReleaseIdempotencyKey", 1, "abc").Return(nil)
+			},
+		},
+		{
+			name:            "Without Key",
+			method:          "POST",
+			token:           true,
+			handlerStatus:   http.StatusCreated,
+			expectedStatus:  http.StatusCreated,
+			expectedBody:    created,
+			expectedHandled: true,
+			mockReturn:      func(mockStore *stores.MockStore, fingerprint string) {},
+		},
+		{
+			name:            "Without Token",
+			method:          "POST",
+			key:             "abc",
+			handlerStatus:   http.StatusCreated,
+			expectedStatus:  http.StatusCreated,
+			expectedBody:    created,
+			expectedHandled: true,
+			mockReturn:      func(mockStore *stores.MockStore, fingerprint string) {},
+		},
+		{
+			name:            "Idempotent Method",
+			method:          "PUT",
+			key:             "abc",
+			token:           true,
+			handlerStatus:   http.StatusOK,
+			expectedStatus:  http.StatusOK,
+			expectedBody:    created,
+			expectedHandled: true,
+			mockReturn:      func(mockStore *stores.MockStore, fingerprint string) {},
+		},
+		{
+			name:            "Key Too Long",
+			method:          "POST",
+			key:             strings.Repeat("k", 256),
+			token:           true,
+			expectedStatus:  http.StatusBadRequest,
+			expectedBody:    `{"error":"Idempotency-Key must be at most 255 characters"}`,
+			expectedHandled: false,
+			mockReturn:      func(mockStore *stores.MockStore, fingerprint string) {},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, "/todos", strings.NewReader(payload))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			if tc.token {
+				req.Header.Set("Authorization", "Bearer "+*token)
+			}
+			if tc.key != "" {
+				req.Header.Set("Idempotency-Key", tc.key)
+			}
+
+			fingerprint, err := finishFingerprint(fingerprintHash(req), strings.NewReader(payload))
+			if err != nil {
+				t.Fatalf("Failed to fingerprint request: %v", err)
+			}
+			mockStore := stores.InitMockStore()
+			tc.mockReturn(mockStore, fingerprint)
+			stores.InitStore(mockStore)
+
+			handled := false
+			r := mux.NewRouter()
+			r.Use(Idempotency)
+			r.HandleFunc("/todos", func(w http.ResponseWriter, r *http.Request) {
+				handled = true
+				if !tc.skipBody {
+					body, _ := io.ReadAll(r.Body)
+					assert.Equal(t, payload, string(body))
+				}
+				if tc.shownOnce {
+					w.Header().Set("Cache-Control", "no-store")
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tc.handlerStatus)
+				w.Write([]byte(created))
+			}).Methods("POST", "PATCH", "PUT")
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(recorder.Body.String()))
+			assert.Equal(t, tc.expectedHandled, handled)
+			for name, value := range tc.expectedHeader {
+				assert.Equal(t, value, recorder.Header().Get(name))
+			}
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo-list/src/importer"
+	"todo-list/src/jobs"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+
+	"github.com/gorilla/mux"
+)
+
+// maxImportRows caps the number of todos one file can import.
+const maxImportRows = 5000
+
+// startImport runs an import in the background. Tests replace it to run
+// imports inline.
+var startImport = func(imp *models.Import, rows []*models.ImportRow) {
+	go jobs.RunImport(context.Background(), imp, rows)
+}
+
+// CreateImportHandler imports todos from an uploaded file. The multipart form
+// carries the file, its format and, for CSV files, an optional JSON object
+// mapping todo fields to column names. Every row is parsed and validated up
+// front; with ?dry_run=true the rows are returned without importing anything,
+// otherwise the valid rows are imported in the background and the import is
+// returned for polling.
+func CreateImportHandler(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": "Invalid dry_run option"}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize+1<<20)
+	err := r.ParseMultipartForm(maxAttachmentSize)
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "The file field is required"}, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	format := r.FormValue("format")
+	var mapping map[string]string
+	if value := r.FormValue("mapping"); value != "" {
+		if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": "mapping must be a JSON object of column names"}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	rows, err := importer.Parse(format, file, mapping)
+	if err == importer.ErrUnknownFormat {
+		utility.WriteJsonData(w, map[string]string{"error": "format must be one of: csv, json, ndjson, todotxt, todoist, ical"}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not read file: %v", err)}, http.StatusBadRequest)
+		return
+	}
+	if len(rows) > maxImportRows {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("A file can import at most %d todos", maxImportRows)}, http.StatusBadRequest)
+		return
+	}
+
+	preview := &models.ImportPreview{Format: format, Total: len(rows), Rows: rows}
+	imp := &models.Import{
+		UserID:      user.ID,
+		WorkspaceID: workspaceID,
+		Format:      format,
+		FileName:    attachmentFileName(fileHeader.Filename),
+		Status:      models.ImportPending,
+		Total:       len(rows),
+		Errors:      []models.ImportError{},
+	}
+	for _, row := range rows {
+		if row.Errors == nil {
+			row.Todo.Tags = normalizeTags(row.Todo.Tags)
+			if errors := validations.ValidateTodo(row.Todo); len(errors) > 0 {
+				row.Errors = errors
+			}
+		}
+		if row.Errors != nil {
+			preview.Invalid++
+			imp.Processed++
+			imp.Failed++
+			imp.Errors = append(imp.Errors, models.ImportError{Line: row.Line, Errors: row.Errors})
+			continue
+		}
+		preview.Valid++
+	}
+
+	if dryRun {
+		utility.WriteJsonData(w, preview, http.StatusOK)
+		return
+	}
+
+	newImport, err := stores.GetStore().CreateImport(imp)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not start import\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	startImport(newImport, rows)
+
+	w.Header().Set("Location", fmt.Sprintf("/imports/%d", newImport.ID))
+	utility.WriteJsonData(w, newImport, http.StatusAccepted)
+}
+
+// GetImportHandler reports the progress of an import and the errors of the
+// rows skipped so far.
+func GetImportHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	importID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	imp, err := stores.GetStore().GetImport(importID, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Import not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get import\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, imp, http.StatusOK)
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func importBody(t *testing.T, fields map[string]string, content string) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	if content != "" {
+		part, err := writer.CreateFormFile("file", "todo.txt")
+		if err != nil {
+			t.Fatalf("Failed to create form file: %v", err)
+		}
+		part.Write([]byte(content))
+	}
+	writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+func TestCreateImportHandler(t *testing.T) {
+	type testCase struct {
+		name           string
+		url            string
+		fields         map[string]string
+		content        string
+		expectedBody   interface{}
+		expectedStatus int
+		expectedRows   int
+		mockReturn     func(*stores.MockStore)
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	createdAt := time.Date(2024, 12, 1, 8, 0, 0, 0, time.UTC)
+	defer func(original func(*models.Import, []*models.ImportRow)) { startImport = original }(startImport)
+	todoTxt := "(A) Call mom +Family\nHi\n"
+
+	tests := []testCase{
+		{
+			name:    "Dry Run",
+			url:     "/imports?dry_run=true",
+			fields:  map[string]string{"format": "todotxt"},
+			content: todoTxt,
+			expectedBody: models.ImportPreview{Format: "todotxt", Total: 2, Valid: 1, Invalid: 1, Rows: []*models.ImportRow{
+				{Line: 1, Todo: &models.Todo{TaskName: "Call mom", Priority: 4, Tags: []string{"family"}}},
+				{Line: 2, Todo: &models.Todo{TaskName: "Hi"}, Errors: map[string]string{"TaskName": "This field must be longer than 5 characters"}},
+			}},
+			expectedStatus: http.StatusOK,
+			mockReturn:     func(mockStore *stores.MockStore) {},
+		},
+		{
+			name:    "Start Import",
+			url:     "/imports",
+			fields:  map[string]string{"format": "todotxt"},
+			content: todoTxt,
+			expectedBody: models.Import{ID: 4, UserID: 1, Format: "todotxt", FileName: "todo.txt", Status: "pending", Total: 2, Processed: 1, Failed: 1,
+				Errors: []models.ImportError{{Line: 2, Errors: map[string]string{"TaskName": "This field must be longer than 5 characters"}}}, CreatedAt: createdAt},
+			expectedStatus: http.StatusAccepted,
+			expectedRows:   2,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("CreateImport", mock.MatchedBy(func(imp *models.Import) bool {
+					return imp.UserID == 1 && imp.Format == "todotxt" && imp.Total == 2 && imp.Processed == 1 && imp.Failed == 1 && len(imp.Errors) == 1
+				})).Return(&models.Import{ID: 4, UserID: 1, Format: "todotxt", FileName: "todo.txt", Status: "pending", Total: 2, Processed: 1, Failed: 1,
+					Errors: []models.ImportError{{Line: 2, Errors: map[string]string{"TaskName": "This field must be longer than 5 characters"}}}, CreatedAt: createdAt}, nil)
+			},
+		},
+		{
+			name:           "CSV With Bad Mapping",
+			url:            "/imports",
+			fields:         map[string]string{"format": "csv", "mapping": `{"task_name": "Name"}`},
+			content:        "Title\nBuy milk\n",
+			expectedBody:   map[string]string{"error": `Can not read file: Column "Name" is not in the file`},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn:     func(mockStore *stores.MockStore) {},
+		},
+		{
+			name:           "Mapping Not An Object",
+			url:            "/imports",
+			fields:         map[string]string{"format": "csv", "mapping": `["Name"]`},
+			content:        "Title\nBuy milk\n",
+			expectedBody:   map[string]string{"error": "mapping must be a JSON object of column names"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn:     func(mockStore *stores.MockStore) {},
+		},
+		{
+			name:           "Unknown Format",
+			url:            "/imports",
+			fields:         map[string]string{"format": "xlsx"},
+			content:        todoTxt,
+			expectedBody:   map[string]string{"error": "format must be one of: csv, json, ndjson, todotxt, todoist, ical"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn:     func(mockStore *stores.MockStore) {},
+		},
+		{
+			name:           "Missing File",
+			url:            "/imports",
+			fields:         map[string]string{"format": "todotxt"},
+			expectedBody:   map[string]string{"error": "The file field is required"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn:     func(mockStore *stores.MockStore) {},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tc.mockReturn(mockStore)
+			stores.InitStore(mockStore)
+
+			var startedRows []*models.ImportRow
+			startImport = func(imp *models.Import, rows []*models.ImportRow) {
+				startedRows = rows
+			}
+
+			body, contentType := importBody(t, tc.fields, tc.content)
+			req, err := http.NewRequest("POST", tc.url, body)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+			req.Header.Set("Content-Type", contentType)
+
+			r := mux.NewRouter()
+			r.HandleFunc("/imports", CreateImportHandler).Methods("POST")
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+
+			decoded := reflect.New(reflect.TypeOf(tc.expectedBody)).Interface()
+			if err := json.NewDecoder(recorder.Body).Decode(decoded); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			if got := reflect.ValueOf(decoded).Elem().Interface(); !reflect.DeepEqual(got, tc.expectedBody) {
+				t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", got, tc.expectedBody)
+			}
+			assert.Len(t, startedRows, tc.expectedRows)
+			if tc.expectedStatus == http.StatusAccepted {
+				assert.Equal(t, "/imports/4", recorder.Header().Get("Location"))
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetImportHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	mockStore := stores.InitMockStore()
+	mockAuthenticatedUser(mockStore)
+	mockStore.On("GetImport", 4, 1).Return(&models.Import{ID: 4, UserID: 1, Format: "csv", Status: "running", Total: 50, Processed: 25, Created: 25, Errors: []models.ImportError{}}, nil)
+	mockStore.On("GetImport", 5, 1).Return((*models.Import)(nil), sql.ErrNoRows)
+	stores.InitStore(mockStore)
+
+	r := mux.NewRouter()
+	r.HandleFunc("/imports/{id:[0-9]+}", GetImportHandler).Methods("GET")
+
+	req, _ := http.NewRequest("GET", "/imports/4", nil)
+	req.Header.Set("Authorization", "Bearer "+*token)
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"id": 4, "user_id": 1, "format": "csv", "file_name": "", "status": "running", "total": 50, "processed": 25, "created": 25, "failed": 0, "errors": [], "created_at": "0001-01-01T00:00:00Z"}`, recorder.Body.String())
+
+	req, _ = http.NewRequest("GET", "/imports/5", nil)
+	req.Header.Set("Authorization", "Bearer "+*token)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.JSONEq(t, `{"error": "Import not found"}`, recorder.Body.String())
+
+	mockStore.AssertExpectations(t)
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"todo-list/src/models"
+	"todo-list/src/quickadd"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+)
+
+// QuickAddHandler creates a todo from one line of text, such as "Pay rent
+// every month on the 1st at 9am #finance !p1", read in the timezone given
+// with it or else the user's. The response carries the todo and how the text was split up; with
+// ?dry_run=true nothing is created, so clients can preview as the user types.
+func QuickAddHandler(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": "Invalid dry_run option"}, http.StatusBadRequest)
+			return
+		}
+	}
+	renderHTML, ok := renderHTMLRequested(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	quickAdd := models.QuickAdd{}
+	err := json.NewDecoder(r.Body).Decode(&quickAdd)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+	errors := validations.ValidateQuickAdd(&quickAdd)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	location := user.Settings.Location()
+	if quickAdd.Timezone != "" {
+		location, err = time.LoadLocation(quickAdd.Timezone)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"Timezone": "Must be an IANA time zone such as Europe/Berlin"}, http.StatusBadRequest)
+			return
+		}
+	}
+	result := quickadd.Parse(quickAdd.Text, now().In(location), user.Settings.FirstWeekday())
+	result.Todo.ProjectID = quickAdd.ProjectID
+	result.Todo.Tags = normalizeTags(result.Todo.Tags)
+	if errors := validations.ValidateTodo(result.Todo); len(errors) > 0 {
+		result.Errors = errors
+	}
+
+	if dryRun {
+		utility.WriteJsonData(w, result, http.StatusOK)
+		return
+	}
+	if result.Errors != nil {
+		utility.WriteJsonData(w, result, http.StatusBadRequest)
+		return
+	}
+	if !placeNewTodo(w, r, result.Todo, user.ID) {
+		return
+	}
+	if !checkNewTodoCustomFields(w, result.Todo) {
+		return
+	}
+
+	newTodo, err := stores.GetStore().CreateTodo(result.Todo, user.ID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create todo\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	prepareTodo(newTodo, renderHTML)
+	result.Todo = newTodo
+
+	utility.WriteJsonData(w, result, http.StatusCreated)
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestQuickAddHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	now = func() time.Time { return time.Date(2024, 11, 27, 10, 30, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	dueDate := time.Date(2024, 12, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		headers        map[string]string
+		body           string
+		mockSetup      func(mockStore *stores.MockStore)
+		expectedStatus int
+		expectedTodo   *models.Todo
+		expectedParts  int
+		expectedErrors map[string]string
+	}{
+		{
+			name: "Create",
+			body: `{"text": "Pay rent every month on the 1st at 9am #Finance !p1", "timezone": "Europe/Berlin"}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("CreateTodo", &models.Todo{TaskName: "Pay rent", DueDate: dueDate, Recurrence: "FREQ=MONTHLY;BYMONTHDAY=1", Tags: []string{"finance"}, Priority: 4}, 1).
+					Return(&models.Todo{ID: 7, TaskName: "Pay rent", DueDate: dueDate, Recurrence: "FREQ=MONTHLY;BYMONTHDAY=1", Tags: []string{"finance"}, Priority: 4, Role: "owner"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedTodo:   &models.Todo{ID: 7, TaskName: "Pay rent", DueDate: dueDate, Recurrence: "FREQ=MONTHLY;BYMONTHDAY=1", Tags: []string{"finance"}, Priority: 4, Role: "owner"},
+			expectedParts:  5,
+		},
+		{
+			name:    "Create In Project",
+			query:   "?render=html",
+			headers: map[string]string{workspaceHeader: "4"},
+			body:    `{"text": "Write launch plan tomorrow", "project_id": 3}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Name: "Acme", Role: models.RoleEditor}, nil)
+				mockStore.On("GetProject", 3, 4).Return(&models.Project{ID: 3, WorkspaceID: 4, Name: "Launch"}, nil)
+				mockStore.On("GetCustomFields", 3).Return([]*models.CustomField{{Key: "points", Type: models.FieldNumber}}, nil)
+				mockStore.On("CreateTodo", mock.MatchedBy(func(todo *models.Todo) bool {
+					return todo.TaskName == "Write launch plan" && todo.WorkspaceID == 4 && todo.ProjectID == 3 && todo.DueDate.Equal(time.Date(2024, 11, 28, 0, 0, 0, 0, time.UTC))
+				}), 1).Return(&models.Todo{ID: 8, TaskName: "Write launch plan", Notes: "**Draft**", WorkspaceID: 4, ProjectID: 3}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedTodo:   &models.Todo{ID: 8, TaskName: "Write launch plan", Notes: "**Draft**", NotesHTML: "<p><strong>Draft</strong></p>\n", WorkspaceID: 4, ProjectID: 3},
+			expectedParts:  2,
+		},
+		{
+			name:    "Viewer In Project",
+			headers: map[string]string{workspaceHeader: "5"},
+			body:    `{"text": "Write launch plan tomorrow", "project_id": 3}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 5, 1).Return(&models.Workspace{ID: 5, Name: "Guests", Role: models.RoleViewer}, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedErrors: map[string]string{"error": "This action requires the editor role"},
+		},
+		{
+			name:           "Dry Run",
+			query:          "?dry_run=true",
+			body:           `{"text": "Water plants daily at 8am"}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusOK,
+			expectedTodo:   &models.Todo{TaskName: "Water plants", DueDate: time.Date(2024, 11, 28, 8, 0, 0, 0, time.UTC), Recurrence: "FREQ=DAILY"},
+			expectedParts:  3,
+		},
+		{
+			name:           "Dry Run With Errors",
+			query:          "?dry_run=true",
+			body:           `{"text": "Pay tomorrow"}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusOK,
+			expectedTodo:   &models.Todo{TaskName: "Pay", DueDate: time.Date(2024, 11, 28, 0, 0, 0, 0, time.UTC), DueAllDay: true},
+			expectedParts:  2,
+			expectedErrors: map[string]string{"TaskName": "This field must be longer than 5 characters"},
+		},
+		{
+			name:           "Invalid Todo",
+			body:           `{"text": "tomorrow #work"}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedTodo:   &models.Todo{DueDate: time.Date(2024, 11, 28, 0, 0, 0, 0, time.UTC), DueAllDay: true, Tags: []string{"work"}},
+			expectedParts:  2,
+			expectedErrors: map[string]string{"TaskName": "This field is required"},
+		},
+		{
+			name:           "Unknown Timezone",
+			body:           `{"text": "Buy milk tomorrow", "timezone": "Mars/Olympus"}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedErrors: map[string]string{"Timezone": "Must be an IANA time zone such as Europe/Berlin"},
+		},
+		{
+			name:           "Missing Text",
+			body:           `{"timezone": "UTC"}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedErrors: map[string]string{"Text": "This field is required"},
+		},
+		{
+			name:           "Invalid Dry Run Option",
+			query:          "?dry_run=maybe",
+			body:           `{"text": "Buy milk tomorrow"}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedErrors: map[string]string{"error": "Invalid dry_run option"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tt.mockSetup(mockStore)
+			stores.InitStore(mockStore)
+
+			req, _ := http.NewRequest("POST", "/todos/quick"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+*token)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			recorder := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/todos/quick", QuickAddHandler).Methods("POST")
+			r.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			if tt.expectedTodo == nil {
+				errors := map[string]string{}
+				if err := json.NewDecoder(recorder.Body).Decode(&errors); err != nil {
+					t.Fatalf("Failed to decode response body: %v", err)
+				}
+				assert.Equal(t, tt.expectedErrors, errors)
+				return
+			}
+			result := &models.QuickAddResult{}
+			if err := json.NewDecoder(recorder.Body).Decode(result); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			assert.Equal(t, tt.expectedTodo, result.Todo)
+			assert.Len(t, result.Parts, tt.expectedParts)
+			assert.Equal(t, tt.expectedErrors, result.Errors)
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestQuickAddHandlerUserSettings(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	// A Wednesday evening in UTC is already Thursday morning in Tokyo.
+	now = func() time.Time { return time.Date(2024, 11, 27, 20, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	tests := []struct {
+		name     string
+		body     string
+		expected *models.Todo
+	}{
+		{
+			name:     "Time Read In The User's Timezone",
+			body:     `{"text": "Call the office tomorrow at 9am"}`,
+			expected: &models.Todo{TaskName: "Call the office", DueDate: time.Date(2024, 11, 29, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:     "Week Starts On The User's Day",
+			body:     `{"text": "Plan the sprint next week"}`,
+			expected: &models.Todo{TaskName: "Plan the sprint", DueDate: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), DueAllDay: true},
+		},
+		{
+			name:     "Timezone In The Request Wins",
+			body:     `{"text": "Call the office tomorrow at 9am", "timezone": "UTC"}`,
+			expected: &models.Todo{TaskName: "Call the office", DueDate: time.Date(2024, 11, 28, 9, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockStore.On("GetUser", &models.User{Email: "test@mail.com", Password: "password"}).
+				Return(&models.User{ID: 1, Email: "test@mail.com", Password: "password", Settings: &models.UserSettings{Timezone: "Asia/Tokyo", WeekStart: "sunday", Locale: "ja-JP"}}, nil)
+			stores.InitStore(mockStore)
+
+			req, _ := http.NewRequest("POST", "/todos/quick?dry_run=true", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+*token)
+			recorder := httptest.NewRecorder()
+			QuickAddHandler(recorder, req)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			result := &models.QuickAddResult{}
+			if err := json.NewDecoder(recorder.Body).Decode(result); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			assert.Equal(t, tt.expected, result.Todo)
+		})
+	}
+}
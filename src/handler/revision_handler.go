@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+
+	"github.com/gorilla/mux"
+)
+
+// GetHistoryHandler returns every revision of a todo, oldest first, with the
+// fields each one changed.
+func GetHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleViewer) {
+		return
+	}
+
+	revisions, err := stores.GetStore().GetRevisions(todoID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get history\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, revisions, http.StatusOK)
+}
+
+// RevertTodoHandler puts a todo back in the state it had right after the
+// given revision. The old state is saved like any other update, so it is
+// validated and recorded as a new revision.
+func RevertTodoHandler(w http.ResponseWriter, r *http.Request) {
+	renderHTML, ok := renderHTMLRequested(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+	revisionID, err := strconv.Atoi(vars["revision"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleEditor) {
+		return
+	}
+
+	revision, err := stores.GetStore().GetRevision(todoID, revisionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Revision not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get revision\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	if revision.After == nil {
+		utility.WriteJsonData(w, map[string]string{"error": "The todo was deleted in this revision"}, http.StatusBadRequest)
+		return
+	}
+
+	todo := models.Todo{
+		TaskName:     revision.After.TaskName,
+		Completed:    revision.After.Completed,
+		DueDate:      revision.After.DueDate,
+		DueAllDay:    revision.After.DueAllDay,
+		Notes:        revision.After.Notes,
+		Priority:     revision.After.Priority,
+		Recurrence:   revision.After.Recurrence,
+		CustomFields: revision.After.CustomFields,
+	}
+	// A todo without custom field values has none in its snapshot either,
+	// and reverting to it clears the values set since.
+	if todo.CustomFields == nil {
+		todo.CustomFields = map[string]any{}
+	}
+	revertedTodo, ok := saveTodo(w, r, &todo, todoID, user.ID)
+	if !ok {
+		return
+	}
+	prepareTodo(revertedTodo, renderHTML)
+
+	utility.WriteJsonData(w, revertedTodo, http.StatusOK)
+}
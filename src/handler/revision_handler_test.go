@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRevisionHandlers(t *testing.T) {
+	type testCase struct {
+		name           string
+		method         string
+		url            string
+		expectedBody   interface{}
+		expectedStatus int
+		mockReturn     func(*stores.MockStore)
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	createdAt := time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC)
+	dueDate := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	history := []*models.Revision{
+		{ID: 1, TodoID: 5, UserID: 1, Action: "create", After: &models.TodoSnapshot{TaskName: "Write docs", DueDate: dueDate}, Changes: []models.TodoChange{{Field: "task_name", NewValue: "Write docs"}}, CreatedAt: createdAt},
+		{ID: 2, TodoID: 5, UserID: 2, Action: "delete", Before: &models.TodoSnapshot{TaskName: "Write docs", DueDate: dueDate}, Changes: []models.TodoChange{}, CreatedAt: createdAt},
+	}
+
+	tests := []testCase{
+		{
+			name:           "Get History",
+			method:         "GET",
+			url:            "/todos/5/history",
+			expectedBody:   history,
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+				mockStore.On("GetRevisions", 5).Return(history, nil)
+			},
+		},
+		{
+			name:           "Revert Todo",
+			method:         "POST",
+			url:            "/todos/5/revert/1",
+			expectedBody:   models.Todo{ID: 5, TaskName: "Write docs", DueDate: dueDate},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("editor", nil)
+				mockStore.On("GetRevision", 5, 1).Return(history[0], nil)
+				mockStore.On("GetTodoCustomFields", 5).Return([]*models.CustomField{{Key: "points", Type: models.FieldNumber}}, nil)
+				mockStore.On("UpdateTodo", &models.Todo{TaskName: "Write docs", DueDate: dueDate, CustomFields: map[string]any{}}, 5, 1, false).Return(&models.Todo{ID: 5, TaskName: "Write docs", DueDate: dueDate}, nil)
+			},
+		},
+		{
+			name:           "Revert To Invalid State",
+			method:         "POST",
+			url:            "/todos/5/revert/3",
+			expectedBody:   map[string]string{"TaskName": "This field must be longer than 5 characters"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("editor", nil)
+				mockStore.On("GetRevision", 5, 3).Return(&models.Revision{ID: 3, TodoID: 5, Action: "update", After: &models.TodoSnapshot{TaskName: "Doc"}}, nil)
+			},
+		},
+		{
+			name:           "Revert To Deletion",
+			method:         "POST",
+			url:            "/todos/5/revert/2",
+			expectedBody:   map[string]string{"error": "The todo was deleted in this revision"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+				mockStore.On("GetRevision", 5, 2).Return(history[1], nil)
+			},
+		},
+		{
+			name:           "Revert Unknown Revision",
+			method:         "POST",
+			url:            "/todos/5/revert/9",
+			expectedBody:   map[string]string{"error": "Revision not found"},
+			expectedStatus: http.StatusNotFound,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+				mockStore.On("GetRevision", 5, 9).Return((*models.Revision)(nil), sql.ErrNoRows)
+			},
+		},
+		{
+			name:           "Revert As Viewer",
+			method:         "POST",
+			url:            "/todos/5/revert/1",
+			expectedBody:   map[string]string{"error": "This action requires the editor role"},
+			expectedStatus: http.StatusForbidden,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tc.mockReturn(mockStore)
+			stores.InitStore(mockStore)
+
+			req, err := http.NewRequest(tc.method, tc.url, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+
+			r := mux.NewRouter()
+			r.HandleFunc("/todos/{id:[0-9]+}/history", GetHistoryHandler).Methods("GET")
+			r.HandleFunc("/todos/{id:[0-9]+}/revert/{revision:[0-9]+}", RevertTodoHandler).Methods("POST")
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+
+			decoded := reflect.New(reflect.TypeOf(tc.expectedBody)).Interface()
+			if err := json.NewDecoder(recorder.Body).Decode(decoded); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			if got := reflect.ValueOf(decoded).Elem().Interface(); !reflect.DeepEqual(got, tc.expectedBody) {
+				t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", got, tc.expectedBody)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo-list/src/filterexpr"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+
+	"github.com/gorilla/mux"
+)
+
+// filterExpression reads a filter expression against the current time in the
+// user's timezone. When it is invalid it writes an error pointing at the
+// offending token and returns false.
+func filterExpression(w http.ResponseWriter, query string, user *models.User) (models.FilterNode, bool) {
+	expression, err := filterexpr.Parse(query, userNow(user))
+	if err != nil {
+		utility.WriteJsonData(w, err, http.StatusBadRequest)
+		return nil, false
+	}
+	return expression, true
+}
+
+// readSavedFilter decodes and validates a saved filter, including its query.
+func readSavedFilter(w http.ResponseWriter, r *http.Request, user *models.User) (*models.SavedFilter, bool) {
+	filter := &models.SavedFilter{}
+	err := json.NewDecoder(r.Body).Decode(filter)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return nil, false
+	}
+	errors := validations.ValidateSavedFilter(filter)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return nil, false
+	}
+	if _, ok := filterExpression(w, filter.Query, user); !ok {
+		return nil, false
+	}
+	filter.UserID = user.ID
+	return filter, true
+}
+
+func savedFilterID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	filterID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid filter id"}, http.StatusBadRequest)
+		return 0, false
+	}
+	return filterID, true
+}
+
+func CreateSavedFilterHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	filter, ok := readSavedFilter(w, r, user)
+	if !ok {
+		return
+	}
+
+	newFilter, err := stores.GetStore().CreateSavedFilter(filter)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create filter\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, newFilter, http.StatusCreated)
+}
+
+func GetSavedFiltersHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	filters, err := stores.GetStore().GetSavedFilters(user.ID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get filters\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, filters, http.StatusOK)
+}
+
+func UpdateSavedFilterHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	filterID, ok := savedFilterID(w, r)
+	if !ok {
+		return
+	}
+	filter, ok := readSavedFilter(w, r, user)
+	if !ok {
+		return
+	}
+	filter.ID = filterID
+
+	updated, err := stores.GetStore().UpdateSavedFilter(filter)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Filter not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not update filter\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, updated, http.StatusOK)
+}
+
+func DeleteSavedFilterHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	filterID, ok := savedFilterID(w, r)
+	if !ok {
+		return
+	}
+
+	err := stores.GetStore().DeleteSavedFilter(filterID, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Filter not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not delete filter\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, map[string]string{"message": "Filter deleted"}, http.StatusOK)
+}
+
+// GetSavedFilterTodosHandler lists the todos a saved filter matches in the
+// active workspace. Its query is read again against the current time, so
+// "due < +3d" always looks three days ahead of today.
+func GetSavedFilterTodosHandler(w http.ResponseWriter, r *http.Request) {
+	renderHTML, ok := renderHTMLRequested(w, r)
+	if !ok {
+		return
+	}
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	filterID, ok := savedFilterID(w, r)
+	if !ok {
+		return
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	savedFilter, err := stores.GetStore().GetSavedFilter(filterID, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Filter not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get filter\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	expression, ok := filterExpression(w, savedFilter.Query, user)
+	if !ok {
+		return
+	}
+
+	todos, err := stores.GetStore().GetTodos(user.ID, workspaceID, &models.TodoFilter{Expression: expression})
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get todos\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	for _, todo := range todos {
+		prepareTodo(todo, renderHTML)
+	}
+
+	utility.WriteJsonData(w, todos, http.StatusOK)
+}
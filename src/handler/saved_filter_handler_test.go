@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSavedFilterHandlers(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	work := &models.SavedFilter{ID: 3, UserID: 1, Name: "Work", Query: "tag:work", CreatedAt: createdAt, UpdatedAt: createdAt}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		mockSetup      func(mockStore *stores.MockStore)
+		expectedStatus int
+		expectedBody   interface{}
+	}{
+		{
+			name:   "Create",
+			method: "POST",
+			url:    "/filters",
+			body:   `{"name": "Work", "query": "tag:work"}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("CreateSavedFilter", &models.SavedFilter{UserID: 1, Name: "Work", Query: "tag:work"}).Return(work, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   map[string]interface{}{"id": float64(3), "name": "Work", "query": "tag:work", "created_at": "2024-11-30T23:59:59Z", "updated_at": "2024-11-30T23:59:59Z"},
+		},
+		{
+			name:           "Create Missing Name",
+			method:         "POST",
+			url:            "/filters",
+			body:           `{"query": "tag:work"}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"Name": "This field is required"},
+		},
+		{
+			name:           "Create Invalid Query",
+			method:         "POST",
+			url:            "/filters",
+			body:           `{"name": "Soon", "query": "due < +3d and tag:work and not dun"}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"error": `Unknown field "dun"`, "token": "dun", "start": float64(31), "end": float64(34)},
+		},
+		{
+			name:   "List",
+			method: "GET",
+			url:    "/filters",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetSavedFilters", 1).Return([]*models.SavedFilter{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []interface{}{},
+		},
+		{
+			name:   "Update Not Found",
+			method: "PUT",
+			url:    "/filters/4",
+			body:   `{"name": "Work", "query": "tag:work"}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("UpdateSavedFilter", &models.SavedFilter{ID: 4, UserID: 1, Name: "Work", Query: "tag:work"}).
+					Return((*models.SavedFilter)(nil), sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   map[string]interface{}{"error": "Filter not found"},
+		},
+		{
+			name:   "Delete",
+			method: "DELETE",
+			url:    "/filters/3",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("DeleteSavedFilter", 3, 1).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"message": "Filter deleted"},
+		},
+		{
+			name:   "Delete Store Error",
+			method: "DELETE",
+			url:    "/filters/3",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("DeleteSavedFilter", 3, 1).Return(fmt.Errorf("some db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   map[string]interface{}{"error": "Can not delete filter\nsome db error"},
+		},
+		{
+			name:   "Todos",
+			method: "GET",
+			url:    "/filters/3/todos",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetSavedFilter", 3, 1).Return(work, nil)
+				mockStore.On("GetTodos", 1, 0, &models.TodoFilter{Expression: models.FilterTag{Tag: "work"}}).
+					Return([]*models.Todo{{ID: 7, TaskName: "Ship it", Tags: []string{"work"}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []interface{}{map[string]interface{}{"id": float64(7), "task_name": "Ship it", "completed": false, "blocked": false, "notes": "", "due_date": "0001-01-01T00:00:00Z", "created_at": "0001-01-01T00:00:00Z", "updated_at": "0001-01-01T00:00:00Z", "tags": []interface{}{"work"}}},
+		},
+		{
+			name:   "Todos Not Found",
+			method: "GET",
+			url:    "/filters/4/todos",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetSavedFilter", 4, 1).Return((*models.SavedFilter)(nil), sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   map[string]interface{}{"error": "Filter not found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tt.mockSetup(mockStore)
+			stores.InitStore(mockStore)
+
+			req, _ := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+*token)
+			recorder := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/filters", GetSavedFiltersHandler).Methods("GET")
+			r.HandleFunc("/filters", CreateSavedFilterHandler).Methods("POST")
+			r.HandleFunc("/filters/{id:[0-9]+}", UpdateSavedFilterHandler).Methods("PUT")
+			r.HandleFunc("/filters/{id:[0-9]+}", DeleteSavedFilterHandler).Methods("DELETE")
+			r.HandleFunc("/filters/{id:[0-9]+}/todos", GetSavedFilterTodosHandler).Methods("GET")
+			r.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			var body interface{}
+			if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			assert.Equal(t, tt.expectedBody, body)
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetTodosHandlerQuery(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	now = func() time.Time { return time.Date(2024, 11, 27, 20, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	mockStore := stores.InitMockStore()
+	mockAuthenticatedUser(mockStore)
+	mockStore.On("GetTodos", 1, 0, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+		return filter.Tag == "home" && assert.ObjectsAreEqual(models.FilterDate{
+			Field: models.FilterDue,
+			Range: models.DueRange{To: time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC)},
+		}, filter.Expression)
+	})).Return([]*models.Todo{}, nil)
+	stores.InitStore(mockStore)
+
+	req, _ := http.NewRequest("GET", "/todos?tag=home&query=due+<+%2B3d", nil)
+	req.Header.Set("Authorization", "Bearer "+*token)
+	recorder := httptest.NewRecorder()
+	GetTodosHandler(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	mockStore.AssertExpectations(t)
+}
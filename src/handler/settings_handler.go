@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+)
+
+// now is the clock relative dates are read against; tests replace it.
+var now = time.Now
+
+// userSettings returns the settings of the user, or the defaults for users
+// loaded without them.
+func userSettings(user *models.User) models.UserSettings {
+	if user.Settings == nil {
+		return models.DefaultSettings
+	}
+	return *user.Settings
+}
+
+// userNow is the current time in the user's timezone, which "today" and
+// other relative dates are read in.
+func userNow(user *models.User) time.Time {
+	return now().In(user.Settings.Location())
+}
+
+func GetSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	utility.WriteJsonData(w, userSettings(user), http.StatusOK)
+}
+
+// UpdateSettingsHandler changes the settings given in the request; the ones
+// left out keep their value.
+func UpdateSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	settings := userSettings(user)
+	err := json.NewDecoder(r.Body).Decode(&settings)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+	errors := validations.ValidateSettings(&settings)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	updated, err := stores.GetStore().UpdateUserSettings(user.ID, &settings)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not update settings\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, updated, http.StatusOK)
+}
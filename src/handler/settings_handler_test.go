@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsHandlers(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	berlin := &models.UserSettings{Timezone: "Europe/Berlin", WeekStart: "monday", Locale: "de-DE"}
+
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		settings       *models.UserSettings
+		mockSetup      func(mockStore *stores.MockStore)
+		expectedStatus int
+		expectedBody   interface{}
+	}{
+		{
+			name:           "Get",
+			method:         "GET",
+			settings:       berlin,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"timezone": "Europe/Berlin", "week_start": "monday", "locale": "de-DE"},
+		},
+		{
+			name:           "Get Defaults",
+			method:         "GET",
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"timezone": "UTC", "week_start": "monday", "locale": "en-US"},
+		},
+		{
+			name:     "Update Some",
+			method:   "PUT",
+			body:     `{"timezone": "America/New_York", "week_start": "sunday"}`,
+			settings: berlin,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("UpdateUserSettings", 1, &models.UserSettings{Timezone: "America/New_York", WeekStart: "sunday", Locale: "de-DE"}).
+					Return(&models.UserSettings{Timezone: "America/New_York", WeekStart: "sunday", Locale: "de-DE"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"timezone": "America/New_York", "week_start": "sunday", "locale": "de-DE"},
+		},
+		{
+			name:           "Invalid Settings",
+			method:         "PUT",
+			body:           `{"timezone": "Mars/Olympus", "week_start": "someday", "locale": "not a locale"}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"Timezone":  "Must be an IANA time zone such as Europe/Berlin",
+				"WeekStart": "Must be a day of the week such as monday",
+				"Locale":    "Must be a language tag such as en-US",
+			},
+		},
+		{
+			name:           "Cleared Setting",
+			method:         "PUT",
+			body:           `{"locale": ""}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"Locale": "This field is required"},
+		},
+		{
+			name:           "Invalid Payload",
+			method:         "PUT",
+			body:           `{"week_start": 1}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"error": "Invalid request payload"},
+		},
+		{
+			name:   "Store Error",
+			method: "PUT",
+			body:   `{"locale": "fr-FR"}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("UpdateUserSettings", 1, &models.UserSettings{Timezone: "UTC", WeekStart: "monday", Locale: "fr-FR"}).
+					Return((*models.UserSettings)(nil), fmt.Errorf("some db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   map[string]interface{}{"error": "Can not update settings\nsome db error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockStore.On("GetUser", &models.User{Email: "test@mail.com", Password: "password"}).
+				Return(&models.User{ID: 1, Email: "test@mail.com", Password: "password", Settings: tt.settings}, nil)
+			tt.mockSetup(mockStore)
+			stores.InitStore(mockStore)
+
+			req, _ := http.NewRequest(tt.method, "/settings", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+*token)
+			recorder := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/settings", GetSettingsHandler).Methods("GET")
+			r.HandleFunc("/settings", UpdateSettingsHandler).Methods("PUT")
+			r.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			var body map[string]interface{}
+			if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			assert.Equal(t, tt.expectedBody, body)
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+
+	"github.com/gorilla/mux"
+)
+
+func GetSharesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleViewer) {
+		return
+	}
+
+	shares, err := stores.GetStore().GetShares(todoID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get shares\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, shares, http.StatusOK)
+}
+
+// ShareTodoHandler shares a todo with another user by email, or changes the
+// role of someone it is already shared with. Only owners may share.
+func ShareTodoHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	shareRequest := models.ShareRequest{}
+	err = json.NewDecoder(r.Body).Decode(&shareRequest)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleOwner) {
+		return
+	}
+
+	errors := validations.ValidateShare(&shareRequest)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	collaborator, err := stores.GetStore().GetUserByEmail(shareRequest.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "User not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not share todo\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	err = stores.GetStore().ShareTodo(todoID, collaborator.ID, shareRequest.Role)
+	if err != nil {
+		if err == stores.ErrLastOwner || err == stores.ErrNotWorkspaceMember {
+			utility.WriteJsonData(w, map[string]string{"error": err.Error()}, http.StatusConflict)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not share todo\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, &models.Share{UserID: collaborator.ID, UserName: collaborator.UserName, Email: collaborator.Email, Role: shareRequest.Role}, http.StatusCreated)
+}
+
+// RevokeShareHandler removes a user's access to a todo. Owners may remove
+// anyone; everyone else may only remove themselves.
+func RevokeShareHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+	collaboratorID, err := strconv.Atoi(vars["userID"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	minimumRole := models.RoleOwner
+	if collaboratorID == user.ID {
+		minimumRole = models.RoleViewer
+	}
+	if !authorizeTodo(w, r, todoID, user.ID, minimumRole) {
+		return
+	}
+
+	err = stores.GetStore().RevokeShare(todoID, collaboratorID)
+	if err != nil {
+		if err == stores.ErrLastOwner {
+			utility.WriteJsonData(w, map[string]string{"error": err.Error()}, http.StatusConflict)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not revoke share\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	userStats.drop(collaboratorID)
+
+	utility.WriteJsonData(w, map[string]string{"message": "Share revoked successfully. User ID: " + vars["userID"]}, http.StatusOK)
+}
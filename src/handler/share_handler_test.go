@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+)
+
+func shareRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/todos/{id:[0-9]+}/shares", GetSharesHandler).Methods("GET")
+	r.HandleFunc("/todos/{id:[0-9]+}/shares", ShareTodoHandler).Methods("POST")
+	r.HandleFunc("/todos/{id:[0-9]+}/shares/{userID:[0-9]+}", RevokeShareHandler).Methods("DELETE")
+	return r
+}
+
+func TestShareHandlers(t *testing.T) {
+	type testCase struct {
+		name           string
+		method         string
+		url            string
+		payload        string
+		expectedBody   interface{}
+		expectedStatus int
+		mockReturn     func(*stores.MockStore)
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	tests := []testCase{
+		{
+			name:           "Share As Owner",
+			method:         "POST",
+			url:            "/todos/5/shares",
+			payload:        `{"email": "friend@mail.com", "role": "editor"}`,
+			expectedBody:   &models.Share{UserID: 2, UserName: "friend", Email: "friend@mail.com", Role: "editor"},
+			expectedStatus: http.StatusCreated,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+				mockStore.On("GetUserByEmail", "friend@mail.com").Return(&models.User{ID: 2, UserName: "friend", Email: "friend@mail.com"}, nil)
+				mockStore.On("ShareTodo", 5, 2, "editor").Return(nil)
+			},
+		},
+		{
+			name:           "Share As Editor",
+			method:         "POST",
+			url:            "/todos/5/shares",
+			payload:        `{"email": "friend@mail.com", "role": "viewer"}`,
+			expectedBody:   map[string]string{"error": "This action requires the owner role"},
+			expectedStatus: http.StatusForbidden,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("editor", nil)
+			},
+		},
+		{
+			name:           "Share With Unknown Role",
+			method:         "POST",
+			url:            "/todos/5/shares",
+			payload:        `{"email": "friend@mail.com", "role": "admin"}`,
+			expectedBody:   map[string]string{"Role": "Must be one of: viewer, editor, owner"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+			},
+		},
+		{
+			name:           "Share With Unknown User",
+			method:         "POST",
+			url:            "/todos/5/shares",
+			payload:        `{"email": "nobody@mail.com", "role": "viewer"}`,
+			expectedBody:   map[string]string{"error": "User not found"},
+			expectedStatus: http.StatusNotFound,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+				mockStore.On("GetUserByEmail", "nobody@mail.com").Return((*models.User)(nil), sql.ErrNoRows)
+			},
+		},
+		{
+			name:           "Demote Last Owner",
+			method:         "POST",
+			url:            "/todos/5/shares",
+			payload:        `{"email": "test@mail.com", "role": "viewer"}`,
+			expectedBody:   map[string]string{"error": stores.ErrLastOwner.Error()},
+			expectedStatus: http.StatusConflict,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+				mockStore.On("GetUserByEmail", "test@mail.com").Return(&models.User{ID: 1, Email: "test@mail.com"}, nil)
+				mockStore.On("ShareTodo", 5, 1, "viewer").Return(stores.ErrLastOwner)
+			},
+		},
+		{
+			name:           "Share Outside The Workspace",
+			method:         "POST",
+			url:            "/todos/5/shares",
+			payload:        `{"email": "other@mail.com", "role": "editor"}`,
+			expectedBody:   map[string]string{"error": stores.ErrNotWorkspaceMember.Error()},
+			expectedStatus: http.StatusConflict,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+				mockStore.On("GetUserByEmail", "other@mail.com").Return(&models.User{ID: 3, Email: "other@mail.com"}, nil)
+				mockStore.On("ShareTodo", 5, 3, "editor").Return(stores.ErrNotWorkspaceMember)
+			},
+		},
+		{
+			name:           "List Shares As Viewer",
+			method:         "GET",
+			url:            "/todos/5/shares",
+			expectedBody:   []*models.Share{{UserID: 1, Email: "test@mail.com", Role: "owner"}, {UserID: 2, Email: "friend@mail.com", Role: "viewer"}},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+				mockStore.On("GetShares", 5).Return([]*models.Share{{UserID: 1, Email: "test@mail.com", Role: "owner"}, {UserID: 2, Email: "friend@mail.com", Role: "viewer"}}, nil)
+			},
+		},
+		{
+			name:           "Revoke As Owner",
+			method:         "DELETE",
+			url:            "/todos/5/shares/2",
+			expectedBody:   map[string]string{"message": "Share revoked successfully. User ID: 2"},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+				mockStore.On("RevokeShare", 5, 2).Return(nil)
+			},
+		},
+		{
+			name:           "Revoke Someone Else As Editor",
+			method:         "DELETE",
+			url:            "/todos/5/shares/2",
+			expectedBody:   map[string]string{"error": "This action requires the owner role"},
+			expectedStatus: http.StatusForbidden,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("editor", nil)
+			},
+		},
+		{
+			name:           "Leave As Viewer",
+			method:         "DELETE",
+			url:            "/todos/5/shares/1",
+			expectedBody:   map[string]string{"message": "Share revoked successfully. User ID: 1"},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+				mockStore.On("RevokeShare", 5, 1).Return(nil)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tc.mockReturn(mockStore)
+			stores.InitStore(mockStore)
+
+			req, err := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.payload))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+
+			recorder := httptest.NewRecorder()
+			shareRouter().ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+
+			decoded := reflect.New(reflect.TypeOf(tc.expectedBody)).Interface()
+			if err := json.NewDecoder(recorder.Body).Decode(decoded); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			if got := reflect.ValueOf(decoded).Elem().Interface(); !reflect.DeepEqual(got, tc.expectedBody) {
+				t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", got, tc.expectedBody)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
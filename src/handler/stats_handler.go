@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+)
+
+// statsCacheTTL bounds how long statistics are served from the cache. Todo
+// events and the user's own writes drop their cached statistics at once; the
+// TTL catches what neither reaches, such as a share revoked on another server.
+const statsCacheTTL = 5 * time.Minute
+
+type cachedStats struct {
+	stats   *models.Stats
+	expires time.Time
+}
+
+// statsCache keeps computed statistics per user ID. Every drop bumps the cache version, and statistics computed across a drop of
+// their user are not kept. Drops are forgotten once older than the TTL, as
+// anything computed before them would have expired by then anyway.
+type statsCache struct {
+	mu      sync.Mutex
+	entries map[int]map[string]cachedStats
+	version int
+	drops   map[int]statsDrop
+}
+
+type statsDrop struct {
+	version int
+	at      time.Time
+}
+
+var userStats = &statsCache{entries: map[int]map[string]cachedStats{}, drops: map[int]statsDrop{}}
+
+// get returns the user's statistics cached under key, if still fresh, and the
+// cache version to pass to put when they are not.
+func (c *statsCache) get(user int, key string, at time.Time) (*models.Stats, int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[user][key]
+	if !ok || !at.Before(entry.expires) {
+		return nil, c.version, false
+	}
+	return entry.stats, c.version, true
+}
+
+// put caches stats under key unless the user's statistics were dropped since
+// get returned version. Expired entries and drops of every user are removed.
+func (c *statsCache) put(user int, key string, version int, stats *models.Stats, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for userID, entries := range c.entries {
+		for k, entry := range entries {
+			if !at.Before(entry.expires) {
+				delete(entries, k)
+			}
+		}
+		if len(entries) == 0 {
+			delete(c.entries, userID)
+		}
+	}
+	for userID, drop := range c.drops {
+		if !at.Before(drop.at.Add(statsCacheTTL)) {
+			delete(c.drops, userID)
+		}
+	}
+	if drop, ok := c.drops[user]; ok && drop.version > version {
+		return
+	}
+	if c.entries[user] == nil {
+		c.entries[user] = map[string]cachedStats{}
+	}
+	c.entries[user][key] = cachedStats{stats: stats, expires: at.Add(statsCacheTTL)}
+}
+
+func (c *statsCache) drop(user int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, user)
+	c.version++
+	c.drops[user] = statsDrop{version: c.version, at: now()}
+}
+
+// InvalidateStats is a middleware dropping the cached statistics of the user
+// behind every request that may write, once it has been handled. It covers
+// writes that change what the user sees without logging a todo event, such as
+// joining or leaving a workspace.
+func InvalidateStats(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, "PROPFIND", "REPORT":
+			return
+		}
+		if user, _ := requestUser(r); user != nil {
+			userStats.drop(user.ID)
+		}
+	})
+}
+
+// DropStats is a realtime publish hook dropping the cached statistics of
+// everyone a todo event is for. Every server publishes every event, so this
+// catches changes made over CalDAV, by background jobs and by other users, on
+// this server or another.
+func DropStats(event *models.TodoEvent) {
+	for _, userID := range event.UserIDs {
+		userStats.drop(userID)
+	}
+}
+
+// dropWorkspaceStats drops the cached statistics of every member of the
+// workspace. If the members can not be looked up the TTL has to do.
+func dropWorkspaceStats(workspaceID int) {
+	members, err := stores.GetStore().GetWorkspaceMembers(workspaceID)
+	if err != nil {
+		return
+	}
+	for _, member := range members {
+		userStats.drop(member.UserID)
+	}
+}
+
+// GetStatsHandler returns completion statistics for the todos of the active
+// space over the days from ?from to ?to, by default the last 30 days.
+func GetStatsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+	from, to, ok := reportRange(w, r, user, func(today time.Time) time.Time {
+		return today.AddDate(0, 0, -29)
+	})
+	if !ok {
+		return
+	}
+
+	at := now()
+	key := fmt.Sprintf("%d/%s/%s/%s", workspaceID, from.Format("2006-01-02"), to.Format("2006-01-02"), from.Location())
+	stats, version, ok := userStats.get(user.ID, key, at)
+	if ok {
+		utility.WriteJsonData(w, stats, http.StatusOK)
+		return
+	}
+
+	stats, err := stores.GetStore().GetStats(user.ID, workspaceID, &models.StatsQuery{
+		From:     from,
+		To:       to.AddDate(0, 0, 1),
+		Timezone: from.Location().String(),
+		Now:      userNow(user),
+	})
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get statistics\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	stats.From = from.Format("2006-01-02")
+	stats.To = to.Format("2006-01-02")
+	userStats.put(user.ID, key, version, stats, at)
+
+	utility.WriteJsonData(w, stats, http.StatusOK)
+}
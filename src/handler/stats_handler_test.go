@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStatsHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	now = func() time.Time { return time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	userStats.drop(1)
+
+	mockStore := stores.InitMockStore()
+	mockAuthenticatedUser(mockStore)
+	query := &models.StatsQuery{
+		From:     time.Date(2024, 10, 22, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2024, 11, 21, 0, 0, 0, 0, time.UTC),
+		Timezone: "UTC",
+		Now:      time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC),
+	}
+	mockStore.On("GetStats", 1, 0, query).Return(&models.Stats{Completed: 4, Days: []*models.StatsDay{}, Projects: []*models.StatsBreakdown{}, Tags: []*models.StatsBreakdown{}}, nil).Once()
+	mockStore.On("GetStats", 1, 0, query).Return(&models.Stats{Completed: 5, Days: []*models.StatsDay{}, Projects: []*models.StatsBreakdown{}, Tags: []*models.StatsBreakdown{}}, nil).Once()
+	mockStore.On("GetStats", 1, 0, query).Return(&models.Stats{Completed: 6, Days: []*models.StatsDay{}, Projects: []*models.StatsBreakdown{}, Tags: []*models.StatsBreakdown{}}, nil).Once()
+	stores.InitStore(mockStore)
+
+	get := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/stats", nil)
+		req.Header.Set("Authorization", "Bearer "+*token)
+		recorder := httptest.NewRecorder()
+		GetStatsHandler(recorder, req)
+		return recorder
+	}
+	expected := func(completed string) string {
+		return `{"from":"2024-10-22","to":"2024-11-20","completed":` + completed + `,"created":0,"average_lead_time_seconds":0,"overdue":0,"current_streak":0,"longest_streak":0,"days":[],"projects":[],"tags":[]}`
+	}
+
+	recorder := get()
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, expected("4"), recorder.Body.String())
+
+	// Served from the cache, without asking the store again.
+	recorder = get()
+	assert.JSONEq(t, expected("4"), recorder.Body.String())
+
+	// Reads leave the cache alone, writes drop it.
+	write := func(method string) {
+		req, _ := http.NewRequest(method, "/todos/3", nil)
+		req.Header.Set("Authorization", "Bearer "+*token)
+		InvalidateStats(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(httptest.NewRecorder(), req)
+	}
+	write("GET")
+	assert.JSONEq(t, expected("4"), get().Body.String())
+	write("PUT")
+	recorder = get()
+	assert.JSONEq(t, expected("5"), recorder.Body.String())
+
+	// So do events of todos the user can see, whoever changed them.
+	DropStats(&models.TodoEvent{ID: 7, Type: models.EventTodoUpdated, TodoID: 3, UserIDs: []int{2}})
+	assert.JSONEq(t, expected("5"), get().Body.String())
+	DropStats(&models.TodoEvent{ID: 8, Type: models.EventTodoUpdated, TodoID: 3, UserIDs: []int{2, 1}})
+	assert.JSONEq(t, expected("6"), get().Body.String())
+	mockStore.AssertExpectations(t)
+}
+
+func TestGetStatsHandlerRange(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	mockStore := stores.InitMockStore()
+	mockAuthenticatedUser(mockStore)
+	stores.InitStore(mockStore)
+
+	req, _ := http.NewRequest("GET", "/stats?from=2024-11-20&to=2024-11-01", nil)
+	req.Header.Set("Authorization", "Bearer "+*token)
+	recorder := httptest.NewRecorder()
+	GetStatsHandler(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.JSONEq(t, `{"error":"to must be on or after from and at most 366 days later"}`, recorder.Body.String())
+	mockStore.AssertExpectations(t)
+}
+
+func TestStatsCacheForgetsDrops(t *testing.T) {
+	at := time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return at }
+	defer func() { now = time.Now }()
+	cache := &statsCache{entries: map[int]map[string]cachedStats{}, drops: map[int]statsDrop{}}
+	stats := &models.Stats{Completed: 1}
+
+	// Statistics computed across a drop of their user are not kept.
+	_, version, _ := cache.get(1, "key", at)
+	cache.drop(1)
+	cache.put(1, "key", version, stats, at)
+	_, _, ok := cache.get(1, "key", at)
+	assert.False(t, ok)
+
+	// Nor are they thrown away for a drop of another user.
+	_, version, _ = cache.get(2, "key", at)
+	cache.drop(1)
+	cache.put(2, "key", version, stats, at)
+	cached, _, ok := cache.get(2, "key", at)
+	assert.True(t, ok)
+	assert.Equal(t, stats, cached)
+
+	// Once the TTL has passed both drops and entries are gone.
+	later := at.Add(statsCacheTTL)
+	_, version, _ = cache.get(3, "key", later)
+	cache.put(3, "key", version, stats, later)
+	assert.Empty(t, cache.drops)
+	assert.Len(t, cache.entries, 1)
+	assert.Contains(t, cache.entries, 3)
+}
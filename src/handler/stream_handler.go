@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+	"todo-list/src/models"
+	"todo-list/src/realtime"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+
+	"golang.org/x/net/websocket"
+)
+
+// streamKeepalive is how often an idle stream sends something, so proxies
+// and clients can tell it is still open.
+var streamKeepalive = 30 * time.Second
+
+// streamOrigins are the origins besides the server's own that may open a
+// WebSocket, set at startup by AllowStreamOrigins.
+var streamOrigins []string
+
+// AllowStreamOrigins lets pages served from origins, such as
+// "https://app.example.com", open WebSockets. Pages of the server's own
+// origin always may.
+func AllowStreamOrigins(origins []string) {
+	streamOrigins = origins
+}
+
+// CreateStreamTicketHandler issues a ticket opening one event stream of the
+// user, for browsers, which can not send the JWT in a header when opening
+// one. The ticket goes in the stream URL as ?ticket; it expires after 30
+// seconds and is used up by the stream it opens, so logged URLs are of no use.
+func CreateStreamTicketHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	ticket, err := newSecretToken()
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Can not create stream ticket"}, http.StatusInternalServerError)
+		return
+	}
+	streamTicket, err := stores.GetStore().CreateStreamTicket(user.ID, ticket)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create stream ticket\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	utility.WriteJsonData(w, streamTicket, http.StatusCreated)
+}
+
+// streamUser authenticates a stream request by its ?ticket, or by its JWT for
+// clients that can send one in the Authorization header.
+func streamUser(w http.ResponseWriter, r *http.Request) (int, bool) {
+	ticket := r.URL.Query().Get("ticket")
+	if ticket == "" {
+		user, ok := authenticateUser(w, r)
+		if !ok {
+			return 0, false
+		}
+		return user.ID, true
+	}
+
+	userID, err := stores.GetStore().RedeemStreamTicket(ticket)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Invalid or expired stream ticket"}, http.StatusUnauthorized)
+			return 0, false
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not check stream ticket\n%v", err)}, http.StatusInternalServerError)
+		return 0, false
+	}
+	return userID, true
+}
+
+// allowedOrigin reports whether a WebSocket may be opened from origin, the
+// Origin header browsers send. Clients other than browsers send none.
+func allowedOrigin(r *http.Request, origin string) bool {
+	if origin == "" {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if parsed.Host == r.Host {
+		return true
+	}
+	return slices.Contains(streamOrigins, origin)
+}
+
+// openStream subscribes the user to the events published from now on and
+// reads the events they missed after the Last-Event-ID header, which
+// EventSource sends when it reconnects, or ?last_event_id. Events logged
+// shortly before it are read again, as they may have committed later. When
+// those are no longer all logged, the missed events are a single reset event
+// instead.
+func openStream(w http.ResponseWriter, r *http.Request, userID int) (*realtime.Subscription, []*models.TodoEvent, bool) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	var lastEventID int64
+	if value != "" {
+		var err error
+		lastEventID, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || lastEventID < 0 {
+			utility.WriteJsonData(w, map[string]string{"error": "Last-Event-ID must be an event id"}, http.StatusBadRequest)
+			return nil, nil, false
+		}
+	}
+
+	// Subscribing first means no event falls between the missed ones and
+	// the published ones; an event in both is only sent once.
+	subscription := realtime.Subscribe(userID)
+	if value == "" {
+		return subscription, nil, true
+	}
+	missed, err := missedEvents(userID, lastEventID)
+	if err != nil {
+		realtime.Unsubscribe(subscription)
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get todo events\n%v", err)}, http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	return subscription, missed, true
+}
+
+func missedEvents(userID int, lastEventID int64) ([]*models.TodoEvent, error) {
+	reset := []*models.TodoEvent{{Type: models.EventReset, CreatedAt: now().UTC()}}
+	start, err := stores.GetStore().GetTodoEventLogStart()
+	if err != nil {
+		return nil, err
+	}
+	if lastEventID < start-1 {
+		return reset, nil
+	}
+	from := lastEventID - models.EventResumeWindow
+	if from < 0 {
+		from = 0
+	}
+	events, err := stores.GetStore().GetTodoEvents(from, userID, models.EventReplayLimit+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) > models.EventReplayLimit {
+		return reset, nil
+	}
+	return events, nil
+}
+
+// pumpEvents sends the missed events, then the events published to
+// subscription until ctx is done or the subscriber is dropped for falling
+// behind, calling keepalive whenever the stream was idle for a while.
+func pumpEvents(ctx context.Context, subscription *realtime.Subscription, missed []*models.TodoEvent, send func(event *models.TodoEvent) error, keepalive func() error) error {
+	sent := map[int64]bool{}
+	for _, event := range missed {
+		if err := send(event); err != nil {
+			return err
+		}
+		sent[event.ID] = true
+	}
+
+	ticker := time.NewTicker(streamKeepalive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return nil
+			}
+			if sent[event.ID] {
+				continue
+			}
+			if err := send(event); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if err := keepalive(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// EventsHandler streams the creates, updates and deletes of every todo the
+// user can see as Server-Sent Events. Each event has the event id, the type
+// as its name and the todo event as JSON data.
+func EventsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := streamUser(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utility.WriteJsonData(w, map[string]string{"error": "Streaming is not supported"}, http.StatusInternalServerError)
+		return
+	}
+	subscription, missed, ok := openStream(w, r, userID)
+	if !ok {
+		return
+	}
+	defer realtime.Unsubscribe(subscription)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	err := pumpEvents(r.Context(), subscription, missed, func(event *models.TodoEvent) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if event.ID != 0 {
+			fmt.Fprintf(w, "id: %d\n", event.ID)
+		}
+		_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		flusher.Flush()
+		return err
+	}, func() error {
+		_, err := fmt.Fprint(w, ": keepalive\n\n")
+		flusher.Flush()
+		return err
+	})
+	if err != nil {
+		log.Printf("Event stream of user %d closed: %v", userID, err)
+	}
+}
+
+// WebSocketHandler streams the same events as EventsHandler over a
+// WebSocket, one JSON text message per event. Messages from the client are
+// ignored. Browsers only connect from the server's own origin and those
+// allowed by AllowStreamOrigins.
+func WebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowedOrigin(r, r.Header.Get("Origin")) {
+		utility.WriteJsonData(w, map[string]string{"error": "Origin not allowed"}, http.StatusForbidden)
+		return
+	}
+	userID, ok := streamUser(w, r)
+	if !ok {
+		return
+	}
+	subscription, missed, ok := openStream(w, r, userID)
+	if !ok {
+		return
+	}
+	defer realtime.Unsubscribe(subscription)
+
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				var message string
+				for websocket.Message.Receive(conn, &message) == nil {
+				}
+				cancel()
+			}()
+
+			err := pumpEvents(ctx, subscription, missed, func(event *models.TodoEvent) error {
+				return websocket.JSON.Send(conn, event)
+			}, func() error {
+				conn.PayloadType = websocket.PingFrame
+				_, err := conn.Write(nil)
+				return err
+			})
+			if err != nil {
+				log.Printf("WebSocket of user %d closed: %v", userID, err)
+			}
+		},
+	}
+	server.ServeHTTP(w, r)
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/realtime"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/net/websocket"
+)
+
+func streamServer() *httptest.Server {
+	r := mux.NewRouter()
+	r.HandleFunc("/events", EventsHandler).Methods("GET")
+	r.HandleFunc("/ws", WebSocketHandler).Methods("GET")
+	return httptest.NewServer(r)
+}
+
+// readEvent reads the next Server-Sent Event, skipping comments.
+func readEvent(t *testing.T, reader *bufio.Reader) []string {
+	lines := []string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" && len(lines) > 0 {
+			return lines
+		}
+		if line != "" && !strings.HasPrefix(line, ":") {
+			lines = append(lines, line)
+		}
+	}
+}
+
+func TestEventsHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	createdAt := time.Date(2024, 12, 2, 9, 0, 0, 0, time.UTC)
+	missed := &models.TodoEvent{ID: 141, Type: models.EventTodoUpdated, TodoID: 5, Todo: json.RawMessage(`{"id":5}`), CreatedAt: createdAt, UserIDs: []int{1}}
+
+	mockStore := stores.InitMockStore()
+	mockAuthenticatedUser(mockStore)
+	mockStore.On("GetTodoEventLogStart").Return(int64(30), nil)
+	mockStore.On("GetTodoEvents", int64(40), 1, models.EventReplayLimit+1).Return([]*models.TodoEvent{missed}, nil)
+	stores.InitStore(mockStore)
+	server := streamServer()
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/events", nil)
+	req.Header.Set("Authorization", "Bearer "+*token)
+	req.Header.Set("Last-Event-ID", "140")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	reader := bufio.NewReader(resp.Body)
+
+	assert.Equal(t, []string{"id: 141", "event: todo.updated", `data: {"id":141,"type":"todo.updated","todo_id":5,"todo":{"id":5},"created_at":"2024-12-02T09:00:00Z"}`}, readEvent(t, reader))
+
+	// The missed event arriving live too is not sent twice, and events of
+	// todos other users see are not sent at all.
+	realtime.Publish(missed)
+	realtime.Publish(&models.TodoEvent{ID: 142, Type: models.EventTodoCreated, TodoID: 6, UserIDs: []int{2}})
+	realtime.Publish(&models.TodoEvent{ID: 143, Type: models.EventTodoDeleted, TodoID: 5, WorkspaceID: 4, CreatedAt: createdAt, UserIDs: []int{1, 2}})
+	assert.Equal(t, []string{"id: 143", "event: todo.deleted", `data: {"id":143,"type":"todo.deleted","todo_id":5,"workspace_id":4,"created_at":"2024-12-02T09:00:00Z"}`}, readEvent(t, reader))
+	mockStore.AssertExpectations(t)
+}
+
+func TestEventsHandlerReset(t *testing.T) {
+	mockStore := stores.InitMockStore()
+	mockStore.On("RedeemStreamTicket", "ticket").Return(1, nil)
+	mockStore.On("GetTodoEventLogStart").Return(int64(30), nil)
+	stores.InitStore(mockStore)
+	server := streamServer()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events?ticket=ticket&last_event_id=12")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	lines := readEvent(t, bufio.NewReader(resp.Body))
+	assert.Equal(t, "event: reset", lines[0])
+	mockStore.AssertExpectations(t)
+}
+
+func TestEventsHandlerUnauthorized(t *testing.T) {
+	mockStore := stores.InitMockStore()
+	mockStore.On("RedeemStreamTicket", "used").Return(0, sql.ErrNoRows)
+	stores.InitStore(mockStore)
+	server := streamServer()
+	defer server.Close()
+
+	for _, path := range []string{"/events", "/events?ticket=used"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Failed to open stream: %v", err)
+		}
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, path)
+	}
+	mockStore.AssertExpectations(t)
+}
+
+func TestCreateStreamTicketHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	expiresAt := time.Date(2024, 12, 2, 9, 0, 30, 0, time.UTC)
+	mockStore := stores.InitMockStore()
+	mockAuthenticatedUser(mockStore)
+	mockStore.On("CreateStreamTicket", 1, mock.AnythingOfType("string")).Return(&models.StreamTicket{Ticket: "ticket", ExpiresAt: expiresAt}, nil)
+	stores.InitStore(mockStore)
+
+	req, _ := http.NewRequest("POST", "/stream-tickets", nil)
+	req.Header.Set("Authorization", "Bearer "+*token)
+	recorder := httptest.NewRecorder()
+	CreateStreamTicketHandler(recorder, req)
+
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"ticket":"ticket","expires_at":"2024-12-02T09:00:30Z"}`, recorder.Body.String())
+	mockStore.AssertExpectations(t)
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	mockStore := stores.InitMockStore()
+	mockStore.On("RedeemStreamTicket", "ticket").Return(1, nil)
+	mockStore.On("GetTodoEventLogStart").Return(int64(0), nil)
+	mockStore.On("GetTodoEvents", int64(0), 1, models.EventReplayLimit+1).Return([]*models.TodoEvent{}, nil)
+	stores.InitStore(mockStore)
+	server := streamServer()
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?ticket=ticket&last_event_id=0"
+	conn, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to open WebSocket: %v", err)
+	}
+	defer conn.Close()
+
+	// The handshake only completes after the handler subscribed.
+	realtime.Publish(&models.TodoEvent{ID: 44, Type: models.EventTodoCreated, TodoID: 7, Todo: json.RawMessage(`{"id":7}`), UserIDs: []int{1}})
+
+	var message map[string]interface{}
+	assert.NoError(t, websocket.JSON.Receive(conn, &message))
+	assert.Equal(t, map[string]interface{}{"id": float64(44), "type": "todo.created", "todo_id": float64(7), "todo": map[string]interface{}{"id": float64(7)}, "created_at": "0001-01-01T00:00:00Z"}, message)
+	mockStore.AssertExpectations(t)
+}
+
+func TestWebSocketHandlerOrigin(t *testing.T) {
+	defer AllowStreamOrigins(nil)
+	AllowStreamOrigins([]string{"https://app.example.com"})
+	mockStore := stores.InitMockStore()
+	mockStore.On("RedeemStreamTicket", "ticket").Return(1, nil)
+	stores.InitStore(mockStore)
+	server := streamServer()
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?ticket=ticket"
+
+	// Other origins are refused before the ticket is used up.
+	_, err := websocket.Dial(url, "", "https://evil.example.com")
+	assert.Error(t, err)
+	mockStore.AssertNotCalled(t, "RedeemStreamTicket", "ticket")
+
+	conn, err := websocket.Dial(url, "", "https://app.example.com")
+	if err != nil {
+		t.Fatalf("Failed to open WebSocket: %v", err)
+	}
+	conn.Close()
+	mockStore.AssertExpectations(t)
+}
@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+
+	"github.com/gorilla/mux"
+)
+
+func templateID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid template id"}, http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// CreateTemplateHandler saves a template, either from the items given or,
+// with project_id, from the todos of that project in the active workspace.
+func CreateTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	template := &models.Template{}
+	err := json.NewDecoder(r.Body).Decode(template)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	if template.ProjectID != 0 {
+		if len(template.Items) > 0 {
+			utility.WriteJsonData(w, map[string]string{"error": "Give either items or project_id"}, http.StatusBadRequest)
+			return
+		}
+		workspaceID, ok := activeWorkspace(w, r, user.ID)
+		if !ok {
+			return
+		}
+		if _, err := stores.GetStore().GetProject(template.ProjectID, workspaceID); err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": "Project not found"}, http.StatusBadRequest)
+			return
+		}
+		todos, err := stores.GetStore().GetTodos(user.ID, workspaceID, &models.TodoFilter{ProjectID: template.ProjectID})
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get todos\n%v", err)}, http.StatusInternalServerError)
+			return
+		}
+		template.Items = templateItems(todos, user.Settings.Location())
+	}
+	normalizeTemplateTags(template.Items)
+
+	errors := validations.ValidateTemplate(template)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+	template.UserID = user.ID
+
+	newTemplate, err := stores.GetStore().CreateTemplate(template)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create template\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, newTemplate, http.StatusCreated)
+}
+
+// templateItems turns todos into template items, keeping their due dates
+// relative to the earliest of them as read in location.
+func templateItems(todos []*models.Todo, location *time.Location) []*models.TemplateItem {
+	var start time.Time
+	for _, todo := range todos {
+		if !todo.DueDate.IsZero() {
+			if due := lib.AllDay(dueTime(todo, location)); start.IsZero() || due.Before(start) {
+				start = due
+			}
+		}
+	}
+
+	items := []*models.TemplateItem{}
+	for _, todo := range todos {
+		item := &models.TemplateItem{TaskName: todo.TaskName, Notes: todo.Notes, Priority: todo.Priority, Tags: todo.Tags}
+		if !todo.DueDate.IsZero() {
+			offset := int(lib.AllDay(dueTime(todo, location)).Sub(start).Hours() / 24)
+			item.DueOffsetDays = &offset
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func normalizeTemplateTags(items []*models.TemplateItem) {
+	for _, item := range items {
+		if item != nil {
+			item.Tags = normalizeTags(item.Tags)
+			normalizeTemplateTags(item.Children)
+		}
+	}
+}
+
+func GetTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	templates, err := stores.GetStore().GetTemplates(user.ID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get templates\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, templates, http.StatusOK)
+}
+
+func GetTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := templateID(w, r)
+	if !ok {
+		return
+	}
+
+	template, err := stores.GetStore().GetTemplate(id, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Template not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get template\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, template, http.StatusOK)
+}
+
+func DeleteTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := templateID(w, r)
+	if !ok {
+		return
+	}
+
+	err := stores.GetStore().DeleteTemplate(id, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Template not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not delete template\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, map[string]string{"message": "Template deleted"}, http.StatusOK)
+}
+
+// InstantiateTemplateHandler creates the todos of a template in the active
+// workspace, all of them or none.
+func InstantiateTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	renderHTML, ok := renderHTMLRequested(w, r)
+	if !ok {
+		return
+	}
+
+	instantiation := models.TemplateInstantiation{}
+	err := json.NewDecoder(r.Body).Decode(&instantiation)
+	if err != nil && err != io.EOF {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+	errors := validations.ValidateTemplateInstantiation(&instantiation)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := templateID(w, r)
+	if !ok {
+		return
+	}
+
+	start := lib.StartOfDay(userNow(user))
+	if instantiation.StartDate != "" {
+		start, err = time.ParseInLocation("2006-01-02", instantiation.StartDate, user.Settings.Location())
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"StartDate": "Must be a date such as 2024-05-01"}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	template, err := stores.GetStore().GetTemplate(id, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Template not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get template\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	todos, parents, missing, errors := templateTodos(template.Items, start, instantiation.Variables)
+	if len(missing) > 0 {
+		utility.WriteJsonData(w, map[string]string{"Variables": "No value given for " + strings.Join(missing, ", ")}, http.StatusBadRequest)
+		return
+	}
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	placement := &models.Todo{ProjectID: instantiation.ProjectID}
+	if !placeNewTodo(w, r, placement, user.ID) {
+		return
+	}
+	for _, todo := range todos {
+		todo.WorkspaceID = placement.WorkspaceID
+		todo.ProjectID = placement.ProjectID
+	}
+	// Template items hold no custom field values, so checking the placement
+	// checks every todo.
+	if !checkNewTodoCustomFields(w, placement) {
+		return
+	}
+
+	created, err := stores.GetStore().CreateTodos(todos, parents, user.ID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create todos\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	for _, todo := range created {
+		prepareTodo(todo, renderHTML)
+	}
+
+	utility.WriteJsonData(w, created, http.StatusCreated)
+}
+
+// templateTodos flattens the tree of a template depth first into the todos
+// to create, along with the index of the todo each one blocks, or -1. Due
+// dates are all-day dates counted from start. It also returns the variables
+// used without a value, sorted, and the validation errors of the todos keyed
+// by the path of their item, e.g. "Items[0].Children[1].TaskName".
+func templateTodos(items []*models.TemplateItem, start time.Time, variables map[string]string) ([]*models.Todo, []int, []string, map[string]string) {
+	todos := []*models.Todo{}
+	parents := []int{}
+	missing := map[string]bool{}
+	errors := map[string]string{}
+	expand := func(text string) string {
+		expanded, names := lib.ExpandVariables(text, variables)
+		for _, name := range names {
+			missing[name] = true
+		}
+		return expanded
+	}
+
+	var walk func(items []*models.TemplateItem, path string, parent int)
+	walk = func(items []*models.TemplateItem, path string, parent int) {
+		for i, item := range items {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			todo := &models.Todo{TaskName: expand(item.TaskName), Notes: expand(item.Notes), Priority: item.Priority, Tags: item.Tags}
+			if item.DueOffsetDays != nil {
+				todo.DueDate = start.AddDate(0, 0, *item.DueOffsetDays)
+				todo.DueAllDay = true
+			}
+			for field, message := range validations.ValidateTodo(todo) {
+				errors[itemPath+"."+field] = message
+			}
+			todos = append(todos, todo)
+			parents = append(parents, parent)
+			walk(item.Children, itemPath+".Children", len(todos)-1)
+		}
+	}
+	walk(items, "Items", -1)
+
+	names := []string{}
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return todos, parents, names, errors
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateHandlers(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	now = func() time.Time { return time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	createdAt := time.Date(2024, 11, 30, 9, 0, 0, 0, time.UTC)
+	offset := func(days int) *int { return &days }
+	release := &models.Template{ID: 3, UserID: 1, Name: "Release", CreatedAt: createdAt, Items: []*models.TemplateItem{
+		{TaskName: "Release {{version}}", DueOffsetDays: offset(7), Children: []*models.TemplateItem{
+			{TaskName: "Write notes for {{version}}", Tags: []string{"docs"}, DueOffsetDays: offset(5)},
+			{TaskName: "Tag the release", Notes: "git tag v{{version}}"},
+		}},
+	}}
+	releaseJSON := map[string]interface{}{"id": float64(3), "name": "Release", "created_at": "2024-11-30T09:00:00Z", "items": []interface{}{
+		map[string]interface{}{"task_name": "Release {{version}}", "due_offset_days": float64(7), "children": []interface{}{
+			map[string]interface{}{"task_name": "Write notes for {{version}}", "tags": []interface{}{"docs"}, "due_offset_days": float64(5)},
+			map[string]interface{}{"task_name": "Tag the release", "notes": "git tag v{{version}}"},
+		}},
+	}}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		header         string
+		body           string
+		mockSetup      func(mockStore *stores.MockStore)
+		expectedStatus int
+		expectedBody   interface{}
+	}{
+		{
+			name:   "Create",
+			method: "POST",
+			url:    "/templates",
+			body:   `{"name": "Release", "items": [{"task_name": "Release {{version}}", "due_offset_days": 7, "children": [{"task_name": "Write notes for {{version}}", "tags": ["#Docs"], "due_offset_days": 5}, {"task_name": "Tag the release", "notes": "git tag v{{version}}"}]}]}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("CreateTemplate", &models.Template{UserID: 1, Name: "Release", Items: release.Items}).Return(release, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   releaseJSON,
+		},
+		{
+			name:           "Create Invalid Child",
+			method:         "POST",
+			url:            "/templates",
+			body:           `{"name": "Release", "items": [{"task_name": "Release", "children": [{"priority": 7}]}]}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"Items[0].Children[0].TaskName": "This field is required", "Items[0].Children[0].Priority": "Must be between 0 and 4"},
+		},
+		{
+			name:           "Create With Items And Project",
+			method:         "POST",
+			url:            "/templates",
+			body:           `{"name": "Release", "project_id": 2, "items": [{"task_name": "Release"}]}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"error": "Give either items or project_id"},
+		},
+		{
+			name:   "Create From Project",
+			method: "POST",
+			url:    "/templates",
+			header: "4",
+			body:   `{"name": "Onboarding", "project_id": 2}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Role: "editor"}, nil)
+				mockStore.On("GetProject", 2, 4).Return(&models.Project{ID: 2, WorkspaceID: 4, Name: "Onboarding"}, nil)
+				mockStore.On("GetTodos", 1, 4, &models.TodoFilter{ProjectID: 2}).Return([]*models.Todo{
+					{ID: 10, TaskName: "Order a laptop", DueDate: time.Date(2024, 12, 2, 15, 0, 0, 0, time.UTC), Tags: []string{"it"}, Completed: true},
+					{ID: 11, TaskName: "Meet the team", DueDate: time.Date(2024, 12, 4, 0, 0, 0, 0, time.UTC), DueAllDay: true, Priority: 2},
+					{ID: 12, TaskName: "Read the handbook", Notes: "- [ ] Benefits"},
+				}, nil)
+				mockStore.On("CreateTemplate", &models.Template{UserID: 1, Name: "Onboarding", ProjectID: 2, Items: []*models.TemplateItem{
+					{TaskName: "Order a laptop", Tags: []string{"it"}, DueOffsetDays: offset(0)},
+					{TaskName: "Meet the team", Priority: 2, DueOffsetDays: offset(2)},
+					{TaskName: "Read the handbook", Notes: "- [ ] Benefits"},
+				}}).Return(&models.Template{ID: 4, UserID: 1, Name: "Onboarding", CreatedAt: createdAt, Items: []*models.TemplateItem{}}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   map[string]interface{}{"id": float64(4), "name": "Onboarding", "created_at": "2024-11-30T09:00:00Z", "items": []interface{}{}},
+		},
+		{
+			name:   "Get Not Found",
+			method: "GET",
+			url:    "/templates/5",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTemplate", 5, 1).Return((*models.Template)(nil), sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   map[string]interface{}{"error": "Template not found"},
+		},
+		{
+			name:   "Delete",
+			method: "DELETE",
+			url:    "/templates/3",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("DeleteTemplate", 3, 1).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"message": "Template deleted"},
+		},
+		{
+			name:   "Instantiate",
+			method: "POST",
+			url:    "/templates/3/instantiate",
+			body:   `{"start_date": "2024-12-02", "variables": {"version": "1.4"}}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTemplate", 3, 1).Return(release, nil)
+				mockStore.On("CreateTodos", []*models.Todo{
+					{TaskName: "Release 1.4", DueDate: time.Date(2024, 12, 9, 0, 0, 0, 0, time.UTC), DueAllDay: true},
+					{TaskName: "Write notes for 1.4", Tags: []string{"docs"}, DueDate: time.Date(2024, 12, 7, 0, 0, 0, 0, time.UTC), DueAllDay: true},
+					{TaskName: "Tag the release", Notes: "git tag v1.4"},
+				}, []int{-1, 0, 0}, 1).Return([]*models.Todo{{ID: 7, TaskName: "Release 1.4", Blocked: true}}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody: []interface{}{map[string]interface{}{"id": float64(7), "task_name": "Release 1.4", "completed": false, "blocked": true, "notes": "",
+				"due_date": "0001-01-01T00:00:00Z", "created_at": "0001-01-01T00:00:00Z", "updated_at": "0001-01-01T00:00:00Z"}},
+		},
+		{
+			name:   "Instantiate Into Project With Required Field",
+			method: "POST",
+			url:    "/templates/3/instantiate",
+			header: "4",
+			body:   `{"project_id": 2, "variables": {"version": "1.4"}}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTemplate", 3, 1).Return(release, nil)
+				mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Role: "editor"}, nil)
+				mockStore.On("GetProject", 2, 4).Return(&models.Project{ID: 2, WorkspaceID: 4, Name: "Onboarding"}, nil)
+				mockStore.On("GetCustomFields", 2).Return([]*models.CustomField{{Key: "points", Type: models.FieldNumber, Required: true}}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"CustomFields.points": "This field is required"},
+		},
+		{
+			name:   "Instantiate Missing Variable",
+			method: "POST",
+			url:    "/templates/3/instantiate",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTemplate", 3, 1).Return(release, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"Variables": "No value given for version"},
+		},
+		{
+			name:   "Instantiate Too Short",
+			method: "POST",
+			url:    "/templates/3/instantiate",
+			body:   `{"variables": {"name": "Ada"}}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTemplate", 3, 1).Return(&models.Template{ID: 3, UserID: 1, Name: "Welcome", Items: []*models.TemplateItem{{TaskName: "{{name}}"}}}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"Items[0].TaskName": "This field must be longer than 5 characters"},
+		},
+		{
+			name:           "Instantiate Invalid Start",
+			method:         "POST",
+			url:            "/templates/3/instantiate",
+			body:           `{"start_date": "next monday"}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"StartDate": "Must be a date such as 2024-05-01"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tt.mockSetup(mockStore)
+			stores.InitStore(mockStore)
+
+			req, _ := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+*token)
+			if tt.header != "" {
+				req.Header.Set("X-Workspace-ID", tt.header)
+			}
+			recorder := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/templates", CreateTemplateHandler).Methods("POST")
+			r.HandleFunc("/templates/{id:[0-9]+}", GetTemplateHandler).Methods("GET")
+			r.HandleFunc("/templates/{id:[0-9]+}", DeleteTemplateHandler).Methods("DELETE")
+			r.HandleFunc("/templates/{id:[0-9]+}/instantiate", InstantiateTemplateHandler).Methods("POST")
+			r.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			var body interface{}
+			if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			assert.Equal(t, tt.expectedBody, body)
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
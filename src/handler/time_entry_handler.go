@@ -0,0 +1,367 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/src/exporter"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+
+	"github.com/gorilla/mux"
+)
+
+// maxReportDays bounds the range of a time report or statistics.
+const maxReportDays = 366
+
+// reportRange reads the inclusive range of days a report covers from the
+// ?from and ?to dates, read in the user's timezone. The range defaults to the
+// days from defaultFrom(today) up to today. When the range is invalid it
+// writes the error response and returns false.
+func reportRange(w http.ResponseWriter, r *http.Request, user *models.User, defaultFrom func(today time.Time) time.Time) (time.Time, time.Time, bool) {
+	today := lib.StartOfDay(userNow(user))
+	from := defaultFrom(today)
+	to := today
+	for name, date := range map[string]*time.Time{"from": &from, "to": &to} {
+		if value := r.URL.Query().Get(name); value != "" {
+			parsed, err := time.ParseInLocation("2006-01-02", value, user.Settings.Location())
+			if err != nil {
+				utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("%s must be a date such as 2024-05-01", name)}, http.StatusBadRequest)
+				return time.Time{}, time.Time{}, false
+			}
+			*date = parsed
+		}
+	}
+	if to.Before(from) || to.After(from.AddDate(0, 0, maxReportDays)) {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("to must be on or after from and at most %d days later", maxReportDays)}, http.StatusBadRequest)
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// StartTimerHandler starts tracking time on a todo. Anyone who can see the
+// todo can track time on it, but only one timer per user runs at a time.
+func StartTimerHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	start := models.TimerStart{}
+	err = json.NewDecoder(r.Body).Decode(&start)
+	if err != nil && err != io.EOF {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleViewer) {
+		return
+	}
+
+	errors := validations.ValidateTimerStart(&start)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	entry, err := stores.GetStore().StartTimer(todoID, user.ID, start.Note)
+	if err != nil {
+		if err == stores.ErrTimerRunning {
+			utility.WriteJsonData(w, map[string]string{"error": "A timer is already running, stop it first"}, http.StatusConflict)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not start timer\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, entry, http.StatusCreated)
+}
+
+func StopTimerHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleViewer) {
+		return
+	}
+
+	entry, err := stores.GetStore().StopTimer(todoID, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "No timer is running on this todo"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not stop timer\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, entry, http.StatusOK)
+}
+
+// GetTimerHandler returns the user's running timer, whichever todo it is on.
+func GetTimerHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := stores.GetStore().GetRunningTimer(user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "No timer is running"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get timer\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, entry, http.StatusOK)
+}
+
+func GetTimeEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleViewer) {
+		return
+	}
+
+	entries, err := stores.GetStore().GetTimeEntries(todoID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get time entries\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, entries, http.StatusOK)
+}
+
+// CreateTimeEntryHandler logs time spent on a todo without a timer.
+func CreateTimeEntryHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	entry := models.TimeEntry{}
+	err = json.NewDecoder(r.Body).Decode(&entry)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleViewer) {
+		return
+	}
+
+	errors := validations.ValidateTimeEntry(&entry)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	newEntry, err := stores.GetStore().CreateTimeEntry(&models.TimeEntry{TodoID: todoID, UserID: user.ID, StartedAt: entry.StartedAt, EndedAt: entry.EndedAt, Note: entry.Note})
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create time entry\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, newEntry, http.StatusCreated)
+}
+
+// UpdateTimeEntryHandler changes the fields given in the request; the others
+// keep their value. Giving a running timer an end stops it.
+func UpdateTimeEntryHandler(w http.ResponseWriter, r *http.Request) {
+	entry, ok := findOwnTimeEntry(w, r, "edit")
+	if !ok {
+		return
+	}
+
+	update := *entry
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+	update.ID, update.TodoID, update.UserID = entry.ID, entry.TodoID, entry.UserID
+	errors := validations.ValidateTimeEntry(&update)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	updatedEntry, err := stores.GetStore().UpdateTimeEntry(&update)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not update time entry\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, updatedEntry, http.StatusOK)
+}
+
+func DeleteTimeEntryHandler(w http.ResponseWriter, r *http.Request) {
+	entry, ok := findOwnTimeEntry(w, r, "delete")
+	if !ok {
+		return
+	}
+
+	err := stores.GetStore().DeleteTimeEntry(entry.ID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not delete time entry\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, map[string]string{"message": "Time entry deleted"}, http.StatusOK)
+}
+
+// findOwnTimeEntry loads the time entry named in the URL and makes sure the
+// requesting user logged it and can still see its todo. It writes the error
+// response on failure.
+func findOwnTimeEntry(w http.ResponseWriter, r *http.Request, action string) (*models.TimeEntry, bool) {
+	vars := mux.Vars(r)
+	entryID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return nil, false
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	entry, err := stores.GetStore().GetTimeEntry(entryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Time entry not found"}, http.StatusNotFound)
+			return nil, false
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get time entry\n%v", err)}, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return nil, false
+	}
+	_, err = stores.GetStore().GetTodoRole(entry.TodoID, user.ID, workspaceID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Time entry not found"}, http.StatusNotFound)
+		return nil, false
+	}
+	if entry.UserID != user.ID {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Only the user who logged it can %s this time entry", action)}, http.StatusForbidden)
+		return nil, false
+	}
+	return entry, true
+}
+
+// GetTimeReportHandler sums the time the user logged from ?from to ?to, both
+// dates in the user's timezone and included, grouped by ?group_by, a comma
+// separated list of project, tag and day. The range defaults to the current
+// month up to today. ?format=csv downloads the report as CSV.
+func GetTimeReportHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		utility.WriteJsonData(w, map[string]string{"error": "format must be json or csv"}, http.StatusBadRequest)
+		return
+	}
+
+	from, to, ok := reportRange(w, r, user, func(today time.Time) time.Time {
+		return today.AddDate(0, 0, 1-today.Day())
+	})
+	if !ok {
+		return
+	}
+
+	groupBy := []string{models.TimeByProject, models.TimeByTag, models.TimeByDay}
+	if value := query.Get("group_by"); value != "" {
+		groupBy = []string{}
+		seen := map[string]bool{}
+		for _, group := range strings.Split(value, ",") {
+			group = strings.TrimSpace(group)
+			if group != models.TimeByProject && group != models.TimeByTag && group != models.TimeByDay {
+				utility.WriteJsonData(w, map[string]string{"error": "group_by must list project, tag or day"}, http.StatusBadRequest)
+				return
+			}
+			if !seen[group] {
+				seen[group] = true
+				groupBy = append(groupBy, group)
+			}
+		}
+	}
+
+	report, err := stores.GetStore().GetTimeReport(user.ID, &models.TimeReportQuery{
+		From:     from,
+		To:       to.AddDate(0, 0, 1),
+		Timezone: user.Settings.Location().String(),
+		GroupBy:  groupBy,
+	})
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get time report\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	report.From = from.Format("2006-01-02")
+	report.To = to.Format("2006-01-02")
+	report.GroupBy = groupBy
+
+	if format != "csv" {
+		utility.WriteJsonData(w, report, http.StatusOK)
+		return
+	}
+	var out bytes.Buffer
+	if err := exporter.WriteTimeReportCSV(&out, report); err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not write time report\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("time-%s-%s.csv", report.From, report.To)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(out.Bytes())
+}
@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeEntryHandlers(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	startedAt := time.Date(2024, 11, 30, 9, 0, 0, 0, time.UTC)
+	endedAt := startedAt.Add(90 * time.Minute)
+	running := &models.TimeEntry{ID: 3, TodoID: 5, UserID: 1, StartedAt: startedAt, Note: "Design review"}
+	logged := &models.TimeEntry{ID: 4, TodoID: 5, UserID: 1, StartedAt: startedAt, EndedAt: &endedAt, Note: "Call", Seconds: 5400}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		mockSetup      func(mockStore *stores.MockStore)
+		expectedStatus int
+		expectedBody   interface{}
+	}{
+		{
+			name:   "Start",
+			method: "POST",
+			url:    "/todos/5/timer/start",
+			body:   `{"note": "Design review"}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+				mockStore.On("StartTimer", 5, 1, "Design review").Return(running, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   map[string]interface{}{"id": float64(3), "todo_id": float64(5), "user_id": float64(1), "started_at": "2024-11-30T09:00:00Z", "ended_at": nil, "note": "Design review", "seconds": float64(0)},
+		},
+		{
+			name:   "Start Without Body",
+			method: "POST",
+			url:    "/todos/5/timer/start",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+				mockStore.On("StartTimer", 5, 1, "").Return(running, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   map[string]interface{}{"id": float64(3), "todo_id": float64(5), "user_id": float64(1), "started_at": "2024-11-30T09:00:00Z", "ended_at": nil, "note": "Design review", "seconds": float64(0)},
+		},
+		{
+			name:   "Start While Running",
+			method: "POST",
+			url:    "/todos/6/timer/start",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 6, 1, 0).Return("owner", nil)
+				mockStore.On("StartTimer", 6, 1, "").Return((*models.TimeEntry)(nil), stores.ErrTimerRunning)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   map[string]interface{}{"error": "A timer is already running, stop it first"},
+		},
+		{
+			name:   "Start On Hidden Todo",
+			method: "POST",
+			url:    "/todos/7/timer/start",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 7, 1, 0).Return("", sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   map[string]interface{}{"error": "Todo not found"},
+		},
+		{
+			name:   "Stop Not Running",
+			method: "POST",
+			url:    "/todos/5/timer/stop",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+				mockStore.On("StopTimer", 5, 1).Return((*models.TimeEntry)(nil), sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   map[string]interface{}{"error": "No timer is running on this todo"},
+		},
+		{
+			name:   "Running Timer",
+			method: "GET",
+			url:    "/timer",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetRunningTimer", 1).Return(running, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"id": float64(3), "todo_id": float64(5), "user_id": float64(1), "started_at": "2024-11-30T09:00:00Z", "ended_at": nil, "note": "Design review", "seconds": float64(0)},
+		},
+		{
+			name:   "Log Time",
+			method: "POST",
+			url:    "/todos/5/time-entries",
+			body:   `{"started_at": "2024-11-30T09:00:00Z", "ended_at": "2024-11-30T10:30:00Z", "note": "Call", "user_id": 2}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+				mockStore.On("CreateTimeEntry", &models.TimeEntry{TodoID: 5, UserID: 1, StartedAt: startedAt, EndedAt: &endedAt, Note: "Call"}).Return(logged, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   map[string]interface{}{"id": float64(4), "todo_id": float64(5), "user_id": float64(1), "started_at": "2024-11-30T09:00:00Z", "ended_at": "2024-11-30T10:30:00Z", "note": "Call", "seconds": float64(5400)},
+		},
+		{
+			name:   "Log Time Without End",
+			method: "POST",
+			url:    "/todos/5/time-entries",
+			body:   `{"note": "Call"}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"StartedAt": "This field is required", "EndedAt": "This field is required"},
+		},
+		{
+			name:   "Log Time Ending Before Start",
+			method: "POST",
+			url:    "/todos/5/time-entries",
+			body:   `{"started_at": "2024-11-30T09:00:00Z", "ended_at": "2024-11-30T08:00:00Z"}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"EndedAt": "Must be after the start"},
+		},
+		{
+			name:   "Edit Note",
+			method: "PATCH",
+			url:    "/time-entries/4",
+			body:   `{"note": "Client call"}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTimeEntry", 4).Return(logged, nil)
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+				mockStore.On("UpdateTimeEntry", &models.TimeEntry{ID: 4, TodoID: 5, UserID: 1, StartedAt: startedAt, EndedAt: &endedAt, Note: "Client call", Seconds: 5400}).
+					Return(&models.TimeEntry{ID: 4, TodoID: 5, UserID: 1, StartedAt: startedAt, EndedAt: &endedAt, Note: "Client call", Seconds: 5400}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"id": float64(4), "todo_id": float64(5), "user_id": float64(1), "started_at": "2024-11-30T09:00:00Z", "ended_at": "2024-11-30T10:30:00Z", "note": "Client call", "seconds": float64(5400)},
+		},
+		{
+			name:   "Edit Someone Elses",
+			method: "PATCH",
+			url:    "/time-entries/8",
+			body:   `{"note": "Mine now"}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTimeEntry", 8).Return(&models.TimeEntry{ID: 8, TodoID: 5, UserID: 2, StartedAt: startedAt, EndedAt: &endedAt}, nil)
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("owner", nil)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   map[string]interface{}{"error": "Only the user who logged it can edit this time entry"},
+		},
+		{
+			name:   "Delete",
+			method: "DELETE",
+			url:    "/time-entries/4",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTimeEntry", 4).Return(logged, nil)
+				mockStore.On("GetTodoRole", 5, 1, 0).Return("viewer", nil)
+				mockStore.On("DeleteTimeEntry", 4).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"message": "Time entry deleted"},
+		},
+		{
+			name:   "Delete Not Found",
+			method: "DELETE",
+			url:    "/time-entries/9",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTimeEntry", 9).Return((*models.TimeEntry)(nil), sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   map[string]interface{}{"error": "Time entry not found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tt.mockSetup(mockStore)
+			stores.InitStore(mockStore)
+
+			req, _ := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+*token)
+			recorder := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/todos/{id:[0-9]+}/timer/start", StartTimerHandler).Methods("POST")
+			r.HandleFunc("/todos/{id:[0-9]+}/timer/stop", StopTimerHandler).Methods("POST")
+			r.HandleFunc("/todos/{id:[0-9]+}/time-entries", CreateTimeEntryHandler).Methods("POST")
+			r.HandleFunc("/time-entries/{id:[0-9]+}", UpdateTimeEntryHandler).Methods("PATCH")
+			r.HandleFunc("/time-entries/{id:[0-9]+}", DeleteTimeEntryHandler).Methods("DELETE")
+			r.HandleFunc("/timer", GetTimerHandler).Methods("GET")
+			r.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			var body interface{}
+			if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			assert.Equal(t, tt.expectedBody, body)
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetTimeReportHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+	now = func() time.Time { return time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	report := &models.TimeReport{TotalSeconds: 5400, Rows: []*models.TimeReportRow{
+		{ProjectID: 2, ProjectName: "Website", Day: "2024-11-04", Seconds: 5400},
+	}}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedQuery  *models.TimeReportQuery
+		expectedStatus int
+		expectedType   string
+		expectedBody   string
+	}{
+		{
+			name:  "This Month",
+			query: "?group_by=project,day",
+			expectedQuery: &models.TimeReportQuery{
+				From:     time.Date(2024, 11, 1, 0, 0, 0, 0, berlin),
+				To:       time.Date(2024, 11, 21, 0, 0, 0, 0, berlin),
+				Timezone: "Europe/Berlin",
+				GroupBy:  []string{models.TimeByProject, models.TimeByDay},
+			},
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/json",
+			expectedBody:   `{"from":"2024-11-01","to":"2024-11-20","group_by":["project","day"],"total_seconds":5400,"rows":[{"project_id":2,"project_name":"Website","day":"2024-11-04","seconds":5400}]}`,
+		},
+		{
+			name:  "CSV",
+			query: "?from=2024-11-04&to=2024-11-04&group_by=day,project&format=csv",
+			expectedQuery: &models.TimeReportQuery{
+				From:     time.Date(2024, 11, 4, 0, 0, 0, 0, berlin),
+				To:       time.Date(2024, 11, 5, 0, 0, 0, 0, berlin),
+				Timezone: "Europe/Berlin",
+				GroupBy:  []string{models.TimeByDay, models.TimeByProject},
+			},
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/csv; charset=utf-8",
+			expectedBody:   "day,project_id,project,seconds,hours\n2024-11-04,2,Website,5400,1.50",
+		},
+		{name: "Unknown Group", query: "?group_by=client", expectedStatus: http.StatusBadRequest, expectedType: "application/json", expectedBody: `{"error":"group_by must list project, tag or day"}`},
+		{name: "Backwards Range", query: "?from=2024-11-04&to=2024-11-01", expectedStatus: http.StatusBadRequest, expectedType: "application/json", expectedBody: `{"error":"to must be on or after from and at most 366 days later"}`},
+		{name: "Invalid Date", query: "?from=yesterday", expectedStatus: http.StatusBadRequest, expectedType: "application/json", expectedBody: `{"error":"from must be a date such as 2024-05-01"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockStore.On("GetUser", &models.User{Email: "test@mail.com", Password: "password"}).
+				Return(&models.User{ID: 1, Email: "test@mail.com", Password: "password", Settings: &models.UserSettings{Timezone: "Europe/Berlin", WeekStart: "monday", Locale: "de-DE"}}, nil)
+			if tt.expectedQuery != nil {
+				mockStore.On("GetTimeReport", 1, tt.expectedQuery).Return(&models.TimeReport{TotalSeconds: report.TotalSeconds, Rows: report.Rows}, nil)
+			}
+			stores.InitStore(mockStore)
+
+			req, _ := http.NewRequest("GET", "/reports/time"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer "+*token)
+			recorder := httptest.NewRecorder()
+			GetTimeReportHandler(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			assert.Equal(t, tt.expectedType, recorder.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(recorder.Body.String()))
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetTimeReportHandlerStoreError(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	mockStore := stores.InitMockStore()
+	mockAuthenticatedUser(mockStore)
+	mockStore.On("GetTimeReport", 1, &models.TimeReportQuery{
+		From:     time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+		Timezone: "UTC",
+		GroupBy:  []string{models.TimeByProject, models.TimeByTag, models.TimeByDay},
+	}).Return((*models.TimeReport)(nil), fmt.Errorf("some db error"))
+	stores.InitStore(mockStore)
+
+	req, _ := http.NewRequest("GET", "/reports/time?from=2024-11-01&to=2024-11-30", nil)
+	req.Header.Set("Authorization", "Bearer "+*token)
+	recorder := httptest.NewRecorder()
+	GetTimeReportHandler(recorder, req)
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	mockStore.AssertExpectations(t)
+}
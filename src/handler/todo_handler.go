@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 	"todo-list/src/lib"
 	"todo-list/src/models"
 	"todo-list/src/stores"
@@ -16,7 +19,96 @@ import (
 
 var todos []models.Todo
 
+// prepareTodo fills in the response fields derived from a todo's notes.
+func prepareTodo(todo *models.Todo, renderHTML bool) {
+	todo.ChecklistDone, todo.ChecklistTotal = lib.ChecklistProgress(todo.Notes)
+	if renderHTML && todo.Notes != "" {
+		todo.NotesHTML = lib.RenderMarkdown(todo.Notes)
+	}
+}
+
+// renderHTMLRequested reports whether the client asked for rendered notes with
+// ?render=html. Any other render value is rejected.
+func renderHTMLRequested(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	switch r.URL.Query().Get("render") {
+	case "":
+		return false, true
+	case "html":
+		return true, true
+	default:
+		utility.WriteJsonData(w, map[string]string{"error": "Unsupported render option"}, http.StatusBadRequest)
+		return false, false
+	}
+}
+
+// dueRanges are the values ?due takes, as ranges around the current time in
+// the user's timezone for weeks starting on weekStart.
+var dueRanges = map[string]func(now time.Time, weekStart time.Weekday) models.DueRange{
+	"today": func(now time.Time, weekStart time.Weekday) models.DueRange {
+		today := lib.StartOfDay(now)
+		return models.DueRange{From: today, To: today.AddDate(0, 0, 1)}
+	},
+	"overdue": func(now time.Time, weekStart time.Weekday) models.DueRange {
+		return models.DueRange{To: now}
+	},
+	"this_week": func(now time.Time, weekStart time.Weekday) models.DueRange {
+		week := lib.StartOfWeek(now, weekStart)
+		return models.DueRange{From: week, To: week.AddDate(0, 0, 7)}
+	},
+}
+
+// todoFilter reads the list filters from the query string. assignee is "me"
+// or a user id and due is "today", "this_week" or "overdue", which only lists
+// open todos, all read in the user's timezone. query is a filter expression,
+// see filterexpr. When a filter is invalid it writes the error response and
+// returns false.
+func todoFilter(w http.ResponseWriter, r *http.Request, user *models.User, workspaceID int) (*models.TodoFilter, bool) {
+	filter := &models.TodoFilter{}
+	switch assignee := r.URL.Query().Get("assignee"); assignee {
+	case "":
+	case "me":
+		filter.AssigneeID = user.ID
+	default:
+		assigneeID, err := strconv.Atoi(assignee)
+		if err != nil || assigneeID <= 0 {
+			utility.WriteJsonData(w, map[string]string{"error": "assignee must be \"me\" or a user id"}, http.StatusBadRequest)
+			return nil, false
+		}
+		filter.AssigneeID = assigneeID
+	}
+	filter.Tag = strings.ToLower(strings.TrimPrefix(r.URL.Query().Get("tag"), "#"))
+	if due := r.URL.Query().Get("due"); due != "" {
+		dueRange, ok := dueRanges[due]
+		if !ok {
+			utility.WriteJsonData(w, map[string]string{"error": "due must be one of today, this_week or overdue"}, http.StatusBadRequest)
+			return nil, false
+		}
+		window := dueRange(userNow(user), user.Settings.FirstWeekday())
+		filter.Due = &window
+		if due == "overdue" {
+			open := false
+			filter.Completed = &open
+		}
+	}
+	if query := r.URL.Query().Get("query"); query != "" {
+		expression, ok := filterExpression(w, query, user)
+		if !ok {
+			return nil, false
+		}
+		filter.Expression = expression
+	}
+	if !customFieldFilter(w, r, filter, workspaceID) {
+		return nil, false
+	}
+	return filter, true
+}
+
 func CreateTodoHandler(w http.ResponseWriter, r *http.Request) {
+	renderHTML, ok := renderHTMLRequested(w, r)
+	if !ok {
+		return
+	}
+
 	todo := models.Todo{}
 	err := json.NewDecoder(r.Body).Decode(&todo)
 	if err != nil {
@@ -44,22 +136,58 @@ func CreateTodoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	todo.Tags = normalizeTags(todo.Tags)
 	errors := validations.ValidateTodo(&todo)
 	if len(errors) > 0 {
 		utility.WriteJsonData(w, errors, http.StatusBadRequest)
 		return
 	}
 
+	if !placeNewTodo(w, r, &todo, user.ID) {
+		return
+	}
+	if !checkNewTodoCustomFields(w, &todo) {
+		return
+	}
+
 	newTodo, err := stores.GetStore().CreateTodo(&todo, user.ID)
 	if err != nil {
 		json.NewEncoder(w).Encode(err)
 		return
 	}
+	prepareTodo(newTodo, renderHTML)
 
 	utility.WriteJsonData(w, newTodo, http.StatusCreated)
 }
 
+// placeNewTodo puts a todo about to be created in the active workspace and
+// checks the user may add it to its project. When they may not it writes the
+// error response and returns false.
+func placeNewTodo(w http.ResponseWriter, r *http.Request, todo *models.Todo, userID int) bool {
+	var ok bool
+	todo.WorkspaceID, ok = activeWorkspace(w, r, userID)
+	if !ok {
+		return false
+	}
+	if todo.ProjectID != 0 {
+		if _, ok := authorizeWorkspace(w, todo.WorkspaceID, userID, models.RoleEditor); !ok {
+			return false
+		}
+		_, err := stores.GetStore().GetProject(todo.ProjectID, todo.WorkspaceID)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": "Project not found"}, http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
 func GetTodosHandler(w http.ResponseWriter, r *http.Request) {
+	renderHTML, ok := renderHTMLRequested(w, r)
+	if !ok {
+		return
+	}
+
 	jwtToken, err := utility.ExtractTokenFromHeader(r)
 	if err != nil {
 		utility.WriteJsonData(w, map[string]string{"error": "Invalid token"}, http.StatusUnauthorized)
@@ -80,16 +208,34 @@ func GetTodosHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	todos, err := stores.GetStore().GetTodos(user.ID)
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	filter, ok := todoFilter(w, r, user, workspaceID)
+	if !ok {
+		return
+	}
+
+	todos, err := stores.GetStore().GetTodos(user.ID, workspaceID, filter)
 	if err != nil {
 		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get todos\n%v", err)}, http.StatusForbidden)
 		return
 	}
+	for _, todo := range todos {
+		prepareTodo(todo, renderHTML)
+	}
 
 	utility.WriteJsonData(w, todos, http.StatusOK)
 }
 
 func UpdateTodoHandler(w http.ResponseWriter, r *http.Request) {
+	renderHTML, ok := renderHTMLRequested(w, r)
+	if !ok {
+		return
+	}
+
 	todo := models.Todo{}
 	vars := mux.Vars(r)
 	todoID, err := strconv.Atoi(vars["id"])
@@ -124,25 +270,70 @@ func UpdateTodoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = stores.GetStore().GetTodo(todoID, user.ID)
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	role, err := stores.GetStore().GetTodoRole(todoID, user.ID, workspaceID)
 	if err != nil {
 		utility.WriteJsonData(w, map[string]string{"error": "You are not authorized to update this todo"}, http.StatusNotFound)
 		return
 	}
+	if !models.RoleAtLeast(role, models.RoleEditor) {
+		utility.WriteJsonData(w, map[string]string{"error": "You are not authorized to update this todo"}, http.StatusForbidden)
+		return
+	}
 
-	errors := validations.ValidateTodo(&todo)
+	updatedTodo, ok := saveTodo(w, r, &todo, todoID, user.ID)
+	if !ok {
+		return
+	}
+	prepareTodo(updatedTodo, renderHTML)
+
+	utility.WriteJsonData(w, updatedTodo, http.StatusCreated)
+}
+
+// saveTodo validates the editable fields of todo and stores them. ?force=true
+// allows completing a todo with open blockers. When the todo can not be saved
+// it writes the error response and returns false.
+func saveTodo(w http.ResponseWriter, r *http.Request, todo *models.Todo, todoID int, userID int) (*models.Todo, bool) {
+	errors := validations.ValidateTodo(todo)
 	if len(errors) > 0 {
 		utility.WriteJsonData(w, errors, http.StatusBadRequest)
-		return
+		return nil, false
+	}
+	if todo.CustomFields != nil {
+		fields, err := stores.GetStore().GetTodoCustomFields(todoID)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get custom fields\n%v", err)}, http.StatusInternalServerError)
+			return nil, false
+		}
+		if !checkCustomFields(w, todo, fields) {
+			return nil, false
+		}
 	}
 
-	updatedTodo, err := stores.GetStore().UpdateTodo(&todo, todoID)
+	allowBlocked := false
+	if force := r.URL.Query().Get("force"); force != "" {
+		var err error
+		allowBlocked, err = strconv.ParseBool(force)
+		if err != nil {
+			utility.WriteJsonData(w, map[string]string{"error": "Invalid force option"}, http.StatusBadRequest)
+			return nil, false
+		}
+	}
+
+	updatedTodo, err := stores.GetStore().UpdateTodo(todo, todoID, userID, allowBlocked)
+	if blocked, ok := err.(*stores.BlockedError); ok {
+		utility.WriteJsonData(w, map[string]interface{}{"error": "This todo is blocked by open todos", "blocked_by": blocked.BlockerIDs}, http.StatusConflict)
+		return nil, false
+	}
 	if err != nil {
 		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not update todo\n%v", err)}, http.StatusForbidden)
-		return
+		return nil, false
 	}
-
-	utility.WriteJsonData(w, updatedTodo, http.StatusCreated)
+	return updatedTodo, true
 }
 
 func DeleteTodoHandler(w http.ResponseWriter, r *http.Request) {
@@ -153,14 +344,71 @@ func DeleteTodoHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
 		return
 	}
-	err = stores.GetStore().DeleteTodo(ID)
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	if !authorizeTodo(w, r, ID, user.ID, models.RoleOwner) {
+		return
+	}
+
+	// The todo only moves to the trash; its attachment files are removed
+	// when it is purged.
+	err = stores.GetStore().DeleteTodo(ID, user.ID)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(map[string]string{"message": "Can not delete todo"})
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Todo deleted successfully. ID: " + vars["id"]})
 }
+
+func MoveTodoHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	move := models.TodoMove{}
+	err = json.NewDecoder(r.Body).Decode(&move)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+	if move.Before == 0 && move.After == 0 {
+		utility.WriteJsonData(w, map[string]string{"error": "Either before or after is required"}, http.StatusBadRequest)
+		return
+	}
+	if move.Before == todoID || move.After == todoID {
+		utility.WriteJsonData(w, map[string]string{"error": "A todo can not be moved relative to itself"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	if !authorizeTodo(w, r, todoID, user.ID, models.RoleViewer) {
+		return
+	}
+
+	movedTodo, err := stores.GetStore().MoveTodo(todoID, user.ID, &move)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Todo not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not move todo\n%v", err)}, http.StatusBadRequest)
+		return
+	}
+	prepareTodo(movedTodo, false)
+
+	utility.WriteJsonData(w, movedTodo, http.StatusOK)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 	"todo-list/src/stores"
 
 	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
 )
 
 // single test case
@@ -145,6 +147,30 @@ func TestCreateTodoHandler(t *testing.T) {
 				}, nil)
 			},
 		},
+		{
+			name:           "Notes Too Long",
+			payload:        `{"task_name": "Learn Go", "completed": false, "due_date": "2024-11-30T23:59:59Z", "notes": "` + strings.Repeat("a", 20001) + `"}`,
+			expectedBody:   map[string]string{"Notes": "This field must be at most 20000 characters"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn:     func(mockStore *stores.MockStore) {},
+			token: func() string {
+				token, err := lib.GenerateJWT("test@mail.com", "password")
+				if err != nil {
+					t.Fatalf("Failed to generate JWT: %v", err)
+				}
+				return *token
+			}(),
+			getUserMockStore: func(mockStore *stores.MockStore) {
+				mockStore.On("GetUser", &models.User{
+					Email:    "test@mail.com",
+					Password: "password",
+				}).Return(&models.User{
+					UserName: "testuser",
+					Email:    "test@mail.com",
+					Password: "password",
+				}, nil)
+			},
+		},
 		{
 			name:             "In Valid token request",
 			payload:          `{"task_name": "Learn Go", "completed": false, "due_date": "2024-11-30T23:59:59Z"}`,
@@ -205,7 +231,7 @@ func TestCreateTodoHandler(t *testing.T) {
 						t.Fatalf("Failed to decode response body: %v", err)
 					}
 
-					if decodedTodo != *todo {
+					if !reflect.DeepEqual(decodedTodo, *todo) {
 						t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", decodedTodo, todos)
 					}
 				} else if errorBody, ok := tc.expectedBody.(map[string]string); ok {
@@ -248,7 +274,7 @@ func TestGetTodosHandler(t *testing.T) {
 			},
 			expectedStatus: http.StatusOK,
 			mockReturn: func(mockStore *stores.MockStore) {
-				mockStore.On("GetTodos", 1).Return([]*models.Todo{
+				mockStore.On("GetTodos", 1, 0, &models.TodoFilter{}).Return([]*models.Todo{
 					{TaskName: "Learn Go", Completed: false, DueDate: time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC()},
 					{TaskName: "Learn Ruby", Completed: false, DueDate: time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC()},
 					{TaskName: "Learn Python", Completed: false, DueDate: time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC()},
@@ -327,7 +353,7 @@ func TestGetTodosHandler(t *testing.T) {
 				}
 
 				for i := range todos {
-					if *decodedTodos[i] != *todos[i] {
+					if !reflect.DeepEqual(*decodedTodos[i], *todos[i]) {
 						t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", decodedTodos[i], todos[i])
 					}
 				}
@@ -377,7 +403,7 @@ func TestUpdateTodoHandler(t *testing.T) {
 					TaskName:  "Learn Go",
 					Completed: false,
 					DueDate:   time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC(),
-				}, 1).Return(&models.Todo{
+				}, 1, 0, false).Return(&models.Todo{
 					TaskName:  "Updated Learn Go",
 					Completed: true,
 					DueDate:   time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC(),
@@ -399,7 +425,30 @@ func TestUpdateTodoHandler(t *testing.T) {
 				}).Return(&models.User{}, nil)
 			},
 			getTodoMockStore: func(mockStore *stores.MockStore) {
-				mockStore.On("GetTodo", 1, 0).Return(nil)
+				mockStore.On("GetTodoRole", 1, 0, 0).Return("editor", nil)
+			},
+		},
+		{
+			name:           "Update Todo As Viewer",
+			payload:        `{"task_name": "Learn Go", "completed": false, "due_date": "2024-11-30T23:59:59Z"}`,
+			expectedBody:   map[string]string{"error": "You are not authorized to update this todo"},
+			expectedStatus: http.StatusForbidden,
+			mockReturn:     func(mockStore *stores.MockStore) {},
+			token: func() string {
+				token, err := lib.GenerateJWT("test@mail.com", "password")
+				if err != nil {
+					t.Fatalf("Failed to generate JWT: %v", err)
+				}
+				return *token
+			}(),
+			getUserMockStore: func(mockStore *stores.MockStore) {
+				mockStore.On("GetUser", &models.User{
+					Email:    "test@mail.com",
+					Password: "password",
+				}).Return(&models.User{}, nil)
+			},
+			getTodoMockStore: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 1, 0, 0).Return("viewer", nil)
 			},
 		},
 		{
@@ -422,7 +471,7 @@ func TestUpdateTodoHandler(t *testing.T) {
 				}).Return(&models.User{}, nil)
 			},
 			getTodoMockStore: func(mockStore *stores.MockStore) {
-				mockStore.On("GetTodo", 1, 0).Return(nil)
+				mockStore.On("GetTodoRole", 1, 0, 0).Return("editor", nil)
 			},
 		},
 	}
@@ -459,7 +508,7 @@ func TestUpdateTodoHandler(t *testing.T) {
 					if err := json.NewDecoder(recorder.Body).Decode(&decodedTodo); err != nil {
 						t.Fatalf("Failed to decode response body: %v", err)
 					}
-					if decodedTodo != *todo {
+					if !reflect.DeepEqual(decodedTodo, *todo) {
 						t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", decodedTodo, todo)
 					}
 				} else {
@@ -488,13 +537,38 @@ func TestDeleteTodoHandler(t *testing.T) {
 		mockReturn     func(*stores.MockStore)
 	}
 
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
 	tests := []testCase{
 		{
 			name:           "Delete Todo",
 			expectedStatus: http.StatusOK,
 			id:             1,
 			mockReturn: func(mockStore *stores.MockStore) {
-				mockStore.On("DeleteTodo", 1).Return(nil)
+				mockAuthenticatedUser(mockStore)
+				mockStore.On("GetTodoRole", 1, 1, 0).Return("owner", nil)
+				mockStore.On("DeleteTodo", 1, 1).Return(nil)
+			},
+		},
+		{
+			name:           "Delete Todo As Editor",
+			expectedStatus: http.StatusForbidden,
+			id:             1,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockAuthenticatedUser(mockStore)
+				mockStore.On("GetTodoRole", 1, 1, 0).Return("editor", nil)
+			},
+		},
+		{
+			name:           "Delete Todo Not Shared",
+			expectedStatus: http.StatusNotFound,
+			id:             1,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockAuthenticatedUser(mockStore)
+				mockStore.On("GetTodoRole", 1, 1, 0).Return("", sql.ErrNoRows)
 			},
 		},
 		{
@@ -516,6 +590,7 @@ func TestDeleteTodoHandler(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Failed to create request: %v", err)
 			}
+			req.Header.Set("Authorization", "Bearer "+*token)
 
 			r := mux.NewRouter()
 			r.HandleFunc("/todos/{id:[0-9]+}", DeleteTodoHandler).Methods("DELETE")
@@ -531,3 +606,344 @@ func TestDeleteTodoHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestMoveTodoHandler(t *testing.T) {
+	type testCase struct {
+		name             string
+		id               any
+		payload          string
+		expectedBody     interface{}
+		expectedStatus   int
+		mockReturn       func(*stores.MockStore)
+		getUserMockStore func(*stores.MockStore)
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	getUser := func(mockStore *stores.MockStore) {
+		mockStore.On("GetUser", &models.User{
+			Email:    "test@mail.com",
+			Password: "password",
+		}).Return(&models.User{ID: 1, Email: "test@mail.com", Password: "password"}, nil)
+	}
+
+	tests := []testCase{
+		{
+			name:    "Move Todo",
+			id:      3,
+			payload: `{"after": 1, "before": 2}`,
+			expectedBody: &models.Todo{
+				ID:       3,
+				TaskName: "Learn Go",
+				DueDate:  time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC(),
+				Position: "N",
+			},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 3, 1, 0).Return("viewer", nil)
+				mockStore.On("MoveTodo", 3, 1, &models.TodoMove{After: 1, Before: 2}).Return(&models.Todo{
+					ID:       3,
+					TaskName: "Learn Go",
+					DueDate:  time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC(),
+					Position: "N",
+				}, nil)
+			},
+			getUserMockStore: getUser,
+		},
+		{
+			name:             "Move Todo Without Anchors",
+			id:               3,
+			payload:          `{}`,
+			expectedBody:     map[string]string{"error": "Either before or after is required"},
+			expectedStatus:   http.StatusBadRequest,
+			mockReturn:       func(mockStore *stores.MockStore) {},
+			getUserMockStore: func(mockStore *stores.MockStore) {},
+		},
+		{
+			name:             "Move Todo Relative To Itself",
+			id:               3,
+			payload:          `{"after": 3}`,
+			expectedBody:     map[string]string{"error": "A todo can not be moved relative to itself"},
+			expectedStatus:   http.StatusBadRequest,
+			mockReturn:       func(mockStore *stores.MockStore) {},
+			getUserMockStore: func(mockStore *stores.MockStore) {},
+		},
+		{
+			name:           "Move Todo Not Found",
+			id:             3,
+			payload:        `{"after": 9}`,
+			expectedBody:   map[string]string{"error": "Todo not found"},
+			expectedStatus: http.StatusNotFound,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodoRole", 3, 1, 0).Return("owner", nil)
+				mockStore.On("MoveTodo", 3, 1, &models.TodoMove{After: 9}).Return((*models.Todo)(nil), sql.ErrNoRows)
+			},
+			getUserMockStore: getUser,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			tc.mockReturn(mockStore)
+			tc.getUserMockStore(mockStore)
+			stores.InitStore(mockStore)
+
+			req, err := http.NewRequest("POST", fmt.Sprintf("/todos/%v/move", tc.id), strings.NewReader(tc.payload))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+
+			r := mux.NewRouter()
+			r.HandleFunc("/todos/{id:[0-9]+}/move", MoveTodoHandler).Methods("POST")
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+
+			if todo, ok := tc.expectedBody.(*models.Todo); ok {
+				var decodedTodo models.Todo
+				if err := json.NewDecoder(recorder.Body).Decode(&decodedTodo); err != nil {
+					t.Fatalf("Failed to decode response body: %v", err)
+				}
+				if !reflect.DeepEqual(decodedTodo, *todo) {
+					t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", decodedTodo, todo)
+				}
+			} else if errorBody, ok := tc.expectedBody.(map[string]string); ok {
+				var decodedErrorBody map[string]string
+				if err := json.NewDecoder(recorder.Body).Decode(&decodedErrorBody); err != nil {
+					t.Fatalf("Failed to decode response body: %v", err)
+				}
+				if !reflect.DeepEqual(decodedErrorBody, errorBody) {
+					t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", decodedErrorBody, errorBody)
+				}
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetTodosHandlerRenderNotes(t *testing.T) {
+	type testCase struct {
+		name           string
+		query          string
+		expectedBody   interface{}
+		expectedStatus int
+		mockReturn     func(*stores.MockStore)
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	notes := "Steps\n- [x] <b>write</b>\n- [ ] ship"
+	tests := []testCase{
+		{
+			name:  "Checklist Progress Without Rendering",
+			query: "",
+			expectedBody: []*models.Todo{
+				{ID: 1, TaskName: "Release", Notes: notes, ChecklistDone: 1, ChecklistTotal: 2},
+			},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodos", 1, 0, &models.TodoFilter{}).Return([]*models.Todo{{ID: 1, TaskName: "Release", Notes: notes}}, nil)
+			},
+		},
+		{
+			name:  "Rendered Notes",
+			query: "?render=html",
+			expectedBody: []*models.Todo{
+				{
+					ID:             1,
+					TaskName:       "Release",
+					Notes:          notes,
+					NotesHTML:      "<p>Steps</p>\n<ul>\n<li><input type=\"checkbox\" disabled checked> &lt;b&gt;write&lt;/b&gt;</li>\n<li><input type=\"checkbox\" disabled> ship</li>\n</ul>\n",
+					ChecklistDone:  1,
+					ChecklistTotal: 2,
+				},
+			},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodos", 1, 0, &models.TodoFilter{}).Return([]*models.Todo{{ID: 1, TaskName: "Release", Notes: notes}}, nil)
+			},
+		},
+		{
+			name:           "Unsupported Render Option",
+			query:          "?render=pdf",
+			expectedBody:   map[string]string{"error": "Unsupported render option"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn:     func(mockStore *stores.MockStore) {},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			tc.mockReturn(mockStore)
+			if tc.expectedStatus == http.StatusOK {
+				mockStore.On("GetUser", &models.User{
+					Email:    "test@mail.com",
+					Password: "password",
+				}).Return(&models.User{ID: 1, Email: "test@mail.com", Password: "password"}, nil)
+			}
+			stores.InitStore(mockStore)
+
+			req, err := http.NewRequest("GET", "/todos"+tc.query, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+
+			recorder := httptest.NewRecorder()
+			handler := http.HandlerFunc(GetTodosHandler)
+			handler.ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+
+			if todos, ok := tc.expectedBody.([]*models.Todo); ok {
+				var decodedTodos []*models.Todo
+				if err := json.NewDecoder(recorder.Body).Decode(&decodedTodos); err != nil {
+					t.Fatalf("Failed to decode response body: %v", err)
+				}
+				if len(decodedTodos) != len(todos) {
+					t.Fatalf("Handler returned unexpected body length:\nGot:  %d\nWant: %d", len(decodedTodos), len(todos))
+				}
+				for i := range todos {
+					if !reflect.DeepEqual(*decodedTodos[i], *todos[i]) {
+						t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", decodedTodos[i], todos[i])
+					}
+				}
+			} else if errorBody, ok := tc.expectedBody.(map[string]string); ok {
+				var decodedErrorBody map[string]string
+				if err := json.NewDecoder(recorder.Body).Decode(&decodedErrorBody); err != nil {
+					t.Fatalf("Failed to decode response body: %v", err)
+				}
+				if !reflect.DeepEqual(decodedErrorBody, errorBody) {
+					t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", decodedErrorBody, errorBody)
+				}
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetTodosHandlerAssigneeFilter(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	tests := []struct {
+		query          string
+		expectedFilter *models.TodoFilter
+		expectedStatus int
+	}{
+		{query: "?assignee=me", expectedFilter: &models.TodoFilter{AssigneeID: 1}, expectedStatus: http.StatusOK},
+		{query: "?assignee=7", expectedFilter: &models.TodoFilter{AssigneeID: 7}, expectedStatus: http.StatusOK},
+		{query: "?assignee=someone", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			if tc.expectedFilter != nil {
+				mockStore.On("GetTodos", 1, 0, tc.expectedFilter).Return([]*models.Todo{}, nil)
+			}
+			stores.InitStore(mockStore)
+
+			req, err := http.NewRequest("GET", "/todos"+tc.query, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+
+			recorder := httptest.NewRecorder()
+			GetTodosHandler(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetTodosHandlerDueFilter(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+	// A Wednesday evening in UTC is already Thursday morning in Tokyo.
+	now = func() time.Time { return time.Date(2024, 11, 27, 20, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	open := false
+
+	tests := []struct {
+		query          string
+		expectedFilter *models.TodoFilter
+		expectedStatus int
+	}{
+		{
+			query:          "?due=today",
+			expectedFilter: &models.TodoFilter{Due: &models.DueRange{From: time.Date(2024, 11, 28, 0, 0, 0, 0, tokyo), To: time.Date(2024, 11, 29, 0, 0, 0, 0, tokyo)}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			query:          "?due=this_week&tag=work",
+			expectedFilter: &models.TodoFilter{Tag: "work", Due: &models.DueRange{From: time.Date(2024, 11, 24, 0, 0, 0, 0, tokyo), To: time.Date(2024, 12, 1, 0, 0, 0, 0, tokyo)}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			query:          "?due=overdue",
+			expectedFilter: &models.TodoFilter{Completed: &open, Due: &models.DueRange{To: time.Date(2024, 11, 28, 5, 0, 0, 0, tokyo)}},
+			expectedStatus: http.StatusOK,
+		},
+		{query: "?due=someday", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockStore.On("GetUser", &models.User{Email: "test@mail.com", Password: "password"}).
+				Return(&models.User{ID: 1, Email: "test@mail.com", Password: "password", Settings: &models.UserSettings{Timezone: "Asia/Tokyo", WeekStart: "sunday", Locale: "ja-JP"}}, nil)
+			if tc.expectedFilter != nil {
+				mockStore.On("GetTodos", 1, 0, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+					want := tc.expectedFilter
+					return filter.Tag == want.Tag && reflect.DeepEqual(want.Completed, filter.Completed) && filter.Due != nil &&
+						filter.Due.From.Equal(want.Due.From) && filter.Due.To.Equal(want.Due.To) && filter.Due.To.Location().String() == "Asia/Tokyo"
+				})).Return([]*models.Todo{}, nil)
+			}
+			stores.InitStore(mockStore)
+
+			req, err := http.NewRequest("GET", "/todos"+tc.query, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+
+			recorder := httptest.NewRecorder()
+			GetTodosHandler(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+
+	"github.com/gorilla/mux"
+)
+
+// GetTrashHandler lists the trashed todos the user owns in the active
+// workspace.
+func GetTrashHandler(w http.ResponseWriter, r *http.Request) {
+	renderHTML, ok := renderHTMLRequested(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	todos, err := stores.GetStore().GetTrash(user.ID, workspaceID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get trash\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	for _, todo := range todos {
+		prepareTodo(todo, renderHTML)
+	}
+
+	utility.WriteJsonData(w, todos, http.StatusOK)
+}
+
+func RestoreTodoHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	err = stores.GetStore().RestoreTodo(todoID, user.ID, workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Todo not found in trash"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not restore todo\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, map[string]string{"message": "Todo restored successfully. ID: " + vars["id"]}, http.StatusOK)
+}
+
+// EmptyTrashHandler permanently deletes the user's trashed todos in the
+// active workspace together with their attachment files.
+func EmptyTrashHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	attachments, err := stores.GetStore().EmptyTrash(user.ID, workspaceID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not empty trash\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	deleteAttachmentBlobs(r, attachments)
+
+	utility.WriteJsonData(w, map[string]string{"message": "Trash emptied successfully"}, http.StatusOK)
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/blobstore"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrashHandlers(t *testing.T) {
+	type testCase struct {
+		name           string
+		method         string
+		url            string
+		expectedBody   interface{}
+		expectedStatus int
+		mockReturn     func(*stores.MockStore)
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	deletedAt := time.Date(2024, 12, 2, 8, 0, 0, 0, time.UTC)
+
+	tests := []testCase{
+		{
+			name:           "Get Trash",
+			method:         "GET",
+			url:            "/trash",
+			expectedBody:   []*models.Todo{{ID: 5, TaskName: "Old task", Role: "owner", DeletedAt: &deletedAt}},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTrash", 1, 0).Return([]*models.Todo{{ID: 5, TaskName: "Old task", Role: "owner", DeletedAt: &deletedAt}}, nil)
+			},
+		},
+		{
+			name:           "Restore Todo",
+			method:         "POST",
+			url:            "/trash/5/restore",
+			expectedBody:   map[string]string{"message": "Todo restored successfully. ID: 5"},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("RestoreTodo", 5, 1, 0).Return(nil)
+			},
+		},
+		{
+			name:           "Restore Todo Not In Trash",
+			method:         "POST",
+			url:            "/trash/6/restore",
+			expectedBody:   map[string]string{"error": "Todo not found in trash"},
+			expectedStatus: http.StatusNotFound,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("RestoreTodo", 6, 1, 0).Return(sql.ErrNoRows)
+			},
+		},
+		{
+			name:           "Restore Todo In Workspace",
+			method:         "POST",
+			url:            "/workspaces/3/trash/5/restore",
+			expectedBody:   map[string]string{"message": "Todo restored successfully. ID: 5"},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 3, 1).Return(&models.Workspace{ID: 3, Name: "Team", Role: "editor"}, nil)
+				mockStore.On("RestoreTodo", 5, 1, 3).Return(nil)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tc.mockReturn(mockStore)
+			stores.InitStore(mockStore)
+
+			req, err := http.NewRequest(tc.method, tc.url, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+
+			r := trashRouter()
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+
+			decoded := reflect.New(reflect.TypeOf(tc.expectedBody)).Interface()
+			if err := json.NewDecoder(recorder.Body).Decode(decoded); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			if got := reflect.ValueOf(decoded).Elem().Interface(); !reflect.DeepEqual(got, tc.expectedBody) {
+				t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", got, tc.expectedBody)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEmptyTrashHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	blobs := &blobstore.LocalStore{Root: t.TempDir()}
+	assert.NoError(t, blobs.Put(context.Background(), "todos/5/a.txt", strings.NewReader("a"), 1))
+	blobstore.InitStore(blobs)
+
+	mockStore := stores.InitMockStore()
+	mockAuthenticatedUser(mockStore)
+	mockStore.On("EmptyTrash", 1, 0).Return([]*models.Attachment{{ID: 2, TodoID: 5, StorageKey: "todos/5/a.txt"}}, nil)
+	stores.InitStore(mockStore)
+
+	req, err := http.NewRequest("DELETE", "/trash", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+*token)
+	recorder := httptest.NewRecorder()
+	trashRouter().ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"message": "Trash emptied successfully"}`, recorder.Body.String())
+	_, err = blobs.Get(context.Background(), "todos/5/a.txt", 0, -1)
+	assert.ErrorIs(t, err, blobstore.ErrNotFound)
+	mockStore.AssertExpectations(t)
+}
+
+func trashRouter() *mux.Router {
+	r := mux.NewRouter()
+	for _, router := range []*mux.Router{r, r.PathPrefix("/workspaces/{workspaceID:[0-9]+}").Subrouter()} {
+		router.HandleFunc("/trash", GetTrashHandler).Methods("GET")
+		router.HandleFunc("/trash", EmptyTrashHandler).Methods("DELETE")
+		router.HandleFunc("/trash/{id:[0-9]+}/restore", RestoreTodoHandler).Methods("POST")
+	}
+	return r
+}
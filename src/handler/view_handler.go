@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+)
+
+// maxUpcomingDays bounds how far ahead /views/upcoming looks.
+const maxUpcomingDays = 90
+
+// viewTodos lists the open todos due within the range window returns for the
+// current time in the user's timezone, ordered by due date. The filters of
+// GET /todos narrow the view further; ?due is replaced by the view's range.
+func viewTodos(w http.ResponseWriter, r *http.Request, window func(now time.Time) models.DueRange) (*models.User, []*models.Todo, bool) {
+	renderHTML, ok := renderHTMLRequested(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return nil, nil, false
+	}
+	filter, ok := todoFilter(w, r, user, workspaceID)
+	if !ok {
+		return nil, nil, false
+	}
+	open := false
+	filter.Completed = &open
+	due := window(userNow(user))
+	filter.Due = &due
+
+	todos, err := stores.GetStore().GetTodos(user.ID, workspaceID, filter)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get todos\n%v", err)}, http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	location := user.Settings.Location()
+	sort.SliceStable(todos, func(i, j int) bool {
+		return dueTime(todos[i], location).Before(dueTime(todos[j], location))
+	})
+	for _, todo := range todos {
+		prepareTodo(todo, renderHTML)
+	}
+	return user, todos, true
+}
+
+// dueTime is when a todo is due in location. All-day todos are due from the
+// start of their day there, ahead of the timed todos of that day.
+func dueTime(todo *models.Todo, location *time.Location) time.Time {
+	if todo.DueAllDay {
+		date := todo.DueDate.UTC()
+		return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, location)
+	}
+	return todo.DueDate.In(location)
+}
+
+// TodayViewHandler lists the open todos due today.
+func TodayViewHandler(w http.ResponseWriter, r *http.Request) {
+	_, todos, ok := viewTodos(w, r, func(now time.Time) models.DueRange {
+		return dueRanges["today"](now, time.Monday)
+	})
+	if !ok {
+		return
+	}
+
+	utility.WriteJsonData(w, todos, http.StatusOK)
+}
+
+// OverdueViewHandler lists the open todos whose due date has passed, oldest
+// first.
+func OverdueViewHandler(w http.ResponseWriter, r *http.Request) {
+	_, todos, ok := viewTodos(w, r, func(now time.Time) models.DueRange {
+		return dueRanges["overdue"](now, time.Monday)
+	})
+	if !ok {
+		return
+	}
+
+	utility.WriteJsonData(w, todos, http.StatusOK)
+}
+
+// UpcomingViewHandler lists the open todos due in the next ?days days,
+// today included, grouped by day. Every day is listed, even without todos.
+func UpcomingViewHandler(w http.ResponseWriter, r *http.Request) {
+	days := 7
+	if value := r.URL.Query().Get("days"); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 1 || days > maxUpcomingDays {
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("days must be a number from 1 to %d", maxUpcomingDays)}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	var today time.Time
+	user, todos, ok := viewTodos(w, r, func(now time.Time) models.DueRange {
+		today = lib.StartOfDay(now)
+		return models.DueRange{From: today, To: today.AddDate(0, 0, days)}
+	})
+	if !ok {
+		return
+	}
+
+	location := user.Settings.Location()
+	upcoming := make([]models.ViewDay, days)
+	index := map[string]int{}
+	for i := range upcoming {
+		date := today.AddDate(0, 0, i).Format("2006-01-02")
+		upcoming[i] = models.ViewDay{Date: date, Todos: []*models.Todo{}}
+		index[date] = i
+	}
+	for _, todo := range todos {
+		date := dueTime(todo, location).Format("2006-01-02")
+		if i, ok := index[date]; ok {
+			upcoming[i].Todos = append(upcoming[i].Todos, todo)
+		}
+	}
+
+	utility.WriteJsonData(w, upcoming, http.StatusOK)
+}
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestViewHandlers(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+	// A Wednesday evening in UTC is already Thursday morning in Tokyo.
+	now = func() time.Time { return time.Date(2024, 11, 27, 20, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	today := time.Date(2024, 11, 28, 0, 0, 0, 0, tokyo)
+
+	tests := []struct {
+		name           string
+		path           string
+		handler        http.HandlerFunc
+		expectedRange  *models.DueRange
+		expectedTag    string
+		todos          []*models.Todo
+		expectedStatus int
+		expectedIDs    []int
+	}{
+		{
+			name:          "Today",
+			path:          "/views/today?tag=work",
+			handler:       TodayViewHandler,
+			expectedRange: &models.DueRange{From: today, To: today.AddDate(0, 0, 1)},
+			expectedTag:   "work",
+			todos: []*models.Todo{
+				{ID: 1, DueDate: time.Date(2024, 11, 28, 3, 0, 0, 0, time.UTC)},
+				{ID: 2, DueDate: time.Date(2024, 11, 28, 0, 0, 0, 0, time.UTC), DueAllDay: true},
+				{ID: 3, DueDate: time.Date(2024, 11, 27, 23, 0, 0, 0, time.UTC)},
+			},
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int{2, 3, 1},
+		},
+		{
+			name:          "Overdue",
+			path:          "/views/overdue",
+			handler:       OverdueViewHandler,
+			expectedRange: &models.DueRange{To: time.Date(2024, 11, 28, 5, 0, 0, 0, tokyo)},
+			todos: []*models.Todo{
+				{ID: 4, DueDate: time.Date(2024, 11, 27, 0, 0, 0, 0, time.UTC), DueAllDay: true},
+				{ID: 5, DueDate: time.Date(2024, 11, 20, 9, 0, 0, 0, time.UTC)},
+			},
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int{5, 4},
+		},
+		{
+			name:           "Invalid Query",
+			path:           "/views/today?query=dew<today",
+			handler:        TodayViewHandler,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockStore.On("GetUser", &models.User{Email: "test@mail.com", Password: "password"}).
+				Return(&models.User{ID: 1, Email: "test@mail.com", Password: "password", Settings: &models.UserSettings{Timezone: "Asia/Tokyo", WeekStart: "monday", Locale: "ja-JP"}}, nil)
+			if tt.expectedRange != nil {
+				mockStore.On("GetTodos", 1, 0, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+					return filter.Tag == tt.expectedTag && filter.Completed != nil && !*filter.Completed && filter.Due != nil &&
+						filter.Due.From.Equal(tt.expectedRange.From) && filter.Due.To.Equal(tt.expectedRange.To)
+				})).Return(tt.todos, nil)
+			}
+			stores.InitStore(mockStore)
+
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+*token)
+			recorder := httptest.NewRecorder()
+			tt.handler(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			if tt.expectedIDs != nil {
+				var todos []models.Todo
+				if err := json.NewDecoder(recorder.Body).Decode(&todos); err != nil {
+					t.Fatalf("Failed to decode response body: %v", err)
+				}
+				ids := []int{}
+				for _, todo := range todos {
+					ids = append(ids, todo.ID)
+				}
+				assert.Equal(t, tt.expectedIDs, ids)
+			}
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpcomingViewHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+	now = func() time.Time { return time.Date(2024, 11, 27, 20, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	today := time.Date(2024, 11, 28, 0, 0, 0, 0, tokyo)
+
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(mockStore *stores.MockStore)
+		expectedStatus int
+		expectedDays   map[string][]int
+	}{
+		{
+			name:  "Three Days",
+			query: "?days=3",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodos", 1, 0, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+					return filter.Due != nil && filter.Due.From.Equal(today) && filter.Due.To.Equal(today.AddDate(0, 0, 3))
+				})).Return([]*models.Todo{
+					{ID: 1, DueDate: time.Date(2024, 11, 29, 0, 0, 0, 0, time.UTC), DueAllDay: true},
+					// Still the 28th in UTC, but the 29th in Tokyo.
+					{ID: 2, DueDate: time.Date(2024, 11, 28, 16, 0, 0, 0, time.UTC)},
+					{ID: 3, DueDate: time.Date(2024, 11, 28, 2, 0, 0, 0, time.UTC)},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedDays:   map[string][]int{"2024-11-28": {3}, "2024-11-29": {1, 2}, "2024-11-30": nil},
+		},
+		{
+			name:  "Default Days",
+			query: "",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodos", 1, 0, mock.MatchedBy(func(filter *models.TodoFilter) bool {
+					return filter.Due != nil && filter.Due.To.Equal(today.AddDate(0, 0, 7))
+				})).Return([]*models.Todo{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Too Many Days",
+			query:          fmt.Sprintf("?days=%d", maxUpcomingDays+1),
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "Store Error",
+			query: "?days=1",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodos", 1, 0, mock.Anything).Return(([]*models.Todo)(nil), fmt.Errorf("some db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			// Days are checked before the user is loaded.
+			if tt.expectedStatus != http.StatusBadRequest {
+				mockStore.On("GetUser", &models.User{Email: "test@mail.com", Password: "password"}).
+					Return(&models.User{ID: 1, Email: "test@mail.com", Password: "password", Settings: &models.UserSettings{Timezone: "Asia/Tokyo", WeekStart: "monday", Locale: "ja-JP"}}, nil)
+			}
+			tt.mockSetup(mockStore)
+			stores.InitStore(mockStore)
+
+			req, _ := http.NewRequest("GET", "/views/upcoming"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer "+*token)
+			recorder := httptest.NewRecorder()
+			UpcomingViewHandler(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var days []models.ViewDay
+				if err := json.NewDecoder(recorder.Body).Decode(&days); err != nil {
+					t.Fatalf("Failed to decode response body: %v", err)
+				}
+				if tt.expectedDays == nil {
+					assert.Len(t, days, 7)
+				} else {
+					assert.Len(t, days, len(tt.expectedDays))
+				}
+				for _, day := range days {
+					var ids []int
+					for _, todo := range day.Todos {
+						ids = append(ids, todo.ID)
+					}
+					if tt.expectedDays != nil {
+						assert.Equal(t, tt.expectedDays[day.Date], ids, day.Date)
+					}
+				}
+			}
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+
+	"github.com/gorilla/mux"
+)
+
+// webhookDeliveryLimit is how many of the latest deliveries the log shows.
+const webhookDeliveryLimit = 100
+
+// userWebhook authenticates the user and reads the webhook of the path, which
+// has to be theirs.
+func userWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	webhookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return nil, false
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	webhook, err := stores.GetStore().GetWebhook(webhookID, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Webhook not found"}, http.StatusNotFound)
+			return nil, false
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get webhook\n%v", err)}, http.StatusInternalServerError)
+		return nil, false
+	}
+	webhook.Secret = ""
+	return webhook, true
+}
+
+// CreateWebhookHandler subscribes a URL to events of the todos the user can
+// see in the active workspace. The response holds the secret deliveries are
+// signed with; it is never shown again.
+func CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook := &models.Webhook{}
+	err := json.NewDecoder(r.Body).Decode(webhook)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	workspaceID, ok := activeWorkspace(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	errors := validations.ValidateWebhook(webhook)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+	webhook.Secret, err = newSecretToken()
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create webhook\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	webhook.UserID = user.ID
+	webhook.WorkspaceID = workspaceID
+
+	newWebhook, err := stores.GetStore().CreateWebhook(webhook)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create webhook\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	// The secret is only shown here, so the response must not be kept for
+	// idempotent retries or by caches.
+	w.Header().Set("Cache-Control", "no-store")
+	utility.WriteJsonData(w, newWebhook, http.StatusCreated)
+}
+
+func GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	webhooks, err := stores.GetStore().GetWebhooks(user.ID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get webhooks\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+
+	utility.WriteJsonData(w, webhooks, http.StatusOK)
+}
+
+func GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := userWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	utility.WriteJsonData(w, webhook, http.StatusOK)
+}
+
+// UpdateWebhookHandler replaces the URL, events and active flag of a webhook.
+// Setting active again on a webhook disabled for failing resumes its
+// deliveries.
+func UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	update := &models.Webhook{}
+	err := json.NewDecoder(r.Body).Decode(update)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	webhook, ok := userWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	errors := validations.ValidateWebhook(update)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+	update.ID = webhook.ID
+	update.UserID = webhook.UserID
+
+	updatedWebhook, err := stores.GetStore().UpdateWebhook(update)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Webhook not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not update webhook\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	updatedWebhook.Secret = ""
+
+	utility.WriteJsonData(w, updatedWebhook, http.StatusOK)
+}
+
+func DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	err = stores.GetStore().DeleteWebhook(webhookID, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Webhook not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not delete webhook\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, map[string]string{"message": "Webhook deleted"}, http.StatusOK)
+}
+
+// GetWebhookDeliveriesHandler lists the latest deliveries of a webhook with
+// the outcome of their last attempt, newest first.
+func GetWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := userWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := stores.GetStore().GetWebhookDeliveries(webhook.ID, webhookDeliveryLimit)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get webhook deliveries\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, deliveries, http.StatusOK)
+}
+
+// RedeliverWebhookHandler queues a delivery again, with the payload it was
+// first sent with, as a new delivery.
+func RedeliverWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	deliveryID, err := strconv.Atoi(mux.Vars(r)["deliveryID"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	webhook, ok := userWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := stores.GetStore().RedeliverWebhook(deliveryID, webhook.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Delivery not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not redeliver webhook\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, delivery, http.StatusAccepted)
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWebhookHandlers(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	createdAt := time.Date(2024, 12, 2, 9, 0, 0, 0, time.UTC)
+	webhook := func() *models.Webhook {
+		return &models.Webhook{ID: 3, UserID: 1, WorkspaceID: 4, URL: "https://example.com/hook", Events: []string{"todo.completed"}, Secret: "s3cret", Active: true, CreatedAt: createdAt}
+	}
+	webhookJSON := map[string]interface{}{"id": float64(3), "workspace_id": float64(4), "url": "https://example.com/hook", "events": []interface{}{"todo.completed"},
+		"active": true, "failure_count": float64(0), "created_at": "2024-12-02T09:00:00Z"}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		header         string
+		body           string
+		mockSetup      func(mockStore *stores.MockStore)
+		expectedStatus int
+		expectedBody   interface{}
+	}{
+		{
+			name:   "Create",
+			method: "POST",
+			url:    "/webhooks",
+			header: "4",
+			body:   `{"url": "https://example.com/hook", "events": ["todo.completed"]}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Role: "viewer"}, nil)
+				mockStore.On("CreateWebhook", mock.MatchedBy(func(created *models.Webhook) bool {
+					return created.UserID == 1 && created.WorkspaceID == 4 && created.URL == "https://example.com/hook" && len(created.Secret) == 43
+				})).Return(webhook(), nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody: map[string]interface{}{"id": float64(3), "workspace_id": float64(4), "url": "https://example.com/hook", "events": []interface{}{"todo.completed"},
+				"secret": "s3cret", "active": true, "failure_count": float64(0), "created_at": "2024-12-02T09:00:00Z"},
+		},
+		{
+			name:           "Create Invalid",
+			method:         "POST",
+			url:            "/webhooks",
+			body:           `{"url": "ftp://example.com/hook", "events": ["todo.archived"]}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"URL":       "Must be an http or https URL",
+				"Events[0]": "Must be one of: todo.created, todo.updated, todo.completed, todo.deleted",
+			},
+		},
+		{
+			name:           "Create For Private Address",
+			method:         "POST",
+			url:            "/webhooks",
+			body:           `{"url": "http://169.254.169.254/latest/meta-data", "events": ["todo.created"]}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"URL": "Must not be a local or private address"},
+		},
+		{
+			name:           "Create For Localhost",
+			method:         "POST",
+			url:            "/webhooks",
+			body:           `{"url": "http://localhost:8080/hook", "events": ["todo.created"]}`,
+			mockSetup:      func(mockStore *stores.MockStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"URL": "Must not be a local or private address"},
+		},
+		{
+			name:   "List Hides Secrets",
+			method: "GET",
+			url:    "/webhooks",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWebhooks", 1).Return([]*models.Webhook{webhook()}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []interface{}{webhookJSON},
+		},
+		{
+			name:   "Get Of Other User",
+			method: "GET",
+			url:    "/webhooks/5",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWebhook", 5, 1).Return((*models.Webhook)(nil), sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   map[string]interface{}{"error": "Webhook not found"},
+		},
+		{
+			name:   "Reactivate",
+			method: "PUT",
+			url:    "/webhooks/3",
+			body:   `{"url": "https://example.com/hook", "events": ["todo.completed"], "active": true}`,
+			mockSetup: func(mockStore *stores.MockStore) {
+				disabled := webhook()
+				disabled.Active = false
+				disabled.FailureCount = models.WebhookDisableAfter
+				mockStore.On("GetWebhook", 3, 1).Return(disabled, nil)
+				mockStore.On("UpdateWebhook", &models.Webhook{ID: 3, UserID: 1, URL: "https://example.com/hook", Events: []string{"todo.completed"}, Active: true}).Return(webhook(), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   webhookJSON,
+		},
+		{
+			name:   "Delete",
+			method: "DELETE",
+			url:    "/webhooks/3",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("DeleteWebhook", 3, 1).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"message": "Webhook deleted"},
+		},
+		{
+			name:   "Deliveries",
+			method: "GET",
+			url:    "/webhooks/3/deliveries",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWebhook", 3, 1).Return(webhook(), nil)
+				mockStore.On("GetWebhookDeliveries", 3, webhookDeliveryLimit).Return([]*models.WebhookDelivery{
+					{ID: 7, WebhookID: 3, Event: "todo.completed", Payload: json.RawMessage(`{"event":"todo.completed","todo":{"id":5}}`), Status: "failed", Attempts: 8,
+						ResponseStatus: 502, Error: "502 Bad Gateway", NextAttemptAt: createdAt, CreatedAt: createdAt},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: []interface{}{map[string]interface{}{"id": float64(7), "webhook_id": float64(3), "event": "todo.completed",
+				"payload": map[string]interface{}{"event": "todo.completed", "todo": map[string]interface{}{"id": float64(5)}}, "status": "failed", "attempts": float64(8),
+				"response_status": float64(502), "error": "502 Bad Gateway", "next_attempt_at": "2024-12-02T09:00:00Z", "created_at": "2024-12-02T09:00:00Z"}},
+		},
+		{
+			name:   "Redeliver Unknown Delivery",
+			method: "POST",
+			url:    "/webhooks/3/deliveries/9/redeliver",
+			mockSetup: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWebhook", 3, 1).Return(webhook(), nil)
+				mockStore.On("RedeliverWebhook", 9, 3).Return((*models.WebhookDelivery)(nil), sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   map[string]interface{}{"error": "Delivery not found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tt.mockSetup(mockStore)
+			stores.InitStore(mockStore)
+
+			req, _ := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+*token)
+			if tt.header != "" {
+				req.Header.Set("X-Workspace-ID", tt.header)
+			}
+			recorder := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/webhooks", GetWebhooksHandler).Methods("GET")
+			r.HandleFunc("/webhooks", CreateWebhookHandler).Methods("POST")
+			r.HandleFunc("/webhooks/{id:[0-9]+}", GetWebhookHandler).Methods("GET")
+			r.HandleFunc("/webhooks/{id:[0-9]+}", UpdateWebhookHandler).Methods("PUT")
+			r.HandleFunc("/webhooks/{id:[0-9]+}", DeleteWebhookHandler).Methods("DELETE")
+			r.HandleFunc("/webhooks/{id:[0-9]+}/deliveries", GetWebhookDeliveriesHandler).Methods("GET")
+			r.HandleFunc("/webhooks/{id:[0-9]+}/deliveries/{deliveryID:[0-9]+}/redeliver", RedeliverWebhookHandler).Methods("POST")
+			r.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			var body interface{}
+			if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			assert.Equal(t, tt.expectedBody, body)
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
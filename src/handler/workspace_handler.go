@@ -0,0 +1,381 @@
+package handler
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"todo-list/src/mailer"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+	"todo-list/src/utility"
+	"todo-list/src/validations"
+
+	"github.com/gorilla/mux"
+)
+
+func GetWorkspacesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	workspaces, err := stores.GetStore().GetWorkspaces(user.ID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get workspaces\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, workspaces, http.StatusOK)
+}
+
+func CreateWorkspaceHandler(w http.ResponseWriter, r *http.Request) {
+	workspace := models.Workspace{}
+	err := json.NewDecoder(r.Body).Decode(&workspace)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	errors := validations.ValidateWorkspace(&workspace)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	newWorkspace, err := stores.GetStore().CreateWorkspace(&workspace, user.ID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create workspace\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, newWorkspace, http.StatusCreated)
+}
+
+func GetWorkspaceMembersHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	workspaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeWorkspace(w, workspaceID, user.ID, models.RoleViewer); !ok {
+		return
+	}
+
+	members, err := stores.GetStore().GetWorkspaceMembers(workspaceID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get members\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, members, http.StatusOK)
+}
+
+// UpdateWorkspaceMemberHandler changes a member's role. Only owners may do so.
+func UpdateWorkspaceMemberHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	workspaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+	memberID, err := strconv.Atoi(vars["userID"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	member := models.WorkspaceMember{}
+	err = json.NewDecoder(r.Body).Decode(&member)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeWorkspace(w, workspaceID, user.ID, models.RoleOwner); !ok {
+		return
+	}
+
+	errors := validations.ValidateWorkspaceMember(&member)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	err = stores.GetStore().UpdateWorkspaceMember(workspaceID, memberID, member.Role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Member not found"}, http.StatusNotFound)
+			return
+		}
+		if err == stores.ErrLastWorkspaceOwner {
+			utility.WriteJsonData(w, map[string]string{"error": err.Error()}, http.StatusConflict)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not update member\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, map[string]string{"message": "Member updated successfully. User ID: " + vars["userID"]}, http.StatusOK)
+}
+
+// RemoveWorkspaceMemberHandler removes a user from a workspace. Owners may
+// remove anyone; everyone else may only leave.
+func RemoveWorkspaceMemberHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	workspaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+	memberID, err := strconv.Atoi(vars["userID"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	minimumRole := models.RoleOwner
+	if memberID == user.ID {
+		minimumRole = models.RoleViewer
+	}
+	if _, ok := authorizeWorkspace(w, workspaceID, user.ID, minimumRole); !ok {
+		return
+	}
+
+	err = stores.GetStore().RemoveWorkspaceMember(workspaceID, memberID)
+	if err != nil {
+		if err == stores.ErrLastWorkspaceOwner {
+			utility.WriteJsonData(w, map[string]string{"error": err.Error()}, http.StatusConflict)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not remove member\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	userStats.drop(memberID)
+
+	utility.WriteJsonData(w, map[string]string{"message": "Member removed successfully. User ID: " + vars["userID"]}, http.StatusOK)
+}
+
+// InviteWorkspaceMemberHandler emails an invitation to join the workspace.
+// The token in the email is the only way to accept it; responses never
+// include it.
+func InviteWorkspaceMemberHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	workspaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	invitation := models.Invitation{}
+	err = json.NewDecoder(r.Body).Decode(&invitation)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	workspace, ok := authorizeWorkspace(w, workspaceID, user.ID, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	errors := validations.ValidateInvitation(&invitation)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Can not create invitation"}, http.StatusInternalServerError)
+		return
+	}
+	invitation.WorkspaceID = workspaceID
+	invitation.InvitedBy = user.ID
+	invitation.Token = token
+
+	newInvitation, err := stores.GetStore().CreateInvitation(&invitation)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create invitation\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	err = mailer.GetMailer().Send(&mailer.Message{
+		To:      newInvitation.Email,
+		Subject: fmt.Sprintf("You have been invited to %s", workspace.Name),
+		Body: fmt.Sprintf("%s invited you to join the %s workspace as %s.\n\n"+
+			"To accept, sign in as %s and send POST /invitations/%s/accept.\n"+
+			"The invitation expires on %s.\n",
+			user.UserName, workspace.Name, newInvitation.Role, newInvitation.Email, token, newInvitation.ExpiresAt.Format("January 2, 2006")),
+	})
+	if err != nil {
+		log.Printf("Can not send invitation %d: %v", newInvitation.ID, err)
+		utility.WriteJsonData(w, map[string]string{"error": "Can not send invitation email"}, http.StatusBadGateway)
+		return
+	}
+
+	utility.WriteJsonData(w, newInvitation, http.StatusCreated)
+}
+
+func AcceptInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+
+	workspace, err := stores.GetStore().AcceptInvitation(vars["token"], user)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			utility.WriteJsonData(w, map[string]string{"error": "Invitation not found"}, http.StatusNotFound)
+		case stores.ErrInvitationExpired:
+			utility.WriteJsonData(w, map[string]string{"error": err.Error()}, http.StatusGone)
+		case stores.ErrInvitationEmail:
+			utility.WriteJsonData(w, map[string]string{"error": err.Error()}, http.StatusForbidden)
+		default:
+			utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not accept invitation\n%v", err)}, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	utility.WriteJsonData(w, workspace, http.StatusOK)
+}
+
+func GetProjectsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	workspaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeWorkspace(w, workspaceID, user.ID, models.RoleViewer); !ok {
+		return
+	}
+
+	projects, err := stores.GetStore().GetProjects(workspaceID)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not get projects\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, projects, http.StatusOK)
+}
+
+func CreateProjectHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	workspaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	project := models.Project{}
+	err = json.NewDecoder(r.Body).Decode(&project)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": "Invalid request payload"}, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeWorkspace(w, workspaceID, user.ID, models.RoleEditor); !ok {
+		return
+	}
+
+	errors := validations.ValidateProject(&project)
+	if len(errors) > 0 {
+		utility.WriteJsonData(w, errors, http.StatusBadRequest)
+		return
+	}
+
+	project.WorkspaceID = workspaceID
+	newProject, err := stores.GetStore().CreateProject(&project)
+	if err != nil {
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not create project\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	utility.WriteJsonData(w, newProject, http.StatusCreated)
+}
+
+func DeleteProjectHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	workspaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+	projectID, err := strconv.Atoi(vars["projectID"])
+	if err != nil {
+		http.Error(w, "Can not convert id to int", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := authenticateUser(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeWorkspace(w, workspaceID, user.ID, models.RoleOwner); !ok {
+		return
+	}
+
+	err = stores.GetStore().DeleteProject(projectID, workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utility.WriteJsonData(w, map[string]string{"error": "Project not found"}, http.StatusNotFound)
+			return
+		}
+		utility.WriteJsonData(w, map[string]string{"error": fmt.Sprintf("Can not delete project\n%v", err)}, http.StatusInternalServerError)
+		return
+	}
+	// Members lose the todos they only saw through the project.
+	dropWorkspaceStats(workspaceID)
+
+	utility.WriteJsonData(w, map[string]string{"message": "Project deleted successfully. ID: " + vars["projectID"]}, http.StatusOK)
+}
+
+// newSecretToken returns a random URL safe token for links that grant access
+// on their own, such as invitations and calendar feeds.
+func newSecretToken() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(random), nil
+}
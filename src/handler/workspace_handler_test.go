@@ -0,0 +1,329 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/mailer"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+)
+
+type recordingMailer struct {
+	messages []*mailer.Message
+	err      error
+}
+
+func (m *recordingMailer) Send(message *mailer.Message) error {
+	m.messages = append(m.messages, message)
+	return m.err
+}
+
+func workspaceRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/workspaces", CreateWorkspaceHandler).Methods("POST")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/members/{userID:[0-9]+}", UpdateWorkspaceMemberHandler).Methods("PATCH")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/members/{userID:[0-9]+}", RemoveWorkspaceMemberHandler).Methods("DELETE")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/invitations", InviteWorkspaceMemberHandler).Methods("POST")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/projects", CreateProjectHandler).Methods("POST")
+	r.HandleFunc("/invitations/{token}/accept", AcceptInvitationHandler).Methods("POST")
+	r.HandleFunc("/todos", GetTodosHandler).Methods("GET")
+	r.HandleFunc("/todos", CreateTodoHandler).Methods("POST")
+	r.PathPrefix("/workspaces/{workspaceID:[0-9]+}").Subrouter().HandleFunc("/todos", GetTodosHandler).Methods("GET")
+	return r
+}
+
+func TestWorkspaceHandlers(t *testing.T) {
+	type testCase struct {
+		name           string
+		method         string
+		url            string
+		header         string
+		payload        string
+		expectedBody   interface{}
+		expectedStatus int
+		mockReturn     func(*stores.MockStore)
+	}
+
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	team := &models.Workspace{ID: 4, Name: "Team", Role: "owner", CreatedAt: createdAt}
+
+	tests := []testCase{
+		{
+			name:           "Create Workspace",
+			method:         "POST",
+			url:            "/workspaces",
+			payload:        `{"name": "Team"}`,
+			expectedBody:   team,
+			expectedStatus: http.StatusCreated,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("CreateWorkspace", &models.Workspace{Name: "Team"}, 1).Return(team, nil)
+			},
+		},
+		{
+			name:           "Create Workspace Without Name",
+			method:         "POST",
+			url:            "/workspaces",
+			payload:        `{}`,
+			expectedBody:   map[string]string{"Name": "This field is required"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn:     func(mockStore *stores.MockStore) {},
+		},
+		{
+			name:           "Invite As Editor",
+			method:         "POST",
+			url:            "/workspaces/4/invitations",
+			payload:        `{"email": "friend@mail.com", "role": "viewer"}`,
+			expectedBody:   map[string]string{"error": "This action requires the owner role"},
+			expectedStatus: http.StatusForbidden,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Name: "Team", Role: "editor"}, nil)
+			},
+		},
+		{
+			name:           "Accept Invitation",
+			method:         "POST",
+			url:            "/invitations/abc/accept",
+			expectedBody:   &models.Workspace{ID: 4, Name: "Team", Role: "viewer", CreatedAt: createdAt},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("AcceptInvitation", "abc", &models.User{ID: 1, Email: "test@mail.com", Password: "password"}).Return(&models.Workspace{ID: 4, Name: "Team", Role: "viewer", CreatedAt: createdAt}, nil)
+			},
+		},
+		{
+			name:           "Accept Expired Invitation",
+			method:         "POST",
+			url:            "/invitations/abc/accept",
+			expectedBody:   map[string]string{"error": stores.ErrInvitationExpired.Error()},
+			expectedStatus: http.StatusGone,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("AcceptInvitation", "abc", mock.Anything).Return((*models.Workspace)(nil), stores.ErrInvitationExpired)
+			},
+		},
+		{
+			name:           "Accept Invitation For Someone Else",
+			method:         "POST",
+			url:            "/invitations/abc/accept",
+			expectedBody:   map[string]string{"error": stores.ErrInvitationEmail.Error()},
+			expectedStatus: http.StatusForbidden,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("AcceptInvitation", "abc", mock.Anything).Return((*models.Workspace)(nil), stores.ErrInvitationEmail)
+			},
+		},
+		{
+			name:           "Demote Last Owner",
+			method:         "PATCH",
+			url:            "/workspaces/4/members/1",
+			payload:        `{"role": "editor"}`,
+			expectedBody:   map[string]string{"error": stores.ErrLastWorkspaceOwner.Error()},
+			expectedStatus: http.StatusConflict,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 4, 1).Return(team, nil)
+				mockStore.On("UpdateWorkspaceMember", 4, 1, "editor").Return(stores.ErrLastWorkspaceOwner)
+			},
+		},
+		{
+			name:           "Leave Workspace As Viewer",
+			method:         "DELETE",
+			url:            "/workspaces/4/members/1",
+			expectedBody:   map[string]string{"message": "Member removed successfully. User ID: 1"},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Role: "viewer"}, nil)
+				mockStore.On("RemoveWorkspaceMember", 4, 1).Return(nil)
+			},
+		},
+		{
+			name:           "Create Project As Viewer",
+			method:         "POST",
+			url:            "/workspaces/4/projects",
+			payload:        `{"name": "Launch"}`,
+			expectedBody:   map[string]string{"error": "This action requires the editor role"},
+			expectedStatus: http.StatusForbidden,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Role: "viewer"}, nil)
+			},
+		},
+		{
+			name:           "Create Project",
+			method:         "POST",
+			url:            "/workspaces/4/projects",
+			payload:        `{"name": "Launch", "workspace_id": 9}`,
+			expectedBody:   &models.Project{ID: 3, WorkspaceID: 4, Name: "Launch", CreatedAt: createdAt},
+			expectedStatus: http.StatusCreated,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Role: "editor"}, nil)
+				mockStore.On("CreateProject", &models.Project{WorkspaceID: 4, Name: "Launch"}).Return(&models.Project{ID: 3, WorkspaceID: 4, Name: "Launch", CreatedAt: createdAt}, nil)
+			},
+		},
+		{
+			name:           "Get Todos In Personal Space",
+			method:         "GET",
+			url:            "/todos",
+			expectedBody:   []*models.Todo{{ID: 1, TaskName: "Mine"}},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetTodos", 1, 0, &models.TodoFilter{}).Return([]*models.Todo{{ID: 1, TaskName: "Mine"}}, nil)
+			},
+		},
+		{
+			name:           "Get Todos With Workspace Header",
+			method:         "GET",
+			url:            "/todos",
+			header:         "4",
+			expectedBody:   []*models.Todo{{ID: 2, TaskName: "Ours", WorkspaceID: 4, ProjectID: 3}},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Role: "viewer"}, nil)
+				mockStore.On("GetTodos", 1, 4, &models.TodoFilter{}).Return([]*models.Todo{{ID: 2, TaskName: "Ours", WorkspaceID: 4, ProjectID: 3}}, nil)
+			},
+		},
+		{
+			name:           "Get Todos With Workspace Prefix",
+			method:         "GET",
+			url:            "/workspaces/4/todos",
+			expectedBody:   []*models.Todo{{ID: 2, TaskName: "Ours", WorkspaceID: 4}},
+			expectedStatus: http.StatusOK,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Role: "viewer"}, nil)
+				mockStore.On("GetTodos", 1, 4, &models.TodoFilter{}).Return([]*models.Todo{{ID: 2, TaskName: "Ours", WorkspaceID: 4}}, nil)
+			},
+		},
+		{
+			name:           "Get Todos With Conflicting Workspaces",
+			method:         "GET",
+			url:            "/workspaces/4/todos",
+			header:         "5",
+			expectedBody:   map[string]string{"error": "X-Workspace-ID does not match the workspace in the path"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn:     func(mockStore *stores.MockStore) {},
+		},
+		{
+			name:           "Get Todos In Foreign Workspace",
+			method:         "GET",
+			url:            "/todos",
+			header:         "5",
+			expectedBody:   map[string]string{"error": "Workspace not found"},
+			expectedStatus: http.StatusNotFound,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 5, 1).Return((*models.Workspace)(nil), sql.ErrNoRows)
+			},
+		},
+		{
+			name:           "Create Todo In Project Of Another Workspace",
+			method:         "POST",
+			url:            "/todos",
+			header:         "4",
+			payload:        `{"task_name": "Ship it", "project_id": 8}`,
+			expectedBody:   map[string]string{"error": "Project not found"},
+			expectedStatus: http.StatusBadRequest,
+			mockReturn: func(mockStore *stores.MockStore) {
+				mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Role: "editor"}, nil)
+				mockStore.On("GetProject", 8, 4).Return((*models.Project)(nil), sql.ErrNoRows)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := stores.InitMockStore()
+			mockAuthenticatedUser(mockStore)
+			tc.mockReturn(mockStore)
+			stores.InitStore(mockStore)
+
+			req, err := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.payload))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+*token)
+			if tc.header != "" {
+				req.Header.Set("X-Workspace-ID", tc.header)
+			}
+
+			recorder := httptest.NewRecorder()
+			workspaceRouter().ServeHTTP(recorder, req)
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+
+			decoded := reflect.New(reflect.TypeOf(tc.expectedBody)).Interface()
+			if err := json.NewDecoder(recorder.Body).Decode(decoded); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			if got := reflect.ValueOf(decoded).Elem().Interface(); !reflect.DeepEqual(got, tc.expectedBody) {
+				t.Errorf("Handler returned unexpected body:\nGot:  %+v\nWant: %+v", got, tc.expectedBody)
+			}
+
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestInviteWorkspaceMemberHandler(t *testing.T) {
+	token, err := lib.GenerateJWT("test@mail.com", "password")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	expiresAt := time.Date(2024, 12, 7, 12, 0, 0, 0, time.UTC)
+
+	for _, sendErr := range []error{nil, errors.New("connection refused")} {
+		mockStore := stores.InitMockStore()
+		mockAuthenticatedUser(mockStore)
+		mockStore.On("GetWorkspace", 4, 1).Return(&models.Workspace{ID: 4, Name: "Team", Role: "owner"}, nil)
+		var savedToken string
+		mockStore.On("CreateInvitation", mock.MatchedBy(func(invitation *models.Invitation) bool {
+			savedToken = invitation.Token
+			return invitation.WorkspaceID == 4 && invitation.InvitedBy == 1 && invitation.Email == "friend@mail.com" && invitation.Role == "editor" && len(invitation.Token) >= 40
+		})).Return(&models.Invitation{ID: 9, WorkspaceID: 4, Email: "friend@mail.com", Role: "editor", InvitedBy: 1, ExpiresAt: expiresAt}, nil)
+		stores.InitStore(mockStore)
+		mails := &recordingMailer{err: sendErr}
+		mailer.InitMailer(mails)
+
+		req, err := http.NewRequest("POST", "/workspaces/4/invitations", strings.NewReader(`{"email": "friend@mail.com", "role": "editor"}`))
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+*token)
+		recorder := httptest.NewRecorder()
+		workspaceRouter().ServeHTTP(recorder, req)
+
+		if sendErr != nil {
+			if recorder.Code != http.StatusBadGateway {
+				t.Errorf("Handler returned wrong status code: got %v want %v", recorder.Code, http.StatusBadGateway)
+			}
+			continue
+		}
+		if recorder.Code != http.StatusCreated {
+			t.Errorf("Handler returned wrong status code: got %v want %v", recorder.Code, http.StatusCreated)
+		}
+		if strings.Contains(recorder.Body.String(), savedToken) {
+			t.Errorf("Response must not contain the invitation token: %s", recorder.Body.String())
+		}
+		if len(mails.messages) != 1 {
+			t.Fatalf("Expected one email, got %d", len(mails.messages))
+		}
+		message := mails.messages[0]
+		if message.To != "friend@mail.com" || message.Subject != "You have been invited to Team" {
+			t.Errorf("Unexpected email: %+v", message)
+		}
+		if !strings.Contains(message.Body, "/invitations/"+savedToken+"/accept") || !strings.Contains(message.Body, "December 7, 2024") {
+			t.Errorf("Email does not explain how to accept:\n%s", message.Body)
+		}
+		mockStore.AssertExpectations(t)
+	}
+}
@@ -0,0 +1,143 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"todo-list/src/models"
+)
+
+// csvFields are the todo fields a CSV column can be mapped to.
+var csvFields = []string{"task_name", "completed", "due_date", "notes", "priority", "tags"}
+
+// parseCSV reads a CSV file with a header row. mapping names the column to
+// read each todo field from, e.g. {"task_name": "Title"}; fields left out
+// are read from the column named like the field, if there is one. Header
+// names are matched case insensitively.
+func parseCSV(r io.Reader, mapping map[string]string) ([]*models.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []*models.ImportRow{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns, err := csvColumns(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []*models.ImportRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		var parseError *csv.ParseError
+		if errors.As(err, &parseError) {
+			rows = append(rows, &models.ImportRow{Line: parseError.Line, Errors: map[string]string{"row": parseError.Err.Error()}})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		value := func(field string) string {
+			index, ok := columns[field]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+		row := &models.ImportRow{Line: line, Todo: &models.Todo{TaskName: value("task_name"), Notes: value("notes")}}
+		rowErrors := map[string]string{}
+		if completed := value("completed"); completed != "" {
+			row.Todo.Completed, err = parseCompleted(completed)
+			if err != nil {
+				rowErrors["completed"] = err.Error()
+			}
+		}
+		if dueDate := value("due_date"); dueDate != "" {
+			row.Todo.DueDate, row.Todo.DueAllDay, err = parseDate(dueDate)
+			if err != nil {
+				rowErrors["due_date"] = err.Error()
+			}
+		}
+		if priority := value("priority"); priority != "" {
+			row.Todo.Priority, err = strconv.Atoi(priority)
+			if err != nil {
+				rowErrors["priority"] = fmt.Sprintf("Can not parse priority %q", priority)
+			}
+		}
+		row.Todo.Tags = splitTags(value("tags"))
+		if len(rowErrors) > 0 {
+			row.Errors = rowErrors
+		}
+		rows = append(rows, row)
+	}
+}
+
+// csvColumns finds the column index of every mapped field.
+func csvColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	for field := range mapping {
+		if !isCSVField(field) {
+			return nil, fmt.Errorf("Can not map unknown field %q", field)
+		}
+	}
+
+	indexes := map[string]int{}
+	for i, name := range header {
+		indexes[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))] = i
+	}
+	columns := map[string]int{}
+	for _, field := range csvFields {
+		name, mapped := mapping[field]
+		if !mapped {
+			name = field
+		}
+		index, ok := indexes[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			if mapped {
+				return nil, fmt.Errorf("Column %q is not in the file", name)
+			}
+			continue
+		}
+		columns[field] = index
+	}
+	if _, ok := columns["task_name"]; !ok {
+		return nil, errors.New("The file has no task_name column")
+	}
+	return columns, nil
+}
+
+func isCSVField(field string) bool {
+	for _, csvField := range csvFields {
+		if field == csvField {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCompleted accepts the usual ways spreadsheets mark a done task.
+func parseCompleted(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "x", "yes", "y", "done":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	completed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("Can not parse completed %q", value)
+	}
+	return completed, nil
+}
@@ -0,0 +1,305 @@
+package importer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+)
+
+// icalProperty is one unfolded content line, NAME;PARAM=value:VALUE.
+type icalProperty struct {
+	line   int
+	name   string
+	params map[string]string
+	value  string
+}
+
+// parseICal reads the VTODO components of an RFC 5545 calendar. Other
+// components, such as events and the alarms of a todo, are skipped. Line is
+// the line the VTODO begins on.
+func parseICal(r io.Reader) ([]*models.ImportRow, error) {
+	rows, _, err := readICalTodos(r)
+	return rows, err
+}
+
+// ParseICalTodo reads a calendar holding exactly one VTODO, as CalDAV clients
+// upload them, and returns the todo with its UID. Field errors are returned
+// on the row.
+func ParseICalTodo(r io.Reader) (*models.ImportRow, string, error) {
+	rows, uids, err := readICalTodos(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(rows) != 1 {
+		return nil, "", errors.New("Expected exactly one VTODO")
+	}
+	if uids[0] == "" {
+		return nil, "", errors.New("The VTODO has no UID")
+	}
+	return rows[0], uids[0], nil
+}
+
+// readICalTodos reads the VTODO components of a calendar along with the UID
+// of each.
+func readICalTodos(r io.Reader) ([]*models.ImportRow, []string, error) {
+	properties, err := readICalProperties(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(properties) == 0 || properties[0].name != "BEGIN" || !strings.EqualFold(properties[0].value, "VCALENDAR") {
+		return nil, nil, errors.New("Expected an iCalendar file")
+	}
+
+	rows := []*models.ImportRow{}
+	uids := []string{}
+	uid := ""
+	var row *models.ImportRow
+	// depth counts the components open inside the current VTODO.
+	depth := 0
+	for _, property := range properties {
+		switch {
+		case row == nil:
+			if property.name == "BEGIN" && strings.EqualFold(property.value, "VTODO") {
+				row = &models.ImportRow{Line: property.line, Todo: &models.Todo{}}
+				uid = ""
+			}
+		case property.name == "BEGIN":
+			depth++
+		case property.name == "END" && depth > 0:
+			depth--
+		case property.name == "END":
+			rows = append(rows, row)
+			uids = append(uids, uid)
+			row = nil
+		case depth == 0 && property.name == "UID":
+			uid = unescapeICalText(property.value)
+		case depth == 0:
+			readICalTodoProperty(row, property)
+		}
+	}
+	if row != nil {
+		return nil, nil, fmt.Errorf("The VTODO on line %d never ends", row.Line)
+	}
+	return rows, uids, nil
+}
+
+func readICalTodoProperty(row *models.ImportRow, property icalProperty) {
+	setError := func(field string, err error) {
+		if row.Errors == nil {
+			row.Errors = map[string]string{}
+		}
+		row.Errors[field] = err.Error()
+	}
+
+	switch property.name {
+	case "SUMMARY":
+		row.Todo.TaskName = unescapeICalText(property.value)
+	case "DESCRIPTION":
+		row.Todo.Notes = unescapeICalText(property.value)
+	case "DUE":
+		dueDate, allDay, err := parseICalTime(property)
+		if err != nil {
+			setError("due_date", err)
+		}
+		row.Todo.DueDate, row.Todo.DueAllDay = dueDate, allDay
+	case "STATUS":
+		row.Todo.Completed = strings.EqualFold(property.value, "COMPLETED")
+	case "COMPLETED":
+		row.Todo.Completed = true
+	case "PRIORITY":
+		priority, err := strconv.Atoi(property.value)
+		if err != nil || priority < 0 || priority > 9 {
+			setError("priority", fmt.Errorf("Can not parse priority %q", property.value))
+			return
+		}
+		row.Todo.Priority = icalPriorityLevel(priority)
+	case "RRULE":
+		// Rules outside the supported subset are dropped rather than failing
+		// the todo; it is imported as a one-off.
+		if recurrence, err := lib.ParseRecurrence(property.value); err == nil {
+			row.Todo.Recurrence = recurrence.String()
+		}
+	case "CATEGORIES":
+		for _, tag := range splitICalList(property.value) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				row.Todo.Tags = append(row.Todo.Tags, tag)
+			}
+		}
+	}
+}
+
+// readICalProperties unfolds the content lines of a calendar and splits them
+// into properties. Blank lines are skipped.
+func readICalProperties(r io.Reader) ([]icalProperty, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	properties := []icalProperty{}
+	var current *strings.Builder
+	start, line := 0, 0
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		property, err := parseICalLine(current.String())
+		if err != nil {
+			return fmt.Errorf("Line %d: %v", start, err)
+		}
+		property.line = start
+		properties = append(properties, property)
+		current = nil
+		return nil
+	}
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\uFEFF")
+		}
+		if current != nil && (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) {
+			current.WriteString(text[1:])
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		current = &strings.Builder{}
+		current.WriteString(text)
+		start = line
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return properties, nil
+}
+
+// parseICalLine splits NAME;PARAM=value;PARAM="quoted":VALUE. Names and
+// parameter names are upper cased.
+func parseICalLine(text string) (icalProperty, error) {
+	property := icalProperty{params: map[string]string{}}
+	quoted := false
+	colon := -1
+	for i := 0; i < len(text) && colon < 0; i++ {
+		switch text[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return property, errors.New("Expected NAME:VALUE")
+	}
+
+	parts := strings.Split(text[:colon], ";")
+	property.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		property.params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+	property.value = text[colon+1:]
+	return property, nil
+}
+
+// parseICalTime reads a DATE or DATE-TIME value. Times ending in Z are UTC,
+// times with a TZID parameter are in that zone and floating times are taken
+// as UTC. Dates are midnight UTC and reported as all day.
+func parseICalTime(property icalProperty) (time.Time, bool, error) {
+	value := property.value
+	if strings.EqualFold(property.params["VALUE"], "DATE") || len(value) == len("20060102") {
+		date, err := time.Parse("20060102", value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("Can not parse date %q", value)
+		}
+		return date, true, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		date, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("Can not parse date %q", value)
+		}
+		return date, false, nil
+	}
+
+	location := time.UTC
+	if tzid := property.params["TZID"]; tzid != "" {
+		var err error
+		location, err = time.LoadLocation(strings.TrimPrefix(tzid, "/"))
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("Unknown time zone %q", tzid)
+		}
+	}
+	date, err := time.ParseInLocation("20060102T150405", value, location)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("Can not parse date %q", value)
+	}
+	return date.UTC(), false, nil
+}
+
+// splitICalList splits a TEXT list on the commas that are not escaped and
+// unescapes every item.
+func splitICalList(value string) []string {
+	items := []string{}
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			items = append(items, unescapeICalText(value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(items, unescapeICalText(value[start:]))
+}
+
+func unescapeICalText(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var text strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			text.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n', 'N':
+			text.WriteByte('\n')
+		default:
+			text.WriteByte(value[i])
+		}
+	}
+	return text.String()
+}
+
+// icalPriorityLevel maps the iCalendar scale, 1 highest to 9 lowest, onto
+// priorities: 1-2 urgent, 3-4 high, 5 medium and 6-9 low. 0 is undefined.
+func icalPriorityLevel(priority int) int {
+	switch {
+	case priority == 0:
+		return 0
+	case priority <= 2:
+		return 4
+	case priority <= 4:
+		return 3
+	case priority == 5:
+		return 2
+	}
+	return 1
+}
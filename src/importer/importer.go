@@ -0,0 +1,73 @@
+// Package importer reads todos from the files other tools export. Parsing
+// only builds the todos; validating and saving them is up to the caller.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"todo-list/src/models"
+)
+
+var ErrUnknownFormat = errors.New("unknown import format")
+
+// dateLayouts are tried in order for every date read from a file.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"Jan 2 2006",
+	"Jan 2 2006 15:04",
+	"January 2 2006",
+	"2 Jan 2006",
+	"2 January 2006",
+}
+
+// Parse reads the rows of an import file in the given format. mapping is
+// only used by CSV files; see parseCSV. Rows that can not be read carry
+// their errors; the error returned is for files that can not be read at all.
+func Parse(format string, r io.Reader, mapping map[string]string) ([]*models.ImportRow, error) {
+	switch format {
+	case models.ImportCSV:
+		return parseCSV(r, mapping)
+	case models.ImportJSON:
+		return parseJSON(r)
+	case models.ImportNDJSON:
+		return parseNDJSON(r)
+	case models.ImportTodoTxt:
+		return parseTodoTxt(r)
+	case models.ImportTodoist:
+		return parseTodoist(r)
+	case models.ImportICal:
+		return parseICal(r)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// parseDate reads a date in one of the dateLayouts. Dates without a time are
+// all day.
+func parseDate(value string) (time.Time, bool, error) {
+	value = strings.Join(strings.Fields(strings.ReplaceAll(value, ",", " ")), " ")
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, !strings.Contains(layout, "15"), nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("Can not parse date %q", value)
+}
+
+// splitTags splits a list of tags separated by commas or spaces, returning
+// nil for an empty list.
+func splitTags(value string) []string {
+	tags := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
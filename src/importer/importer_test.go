@@ -0,0 +1,173 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	type testCase struct {
+		name          string
+		format        string
+		source        string
+		mapping       map[string]string
+		expected      []*models.ImportRow
+		expectedError string
+	}
+
+	dueDate := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []testCase{
+		{
+			name:   "CSV",
+			format: models.ImportCSV,
+			source: "task_name,completed,due_date,notes,priority,tags\nBuy milk,false,2024-12-01,2 liters,2,\"shopping, home\"\nFile taxes,x,,,,\n",
+			expected: []*models.ImportRow{
+				{Line: 2, Todo: &models.Todo{TaskName: "Buy milk", DueDate: dueDate, DueAllDay: true, Notes: "2 liters", Priority: 2, Tags: []string{"shopping", "home"}}},
+				{Line: 3, Todo: &models.Todo{TaskName: "File taxes", Completed: true}},
+			},
+		},
+		{
+			name:    "CSV With Mapping",
+			format:  models.ImportCSV,
+			source:  "Title,Deadline,Done\nBuy milk,12/01/2024,yes\nCall mom,2024-12-01,maybe\n",
+			mapping: map[string]string{"task_name": "title", "due_date": "Deadline", "completed": "Done"},
+			expected: []*models.ImportRow{
+				{Line: 2, Todo: &models.Todo{TaskName: "Buy milk", Completed: true}, Errors: map[string]string{"due_date": `Can not parse date "12/01/2024"`}},
+				{Line: 3, Todo: &models.Todo{TaskName: "Call mom", DueDate: dueDate, DueAllDay: true}, Errors: map[string]string{"completed": `Can not parse completed "maybe"`}},
+			},
+		},
+		{
+			name:          "CSV Without Task Name",
+			format:        models.ImportCSV,
+			source:        "Title\nBuy milk\n",
+			expectedError: "The file has no task_name column",
+		},
+		{
+			name:          "CSV Mapped To Missing Column",
+			format:        models.ImportCSV,
+			source:        "Title\nBuy milk\n",
+			mapping:       map[string]string{"task_name": "Name"},
+			expectedError: `Column "Name" is not in the file`,
+		},
+		{
+			name:          "CSV Mapping Unknown Field",
+			format:        models.ImportCSV,
+			source:        "Title\nBuy milk\n",
+			mapping:       map[string]string{"owner": "Title"},
+			expectedError: `Can not map unknown field "owner"`,
+		},
+		{
+			name:   "JSON",
+			format: models.ImportJSON,
+			source: `[{"id": 9, "task_name": "Buy milk", "completed": true, "due_date": "2024-12-01T00:00:00Z", "role": "owner", "tags": ["home"]}, {"task_name": 5}]`,
+			expected: []*models.ImportRow{
+				{Line: 1, Todo: &models.Todo{TaskName: "Buy milk", Completed: true, DueDate: dueDate, Tags: []string{"home"}}},
+				{Line: 2, Errors: map[string]string{"row": "json: cannot unmarshal number into Go struct field Todo.task_name of type string"}},
+			},
+		},
+		{
+			name:          "JSON Object",
+			format:        models.ImportJSON,
+			source:        `{"task_name": "Buy milk"}`,
+			expectedError: "Expected an array of todos",
+		},
+		{
+			name:   "NDJSON",
+			format: models.ImportNDJSON,
+			source: "{\"task_name\": \"Buy milk\", \"priority\": 2}\n\n{\"task_name\": true}\n",
+			expected: []*models.ImportRow{
+				{Line: 1, Todo: &models.Todo{TaskName: "Buy milk", Priority: 2}},
+				{Line: 3, Errors: map[string]string{"row": "json: cannot unmarshal bool into Go struct field Todo.task_name of type string"}},
+			},
+		},
+		{
+			name:   "Todo.txt",
+			format: models.ImportTodoTxt,
+			source: "(A) 2024-11-30 Call mom +Family @phone due:2024-12-01\n\nx 2024-12-02 2024-11-30 Pay rent pri:B\n(D) Read https://example.com/a:b\nWater plants due:tomorrow\n",
+			expected: []*models.ImportRow{
+				{Line: 1, Todo: &models.Todo{TaskName: "Call mom", DueDate: dueDate, DueAllDay: true, Priority: 4, Tags: []string{"Family", "phone"}}},
+				{Line: 3, Todo: &models.Todo{TaskName: "Pay rent", Completed: true, Priority: 3}},
+				{Line: 4, Todo: &models.Todo{TaskName: "Read https://example.com/a:b", Priority: 1}},
+				{Line: 5, Todo: &models.Todo{TaskName: "Water plants"}, Errors: map[string]string{"due_date": `Can not parse date "tomorrow"`}},
+			},
+		},
+		{
+			name:   "Todoist",
+			format: models.ImportTodoist,
+			source: "\uFEFFTYPE,CONTENT,DESCRIPTION,PRIORITY,INDENT,AUTHOR,RESPONSIBLE,DATE,DATE_LANG,TIMEZONE\n" +
+				"section,Errands,,,,,,,,\n" +
+				"task,Buy milk @shopping,2 liters,1,1,Ana (1),,\"Dec 1, 2024\",en,UTC\n" +
+				"note,Oat milk is fine,,,,,,,,\n" +
+				"task,Stretch,,4,1,Ana (1),,every day,en,UTC\n",
+			expected: []*models.ImportRow{
+				{Line: 3, Todo: &models.Todo{TaskName: "Buy milk", Notes: "2 liters\n\nOat milk is fine", Priority: 4, DueDate: dueDate, DueAllDay: true, Tags: []string{"shopping"}}},
+				{Line: 5, Todo: &models.Todo{TaskName: "Stretch", Priority: 1}, Errors: map[string]string{"due_date": `Can not parse date "every day"`}},
+			},
+		},
+		{
+			name:          "Todoist Without Type",
+			format:        models.ImportTodoist,
+			source:        "CONTENT\nBuy milk\n",
+			expectedError: "The file has no TYPE column; is it a Todoist export?",
+		},
+		{
+			name:   "iCalendar",
+			format: models.ImportICal,
+			source: "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+				"BEGIN:VEVENT\r\nSUMMARY:Standup\r\nEND:VEVENT\r\n" +
+				"BEGIN:VTODO\r\nUID:1\r\nSUMMARY:Buy milk\\, eggs\r\nDESCRIPTION:2 liters\\nOat is fi\r\n ne\r\n" +
+				"DUE;TZID=Europe/Berlin:20241201T010000\r\nPRIORITY:5\r\nCATEGORIES:shopping,home\r\nRRULE:freq=monthly;bymonthday=1\r\n" +
+				"BEGIN:VALARM\r\nACTION:DISPLAY\r\nDESCRIPTION:Reminder\r\nEND:VALARM\r\nEND:VTODO\r\n" +
+				"BEGIN:VTODO\r\nSUMMARY:File taxes\r\nSTATUS:COMPLETED\r\nDUE;VALUE=DATE:20241201\r\nPRIORITY:1\r\nRRULE:FREQ=YEARLY;COUNT=3\r\nEND:VTODO\r\n" +
+				"BEGIN:VTODO\r\nSUMMARY:Water plants\r\nDUE;TZID=Mars/Olympus:20241201T000000\r\nPRIORITY:high\r\nEND:VTODO\r\n" +
+				"END:VCALENDAR\r\n",
+			expected: []*models.ImportRow{
+				{Line: 6, Todo: &models.Todo{TaskName: "Buy milk, eggs", Notes: "2 liters\nOat is fine", DueDate: dueDate, Priority: 2, Tags: []string{"shopping", "home"}, Recurrence: "FREQ=MONTHLY;BYMONTHDAY=1"}},
+				{Line: 20, Todo: &models.Todo{TaskName: "File taxes", Completed: true, DueDate: dueDate, DueAllDay: true, Priority: 4}},
+				{Line: 27, Todo: &models.Todo{TaskName: "Water plants"}, Errors: map[string]string{"due_date": `Unknown time zone "Mars/Olympus"`, "priority": `Can not parse priority "high"`}},
+			},
+		},
+		{
+			name:          "iCalendar Without Calendar",
+			format:        models.ImportICal,
+			source:        "BEGIN:VTODO\nSUMMARY:Buy milk\nEND:VTODO\n",
+			expectedError: "Expected an iCalendar file",
+		},
+		{
+			name:          "Unknown Format",
+			format:        "xlsx",
+			source:        "",
+			expectedError: ErrUnknownFormat.Error(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rows, err := Parse(tc.format, strings.NewReader(tc.source), tc.mapping)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, rows)
+		})
+	}
+}
+
+func TestParseICalTodo(t *testing.T) {
+	row, uid, err := ParseICalTodo(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:abc\\,123\r\nSUMMARY:Buy milk\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "abc,123", uid)
+	assert.Equal(t, &models.ImportRow{Line: 2, Todo: &models.Todo{TaskName: "Buy milk"}}, row)
+
+	_, _, err = ParseICalTodo(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:Buy milk\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"))
+	assert.EqualError(t, err, "The VTODO has no UID")
+
+	_, _, err = ParseICalTodo(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.EqualError(t, err, "Expected exactly one VTODO")
+}
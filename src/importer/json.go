@@ -0,0 +1,75 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"todo-list/src/models"
+)
+
+// parseJSON reads an array of todos in the format the API returns them.
+// Only the user editable fields are kept; IDs, positions and the like are
+// assigned anew. Line is the position of the todo in the array.
+func parseJSON(r io.Reader) ([]*models.ImportRow, error) {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("Expected an array of todos")
+	}
+
+	rows := []*models.ImportRow{}
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, err
+		}
+		rows = append(rows, jsonRow(len(rows)+1, raw))
+	}
+	return rows, nil
+}
+
+// parseNDJSON reads one todo per line, as the NDJSON export writes them.
+// Blank lines are skipped.
+func parseNDJSON(r io.Reader) ([]*models.ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	rows := []*models.ImportRow{}
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		rows = append(rows, jsonRow(line, raw))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func jsonRow(line int, raw []byte) *models.ImportRow {
+	row := &models.ImportRow{Line: line}
+	todo := &models.Todo{}
+	if err := json.Unmarshal(raw, todo); err != nil {
+		row.Errors = map[string]string{"row": err.Error()}
+		return row
+	}
+	row.Todo = &models.Todo{
+		TaskName:   todo.TaskName,
+		Completed:  todo.Completed,
+		DueDate:    todo.DueDate,
+		DueAllDay:  todo.DueAllDay,
+		Notes:      todo.Notes,
+		Priority:   todo.Priority,
+		Recurrence: todo.Recurrence,
+		Tags:       todo.Tags,
+	}
+	return row
+}
@@ -0,0 +1,103 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"todo-list/src/models"
+)
+
+// parseTodoist reads the CSV files Todoist exports projects as. Only task
+// rows become todos; the notes following a task are appended to its notes
+// and sections are skipped. Todoist's priority 1 is its most urgent, so it
+// maps to 4 here. "@label" words in the content become tags.
+func parseTodoist(r io.Reader) ([]*models.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []*models.ImportRow{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))] = i
+	}
+	if _, ok := columns["TYPE"]; !ok {
+		return nil, errors.New("The file has no TYPE column; is it a Todoist export?")
+	}
+	if _, ok := columns["CONTENT"]; !ok {
+		return nil, errors.New("The file has no CONTENT column; is it a Todoist export?")
+	}
+
+	rows := []*models.ImportRow{}
+	var lastTask *models.ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		value := func(column string) string {
+			index, ok := columns[column]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		switch value("TYPE") {
+		case "task":
+		case "note":
+			if lastTask != nil && lastTask.Todo != nil && value("CONTENT") != "" {
+				if lastTask.Todo.Notes != "" {
+					lastTask.Todo.Notes += "\n\n"
+				}
+				lastTask.Todo.Notes += value("CONTENT")
+			}
+			continue
+		default:
+			continue
+		}
+
+		row := &models.ImportRow{Line: line, Todo: &models.Todo{Notes: value("DESCRIPTION")}}
+		name := []string{}
+		for _, word := range strings.Fields(value("CONTENT")) {
+			if len(word) > 1 && word[0] == '@' {
+				row.Todo.Tags = append(row.Todo.Tags, word[1:])
+				continue
+			}
+			name = append(name, word)
+		}
+		row.Todo.TaskName = strings.Join(name, " ")
+
+		rowErrors := map[string]string{}
+		if priority := value("PRIORITY"); priority != "" {
+			level, err := strconv.Atoi(priority)
+			if err != nil || level < 1 || level > 4 {
+				rowErrors["priority"] = "Priority must be between 1 and 4"
+			} else {
+				row.Todo.Priority = 5 - level
+			}
+		}
+		if date := value("DATE"); date != "" {
+			row.Todo.DueDate, row.Todo.DueAllDay, err = parseDate(date)
+			if err != nil {
+				rowErrors["due_date"] = err.Error()
+			}
+		}
+		if len(rowErrors) > 0 {
+			row.Errors = rowErrors
+		}
+		rows = append(rows, row)
+		lastTask = row
+	}
+}
@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"todo-list/src/models"
+)
+
+var (
+	todoTxtDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	todoTxtPriority = regexp.MustCompile(`^\([A-Z]\)$`)
+)
+
+// parseTodoTxt reads the todo.txt format, one task per line:
+//
+//	x (A) 2024-11-30 Call mom +Family @phone due:2024-12-01
+//
+// A leading "x" completes the task. Priorities A, B and C map to urgent, high
+// and medium, any later letter to low. Projects and contexts become tags, and
+// the due: and pri: keys are read; other key:value pairs stay in the name.
+func parseTodoTxt(r io.Reader) ([]*models.ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	rows := []*models.ImportRow{}
+	line := 0
+	for scanner.Scan() {
+		line++
+		words := strings.Fields(scanner.Text())
+		if len(words) == 0 {
+			continue
+		}
+
+		row := &models.ImportRow{Line: line, Todo: &models.Todo{}}
+		if words[0] == "x" {
+			row.Todo.Completed = true
+			words = words[1:]
+		}
+		if len(words) > 0 && todoTxtPriority.MatchString(words[0]) {
+			row.Todo.Priority = todoTxtPriorityLevel(words[0][1])
+			words = words[1:]
+		}
+		// A completed task has its completion date first, then the
+		// creation date; neither is kept.
+		for i := 0; i < 2 && len(words) > 0 && todoTxtDate.MatchString(words[0]); i++ {
+			words = words[1:]
+		}
+
+		name := []string{}
+		for _, word := range words {
+			switch {
+			case len(word) > 1 && (word[0] == '+' || word[0] == '@'):
+				row.Todo.Tags = append(row.Todo.Tags, word[1:])
+			case strings.HasPrefix(word, "due:") && len(word) > len("due:"):
+				dueDate, allDay, err := parseDate(strings.TrimPrefix(word, "due:"))
+				if err != nil {
+					row.Errors = map[string]string{"due_date": err.Error()}
+				}
+				row.Todo.DueDate, row.Todo.DueAllDay = dueDate, allDay
+			case strings.HasPrefix(word, "pri:") && len(word) == len("pri:A") && word[4] >= 'A' && word[4] <= 'Z':
+				row.Todo.Priority = todoTxtPriorityLevel(word[4])
+			default:
+				name = append(name, word)
+			}
+		}
+		row.Todo.TaskName = strings.Join(name, " ")
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func todoTxtPriorityLevel(letter byte) int {
+	switch letter {
+	case 'A':
+		return 4
+	case 'B':
+		return 3
+	case 'C':
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+)
+
+// RunIdempotencyKeyPurge deletes the idempotency keys older than
+// models.IdempotencyKeyTTL every interval until ctx is done. Expired keys are
+// already ignored when claimed; this only keeps the table small.
+func RunIdempotencyKeyPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := stores.GetStore().PurgeIdempotencyKeys(time.Now().Add(-models.IdempotencyKeyTTL)); err != nil {
+			log.Printf("Can not purge idempotency keys: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+)
+
+// importProgressInterval is how many rows are imported between two saves of
+// the import's progress.
+const importProgressInterval = 25
+
+// RunImport creates the todos of an import's valid rows for the user who
+// started it, saving progress as it goes. Rows with errors are expected to be
+// counted as processed and failed already.
+func RunImport(ctx context.Context, imp *models.Import, rows []*models.ImportRow) {
+	save := func() {
+		if err := stores.GetStore().UpdateImport(imp); err != nil {
+			log.Printf("Can not save progress of import %d: %v", imp.ID, err)
+		}
+	}
+	imp.Status = models.ImportRunning
+	save()
+
+	imported := 0
+	for _, row := range rows {
+		if row.Errors != nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			imp.Status = models.ImportFailed
+			break
+		}
+
+		row.Todo.WorkspaceID = imp.WorkspaceID
+		_, err := stores.GetStore().CreateTodo(row.Todo, imp.UserID)
+		if err != nil {
+			log.Printf("Can not import line %d of import %d: %v", row.Line, imp.ID, err)
+			imp.Failed++
+			imp.Errors = append(imp.Errors, models.ImportError{Line: row.Line, Errors: map[string]string{"row": "Can not create todo"}})
+		} else {
+			imp.Created++
+		}
+		imp.Processed++
+
+		imported++
+		if imported%importProgressInterval == 0 {
+			save()
+		}
+	}
+
+	if imp.Status == models.ImportRunning {
+		imp.Status = models.ImportCompleted
+	}
+	finishedAt := time.Now()
+	imp.FinishedAt = &finishedAt
+	save()
+}
@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRunImport(t *testing.T) {
+	mockStore := stores.InitMockStore()
+	stores.InitStore(mockStore)
+
+	var statuses []string
+	mockStore.On("UpdateImport", mock.Anything).Run(func(args mock.Arguments) {
+		statuses = append(statuses, args.Get(0).(*models.Import).Status)
+	}).Return(nil)
+	mockStore.On("CreateTodo", &models.Todo{TaskName: "Buy milk", WorkspaceID: 3}, 1).Return(&models.Todo{ID: 7}, nil)
+	mockStore.On("CreateTodo", &models.Todo{TaskName: "Call mom", WorkspaceID: 3}, 1).Return((*models.Todo)(nil), errors.New("connection lost"))
+
+	imp := &models.Import{ID: 4, UserID: 1, WorkspaceID: 3, Status: models.ImportPending, Total: 3, Processed: 1, Failed: 1,
+		Errors: []models.ImportError{{Line: 2, Errors: map[string]string{"TaskName": "This field is required"}}}}
+	RunImport(context.Background(), imp, []*models.ImportRow{
+		{Line: 1, Todo: &models.Todo{TaskName: "Buy milk"}},
+		{Line: 2, Todo: &models.Todo{}, Errors: map[string]string{"TaskName": "This field is required"}},
+		{Line: 3, Todo: &models.Todo{TaskName: "Call mom"}},
+	})
+
+	assert.Equal(t, []string{models.ImportRunning, models.ImportCompleted}, statuses)
+	assert.Equal(t, 3, imp.Processed)
+	assert.Equal(t, 1, imp.Created)
+	assert.Equal(t, 2, imp.Failed)
+	assert.Equal(t, []models.ImportError{
+		{Line: 2, Errors: map[string]string{"TaskName": "This field is required"}},
+		{Line: 3, Errors: map[string]string{"row": "Can not create todo"}},
+	}, imp.Errors)
+	assert.NotNil(t, imp.FinishedAt)
+	mockStore.AssertExpectations(t)
+}
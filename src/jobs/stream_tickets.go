@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+	"todo-list/src/stores"
+)
+
+// RunStreamTicketPurge deletes the expired stream tickets every interval
+// until ctx is done. Redeeming a ticket deletes it; this removes the ones
+// never used.
+func RunStreamTicketPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := stores.GetStore().PurgeStreamTickets(time.Now()); err != nil {
+			log.Printf("Can not purge stream tickets: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+)
+
+// RunTodoEventPurge deletes the todo events older than
+// models.EventLogRetention every interval until ctx is done. Clients resuming
+// from a purged event are told to reset.
+func RunTodoEventPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := stores.GetStore().PurgeTodoEvents(time.Now().Add(-models.EventLogRetention)); err != nil {
+			log.Printf("Can not purge todo events: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"todo-list/src/blobstore"
+	"todo-list/src/stores"
+)
+
+// DefaultTrashRetention is how long trashed todos are kept when no other
+// retention window is configured.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// PurgeTrash permanently deletes the todos trashed more than retention ago
+// and removes their attachment files from the blob store.
+func PurgeTrash(ctx context.Context, retention time.Duration) error {
+	attachments, err := stores.GetStore().PurgeTrash(time.Now().Add(-retention))
+	if err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		err := blobstore.GetStore().Delete(ctx, attachment.StorageKey)
+		if err != nil && !errors.Is(err, blobstore.ErrNotFound) {
+			log.Printf("Can not delete blob %s: %v", attachment.StorageKey, err)
+		}
+	}
+	return nil
+}
+
+// RunTrashPurge calls PurgeTrash every interval until ctx is done.
+func RunTrashPurge(ctx context.Context, retention time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := PurgeTrash(ctx, retention); err != nil {
+			log.Printf("Can not purge trash: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
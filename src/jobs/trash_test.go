@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/blobstore"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPurgeTrash(t *testing.T) {
+	root := t.TempDir()
+	blobs := &blobstore.LocalStore{Root: root}
+	blobstore.InitStore(blobs)
+	ctx := context.Background()
+	assert.NoError(t, blobs.Put(ctx, "todos/1/a.txt", strings.NewReader("a"), 1))
+	assert.NoError(t, blobs.Put(ctx, "todos/2/b.txt", strings.NewReader("b"), 1))
+
+	mockStore := stores.InitMockStore()
+	stores.InitStore(mockStore)
+	start := time.Now()
+	mockStore.On("PurgeTrash", mock.MatchedBy(func(before time.Time) bool {
+		cutoff := start.Add(-48 * time.Hour)
+		return !before.Before(cutoff) && before.Before(cutoff.Add(time.Minute))
+	})).Return([]*models.Attachment{{ID: 1, TodoID: 1, StorageKey: "todos/1/a.txt"}, {ID: 3, TodoID: 1, StorageKey: "todos/1/missing.txt"}}, nil)
+
+	assert.NoError(t, PurgeTrash(ctx, 48*time.Hour))
+
+	_, err := os.Stat(filepath.Join(root, "todos/1/a.txt"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(root, "todos/2/b.txt"))
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+}
@@ -0,0 +1,164 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+)
+
+// webhookBatch is how many deliveries are claimed and sent at once. Sending
+// them all in parallel keeps a batch well inside the lease of a claim even
+// when every receiver times out.
+const webhookBatch = 50
+
+// webhookAddressAllowed tells whether deliveries may connect to an address.
+var webhookAddressAllowed = lib.PublicAddress
+
+// webhookClient sends deliveries. Redirects are not followed, so a receiver
+// answering with one has to be updated to the URL it moved to. Every
+// connection is checked against the address it is made to, after resolving,
+// so a host name can not be pointed at a local or private address once its
+// webhook was saved. No proxy is used, as it would hide that address.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, c syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !webhookAddressAllowed(ip) {
+					return fmt.Errorf("webhooks can not be delivered to %s", host)
+				}
+				return nil
+			},
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// DeliverWebhooks sends the webhook deliveries that are due until none are
+// left. Failed attempts are retried with exponential backoff, starting at a
+// minute, until models.WebhookMaxAttempts.
+func DeliverWebhooks(ctx context.Context) error {
+	for {
+		deliveries, err := stores.GetStore().ClaimWebhookDeliveries(webhookBatch)
+		if err != nil {
+			return err
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func(delivery *models.WebhookDelivery) {
+				defer wg.Done()
+				sendWebhook(ctx, delivery)
+				if err := stores.GetStore().RecordWebhookAttempt(delivery); err != nil {
+					log.Printf("Can not record webhook delivery %d: %v", delivery.ID, err)
+				}
+			}(delivery)
+		}
+		wg.Wait()
+
+		if len(deliveries) < webhookBatch || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// sendWebhook posts a delivery to its webhook and sets its status, attempts,
+// response and next attempt to the outcome.
+func sendWebhook(ctx context.Context, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+	delivery.Error = ""
+
+	sentAt := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "todo-list-webhooks")
+		req.Header.Set("X-Webhook-ID", strconv.Itoa(delivery.ID))
+		req.Header.Set("X-Webhook-Event", delivery.Event)
+		req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(sentAt.Unix(), 10))
+		req.Header.Set("X-Webhook-Signature", lib.SignWebhook(delivery.Secret, sentAt, delivery.Payload))
+
+		var resp *http.Response
+		resp, err = webhookClient.Do(req)
+		if err == nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			delivery.ResponseStatus = resp.StatusCode
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				delivery.Status = models.DeliveryDelivered
+				delivery.NextAttemptAt = sentAt
+				return
+			}
+			delivery.Error = resp.Status
+		}
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	if delivery.Attempts >= models.WebhookMaxAttempts {
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = sentAt
+		return
+	}
+	delivery.Status = models.DeliveryPending
+	delivery.NextAttemptAt = sentAt.Add(time.Minute << (delivery.Attempts - 1))
+}
+
+// RunWebhookDeliveries calls DeliverWebhooks every interval until ctx is
+// done.
+func RunWebhookDeliveries(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := DeliverWebhooks(ctx); err != nil {
+			log.Printf("Can not deliver webhooks: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunWebhookDeliveryPurge deletes the finished deliveries older than
+// models.WebhookDeliveryRetention every interval until ctx is done.
+func RunWebhookDeliveryPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := stores.GetStore().PurgeWebhookDeliveries(time.Now().Add(-models.WebhookDeliveryRetention)); err != nil {
+			log.Printf("Can not purge webhook deliveries: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
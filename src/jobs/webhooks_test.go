@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDeliverWebhooks(t *testing.T) {
+	payload := []byte(`{"event":"todo.created","todo":{"id":5,"task_name":"Pay rent"}}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		unix, _ := strconv.ParseInt(r.Header.Get("X-Webhook-Timestamp"), 10, 64)
+		if r.Header.Get("X-Webhook-Signature") != lib.SignWebhook("s3cret", time.Unix(unix, 0), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "todo.created", r.Header.Get("X-Webhook-Event"))
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	// The test server listens on loopback.
+	webhookAddressAllowed = func(ip net.IP) bool { return true }
+	defer func() { webhookAddressAllowed = lib.PublicAddress }()
+
+	mockStore := stores.InitMockStore()
+	stores.InitStore(mockStore)
+	mockStore.On("ClaimWebhookDeliveries", webhookBatch).Return([]*models.WebhookDelivery{
+		{ID: 7, WebhookID: 3, Event: "todo.created", Payload: payload, Status: "pending", URL: server.URL + "/hook", Secret: "s3cret"},
+		{ID: 8, WebhookID: 4, Event: "todo.created", Payload: payload, Status: "pending", Attempts: 2, URL: server.URL + "/down", Secret: "s3cret"},
+		{ID: 9, WebhookID: 5, Event: "todo.created", Payload: payload, Status: "pending", Attempts: 7, URL: server.URL + "/hook", Secret: "wrong"},
+	}, nil).Once()
+	start := time.Now()
+	mockStore.On("RecordWebhookAttempt", mock.MatchedBy(func(delivery *models.WebhookDelivery) bool {
+		return delivery.ID == 7 && delivery.Status == models.DeliveryDelivered && delivery.Attempts == 1 && delivery.ResponseStatus == http.StatusNoContent
+	})).Return(nil)
+	mockStore.On("RecordWebhookAttempt", mock.MatchedBy(func(delivery *models.WebhookDelivery) bool {
+		retry := delivery.NextAttemptAt.Sub(start)
+		return delivery.ID == 8 && delivery.Status == models.DeliveryPending && delivery.Attempts == 3 && delivery.ResponseStatus == http.StatusBadGateway &&
+			delivery.Error == "502 Bad Gateway" && retry > 4*time.Minute-time.Second && retry < 4*time.Minute+time.Minute
+	})).Return(nil)
+	mockStore.On("RecordWebhookAttempt", mock.MatchedBy(func(delivery *models.WebhookDelivery) bool {
+		return delivery.ID == 9 && delivery.Status == models.DeliveryFailed && delivery.Attempts == models.WebhookMaxAttempts && delivery.ResponseStatus == http.StatusUnauthorized
+	})).Return(nil)
+
+	assert.NoError(t, DeliverWebhooks(context.Background()))
+	mockStore.AssertExpectations(t)
+}
+
+func TestDeliverWebhooksRefusesPrivateAddresses(t *testing.T) {
+	received := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = true
+	}))
+	defer server.Close()
+	// A host name resolving to loopback is refused the same as the address.
+	url := "http://localhost:" + server.URL[strings.LastIndex(server.URL, ":")+1:] + "/hook"
+
+	mockStore := stores.InitMockStore()
+	stores.InitStore(mockStore)
+	mockStore.On("ClaimWebhookDeliveries", webhookBatch).Return([]*models.WebhookDelivery{
+		{ID: 7, WebhookID: 3, Event: "todo.created", Payload: []byte(`{}`), Status: "pending", URL: url, Secret: "s3cret"},
+	}, nil).Once()
+	mockStore.On("RecordWebhookAttempt", mock.MatchedBy(func(delivery *models.WebhookDelivery) bool {
+		return delivery.ID == 7 && delivery.Status == models.DeliveryPending && delivery.ResponseStatus == 0 &&
+			strings.Contains(delivery.Error, "webhooks can not be delivered to")
+	})).Return(nil)
+
+	assert.NoError(t, DeliverWebhooks(context.Background()))
+	assert.False(t, received)
+	mockStore.AssertExpectations(t)
+}
@@ -0,0 +1,21 @@
+package lib
+
+import "time"
+
+// StartOfDay is midnight at the start of t's day in t's location.
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// StartOfWeek is midnight at the start of the week t is in, for weeks that
+// start on weekStart.
+func StartOfWeek(t time.Time, weekStart time.Weekday) time.Time {
+	return StartOfDay(t).AddDate(0, 0, -((int(t.Weekday()) - int(weekStart) + 7) % 7))
+}
+
+// AllDay is the calendar date of t, as read in t's location, at midnight UTC.
+// All-day due dates are kept in this form so they name the same day in every
+// timezone.
+func AllDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,40 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartOfWeek(t *testing.T) {
+	// A Wednesday afternoon.
+	wednesday := time.Date(2024, 11, 27, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		weekStart time.Weekday
+		expected  time.Time
+	}{
+		{weekStart: time.Monday, expected: time.Date(2024, 11, 25, 0, 0, 0, 0, time.UTC)},
+		{weekStart: time.Sunday, expected: time.Date(2024, 11, 24, 0, 0, 0, 0, time.UTC)},
+		{weekStart: time.Saturday, expected: time.Date(2024, 11, 23, 0, 0, 0, 0, time.UTC)},
+		{weekStart: time.Wednesday, expected: time.Date(2024, 11, 27, 0, 0, 0, 0, time.UTC)},
+		{weekStart: time.Thursday, expected: time.Date(2024, 11, 21, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.weekStart.String(), func(t *testing.T) {
+			assert.Equal(t, tt.expected, StartOfWeek(wednesday, tt.weekStart))
+		})
+	}
+}
+
+func TestAllDay(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+	// Still the 27th in UTC, but the 28th where it was written.
+	late := time.Date(2024, 11, 28, 1, 0, 0, 0, tokyo)
+	assert.Equal(t, time.Date(2024, 11, 28, 0, 0, 0, 0, time.UTC), AllDay(late))
+	assert.Equal(t, time.Date(2024, 11, 28, 0, 0, 0, 0, tokyo), StartOfDay(late))
+}
@@ -0,0 +1,124 @@
+package lib
+
+import (
+	"errors"
+	"strings"
+)
+
+// positionDigits is the alphabet used for fractional index keys. The digits are
+// in ascending byte order so keys compare correctly with plain string (and SQL
+// "C" collation) comparison.
+const positionDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var ErrInvalidPosition = errors.New("invalid position key")
+
+// PositionBetween returns a key that sorts strictly between a and b. An empty a
+// means "before everything" and an empty b means "after everything".
+func PositionBetween(a string, b string) (string, error) {
+	if !validPosition(a) || !validPosition(b) {
+		return "", ErrInvalidPosition
+	}
+	if a != "" && b != "" && a >= b {
+		return "", errors.New("position keys are out of order")
+	}
+	return midpoint(a, b), nil
+}
+
+// PositionsBetween returns n evenly spaced keys between a and b. It is used to
+// rebalance a list once its keys have grown long.
+func PositionsBetween(a string, b string, n int) ([]string, error) {
+	if n <= 0 {
+		return []string{}, nil
+	}
+	if a == "" && b == "" {
+		return evenPositions(n), nil
+	}
+
+	keys := make([]string, 0, n)
+	prev := a
+	for i := 0; i < n; i++ {
+		key, err := PositionBetween(prev, b)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		prev = key
+	}
+	return keys, nil
+}
+
+func evenPositions(n int) []string {
+	base := len(positionDigits)
+	width := 1
+	space := base
+	for space <= n {
+		width++
+		space *= base
+	}
+
+	keys := make([]string, n)
+	for i := 0; i < n; i++ {
+		value := (i + 1) * space / (n + 1)
+		digits := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			digits[j] = positionDigits[value%base]
+			value /= base
+		}
+		keys[i] = strings.TrimRight(string(digits), positionDigits[:1])
+	}
+	return keys
+}
+
+func validPosition(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(positionDigits, key[i]) < 0 {
+			return false
+		}
+	}
+	// A trailing zero digit would leave no room for a key directly before it.
+	return !strings.HasSuffix(key, positionDigits[:1])
+}
+
+func midpoint(a string, b string) string {
+	if b != "" {
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+
+	digitA := 0
+	if a != "" {
+		digitA = strings.IndexByte(positionDigits, a[0])
+	}
+	digitB := len(positionDigits)
+	if b != "" {
+		digitB = strings.IndexByte(positionDigits, b[0])
+	}
+
+	if digitB-digitA > 1 {
+		return string(positionDigits[(digitA+digitB+1)/2])
+	}
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(positionDigits[digitA]) + midpoint(rest, "")
+}
+
+func digitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return positionDigits[0]
+}
@@ -0,0 +1,87 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionBetween(t *testing.T) {
+	type testCase struct {
+		name        string
+		a           string
+		b           string
+		expected    string
+		shouldError bool
+	}
+
+	tests := []testCase{
+		{name: "Empty list", a: "", b: "", expected: "V"},
+		{name: "Append to end", a: "V", b: "", expected: "l"},
+		{name: "Prepend to start", a: "", b: "V", expected: "G"},
+		{name: "Between neighbours", a: "F", b: "V", expected: "N"},
+		{name: "Adjacent digits", a: "V", b: "W", expected: "VV"},
+		{name: "Shared prefix", a: "V1", b: "V2", expected: "V1V"},
+		{name: "Before key with leading zero", a: "", b: "01", expected: "00V"},
+		{name: "Out of order", a: "W", b: "V", shouldError: true},
+		{name: "Equal keys", a: "V", b: "V", shouldError: true},
+		{name: "Trailing zero", a: "V0", b: "", shouldError: true},
+		{name: "Invalid character", a: "V-", b: "", shouldError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := PositionBetween(tc.a, tc.b)
+			if tc.shouldError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, key)
+			if tc.a != "" {
+				assert.Less(t, tc.a, key)
+			}
+			if tc.b != "" {
+				assert.Less(t, key, tc.b)
+			}
+		})
+	}
+}
+
+func TestPositionBetweenRepeatedInserts(t *testing.T) {
+	a, b := "V", "W"
+	for i := 0; i < 200; i++ {
+		key, err := PositionBetween(a, b)
+		assert.NoError(t, err)
+		assert.Less(t, a, key)
+		assert.Less(t, key, b)
+		if i%2 == 0 {
+			a = key
+		} else {
+			b = key
+		}
+	}
+}
+
+func TestPositionsBetween(t *testing.T) {
+	for _, n := range []int{0, 1, 5, 61, 62, 500} {
+		keys, err := PositionsBetween("", "", n)
+		assert.NoError(t, err)
+		assert.Len(t, keys, n)
+		for i := 1; i < len(keys); i++ {
+			assert.Less(t, keys[i-1], keys[i])
+		}
+		for _, key := range keys {
+			assert.True(t, validPosition(key), key)
+			assert.LessOrEqual(t, len(key), 2)
+		}
+	}
+
+	keys, err := PositionsBetween("V", "W", 3)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 3)
+	assert.Less(t, "V", keys[0])
+	assert.Less(t, keys[0], keys[1])
+	assert.Less(t, keys[1], keys[2])
+	assert.Less(t, keys[2], "W")
+}
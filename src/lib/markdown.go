@@ -0,0 +1,178 @@
+package lib
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletPattern    = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedPattern   = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	taskPattern      = regexp.MustCompile(`^\[( |x|X)\]\s+(.*)$`)
+	rulePattern      = regexp.MustCompile(`^\s*(-{3,}|\*{3,}|_{3,})\s*$`)
+	boldPattern      = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	italicPattern    = regexp.MustCompile(`\*([^*]+)\*`)
+	strikePattern    = regexp.MustCompile(`~~([^~]+)~~`)
+	linkPattern      = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s"<>]+)\)`)
+	checklistPattern = regexp.MustCompile(`^\s*[-*+]\s+\[( |x|X)\]\s+`)
+)
+
+// RenderMarkdown converts Markdown notes to HTML. Every piece of the source is
+// HTML-escaped before any markup is added, so raw tags, style blocks and event
+// attributes in the notes are rendered as text instead of being passed through.
+// Links are only emitted for http, https and mailto URLs.
+func RenderMarkdown(source string) string {
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	var out strings.Builder
+	renderBlocks(&out, lines)
+	return out.String()
+}
+
+// ChecklistProgress counts the "- [ ]" and "- [x]" items in Markdown notes,
+// ignoring anything inside fenced code blocks.
+func ChecklistProgress(source string) (int, int) {
+	done, total := 0, 0
+	inFence := false
+	for _, line := range strings.Split(source, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		match := checklistPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		total++
+		if match[1] != " " {
+			done++
+		}
+	}
+	return done, total
+}
+
+func renderBlocks(out *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+		case strings.HasPrefix(trimmed, "```"):
+			i++
+			out.WriteString("<pre><code>")
+			first := true
+			for ; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				if !first {
+					out.WriteString("\n")
+				}
+				out.WriteString(html.EscapeString(lines[i]))
+				first = false
+			}
+			out.WriteString("</code></pre>\n")
+			i++
+		case headingPattern.MatchString(trimmed):
+			match := headingPattern.FindStringSubmatch(trimmed)
+			level := string(rune('0' + len(match[1])))
+			out.WriteString("<h" + level + ">" + renderInline(match[2]) + "</h" + level + ">\n")
+			i++
+		case rulePattern.MatchString(line):
+			out.WriteString("<hr>\n")
+			i++
+		case strings.HasPrefix(trimmed, ">"):
+			quoted := []string{}
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quoted = append(quoted, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+			}
+			out.WriteString("<blockquote>\n")
+			renderBlocks(out, quoted)
+			out.WriteString("</blockquote>\n")
+		case bulletPattern.MatchString(line):
+			i = renderList(out, lines, i, "ul", bulletPattern)
+		case orderedPattern.MatchString(line):
+			i = renderList(out, lines, i, "ol", orderedPattern)
+		default:
+			paragraph := []string{}
+			for ; i < len(lines) && isParagraphLine(lines[i]); i++ {
+				paragraph = append(paragraph, renderInline(strings.TrimSpace(lines[i])))
+			}
+			out.WriteString("<p>" + strings.Join(paragraph, "\n") + "</p>\n")
+		}
+	}
+}
+
+func renderList(out *strings.Builder, lines []string, i int, tag string, pattern *regexp.Regexp) int {
+	out.WriteString("<" + tag + ">\n")
+	for ; i < len(lines) && pattern.MatchString(lines[i]); i++ {
+		item := pattern.FindStringSubmatch(lines[i])[1]
+		if task := taskPattern.FindStringSubmatch(item); task != nil {
+			checked := ""
+			if task[1] != " " {
+				checked = " checked"
+			}
+			out.WriteString(`<li><input type="checkbox" disabled` + checked + "> " + renderInline(task[2]) + "</li>\n")
+			continue
+		}
+		out.WriteString("<li>" + renderInline(item) + "</li>\n")
+	}
+	out.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func isParagraphLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed != "" &&
+		!strings.HasPrefix(trimmed, "```") &&
+		!strings.HasPrefix(trimmed, ">") &&
+		!headingPattern.MatchString(trimmed) &&
+		!rulePattern.MatchString(line) &&
+		!bulletPattern.MatchString(line) &&
+		!orderedPattern.MatchString(line)
+}
+
+func renderInline(text string) string {
+	// Odd segments are inside backticks and are left as literal code.
+	segments := strings.Split(text, "`")
+	if len(segments)%2 == 0 {
+		// An unmatched backtick is kept as a plain character.
+		last := len(segments) - 1
+		segments[last-1] = segments[last-1] + "`" + segments[last]
+		segments = segments[:last]
+	}
+
+	var out strings.Builder
+	for i, segment := range segments {
+		escaped := html.EscapeString(segment)
+		if i%2 == 1 {
+			out.WriteString("<code>" + escaped + "</code>")
+			continue
+		}
+		escaped = boldPattern.ReplaceAllString(escaped, "<strong>$1</strong>")
+		escaped = italicPattern.ReplaceAllString(escaped, "<em>$1</em>")
+		escaped = strikePattern.ReplaceAllString(escaped, "<del>$1</del>")
+		escaped = linkPattern.ReplaceAllStringFunc(escaped, renderLink)
+		out.WriteString(escaped)
+	}
+	return out.String()
+}
+
+func renderLink(match string) string {
+	parts := linkPattern.FindStringSubmatch(match)
+	text, href := parts[1], parts[2]
+	parsed, err := url.Parse(html.UnescapeString(href))
+	if err != nil {
+		return text
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https", "mailto":
+		return `<a href="` + href + `" rel="nofollow noopener">` + text + "</a>"
+	default:
+		return text
+	}
+}
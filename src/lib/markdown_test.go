@@ -0,0 +1,64 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	type testCase struct {
+		name     string
+		source   string
+		expected string
+	}
+
+	tests := []testCase{
+		{name: "Empty", source: "", expected: ""},
+		{name: "Paragraph", source: "Buy milk\nand eggs", expected: "<p>Buy milk\nand eggs</p>\n"},
+		{name: "Heading", source: "## Steps", expected: "<h2>Steps</h2>\n"},
+		{name: "Emphasis", source: "**bold** *italic* ~~gone~~", expected: "<p><strong>bold</strong> <em>italic</em> <del>gone</del></p>\n"},
+		{name: "Inline code", source: "run `go test **./...**`", expected: "<p>run <code>go test **./...**</code></p>\n"},
+		{name: "Code block", source: "```\n<b>x</b>\n```", expected: "<pre><code>&lt;b&gt;x&lt;/b&gt;</code></pre>\n"},
+		{name: "Bullet list", source: "- one\n- two", expected: "<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n"},
+		{name: "Ordered list", source: "1. one\n2. two", expected: "<ol>\n<li>one</li>\n<li>two</li>\n</ol>\n"},
+		{name: "Task list", source: "- [ ] todo\n- [x] done", expected: "<ul>\n<li><input type=\"checkbox\" disabled> todo</li>\n<li><input type=\"checkbox\" disabled checked> done</li>\n</ul>\n"},
+		{name: "Blockquote", source: "> quoted", expected: "<blockquote>\n<p>quoted</p>\n</blockquote>\n"},
+		{name: "Rule", source: "---", expected: "<hr>\n"},
+		{name: "Link", source: "[docs](https://example.com/a?b=1&c=2)", expected: "<p><a href=\"https://example.com/a?b=1&amp;c=2\" rel=\"nofollow noopener\">docs</a></p>\n"},
+		{name: "Script tag", source: "<script>alert(1)</script>", expected: "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{name: "Style tag", source: "<style>body{}</style>", expected: "<p>&lt;style&gt;body{}&lt;/style&gt;</p>\n"},
+		{name: "Event attribute", source: `<img src=x onerror="alert(1)">`, expected: "<p>&lt;img src=x onerror=&#34;alert(1)&#34;&gt;</p>\n"},
+		{name: "Javascript link", source: "[click](javascript:alert(1))", expected: "<p>click)</p>\n"},
+		{name: "Quote in link", source: `[x](https://a.com/"onmouseover="alert(1))`, expected: "<p><a href=\"https://a.com/&#34;onmouseover=&#34;alert(1\" rel=\"nofollow noopener\">x</a>)</p>\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, RenderMarkdown(tc.source))
+		})
+	}
+}
+
+func TestChecklistProgress(t *testing.T) {
+	type testCase struct {
+		name          string
+		source        string
+		expectedDone  int
+		expectedTotal int
+	}
+
+	tests := []testCase{
+		{name: "No checklist", source: "just notes\n- a bullet", expectedDone: 0, expectedTotal: 0},
+		{name: "Mixed items", source: "- [ ] one\n- [x] two\n* [X] three\n  + [ ] four", expectedDone: 2, expectedTotal: 4},
+		{name: "Items in code block", source: "```\n- [ ] not a task\n```\n- [x] task", expectedDone: 1, expectedTotal: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			done, total := ChecklistProgress(tc.source)
+			assert.Equal(t, tc.expectedDone, done)
+			assert.Equal(t, tc.expectedTotal, total)
+		})
+	}
+}
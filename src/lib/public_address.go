@@ -0,0 +1,12 @@
+package lib
+
+import "net"
+
+// PublicAddress tells whether ip may be reached on behalf of users, such as
+// by webhooks. Loopback, private, link-local, multicast and unspecified
+// addresses are refused, so users can not make the server call into its own
+// network.
+func PublicAddress(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
@@ -0,0 +1,147 @@
+package lib
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rruleDays are the iCalendar weekday codes, indexed by time.Weekday.
+var rruleDays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+var ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+
+// Recurrence is the subset of iCalendar RRULEs a todo can repeat by: a
+// frequency and interval, narrowed by weekdays, a day of the month (-1 for
+// the last one) and a month.
+type Recurrence struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay int
+	ByMonth    time.Month
+}
+
+// ParseRecurrence reads a rule such as "FREQ=WEEKLY;BYDAY=MO,WE". Parts
+// outside the supported subset, and rules that never occur, are rejected.
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	recurrence := &Recurrence{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.ToUpper(rule), "RRULE:"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" || seen[key] {
+			return nil, ErrInvalidRecurrence
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			switch value {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+				recurrence.Freq = value
+			default:
+				return nil, ErrInvalidRecurrence
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 || interval > 999 {
+				return nil, ErrInvalidRecurrence
+			}
+			recurrence.Interval = interval
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day := weekdayCode(code)
+				if day < 0 {
+					return nil, ErrInvalidRecurrence
+				}
+				recurrence.ByDay = append(recurrence.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			monthDay, err := strconv.Atoi(value)
+			if err != nil || monthDay < -1 || monthDay == 0 || monthDay > 31 {
+				return nil, ErrInvalidRecurrence
+			}
+			recurrence.ByMonthDay = monthDay
+		case "BYMONTH":
+			month, err := strconv.Atoi(value)
+			if err != nil || month < 1 || month > 12 {
+				return nil, ErrInvalidRecurrence
+			}
+			recurrence.ByMonth = time.Month(month)
+		default:
+			return nil, ErrInvalidRecurrence
+		}
+	}
+	if recurrence.Freq == "" || recurrence.First(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, ErrInvalidRecurrence
+	}
+	return recurrence, nil
+}
+
+func weekdayCode(code string) time.Weekday {
+	for day, dayCode := range rruleDays {
+		if dayCode == code {
+			return time.Weekday(day)
+		}
+	}
+	return -1
+}
+
+// String formats the rule the way it is stored, without an "RRULE:" prefix.
+func (r *Recurrence) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := []string{}
+		for _, day := range r.ByDay {
+			codes = append(codes, rruleDays[day])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.ByMonthDay != 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.ByMonthDay))
+	}
+	if r.ByMonth != 0 {
+		parts = append(parts, "BYMONTH="+strconv.Itoa(int(r.ByMonth)))
+	}
+	return strings.Join(parts, ";")
+}
+
+// First returns the first day on or after from that the rule occurs on, at
+// from's time of day. The interval only spaces later occurrences, so it does
+// not matter here.
+func (r *Recurrence) First(from time.Time) time.Time {
+	day := from
+	// Four years always include a 29th of February.
+	for i := 0; i < 4*366; i++ {
+		if r.matches(day) {
+			return day
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+func (r *Recurrence) matches(day time.Time) bool {
+	if r.ByMonth != 0 && day.Month() != r.ByMonth {
+		return false
+	}
+	if r.ByMonthDay == -1 && day.AddDate(0, 0, 1).Day() != 1 {
+		return false
+	}
+	if r.ByMonthDay > 0 && day.Day() != r.ByMonthDay {
+		return false
+	}
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, weekday := range r.ByDay {
+		if weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
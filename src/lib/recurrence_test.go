@@ -0,0 +1,72 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		name        string
+		rule        string
+		expected    string
+		shouldError bool
+	}{
+		{name: "Daily", rule: "FREQ=DAILY", expected: "FREQ=DAILY"},
+		{name: "Interval", rule: "FREQ=WEEKLY;INTERVAL=2", expected: "FREQ=WEEKLY;INTERVAL=2"},
+		{name: "Interval of one is dropped", rule: "FREQ=DAILY;INTERVAL=1", expected: "FREQ=DAILY"},
+		{name: "Weekdays", rule: "FREQ=WEEKLY;BYDAY=MO,WE,FR", expected: "FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		{name: "Lower case with prefix", rule: "rrule:freq=monthly;bymonthday=1", expected: "FREQ=MONTHLY;BYMONTHDAY=1"},
+		{name: "Parts in any order", rule: "BYMONTH=3;BYMONTHDAY=14;FREQ=YEARLY", expected: "FREQ=YEARLY;BYMONTHDAY=14;BYMONTH=3"},
+		{name: "Last day of the month", rule: "FREQ=MONTHLY;BYMONTHDAY=-1", expected: "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{name: "Leap day", rule: "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29", expected: "FREQ=YEARLY;BYMONTHDAY=29;BYMONTH=2"},
+		{name: "Empty", rule: "", shouldError: true},
+		{name: "Missing frequency", rule: "INTERVAL=2", shouldError: true},
+		{name: "Unknown frequency", rule: "FREQ=HOURLY", shouldError: true},
+		{name: "Zero interval", rule: "FREQ=DAILY;INTERVAL=0", shouldError: true},
+		{name: "Unknown weekday", rule: "FREQ=WEEKLY;BYDAY=XX", shouldError: true},
+		{name: "Ordinal weekday", rule: "FREQ=MONTHLY;BYDAY=1MO", shouldError: true},
+		{name: "Repeated part", rule: "FREQ=DAILY;FREQ=WEEKLY", shouldError: true},
+		{name: "Unsupported part", rule: "FREQ=DAILY;COUNT=3", shouldError: true},
+		{name: "Never occurs", rule: "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", shouldError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recurrence, err := ParseRecurrence(tt.rule)
+			if tt.shouldError {
+				assert.ErrorIs(t, err, ErrInvalidRecurrence)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, recurrence.String())
+		})
+	}
+}
+
+func TestRecurrenceFirst(t *testing.T) {
+	// A Wednesday.
+	from := time.Date(2024, 11, 27, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		rule     string
+		expected time.Time
+	}{
+		{rule: "FREQ=DAILY", expected: from},
+		{rule: "FREQ=WEEKLY;BYDAY=WE", expected: from},
+		{rule: "FREQ=WEEKLY;BYDAY=MO,FR", expected: time.Date(2024, 11, 29, 9, 0, 0, 0, time.UTC)},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=1", expected: time.Date(2024, 12, 1, 9, 0, 0, 0, time.UTC)},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=-1", expected: time.Date(2024, 11, 30, 9, 0, 0, 0, time.UTC)},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=31", expected: time.Date(2024, 12, 31, 9, 0, 0, 0, time.UTC)},
+		{rule: "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29", expected: time.Date(2028, 2, 29, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			recurrence, err := ParseRecurrence(tt.rule)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, recurrence.First(from))
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package lib
+
+import (
+	"regexp"
+	"sort"
+)
+
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// ExpandVariables replaces the {{name}} placeholders in text with their values.
+// Placeholders without a value are left as they are and their names returned,
+// sorted and without repeats.
+func ExpandVariables(text string, values map[string]string) (string, []string) {
+	missing := map[string]bool{}
+	expanded := variablePattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := variablePattern.FindStringSubmatch(placeholder)[1]
+		value, ok := values[name]
+		if !ok {
+			missing[name] = true
+			return placeholder
+		}
+		return value
+	})
+	names := []string{}
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return expanded, names
+}
@@ -0,0 +1,34 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandVariables(t *testing.T) {
+	type testCase struct {
+		name     string
+		text     string
+		values   map[string]string
+		expected string
+		missing  []string
+	}
+
+	tests := []testCase{
+		{name: "No variables", text: "Write release notes", expected: "Write release notes", missing: []string{}},
+		{name: "Variable", text: "Release {{version}}", values: map[string]string{"version": "1.4"}, expected: "Release 1.4", missing: []string{}},
+		{name: "Spaces", text: "Welcome {{ name }} to {{team}}", values: map[string]string{"name": "Ada", "team": "Ops"}, expected: "Welcome Ada to Ops", missing: []string{}},
+		{name: "Values are not expanded again", text: "{{a}}", values: map[string]string{"a": "{{b}}", "b": "x"}, expected: "{{b}}", missing: []string{}},
+		{name: "Missing", text: "{{team}} onboarding for {{name}}, {{name}}", values: map[string]string{}, expected: "{{team}} onboarding for {{name}}, {{name}}", missing: []string{"name", "team"}},
+		{name: "Not a placeholder", text: "{{ two words }} and {single}", expected: "{{ two words }} and {single}", missing: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expanded, missing := ExpandVariables(tt.text, tt.values)
+			assert.Equal(t, tt.expected, expanded)
+			assert.Equal(t, tt.missing, missing)
+		})
+	}
+}
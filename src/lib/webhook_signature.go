@@ -0,0 +1,20 @@
+package lib
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// SignWebhook signs a webhook body sent at timestamp with secret. The
+// signature is "sha256=" and the hex HMAC-SHA256 of the Unix timestamp, a
+// dot and the body, so receivers can refuse old deliveries replayed with a
+// valid signature.
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,23 @@
+package lib
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignWebhook(t *testing.T) {
+	timestamp := time.Unix(1733130000, 0)
+	body := []byte(`{"event":"todo.created"}`)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(`1733130000.{"event":"todo.created"}`))
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), SignWebhook("secret", timestamp, body))
+
+	assert.NotEqual(t, SignWebhook("secret", timestamp, body), SignWebhook("other", timestamp, body))
+	assert.NotEqual(t, SignWebhook("secret", timestamp, body), SignWebhook("secret", timestamp.Add(time.Second), body))
+}
@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"log"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers plain text emails.
+type Mailer interface {
+	Send(message *Message) error
+}
+
+var mailer Mailer
+
+func GetMailer() Mailer {
+	return mailer
+}
+
+func InitMailer(m Mailer) {
+	mailer = m
+}
+
+// LogMailer writes messages to the log instead of sending them. It is used
+// when no SMTP server is configured, e.g. in development.
+type LogMailer struct{}
+
+func (LogMailer) Send(message *Message) error {
+	log.Printf("Email to %s: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
@@ -0,0 +1,73 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPMailer sends messages through an SMTP server, authenticating with PLAIN
+// auth when Username is set.
+type SMTPMailer struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+
+	// send is overridden in tests to capture messages.
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func (s *SMTPMailer) Send(message *Message) error {
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+	data, err := buildMessage(from, to, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	send := s.send
+	if send == nil {
+		send = smtp.SendMail
+	}
+	return send(s.Addr, auth, from.Address, []string{to.Address}, data)
+}
+
+// buildMessage renders an RFC 5322 message. Header values are encoded, and
+// line breaks in them are refused so callers can not inject headers.
+func buildMessage(from *mail.Address, to *mail.Address, message *Message, date time.Time) ([]byte, error) {
+	if strings.ContainsAny(message.Subject, "\r\n") {
+		return nil, errors.New("subject must not contain line breaks")
+	}
+
+	var out strings.Builder
+	out.WriteString("From: " + from.String() + "\r\n")
+	out.WriteString("To: " + to.String() + "\r\n")
+	out.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", message.Subject) + "\r\n")
+	out.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	out.WriteString("MIME-Version: 1.0\r\n")
+	out.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	out.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	out.WriteString("\r\n")
+	out.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(out.String()), nil
+}
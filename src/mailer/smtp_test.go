@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMTPMailerSend(t *testing.T) {
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMessage []byte
+	var gotAuth smtp.Auth
+	mailer := &SMTPMailer{
+		Addr:     "mail.example.com:587",
+		From:     "Todos <todos@example.com>",
+		Username: "todos",
+		Password: "secret",
+		send: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			gotAddr, gotAuth, gotFrom, gotTo, gotMessage = addr, a, from, to, msg
+			return nil
+		},
+	}
+
+	err := mailer.Send(&Message{To: "friend@mail.com", Subject: "You are invited: Café", Body: "Line one\nLine two\n"})
+	assert.NoError(t, err)
+	assert.Equal(t, "mail.example.com:587", gotAddr)
+	assert.NotNil(t, gotAuth)
+	assert.Equal(t, "todos@example.com", gotFrom)
+	assert.Equal(t, []string{"friend@mail.com"}, gotTo)
+
+	message := string(gotMessage)
+	assert.Contains(t, message, "From: \"Todos\" <todos@example.com>\r\n")
+	assert.Contains(t, message, "To: <friend@mail.com>\r\n")
+	assert.Contains(t, message, "Subject: =?utf-8?q?You_are_invited:_Caf=C3=A9?=\r\n")
+	assert.True(t, strings.HasSuffix(message, "\r\n\r\nLine one\r\nLine two\r\n"))
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	mailer := &SMTPMailer{
+		Addr: "mail.example.com:25",
+		From: "todos@example.com",
+		send: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			t.Fatal("message should not be sent")
+			return nil
+		},
+	}
+
+	assert.Error(t, mailer.Send(&Message{To: "friend@mail.com", Subject: "Hi\r\nBcc: victim@mail.com"}))
+	assert.Error(t, mailer.Send(&Message{To: "friend@mail.com\r\nBcc: victim@mail.com", Subject: "Hi"}))
+}
@@ -1,10 +1,19 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	"time"
+	"todo-list/src/blobstore"
 	"todo-list/src/handler"
+	"todo-list/src/jobs"
+	"todo-list/src/mailer"
+	"todo-list/src/notifications"
+	"todo-list/src/realtime"
 	"todo-list/src/stores"
 
 	"github.com/gorilla/mux"
@@ -13,14 +22,170 @@ import (
 
 func routes() *mux.Router {
 	r := mux.NewRouter()
+	r.Use(handler.Idempotency)
+	r.Use(handler.InvalidateStats)
+	r.HandleFunc("/workspaces", handler.GetWorkspacesHandler).Methods("GET")
+	r.HandleFunc("/workspaces", handler.CreateWorkspaceHandler).Methods("POST")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/members", handler.GetWorkspaceMembersHandler).Methods("GET")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/members/{userID:[0-9]+}", handler.UpdateWorkspaceMemberHandler).Methods("PATCH")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/members/{userID:[0-9]+}", handler.RemoveWorkspaceMemberHandler).Methods("DELETE")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/invitations", handler.InviteWorkspaceMemberHandler).Methods("POST")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/projects", handler.GetProjectsHandler).Methods("GET")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/projects", handler.CreateProjectHandler).Methods("POST")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/projects/{projectID:[0-9]+}", handler.DeleteProjectHandler).Methods("DELETE")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/projects/{projectID:[0-9]+}/fields", handler.GetCustomFieldsHandler).Methods("GET")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/projects/{projectID:[0-9]+}/fields", handler.CreateCustomFieldHandler).Methods("POST")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/projects/{projectID:[0-9]+}/fields/{fieldID:[0-9]+}", handler.UpdateCustomFieldHandler).Methods("PUT")
+	r.HandleFunc("/workspaces/{id:[0-9]+}/projects/{projectID:[0-9]+}/fields/{fieldID:[0-9]+}", handler.DeleteCustomFieldHandler).Methods("DELETE")
+	r.HandleFunc("/imports/{id:[0-9]+}", handler.GetImportHandler).Methods("GET")
+	r.HandleFunc("/invitations/{token}/accept", handler.AcceptInvitationHandler).Methods("POST")
+	r.HandleFunc("/feeds/{token:[A-Za-z0-9_-]+}.ics", handler.GetCalendarFeedHandler).Methods("GET")
+	r.HandleFunc("/settings", handler.GetSettingsHandler).Methods("GET")
+	r.HandleFunc("/settings", handler.UpdateSettingsHandler).Methods("PUT")
+	r.HandleFunc("/views/today", handler.TodayViewHandler).Methods("GET")
+	r.HandleFunc("/views/upcoming", handler.UpcomingViewHandler).Methods("GET")
+	r.HandleFunc("/views/overdue", handler.OverdueViewHandler).Methods("GET")
+	r.HandleFunc("/filters", handler.GetSavedFiltersHandler).Methods("GET")
+	r.HandleFunc("/filters", handler.CreateSavedFilterHandler).Methods("POST")
+	r.HandleFunc("/filters/{id:[0-9]+}", handler.UpdateSavedFilterHandler).Methods("PUT")
+	r.HandleFunc("/filters/{id:[0-9]+}", handler.DeleteSavedFilterHandler).Methods("DELETE")
+	r.HandleFunc("/filters/{id:[0-9]+}/todos", handler.GetSavedFilterTodosHandler).Methods("GET")
+	r.HandleFunc("/templates", handler.GetTemplatesHandler).Methods("GET")
+	r.HandleFunc("/templates/{id:[0-9]+}", handler.GetTemplateHandler).Methods("GET")
+	r.HandleFunc("/templates/{id:[0-9]+}", handler.DeleteTemplateHandler).Methods("DELETE")
+	r.HandleFunc("/stream-tickets", handler.CreateStreamTicketHandler).Methods("POST")
+	r.HandleFunc("/events", handler.EventsHandler).Methods("GET")
+	r.HandleFunc("/ws", handler.WebSocketHandler).Methods("GET")
+	r.HandleFunc("/webhooks", handler.GetWebhooksHandler).Methods("GET")
+	r.HandleFunc("/webhooks/{id:[0-9]+}", handler.GetWebhookHandler).Methods("GET")
+	r.HandleFunc("/webhooks/{id:[0-9]+}", handler.UpdateWebhookHandler).Methods("PUT")
+	r.HandleFunc("/webhooks/{id:[0-9]+}", handler.DeleteWebhookHandler).Methods("DELETE")
+	r.HandleFunc("/webhooks/{id:[0-9]+}/deliveries", handler.GetWebhookDeliveriesHandler).Methods("GET")
+	r.HandleFunc("/webhooks/{id:[0-9]+}/deliveries/{deliveryID:[0-9]+}/redeliver", handler.RedeliverWebhookHandler).Methods("POST")
+	r.HandleFunc("/app-passwords", handler.GetAppPasswordsHandler).Methods("GET")
+	r.HandleFunc("/app-passwords", handler.CreateAppPasswordHandler).Methods("POST")
+	r.HandleFunc("/app-passwords/{id:[0-9]+}", handler.DeleteAppPasswordHandler).Methods("DELETE")
+	// CalDAV clients sign in with app passwords instead of a JWT.
+	r.Handle("/.well-known/caldav", http.RedirectHandler("/caldav/", http.StatusMovedPermanently))
+	r.PathPrefix("/caldav/").HandlerFunc(handler.CalDAVHandler)
+	r.HandleFunc("/users", handler.CreateUserHandler).Methods("POST")
+	r.HandleFunc("/users/login", handler.LoginUserHandler).Methods("POST")
+
+	// Todo routes work in the personal space or the workspace named by the
+	// X-Workspace-ID header, and in the workspace of the path when mounted
+	// under /workspaces/{workspaceID}.
+	todoRoutes(r)
+	todoRoutes(r.PathPrefix("/workspaces/{workspaceID:[0-9]+}").Subrouter())
+
+	return r
+}
+
+func todoRoutes(r *mux.Router) {
 	r.HandleFunc("/todos", handler.GetTodosHandler).Methods("GET")
 	r.HandleFunc("/todos", handler.CreateTodoHandler).Methods("POST")
+	r.HandleFunc("/todos/bulk", handler.BulkTodosHandler).Methods("POST")
+	r.HandleFunc("/todos/quick", handler.QuickAddHandler).Methods("POST")
+	r.HandleFunc("/todos/export", handler.GetExportHandler).Methods("GET")
 	r.HandleFunc("/todos/{id:[0-9]+}", handler.UpdateTodoHandler).Methods("PUT")
 	r.HandleFunc("/todos/{id:[0-9]+}", handler.DeleteTodoHandler).Methods("DELETE")
-	r.HandleFunc("/users", handler.CreateUserHandler).Methods("POST")
-	r.HandleFunc("/users/login", handler.LoginUserHandler).Methods("POST")
+	r.HandleFunc("/todos/{id:[0-9]+}/move", handler.MoveTodoHandler).Methods("POST")
+	r.HandleFunc("/todos/{id:[0-9]+}/assignees", handler.SetAssigneesHandler).Methods("PUT")
+	r.HandleFunc("/todos/{id:[0-9]+}/dependencies", handler.AddDependencyHandler).Methods("POST")
+	r.HandleFunc("/todos/{id:[0-9]+}/dependencies/{blockerID:[0-9]+}", handler.RemoveDependencyHandler).Methods("DELETE")
+	r.HandleFunc("/todos/{id:[0-9]+}/graph", handler.GetDependencyGraphHandler).Methods("GET")
+	r.HandleFunc("/todos/{id:[0-9]+}/attachments", handler.GetAttachmentsHandler).Methods("GET")
+	r.HandleFunc("/todos/{id:[0-9]+}/attachments", handler.CreateAttachmentHandler).Methods("POST")
+	r.HandleFunc("/todos/{id:[0-9]+}/attachments/{attachmentID:[0-9]+}", handler.DownloadAttachmentHandler).Methods("GET")
+	r.HandleFunc("/todos/{id:[0-9]+}/attachments/{attachmentID:[0-9]+}", handler.DeleteAttachmentHandler).Methods("DELETE")
+	r.HandleFunc("/todos/{id:[0-9]+}/comments", handler.GetCommentsHandler).Methods("GET")
+	r.HandleFunc("/todos/{id:[0-9]+}/comments", handler.CreateCommentHandler).Methods("POST")
+	r.HandleFunc("/todos/{id:[0-9]+}/activity", handler.GetActivityHandler).Methods("GET")
+	r.HandleFunc("/todos/{id:[0-9]+}/history", handler.GetHistoryHandler).Methods("GET")
+	r.HandleFunc("/todos/{id:[0-9]+}/revert/{revision:[0-9]+}", handler.RevertTodoHandler).Methods("POST")
+	r.HandleFunc("/todos/{id:[0-9]+}/shares", handler.GetSharesHandler).Methods("GET")
+	r.HandleFunc("/todos/{id:[0-9]+}/shares", handler.ShareTodoHandler).Methods("POST")
+	r.HandleFunc("/todos/{id:[0-9]+}/shares/{userID:[0-9]+}", handler.RevokeShareHandler).Methods("DELETE")
+	r.HandleFunc("/comments/{id:[0-9]+}", handler.UpdateCommentHandler).Methods("PATCH")
+	r.HandleFunc("/comments/{id:[0-9]+}", handler.DeleteCommentHandler).Methods("DELETE")
+	r.HandleFunc("/todos/{id:[0-9]+}/timer/start", handler.StartTimerHandler).Methods("POST")
+	r.HandleFunc("/todos/{id:[0-9]+}/timer/stop", handler.StopTimerHandler).Methods("POST")
+	r.HandleFunc("/todos/{id:[0-9]+}/time-entries", handler.GetTimeEntriesHandler).Methods("GET")
+	r.HandleFunc("/todos/{id:[0-9]+}/time-entries", handler.CreateTimeEntryHandler).Methods("POST")
+	r.HandleFunc("/time-entries/{id:[0-9]+}", handler.UpdateTimeEntryHandler).Methods("PATCH")
+	r.HandleFunc("/time-entries/{id:[0-9]+}", handler.DeleteTimeEntryHandler).Methods("DELETE")
+	r.HandleFunc("/timer", handler.GetTimerHandler).Methods("GET")
+	r.HandleFunc("/reports/time", handler.GetTimeReportHandler).Methods("GET")
+	r.HandleFunc("/stats", handler.GetStatsHandler).Methods("GET")
+	r.HandleFunc("/imports", handler.CreateImportHandler).Methods("POST")
+	r.HandleFunc("/templates", handler.CreateTemplateHandler).Methods("POST")
+	r.HandleFunc("/templates/{id:[0-9]+}/instantiate", handler.InstantiateTemplateHandler).Methods("POST")
+	r.HandleFunc("/webhooks", handler.CreateWebhookHandler).Methods("POST")
+	r.HandleFunc("/feed", handler.CreateCalendarFeedHandler).Methods("POST")
+	r.HandleFunc("/feed", handler.DeleteCalendarFeedHandler).Methods("DELETE")
+	r.HandleFunc("/trash", handler.GetTrashHandler).Methods("GET")
+	r.HandleFunc("/trash", handler.EmptyTrashHandler).Methods("DELETE")
+	r.HandleFunc("/trash/{id:[0-9]+}/restore", handler.RestoreTodoHandler).Methods("POST")
+}
 
-	return r
+// newBlobStore picks where attachment files are kept. BLOB_STORE=s3 uses an
+// S3-compatible bucket; anything else keeps files on the local disk.
+func newBlobStore() blobstore.Store {
+	if os.Getenv("BLOB_STORE") == "s3" {
+		return &blobstore.S3Store{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		}
+	}
+	root := os.Getenv("ATTACHMENTS_DIR")
+	if root == "" {
+		root = "attachments"
+	}
+	return &blobstore.LocalStore{Root: root}
+}
+
+// newMailer sends invitation emails through SMTP_ADDR when it is set and only
+// logs them otherwise.
+func newMailer() mailer.Mailer {
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		return mailer.LogMailer{}
+	}
+	return &mailer.SMTPMailer{
+		Addr:     addr,
+		From:     os.Getenv("SMTP_FROM"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+	}
+}
+
+// trashRetention is how long trashed todos are kept before they are purged.
+// TRASH_RETENTION takes a Go duration such as "720h".
+func trashRetention() time.Duration {
+	value := os.Getenv("TRASH_RETENTION")
+	if value == "" {
+		return jobs.DefaultTrashRetention
+	}
+	retention, err := time.ParseDuration(value)
+	if err != nil || retention <= 0 {
+		log.Fatalf("Invalid TRASH_RETENTION %q", value)
+	}
+	return retention
+}
+
+// streamOrigins are the origins of pages that may open WebSockets besides the
+// server's own. STREAM_ALLOWED_ORIGINS takes them separated by commas, such as
+// "https://app.example.com,https://admin.example.com".
+func streamOrigins() []string {
+	origins := []string{}
+	for _, origin := range strings.Split(os.Getenv("STREAM_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
 }
 
 // func handler(w http.ResponseWriter, r *http.Request) {
@@ -39,6 +204,19 @@ func main() {
 	}
 
 	stores.InitStore(&stores.DbStore{DB: db})
+	blobstore.InitStore(newBlobStore())
+	mailer.InitMailer(newMailer())
+	notifications.OnAssignment(notifications.EmailAssignee)
+	handler.AllowStreamOrigins(streamOrigins())
+	realtime.OnPublish(handler.DropStats)
+	go notifications.RunEmailQueue(context.Background())
+	go jobs.RunTrashPurge(context.Background(), trashRetention(), time.Hour)
+	go jobs.RunIdempotencyKeyPurge(context.Background(), time.Hour)
+	go jobs.RunWebhookDeliveries(context.Background(), 10*time.Second)
+	go jobs.RunWebhookDeliveryPurge(context.Background(), time.Hour)
+	go jobs.RunTodoEventPurge(context.Background(), time.Hour)
+	go jobs.RunStreamTicketPurge(context.Background(), time.Hour)
+	go realtime.ListenPostgres(context.Background(), connStirng)
 	r := routes()
 	log.Fatal(http.ListenAndServe(":8080", r))
 }
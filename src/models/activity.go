@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// TodoChange records one field of a todo being changed by a user.
+type TodoChange struct {
+	ID        int       `json:"id,omitempty"`
+	TodoID    int       `json:"todo_id"`
+	UserID    int       `json:"user_id"`
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Activity is one entry of a todo's timeline: either a comment or a change.
+type Activity struct {
+	Type    string      `json:"type"`
+	At      time.Time   `json:"at"`
+	UserID  int         `json:"user_id"`
+	Comment *Comment    `json:"comment,omitempty"`
+	Change  *TodoChange `json:"change,omitempty"`
+}
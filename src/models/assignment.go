@@ -0,0 +1,16 @@
+package models
+
+// Assignment replaces the assignees of a todo. AssigneeID is accepted as a
+// shorthand for a single assignee and is merged into AssigneeIDs.
+type Assignment struct {
+	AssigneeID  int   `json:"assignee_id,omitempty"`
+	AssigneeIDs []int `json:"assignee_ids" validate:"max=20,dive,gt=0"`
+}
+
+// AssigneeChanges reports who an assignment added and removed, along with
+// the todo as it is afterwards.
+type AssigneeChanges struct {
+	Todo       *Todo
+	Assigned   []*User
+	Unassigned []*User
+}
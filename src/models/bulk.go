@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+const (
+	BulkComplete   = "complete"
+	BulkUncomplete = "uncomplete"
+	BulkDelete     = "delete"
+	BulkMove       = "move"
+	BulkTag        = "tag"
+	BulkUntag      = "untag"
+	BulkSetDueDate = "set_due_date"
+)
+
+const (
+	// BulkAllOrNothing rolls every operation back when one item fails.
+	BulkAllOrNothing = "all_or_nothing"
+	// BulkBestEffort keeps the items that succeeded and reports the others.
+	BulkBestEffort = "best_effort"
+)
+
+const (
+	BulkOK         = "ok"
+	BulkFailed     = "failed"
+	BulkRolledBack = "rolled_back"
+)
+
+// BulkRequest applies a list of operations in one transaction. Mode defaults
+// to BulkAllOrNothing. Force allows completing todos with open blockers.
+type BulkRequest struct {
+	Operations []BulkOperation `json:"operations" validate:"required,min=1,max=50,dive"`
+	Mode       string          `json:"mode" validate:"omitempty,oneof=all_or_nothing best_effort"`
+	Force      bool            `json:"force"`
+}
+
+// BulkOperation targets either the listed IDs or every visible todo matching
+// Filter and Query, a filter expression as saved filters use. ProjectID is
+// used by move, where 0 takes todos out of their project and todos changing
+// project lose their custom field values, Tags by tag and untag, and DueDate
+// and DueAllDay by set_due_date, where leaving the date out clears it.
+type BulkOperation struct {
+	Op        string      `json:"op" validate:"required,oneof=complete uncomplete delete move tag untag set_due_date"`
+	IDs       []int       `json:"ids,omitempty" validate:"max=500,dive,gt=0"`
+	Filter    *TodoFilter `json:"filter,omitempty"`
+	Query     string      `json:"query,omitempty" validate:"max=1000"`
+	ProjectID *int        `json:"project_id,omitempty" validate:"omitempty,gte=0"`
+	Tags      []string    `json:"tags,omitempty" validate:"max=20,dive,required,max=50,excludesall=0x2C"`
+	DueDate   time.Time   `json:"due_date"`
+	DueAllDay bool        `json:"due_all_day,omitempty"`
+}
+
+// BulkResult is the outcome of one operation on one todo. Operation is the
+// index of the operation in the request.
+type BulkResult struct {
+	Operation int    `json:"operation"`
+	TodoID    int    `json:"todo_id"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+type BulkResponse struct {
+	Committed bool          `json:"committed"`
+	Results   []*BulkResult `json:"results"`
+}
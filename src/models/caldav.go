@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// AppPassword lets a user sign in to CalDAV with HTTP Basic auth, where
+// clients can not use a JWT. Password is only known when it is created and is
+// only returned in that response.
+type AppPassword struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"-"`
+	Name       string     `json:"name" validate:"required,max=100"`
+	Password   string     `json:"password,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// CalDAVResource keeps the name and UID a CalDAV client of the user gave a
+// todo it created. Other todos are served as {id}.ics with a UID made from
+// their id.
+type CalDAVResource struct {
+	TodoID int
+	UserID int
+	Name   string
+	UID    string
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type Comment struct {
+	ID        int        `json:"id,omitempty"`
+	TodoID    int        `json:"todo_id"`
+	UserID    int        `json:"user_id"`
+	Body      string     `json:"body" validate:"required,max=10000"`
+	BodyHTML  string     `json:"body_html,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	FieldText        = "text"
+	FieldNumber      = "number"
+	FieldDate        = "date"
+	FieldSelect      = "select"
+	FieldMultiSelect = "multi_select"
+	FieldURL         = "url"
+)
+
+// CustomField defines an extra attribute the todos of a project can hold,
+// such as an estimate or a customer. Todos keep their values in CustomFields
+// under Key, which never changes once the field is created. Options lists the
+// choices of select and multi-select fields.
+//
+// Required is enforced when todos are created with POST /todos or updated;
+// todos from quick add, templates, imports or calendar clients may lack a
+// value.
+type CustomField struct {
+	ID        int       `json:"id,omitempty"`
+	ProjectID int       `json:"project_id"`
+	Key       string    `json:"key" validate:"required,max=50,fieldkey"`
+	Name      string    `json:"name" validate:"required,max=100"`
+	Type      string    `json:"type" validate:"required,oneof=text number date select multi_select url"`
+	Options   []string  `json:"options,omitempty" validate:"max=100,dive,required,max=100"`
+	Required  bool      `json:"required"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CustomFieldMatch keeps the todos whose custom field Key holds Value, or
+// for multi-select fields, whose options include it.
+type CustomFieldMatch struct {
+	Key   string
+	Value any
+}
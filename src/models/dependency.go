@@ -0,0 +1,29 @@
+package models
+
+// Dependency makes a todo wait for BlockedBy to be completed.
+type Dependency struct {
+	BlockedBy int `json:"blocked_by"`
+}
+
+// DependencyGraph is the part of the dependency DAG around one todo: every
+// todo it transitively waits for and every todo transitively waiting for it.
+type DependencyGraph struct {
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []*GraphEdge `json:"edges"`
+}
+
+// GraphNode is a todo in a dependency graph. Todos the requesting user can
+// not see only keep their ID and are marked Hidden.
+type GraphNode struct {
+	ID        int    `json:"id"`
+	TaskName  string `json:"task_name,omitempty"`
+	Completed bool   `json:"completed"`
+	Blocked   bool   `json:"blocked"`
+	Hidden    bool   `json:"hidden,omitempty"`
+}
+
+// GraphEdge points from a todo to the todo blocking it.
+type GraphEdge struct {
+	TodoID    int `json:"todo_id"`
+	BlockedBy int `json:"blocked_by"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// Export formats. All but Markdown can be imported again with the import
+// format of the same name.
+const (
+	ExportCSV      = "csv"
+	ExportJSON     = "json"
+	ExportNDJSON   = "ndjson"
+	ExportMarkdown = "markdown"
+	ExportTodoTxt  = "todotxt"
+	ExportICal     = "ical"
+)
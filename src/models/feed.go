@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// CalendarFeed is a user's private iCalendar subscription to the todos of
+// the personal space or a workspace. Its Token is only known when the feed is
+// created; URL carries it and is only set in that response.
+type CalendarFeed struct {
+	UserID      int       `json:"-"`
+	WorkspaceID int       `json:"workspace_id,omitempty"`
+	Token       string    `json:"-"`
+	URL         string    `json:"url,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,111 @@
+package models
+
+import "time"
+
+// FilterNode is a filter expression such as "due < +3d and tag:work and not
+// completed", as read by filterexpr.Parse. Relative dates in it are already
+// resolved against the time it was read at.
+type FilterNode interface {
+	filterNode()
+}
+
+// FilterAnd matches todos both sides match.
+type FilterAnd struct {
+	Left  FilterNode
+	Right FilterNode
+}
+
+// FilterOr matches todos either side matches.
+type FilterOr struct {
+	Left  FilterNode
+	Right FilterNode
+}
+
+// FilterNot matches the todos Operand does not.
+type FilterNot struct {
+	Operand FilterNode
+}
+
+// Flags a filter can test on their own.
+const (
+	FilterCompleted = "completed"
+	FilterBlocked   = "blocked"
+	FilterRecurring = "recurring"
+	FilterNoDueDate = "no_due_date"
+)
+
+// FilterFlag matches todos with one of the Filter flags set.
+type FilterFlag struct {
+	Name string
+}
+
+// FilterTag matches todos with a tag; FilterText todos whose name or notes
+// contain Text, ignoring case.
+type FilterTag struct {
+	Tag string
+}
+
+type FilterText struct {
+	Text string
+}
+
+// FilterProject matches todos in a project, or outside any when ProjectID
+// is 0. FilterAssignee matches todos assigned to a user, or to nobody when
+// UserID is 0.
+type FilterProject struct {
+	ProjectID int
+}
+
+type FilterAssignee struct {
+	UserID int
+}
+
+// FilterPriority compares the priority with Value using Operator, one of
+// =, !=, <, <=, > and >=.
+type FilterPriority struct {
+	Operator string
+	Value    int
+}
+
+// Date fields a FilterDate can test.
+const (
+	FilterDue     = "due"
+	FilterCreated = "created"
+	FilterUpdated = "updated"
+)
+
+// FilterDate matches todos whose date Field falls in Range. For due dates the
+// range works like TodoFilter.Due; todos without one never match.
+type FilterDate struct {
+	Field string
+	Range DueRange
+}
+
+func (FilterAnd) filterNode()      {}
+func (FilterOr) filterNode()       {}
+func (FilterNot) filterNode()      {}
+func (FilterFlag) filterNode()     {}
+func (FilterTag) filterNode()      {}
+func (FilterText) filterNode()     {}
+func (FilterProject) filterNode()  {}
+func (FilterAssignee) filterNode() {}
+func (FilterPriority) filterNode() {}
+func (FilterDate) filterNode()     {}
+
+// SavedFilter is a filter expression a user named to list its todos again.
+// The query is read anew on every use, so relative dates like "+3d" move
+// with the days.
+type SavedFilter struct {
+	ID        int       `json:"id,omitempty"`
+	UserID    int       `json:"-"`
+	Name      string    `json:"name" validate:"required,max=100"`
+	Query     string    `json:"query" validate:"required,max=1000"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ViewDay holds the todos of a view due on one day in the user's timezone.
+type ViewDay struct {
+	Date  string  `json:"date"`
+	Todos []*Todo `json:"todos"`
+}
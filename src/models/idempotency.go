@@ -0,0 +1,24 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+// IdempotencyKeyTTL is how long an idempotency key is remembered. A retry
+// after that runs the request again.
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyKey is a client supplied key and the response of the first
+// request made with it. Fingerprint identifies that request so a reuse of the
+// key for a different one can be refused. StatusCode is 0 while the first
+// request is still running.
+type IdempotencyKey struct {
+	UserID      int
+	Key         string
+	Fingerprint string
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+	CreatedAt   time.Time
+}
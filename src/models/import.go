@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+const (
+	ImportCSV     = "csv"
+	ImportJSON    = "json"
+	ImportNDJSON  = "ndjson"
+	ImportTodoTxt = "todotxt"
+	ImportTodoist = "todoist"
+	ImportICal    = "ical"
+)
+
+const (
+	ImportPending   = "pending"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// ImportRow is one todo read from an import file. Line is the line or record
+// number in the file. Errors is keyed by field and set when the row can not be
+// imported.
+type ImportRow struct {
+	Line   int               `json:"line"`
+	Todo   *Todo             `json:"todo,omitempty"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// ImportError reports why one row of an import was skipped.
+type ImportError struct {
+	Line   int               `json:"line"`
+	Errors map[string]string `json:"errors"`
+}
+
+// Import tracks an import running in the background. Processed counts the
+// rows handled so far out of Total, each of them either Created or Failed.
+type Import struct {
+	ID          int           `json:"id"`
+	UserID      int           `json:"user_id"`
+	WorkspaceID int           `json:"workspace_id,omitempty"`
+	Format      string        `json:"format"`
+	FileName    string        `json:"file_name"`
+	Status      string        `json:"status"`
+	Total       int           `json:"total"`
+	Processed   int           `json:"processed"`
+	Created     int           `json:"created"`
+	Failed      int           `json:"failed"`
+	Errors      []ImportError `json:"errors"`
+	CreatedAt   time.Time     `json:"created_at"`
+	FinishedAt  *time.Time    `json:"finished_at,omitempty"`
+}
+
+// ImportPreview is the answer to a dry run: every row as it would be
+// imported, without creating anything.
+type ImportPreview struct {
+	Format  string       `json:"format"`
+	Total   int          `json:"total"`
+	Valid   int          `json:"valid"`
+	Invalid int          `json:"invalid"`
+	Rows    []*ImportRow `json:"rows"`
+}
@@ -0,0 +1,39 @@
+package models
+
+// Kinds of the parts a quick add is read into.
+const (
+	QuickAddText       = "text"
+	QuickAddDate       = "date"
+	QuickAddTime       = "time"
+	QuickAddRecurrence = "recurrence"
+	QuickAddTag        = "tag"
+	QuickAddPriority   = "priority"
+)
+
+// QuickAdd is a todo typed as one line, such as "Pay rent every month on the
+// 1st at 9am #finance !p1". Dates in it are read in Timezone, an IANA zone
+// that defaults to the user's.
+type QuickAdd struct {
+	Text      string `json:"text" validate:"required,max=1000"`
+	Timezone  string `json:"timezone,omitempty" validate:"omitempty,timezone"`
+	ProjectID int    `json:"project_id,omitempty"`
+}
+
+// QuickAddPart is a stretch of the text and what it was read as. Start and
+// End count characters, not bytes, so clients can highlight the part. Value
+// is the date, time, rule, tag or priority it stands for.
+type QuickAddPart struct {
+	Kind  string `json:"kind"`
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Value string `json:"value,omitempty"`
+}
+
+// QuickAddResult is the todo read from a quick add and how the text was split
+// up to get it. Errors is set when the todo would not be valid.
+type QuickAddResult struct {
+	Todo   *Todo             `json:"todo"`
+	Parts  []QuickAddPart    `json:"parts"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
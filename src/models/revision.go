@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+)
+
+// TodoSnapshot is the user editable state of a todo at one point in time.
+type TodoSnapshot struct {
+	TaskName   string    `json:"task_name"`
+	Completed  bool      `json:"completed"`
+	DueDate    time.Time `json:"due_date"`
+	DueAllDay  bool      `json:"due_all_day,omitempty"`
+	Notes      string    `json:"notes"`
+	Priority   int       `json:"priority,omitempty"`
+	Recurrence string    `json:"recurrence,omitempty"`
+
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
+}
+
+// Revision records one create, update, delete or restore of a todo. Before is
+// nil for creations and After for deletions. Changes lists the fields that
+// differ between the two snapshots.
+type Revision struct {
+	ID        int           `json:"id"`
+	TodoID    int           `json:"todo_id"`
+	UserID    int           `json:"user_id"`
+	Action    string        `json:"action"`
+	Before    *TodoSnapshot `json:"before"`
+	After     *TodoSnapshot `json:"after"`
+	Changes   []TodoChange  `json:"changes"`
+	CreatedAt time.Time     `json:"created_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// UserSettings are the preferences dates are read and shown with. Timezone
+// is an IANA zone, WeekStart the lower case name of the day weeks start on
+// and Locale a BCP 47 tag such as "de-DE" that clients format dates with.
+type UserSettings struct {
+	Timezone  string `json:"timezone" validate:"required,max=64,timezone"`
+	WeekStart string `json:"week_start" validate:"required,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	Locale    string `json:"locale" validate:"required,max=35,bcp47_language_tag"`
+}
+
+// DefaultSettings are the settings of users who never changed them.
+var DefaultSettings = UserSettings{Timezone: "UTC", WeekStart: "monday", Locale: "en-US"}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Location is the user's timezone. Users without settings, such as those
+// authenticated by an app password, and unknown zones get UTC.
+func (settings *UserSettings) Location() *time.Location {
+	if settings == nil || settings.Timezone == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// FirstWeekday is the day the user's weeks start on, Monday by default.
+func (settings *UserSettings) FirstWeekday() time.Weekday {
+	if settings != nil {
+		if day, ok := weekdays[settings.WeekStart]; ok {
+			return day
+		}
+	}
+	return time.Monday
+}
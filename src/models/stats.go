@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// StatsQuery selects the todos completed or created from From up to but not
+// including To. Days are counted in Timezone and Now decides which open todos
+// are overdue and whether the current streak is still alive.
+type StatsQuery struct {
+	From     time.Time
+	To       time.Time
+	Timezone string
+	Now      time.Time
+}
+
+// Stats summarises the completions of the todos in a space over a range of
+// days. From and To are the first and last day of the range.
+type Stats struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Completed int    `json:"completed"`
+	Created   int    `json:"created"`
+
+	// AverageLeadTimeSeconds is the mean time from creation to completion of
+	// the todos completed in the range.
+	AverageLeadTimeSeconds int64 `json:"average_lead_time_seconds"`
+
+	// Overdue counts the open todos past their due date now.
+	Overdue int `json:"overdue"`
+
+	// Streaks count consecutive days with at least one completion, over all
+	// time. The current streak survives a day without completions until the
+	// day is over.
+	CurrentStreak int `json:"current_streak"`
+	LongestStreak int `json:"longest_streak"`
+
+	Days     []*StatsDay       `json:"days"`
+	Projects []*StatsBreakdown `json:"projects"`
+	Tags     []*StatsBreakdown `json:"tags"`
+}
+
+// StatsDay is one day of the range, listed even without completions. Average
+// is the mean of completions over the seven days ending with it, counting
+// only days in the range.
+type StatsDay struct {
+	Date      string  `json:"date"`
+	Completed int     `json:"completed"`
+	Average   float64 `json:"average"`
+}
+
+// StatsBreakdown counts the todos of one project or tag. Todos outside any
+// project are counted under project 0.
+type StatsBreakdown struct {
+	ProjectID   int    `json:"project_id,omitempty"`
+	ProjectName string `json:"project_name,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+	Completed   int    `json:"completed"`
+	Open        int    `json:"open"`
+	Overdue     int    `json:"overdue"`
+}
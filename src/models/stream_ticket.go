@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// StreamTicket opens a single event stream for the user it was issued to.
+// Browsers can not set headers on EventSource and WebSocket requests, so it
+// is given as ?ticket instead, where a leaked copy is of no use.
+type StreamTicket struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+const (
+	// TemplateMaxTodos bounds the todos of a template, children included.
+	TemplateMaxTodos = 200
+	// TemplateMaxDepth bounds how deeply template items may nest.
+	TemplateMaxDepth = 5
+)
+
+// Template is a reusable tree of todos, such as an onboarding or release
+// checklist. Task names and notes may hold variables like {{version}} that
+// are filled in when the template is instantiated.
+type Template struct {
+	ID        int             `json:"id,omitempty"`
+	UserID    int             `json:"-"`
+	Name      string          `json:"name" validate:"required,max=100"`
+	Items     []*TemplateItem `json:"items" validate:"required,min=1,max=200,dive,required"`
+	CreatedAt time.Time       `json:"created_at"`
+
+	// ProjectID is only read when a template is created: the todos of that
+	// project are saved as its items.
+	ProjectID int `json:"project_id,omitempty"`
+}
+
+// TemplateItem is one todo of a template. DueOffsetDays sets its due date
+// that many days after the start date the template is instantiated with;
+// without it the todo has no due date. Children become todos blocking their
+// parent, so a parent can only be completed once its children are.
+type TemplateItem struct {
+	TaskName      string          `json:"task_name" validate:"required,max=255"`
+	Notes         string          `json:"notes,omitempty" validate:"max=20000"`
+	Priority      int             `json:"priority,omitempty" validate:"gte=0,lte=4"`
+	Tags          []string        `json:"tags,omitempty" validate:"max=20,dive,required,max=50,excludesall=0x2C"`
+	DueOffsetDays *int            `json:"due_offset_days,omitempty" validate:"omitempty,gte=-3650,lte=3650"`
+	Children      []*TemplateItem `json:"children,omitempty" validate:"max=200,dive,required"`
+}
+
+// TemplateInstantiation creates the todos of a template. StartDate is a date
+// such as 2024-05-01 in the user's timezone and defaults to today. Variables
+// fill in the {{name}} placeholders of the template.
+type TemplateInstantiation struct {
+	StartDate string            `json:"start_date"`
+	Variables map[string]string `json:"variables,omitempty" validate:"max=50,dive,max=255"`
+	ProjectID int               `json:"project_id,omitempty"`
+}
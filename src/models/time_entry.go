@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// TimeEntry is time a user spent on a todo. Entries without an end are
+// running timers; a user has at most one of them at a time.
+type TimeEntry struct {
+	ID        int        `json:"id,omitempty"`
+	TodoID    int        `json:"todo_id"`
+	UserID    int        `json:"user_id"`
+	StartedAt time.Time  `json:"started_at" validate:"required"`
+	EndedAt   *time.Time `json:"ended_at" validate:"required,gtfield=StartedAt"`
+	Note      string     `json:"note" validate:"max=1000"`
+
+	// Seconds is the length of the entry, up to now for running timers.
+	Seconds int64 `json:"seconds"`
+}
+
+// Time report groupings, which can be combined.
+const (
+	TimeByProject = "project"
+	TimeByTag     = "tag"
+	TimeByDay     = "day"
+)
+
+// TimeReportQuery asks for the time a user logged from From up to but not
+// including To, summed per combination of the GroupBy values. Entries count
+// on the day they started, in Timezone.
+type TimeReportQuery struct {
+	From     time.Time
+	To       time.Time
+	Timezone string
+	GroupBy  []string
+}
+
+// TimeReportRow is the time logged for one group of a report. Only the fields
+// the report is grouped by are set. Todos with several tags count towards
+// each of them, and untagged todos have an empty Tag.
+type TimeReportRow struct {
+	ProjectID   int    `json:"project_id,omitempty"`
+	ProjectName string `json:"project_name,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+	Day         string `json:"day,omitempty"`
+	Seconds     int64  `json:"seconds"`
+}
+
+// TimeReport is the answer to a TimeReportQuery.
+type TimeReport struct {
+	From         string           `json:"from"`
+	To           string           `json:"to"`
+	GroupBy      []string         `json:"group_by"`
+	TotalSeconds int64            `json:"total_seconds"`
+	Rows         []*TimeReportRow `json:"rows"`
+}
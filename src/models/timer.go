@@ -0,0 +1,6 @@
+package models
+
+// TimerStart is the optional body of a request starting a timer.
+type TimerStart struct {
+	Note string `json:"note" validate:"max=1000"`
+}
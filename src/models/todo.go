@@ -11,4 +11,99 @@ type Todo struct {
 	DueDate   time.Time `json:"due_date"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Position  string    `json:"position,omitempty"`
+	Role      string    `json:"role,omitempty"`
+	Notes     string    `json:"notes" validate:"max=20000"`
+
+	// DueAllDay marks a due date that is a whole day rather than an instant.
+	// Such dates are stored at midnight UTC and mean that calendar day in
+	// whatever timezone they are read.
+	DueAllDay bool `json:"due_all_day,omitempty"`
+
+	// Priority runs from 1 (low) to 4 (urgent); 0 means none.
+	Priority int `json:"priority,omitempty" validate:"gte=0,lte=4"`
+
+	// WorkspaceID is 0 for todos in the owner's personal space. ProjectID is
+	// only set for todos in a team project of that workspace.
+	WorkspaceID int `json:"workspace_id,omitempty"`
+	ProjectID   int `json:"project_id,omitempty"`
+
+	// AssigneeIDs lists the users responsible for the todo. It is changed
+	// through the assignees endpoint, not by updating the todo.
+	AssigneeIDs []int `json:"assignee_ids,omitempty"`
+
+	// Blocked is set while any todo this one depends on is still open.
+	Blocked bool `json:"blocked"`
+
+	// Tags are lower case labels without the leading "#". They can be given
+	// when the todo is created; later changes go through bulk operations.
+	Tags []string `json:"tags,omitempty" validate:"max=20,dive,required,max=50,excludesall=0x2C"`
+
+	// Recurrence is an iCalendar RRULE such as "FREQ=WEEKLY;BYDAY=MO" for
+	// todos that repeat; lib.ParseRecurrence lists the parts supported.
+	Recurrence string `json:"recurrence,omitempty" validate:"omitempty,max=200,rrule"`
+
+	// TrackedSeconds is the time logged on the todo by all users, running
+	// timers included up to now. It is changed through time entries.
+	TrackedSeconds int64 `json:"tracked_seconds,omitempty"`
+
+	// CompletedAt is when the todo was last completed, kept by the database
+	// as Completed changes.
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	// CustomFields holds the values of the custom fields of the todo's
+	// project by field key: strings, numbers, dates as 2024-05-01 and lists of
+	// options for multi-select fields. An update replaces all of them when
+	// given and leaves them alone otherwise; null values are dropped.
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
+
+	// DeletedAt is only returned for todos listed in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// Derived from Notes when the todo is returned; never stored.
+	NotesHTML      string `json:"notes_html,omitempty"`
+	ChecklistDone  int    `json:"checklist_done,omitempty"`
+	ChecklistTotal int    `json:"checklist_total,omitempty"`
+}
+
+// TodoMove anchors a todo between two of its siblings. After is the todo that
+// should come directly before the moved one and Before the one directly after
+// it; either may be left out to move to the start or end of the list.
+type TodoMove struct {
+	Before int `json:"before,omitempty"`
+	After  int `json:"after,omitempty"`
+}
+
+// TodoFilter narrows the todos returned by GetTodos or changed by a bulk
+// operation. Zero fields do not filter.
+type TodoFilter struct {
+	AssigneeID int    `json:"assignee_id,omitempty"`
+	Completed  *bool  `json:"completed,omitempty"`
+	ProjectID  int    `json:"project_id,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	IDs        []int  `json:"ids,omitempty"`
+
+	// Due keeps the todos due within a range, such as today in the user's
+	// timezone.
+	Due *DueRange `json:"-"`
+
+	// Expression keeps the todos a filter expression matches.
+	Expression FilterNode `json:"-"`
+
+	// Fields keeps the todos whose custom fields hold all the values given.
+	Fields []CustomFieldMatch `json:"-"`
+
+	// SortField orders the todos by the value of that custom field, todos
+	// without one last, ahead of their list order.
+	SortField      string `json:"-"`
+	SortDescending bool   `json:"-"`
+}
+
+// DueRange matches todos due from From up to but not including To; a zero
+// bound leaves that side open. Timed todos are compared as instants and
+// all-day todos by their date against the calendar days of From and To in
+// their location, so a todo due all day today is due today but not overdue.
+type DueRange struct {
+	From time.Time
+	To   time.Time
 }
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventReset tells a stream client that events it missed are no longer in
+// the log, so it has to fetch its todos again before relying on the stream.
+const EventReset = "reset"
+
+const (
+	// EventLogRetention is how long todo events are kept for clients resuming
+	// a stream.
+	EventLogRetention = 24 * time.Hour
+	// EventReplayLimit is how many missed events a resuming client is sent
+	// before it is told to reset instead.
+	EventReplayLimit = 1000
+	// EventResumeWindow is how many event IDs below the last one seen are
+	// read again when resuming. IDs are handed out when an event is logged,
+	// not when its transaction commits, so an event can turn up after ones
+	// with higher IDs. Resumed streams may repeat events within the window;
+	// their IDs tell them apart.
+	EventResumeWindow = 100
+)
+
+// TodoEvent is a todo created, updated or deleted, as sent on the change
+// streams. Its type is one of todo.created, todo.updated and todo.deleted; a
+// todo restored from the trash is created again. UserIDs are the users who
+// could see the todo when it changed, who are the only ones it is sent to.
+type TodoEvent struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	TodoID      int             `json:"todo_id,omitempty"`
+	WorkspaceID int             `json:"workspace_id,omitempty"`
+	Todo        json.RawMessage `json:"todo,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UserIDs     []int           `json:"-"`
+}
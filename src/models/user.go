@@ -5,4 +5,7 @@ type User struct {
 	UserName string `json:"username"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password,omitempty" validate:"required,min=8"`
+
+	// Settings are read with the user; they are changed through /settings.
+	Settings *UserSettings `json:"settings,omitempty" validate:"-"`
 }
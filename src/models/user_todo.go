@@ -1,6 +1,33 @@
 package models
 
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleOwner  = "owner"
+)
+
+var roleRanks = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// RoleAtLeast reports whether role grants everything minimum does.
+func RoleAtLeast(role string, minimum string) bool {
+	return roleRanks[role] >= roleRanks[minimum] && roleRanks[role] > 0
+}
+
 type UserTodo struct {
-	UserId int `json:"user_id"`
-	TodoId int `json:"todo_id"`
+	UserId int    `json:"user_id"`
+	TodoId int    `json:"todo_id"`
+	Role   string `json:"role"`
+}
+
+// Share is a user's access to a todo as listed by the shares endpoint.
+type Share struct {
+	UserID   int    `json:"user_id"`
+	UserName string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+type ShareRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=viewer editor owner"`
 }
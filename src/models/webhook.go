@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Events a webhook can subscribe to. A todo completed by an update sends both
+// todo.updated and todo.completed; a todo restored from the trash is sent as
+// todo.created again.
+const (
+	EventTodoCreated   = "todo.created"
+	EventTodoUpdated   = "todo.updated"
+	EventTodoCompleted = "todo.completed"
+	EventTodoDeleted   = "todo.deleted"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+const (
+	// WebhookMaxAttempts is how often a delivery is tried before it fails.
+	WebhookMaxAttempts = 8
+	// WebhookDisableAfter is how many failed attempts in a row, across all
+	// deliveries, disable a webhook.
+	WebhookDisableAfter = 20
+	// WebhookDeliveryRetention is how long finished deliveries stay in the
+	// log.
+	WebhookDeliveryRetention = 30 * 24 * time.Hour
+)
+
+// Webhook posts the events of the todos its user can see in the personal
+// space or a workspace to URL. Secret signs every delivery and is only known
+// when the webhook is created. A webhook failing WebhookDisableAfter times in
+// a row is disabled until it is updated with active set again.
+type Webhook struct {
+	ID           int        `json:"id,omitempty"`
+	UserID       int        `json:"-"`
+	WorkspaceID  int        `json:"workspace_id,omitempty"`
+	URL          string     `json:"url" validate:"required,max=2000,url"`
+	Events       []string   `json:"events" validate:"required,min=1,max=4,dive,oneof=todo.created todo.updated todo.completed todo.deleted"`
+	Secret       string     `json:"secret,omitempty"`
+	Active       bool       `json:"active"`
+	FailureCount int        `json:"failure_count"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// WebhookDelivery is one event queued for a webhook, with the outcome of its
+// last attempt. Payload is the JSON body sent: the event and the todo.
+type WebhookDelivery struct {
+	ID             int             `json:"id"`
+	WebhookID      int             `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+
+	// URL and Secret of the webhook, only read for deliveries being sent.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type Workspace struct {
+	ID        int       `json:"id,omitempty"`
+	Name      string    `json:"name" validate:"required,max=255"`
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WorkspaceMember is a user's membership as listed by the members endpoint.
+// Updates only read Role.
+type WorkspaceMember struct {
+	UserID   int    `json:"user_id"`
+	UserName string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role" validate:"required,oneof=viewer editor owner"`
+}
+
+// Invitation asks the owner of Email to join a workspace with Role. Token is
+// only known when the invitation is created and is sent by email, never in a
+// response.
+type Invitation struct {
+	ID          int       `json:"id,omitempty"`
+	WorkspaceID int       `json:"workspace_id"`
+	Email       string    `json:"email" validate:"required,email"`
+	Role        string    `json:"role" validate:"required,oneof=viewer editor owner"`
+	InvitedBy   int       `json:"invited_by"`
+	Token       string    `json:"-"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Project struct {
+	ID          int       `json:"id,omitempty"`
+	WorkspaceID int       `json:"workspace_id"`
+	Name        string    `json:"name" validate:"required,max=255"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// MemberProject is a project of a workspace the user is a member of, with the
+// workspace's name and the user's role in it.
+type MemberProject struct {
+	Project
+	WorkspaceName string
+	Role          string
+}
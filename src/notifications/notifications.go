@@ -0,0 +1,81 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"todo-list/src/mailer"
+	"todo-list/src/models"
+)
+
+// AssignmentEvent describes a user being assigned to or unassigned from a
+// todo by another user.
+type AssignmentEvent struct {
+	Todo       *models.Todo
+	Assignee   *models.User
+	AssignedBy *models.User
+	Assigned   bool
+}
+
+type AssignmentHook func(event *AssignmentEvent)
+
+var assignmentHooks []AssignmentHook
+
+// OnAssignment registers a hook to run for every assignment change. Hooks are
+// registered at startup and run in order on the request's goroutine.
+func OnAssignment(hook AssignmentHook) {
+	assignmentHooks = append(assignmentHooks, hook)
+}
+
+func NotifyAssignment(event *AssignmentEvent) {
+	for _, hook := range assignmentHooks {
+		hook(event)
+	}
+}
+
+// EmailAssignee is an assignment hook telling the assignee about the change.
+// Nobody is emailed about assigning themselves. The email is queued for
+// RunEmailQueue, so a slow mail server never holds up the request.
+func EmailAssignee(event *AssignmentEvent) {
+	if event.Assignee.ID == event.AssignedBy.ID {
+		return
+	}
+
+	message := &mailer.Message{To: event.Assignee.Email}
+	if event.Assigned {
+		message.Subject = fmt.Sprintf("You were assigned to %q", event.Todo.TaskName)
+		message.Body = fmt.Sprintf("%s assigned you to %q (todo %d).\n", event.AssignedBy.UserName, event.Todo.TaskName, event.Todo.ID)
+	} else {
+		message.Subject = fmt.Sprintf("You were unassigned from %q", event.Todo.TaskName)
+		message.Body = fmt.Sprintf("%s removed you from %q (todo %d).\n", event.AssignedBy.UserName, event.Todo.TaskName, event.Todo.ID)
+	}
+	queueEmail(message)
+}
+
+// emailQueueSize is how many emails can wait to be sent before new ones are
+// dropped.
+const emailQueueSize = 1000
+
+var emailQueue = make(chan *mailer.Message, emailQueueSize)
+
+func queueEmail(message *mailer.Message) {
+	select {
+	case emailQueue <- message:
+	default:
+		log.Printf("Email queue is full, dropping email to %s", message.To)
+	}
+}
+
+// RunEmailQueue sends the queued emails one at a time until ctx is done.
+func RunEmailQueue(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message := <-emailQueue:
+			if err := mailer.GetMailer().Send(message); err != nil {
+				log.Printf("Can not email %s: %v", message.To, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package notifications
+
+import (
+	"context"
+	"testing"
+	"time"
+	"todo-list/src/mailer"
+	"todo-list/src/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifyAssignmentRunsHooksInOrder(t *testing.T) {
+	defer func() { assignmentHooks = nil }()
+
+	calls := []string{}
+	OnAssignment(func(event *AssignmentEvent) { calls = append(calls, "first") })
+	OnAssignment(func(event *AssignmentEvent) { calls = append(calls, "second") })
+	NotifyAssignment(&AssignmentEvent{})
+
+	assert.Equal(t, []string{"first", "second"}, calls)
+}
+
+func TestEmailAssignee(t *testing.T) {
+	todo := &models.Todo{ID: 5, TaskName: "Ship it"}
+	alice := &models.User{ID: 1, UserName: "alice", Email: "alice@mail.com"}
+	bob := &models.User{ID: 2, UserName: "bob", Email: "bob@mail.com"}
+
+	EmailAssignee(&AssignmentEvent{Todo: todo, Assignee: bob, AssignedBy: alice, Assigned: true})
+	EmailAssignee(&AssignmentEvent{Todo: todo, Assignee: bob, AssignedBy: alice, Assigned: false})
+	EmailAssignee(&AssignmentEvent{Todo: todo, Assignee: alice, AssignedBy: alice, Assigned: true})
+
+	assert.Len(t, emailQueue, 2)
+	assert.Equal(t, &mailer.Message{To: "bob@mail.com", Subject: `You were assigned to "Ship it"`, Body: "alice assigned you to \"Ship it\" (todo 5).\n"}, <-emailQueue)
+	assert.Equal(t, &mailer.Message{To: "bob@mail.com", Subject: `You were unassigned from "Ship it"`, Body: "alice removed you from \"Ship it\" (todo 5).\n"}, <-emailQueue)
+}
+
+type channelMailer chan *mailer.Message
+
+func (m channelMailer) Send(message *mailer.Message) error {
+	m <- message
+	return nil
+}
+
+func TestRunEmailQueue(t *testing.T) {
+	mails := make(channelMailer)
+	mailer.InitMailer(mails)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go RunEmailQueue(ctx)
+
+	message := &mailer.Message{To: "bob@mail.com", Subject: "Hi"}
+	queueEmail(message)
+	select {
+	case sent := <-mails:
+		assert.Equal(t, message, sent)
+	case <-time.After(time.Second):
+		t.Fatal("The queued email was not sent")
+	}
+}
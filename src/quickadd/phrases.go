@@ -0,0 +1,514 @@
+package quickadd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+var monthNames = map[string]time.Month{
+	"january": time.January, "jan": time.January,
+	"february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April,
+	"may":  time.May,
+	"june": time.June, "jun": time.June,
+	"july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September,
+	"october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November,
+	"december": time.December, "dec": time.December,
+}
+
+var numberWords = map[string]int{"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10}
+
+// frequencies maps the units "every" counts in to RRULE frequencies.
+var frequencies = map[string]string{"day": "DAILY", "week": "WEEKLY", "month": "MONTHLY", "year": "YEARLY"}
+
+// priorities follows the convention of other task apps, where p1 is the most
+// urgent; it is priority 4 here.
+var priorities = map[string]int{"!p1": 4, "!p2": 3, "!p3": 2, "!p4": 1, "!urgent": 4, "!high": 3, "!medium": 2, "!low": 1}
+
+var (
+	clockPattern = regexp.MustCompile(`^(\d{1,2})(?:[:.](\d{2}))?(am|pm|a|p)?$`)
+	dayPattern   = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)?$`)
+	isoPattern   = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	yearPattern  = regexp.MustCompile(`^(19|20)\d\d$`)
+)
+
+// matchTag reads "#tag".
+func matchTag(p *parser, words []string) (int, string) {
+	tag := strings.TrimPrefix(words[0], "#")
+	if tag == words[0] || tag == "" || strings.HasPrefix(tag, "#") {
+		return 0, ""
+	}
+	p.tags = append(p.tags, tag)
+	return 1, tag
+}
+
+// matchPriority reads "!p1" to "!p4" or "!urgent", "!high", "!medium" and
+// "!low".
+func matchPriority(p *parser, words []string) (int, string) {
+	priority, ok := priorities[words[0]]
+	if !ok {
+		return 0, ""
+	}
+	p.priority = priority
+	return 1, strconv.Itoa(priority)
+}
+
+// matchTime reads "9am", "9:30pm", "17:00", "noon" and "midnight", optionally
+// after "at", which also allows a bare hour as in "at 17".
+func matchTime(p *parser, words []string) (int, string) {
+	n, c, ok := 0, clock{}, false
+	if words[0] == "at" || words[0] == "@" {
+		if len(words) > 1 {
+			n, c, ok = readClock(words[1:], true)
+			n++
+		}
+	} else {
+		n, c, ok = readClock(words, false)
+	}
+	if !ok {
+		return 0, ""
+	}
+	p.clock = &c
+	return n, fmt.Sprintf("%02d:%02d", c.hour, c.minute)
+}
+
+func readClock(words []string, bare bool) (int, clock, bool) {
+	switch words[0] {
+	case "noon":
+		return 1, clock{hour: 12}, true
+	case "midnight":
+		return 1, clock{}, true
+	}
+	match := clockPattern.FindStringSubmatch(words[0])
+	if match == nil {
+		return 0, clock{}, false
+	}
+	n, suffix := 1, match[3]
+	if suffix == "" && len(words) > 1 && (words[1] == "am" || words[1] == "pm") {
+		n, suffix = 2, words[1]
+	}
+	hour, _ := strconv.Atoi(match[1])
+	minute := 0
+	if match[2] != "" {
+		minute, _ = strconv.Atoi(match[2])
+	}
+	if minute > 59 {
+		return 0, clock{}, false
+	}
+
+	switch {
+	case suffix != "":
+		if hour < 1 || hour > 12 {
+			return 0, clock{}, false
+		}
+		hour %= 12
+		if suffix[0] == 'p' {
+			hour += 12
+		}
+	case match[2] != "" || bare:
+		if hour > 23 {
+			return 0, clock{}, false
+		}
+	default:
+		return 0, clock{}, false
+	}
+	return n, clock{hour: hour, minute: minute}, true
+}
+
+// matchDate reads a day: "today", "tomorrow", weekdays, "next week", "in 3
+// days", "Dec 1", "1st of December 2025", "2024-12-01" and, after "on", "the
+// 15th". "on", "due" and "by" may lead any of them. "in 2 hours" sets the time
+// as well.
+func matchDate(p *parser, words []string) (int, string) {
+	switch words[0] {
+	case "on", "due", "by":
+		if len(words) == 1 {
+			return 0, ""
+		}
+		if n, value := matchDate(p, words[1:]); n > 0 {
+			return n + 1, value
+		}
+		if n, date := readDayOfMonth(p.today(), words[1:]); n > 0 {
+			p.date = date
+			return n + 1, date.Format("2006-01-02")
+		}
+		return 0, ""
+	case "in":
+		return matchOffset(p, words)
+	}
+
+	n, date := p.readDate(words)
+	if n == 0 {
+		return 0, ""
+	}
+	p.date = date
+	return n, date.Format("2006-01-02")
+}
+
+// matchOffset reads "in 3 days", "in a week" or "in 2 hours".
+func matchOffset(p *parser, words []string) (int, string) {
+	if len(words) < 3 {
+		return 0, ""
+	}
+	amount, ok := readAmount(words[1])
+	if !ok {
+		return 0, ""
+	}
+	today := p.today()
+	switch strings.TrimSuffix(words[2], "s") {
+	case "day":
+		p.date = today.AddDate(0, 0, amount)
+	case "week":
+		p.date = today.AddDate(0, 0, 7*amount)
+	case "month":
+		p.date = today.AddDate(0, amount, 0)
+	case "year":
+		p.date = today.AddDate(amount, 0, 0)
+	case "hour", "hr", "minute", "min":
+		if p.read[models.QuickAddTime] {
+			return 0, ""
+		}
+		unit := time.Hour
+		if strings.HasPrefix(words[2], "min") {
+			unit = time.Minute
+		}
+		at := p.now.Add(time.Duration(amount) * unit)
+		p.date = time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+		p.clock = &clock{hour: at.Hour(), minute: at.Minute()}
+		p.read[models.QuickAddTime] = true
+		return 3, at.Format("2006-01-02T15:04")
+	default:
+		return 0, ""
+	}
+	return 3, p.date.Format("2006-01-02")
+}
+
+func (p *parser) readDate(words []string) (int, time.Time) {
+	today := p.today()
+	switch words[0] {
+	case "today", "tod":
+		return 1, today
+	case "tomorrow", "tmr", "tmrw":
+		return 1, today.AddDate(0, 0, 1)
+	case "weekend":
+		return 1, nextWeekday(today, time.Saturday, 0)
+	case "this":
+		if len(words) > 1 && words[1] == "weekend" {
+			return 2, nextWeekday(today, time.Saturday, 0)
+		}
+		if len(words) > 1 {
+			if day, ok := weekdayNames[words[1]]; ok {
+				return 2, nextWeekday(today, day, 0)
+			}
+		}
+		return 0, time.Time{}
+	case "next":
+		if len(words) == 1 {
+			return 0, time.Time{}
+		}
+		nextWeek := lib.StartOfWeek(today, p.weekStart).AddDate(0, 0, 7)
+		switch words[1] {
+		case "week":
+			return 2, nextWeek
+		case "month":
+			return 2, time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, today.Location())
+		case "year":
+			return 2, time.Date(today.Year()+1, time.January, 1, 0, 0, 0, 0, today.Location())
+		}
+		if day, ok := weekdayNames[words[1]]; ok {
+			return 2, nextWeekday(nextWeek, day, 0)
+		}
+		return 0, time.Time{}
+	}
+
+	if day, ok := weekdayNames[words[0]]; ok {
+		return 1, nextWeekday(today, day, 1)
+	}
+	if isoPattern.MatchString(words[0]) {
+		if date, err := time.ParseInLocation("2006-01-02", words[0], today.Location()); err == nil {
+			return 1, date
+		}
+		return 0, time.Time{}
+	}
+
+	n, month, day := readMonthDay(words)
+	if n == 0 {
+		return 0, time.Time{}
+	}
+	if len(words) > n && yearPattern.MatchString(words[n]) {
+		year, _ := strconv.Atoi(words[n])
+		date := time.Date(year, month, day, 0, 0, 0, 0, today.Location())
+		if date.Day() != day {
+			return 0, time.Time{}
+		}
+		return n + 1, date
+	}
+	// Without a year the date is the next one to come, which for the 29th
+	// of February may be years away.
+	for year := today.Year(); year <= today.Year()+4; year++ {
+		date := time.Date(year, month, day, 0, 0, 0, 0, today.Location())
+		if date.Day() == day && !date.Before(today) {
+			return n, date
+		}
+	}
+	return 0, time.Time{}
+}
+
+// readDayOfMonth reads "the 15th", the next day of the month with that
+// number.
+func readDayOfMonth(today time.Time, words []string) (int, time.Time) {
+	if len(words) < 2 || words[0] != "the" {
+		return 0, time.Time{}
+	}
+	day, ok := readDay(words[1])
+	if !ok {
+		return 0, time.Time{}
+	}
+	for month := 0; month < 12; month++ {
+		date := time.Date(today.Year(), today.Month()+time.Month(month), day, 0, 0, 0, 0, today.Location())
+		if date.Day() == day && !date.Before(today) {
+			return 2, date
+		}
+	}
+	return 0, time.Time{}
+}
+
+// readMonthDay reads "Dec 1", "December 1st", "1 Dec" or "1st of December".
+func readMonthDay(words []string) (int, time.Month, int) {
+	if len(words) < 2 {
+		return 0, 0, 0
+	}
+	if month, ok := monthNames[words[0]]; ok {
+		if day, ok := readDay(words[1]); ok {
+			return 2, month, day
+		}
+		return 0, 0, 0
+	}
+	day, ok := readDay(words[0])
+	if !ok {
+		return 0, 0, 0
+	}
+	if month, ok := monthNames[words[1]]; ok {
+		return 2, month, day
+	}
+	if len(words) > 2 && words[1] == "of" {
+		if month, ok := monthNames[words[2]]; ok {
+			return 3, month, day
+		}
+	}
+	return 0, 0, 0
+}
+
+// readDay reads a day of the month, "15" or "15th".
+func readDay(word string) (int, bool) {
+	match := dayPattern.FindStringSubmatch(word)
+	if match == nil {
+		return 0, false
+	}
+	day, _ := strconv.Atoi(match[1])
+	return day, day >= 1 && day <= 31
+}
+
+// readOrdinal reads a day of the month written "15th". Bare numbers are left
+// alone, as in "every 2 weeks".
+func readOrdinal(word string) (int, bool) {
+	match := dayPattern.FindStringSubmatch(word)
+	if match == nil || match[2] == "" {
+		return 0, false
+	}
+	return readDay(word)
+}
+
+func readAmount(word string) (int, bool) {
+	if amount, ok := numberWords[word]; ok {
+		return amount, true
+	}
+	amount, err := strconv.Atoi(word)
+	return amount, err == nil && amount > 0 && amount < 1000
+}
+
+// nextWeekday returns the first day on the given weekday that is at least
+// skip days after from.
+func nextWeekday(from time.Time, day time.Weekday, skip int) time.Time {
+	days := (int(day) - int(from.Weekday()) - skip + 14) % 7
+	return from.AddDate(0, 0, skip+days)
+}
+
+// matchRecurrence reads "daily", "every 2 weeks", "every other month",
+// "every weekday", "every Mon and Thu", "every 15th", "every last day", "every
+// Mar 14" and a frequency followed by the day it repeats on, as in "every
+// month on the 1st".
+func matchRecurrence(p *parser, words []string) (int, string) {
+	recurrence := &lib.Recurrence{Interval: 1}
+	n := 0
+	switch words[0] {
+	case "daily", "everyday":
+		recurrence.Freq, n = "DAILY", 1
+	case "weekly":
+		recurrence.Freq, n = "WEEKLY", 1
+	case "monthly":
+		recurrence.Freq, n = "MONTHLY", 1
+	case "yearly", "annually":
+		recurrence.Freq, n = "YEARLY", 1
+	case "every":
+		n = readEvery(words[1:], recurrence)
+		if n == 0 {
+			return 0, ""
+		}
+		n++
+	default:
+		return 0, ""
+	}
+	n += readRecurrenceDay(words[n:], recurrence)
+	p.recurrence = recurrence
+	return n, recurrence.String()
+}
+
+// readEvery reads what follows "every".
+func readEvery(words []string, recurrence *lib.Recurrence) int {
+	if len(words) == 0 {
+		return 0
+	}
+	if freq, ok := frequencies[words[0]]; ok {
+		recurrence.Freq = freq
+		return 1
+	}
+	if len(words) > 1 {
+		freq, ok := frequencies[strings.TrimSuffix(words[1], "s")]
+		if words[0] == "other" && ok {
+			recurrence.Freq, recurrence.Interval = freq, 2
+			return 2
+		}
+		if amount, isAmount := readAmount(words[0]); isAmount && ok {
+			recurrence.Freq, recurrence.Interval = freq, amount
+			return 2
+		}
+	}
+
+	switch words[0] {
+	case "weekday", "weekdays", "workday", "workdays":
+		recurrence.Freq = "WEEKLY"
+		recurrence.ByDay = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+		return 1
+	case "weekend", "weekends":
+		recurrence.Freq = "WEEKLY"
+		recurrence.ByDay = []time.Weekday{time.Saturday, time.Sunday}
+		return 1
+	}
+	if n, days := readWeekdays(words); n > 0 {
+		recurrence.Freq, recurrence.ByDay = "WEEKLY", days
+		return n
+	}
+	if n, month, day := readYearlyDay(words); n > 0 {
+		recurrence.Freq, recurrence.ByMonth, recurrence.ByMonthDay = "YEARLY", month, day
+		return n
+	}
+	if n, monthDay := readMonthlyDay(words); n > 0 {
+		recurrence.Freq, recurrence.ByMonthDay = "MONTHLY", monthDay
+		return n
+	}
+	return 0
+}
+
+// readRecurrenceDay reads the day a plain frequency repeats on: "on the 1st"
+// for months, "on Mondays" for weeks and "on Mar 14" for years.
+func readRecurrenceDay(words []string, recurrence *lib.Recurrence) int {
+	if len(words) < 2 || words[0] != "on" || recurrence.ByDay != nil || recurrence.ByMonthDay != 0 {
+		return 0
+	}
+	rest := words[1:]
+	if rest[0] == "the" {
+		rest = rest[1:]
+	}
+	skipped := len(words) - len(rest)
+
+	switch recurrence.Freq {
+	case "WEEKLY":
+		if n, days := readWeekdays(rest); n > 0 {
+			recurrence.ByDay = days
+			return skipped + n
+		}
+	case "MONTHLY":
+		if n, monthDay := readMonthlyDay(rest); n > 0 {
+			recurrence.ByMonthDay = monthDay
+			return skipped + n
+		}
+	case "YEARLY":
+		if n, month, day := readYearlyDay(rest); n > 0 {
+			recurrence.ByMonth, recurrence.ByMonthDay = month, day
+			return skipped + n
+		}
+	}
+	return 0
+}
+
+// readWeekdays reads a list of weekdays such as "Mon, Wed and Fri", in the
+// singular or plural.
+func readWeekdays(words []string) (int, []time.Weekday) {
+	n := 0
+	days := []time.Weekday{}
+	for n < len(words) {
+		day, ok := readWeekday(words[n])
+		if !ok {
+			break
+		}
+		days = append(days, day)
+		n++
+		if n+1 < len(words) && (words[n] == "and" || words[n] == "&") {
+			if _, ok := readWeekday(words[n+1]); ok {
+				n++
+			}
+		}
+	}
+	if len(days) == 0 {
+		return 0, nil
+	}
+	return n, days
+}
+
+func readWeekday(word string) (time.Weekday, bool) {
+	if day, ok := weekdayNames[word]; ok {
+		return day, true
+	}
+	day, ok := weekdayNames[strings.TrimSuffix(word, "s")]
+	return day, ok
+}
+
+// readMonthlyDay reads "15th" or "last day"; -1 stands for the last day.
+func readMonthlyDay(words []string) (int, int) {
+	if len(words) > 1 && words[0] == "last" && words[1] == "day" {
+		return 2, -1
+	}
+	if day, ok := readOrdinal(words[0]); ok {
+		return 1, day
+	}
+	return 0, 0
+}
+
+// readYearlyDay reads a month and day that exist in some year.
+func readYearlyDay(words []string) (int, time.Month, int) {
+	n, month, day := readMonthDay(words)
+	if n == 0 || time.Date(2000, month, day, 0, 0, 0, 0, time.UTC).Day() != day {
+		return 0, 0, 0
+	}
+	return n, month, day
+}
@@ -0,0 +1,174 @@
+// Package quickadd reads a todo from one line of text, such as "Pay rent
+// every month on the 1st at 9am #finance !p1". The grammar is a list of rules
+// tried in turn at every word; the words no rule reads make up the task name.
+// Parsing only builds the todo; validating and saving it is up to the caller.
+package quickadd
+
+import (
+	"strings"
+	"time"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+)
+
+// rule reads one kind of phrase. match is given the normalized words from the
+// current one on and returns how many of them the phrase takes, or 0, along
+// with the value shown in the breakdown; it only changes the parser when it
+// matches. Once a kind has been read its rule is skipped unless repeats is
+// set, so the first date in the text wins and later ones stay in the task
+// name.
+type rule struct {
+	kind    string
+	repeats bool
+	match   func(p *parser, words []string) (int, string)
+}
+
+// rules is the grammar. Teaching quick add a new phrase means adding a rule
+// here, ahead of any rule that would read a prefix of it.
+var rules = []rule{
+	{kind: models.QuickAddTag, repeats: true, match: matchTag},
+	{kind: models.QuickAddPriority, match: matchPriority},
+	{kind: models.QuickAddRecurrence, match: matchRecurrence},
+	{kind: models.QuickAddDate, match: matchDate},
+	{kind: models.QuickAddTime, match: matchTime},
+}
+
+// parser collects what the rules read.
+type parser struct {
+	now        time.Time
+	weekStart  time.Weekday
+	date       time.Time
+	clock      *clock
+	recurrence *lib.Recurrence
+	priority   int
+	tags       []string
+	read       map[string]bool
+}
+
+type clock struct {
+	hour   int
+	minute int
+}
+
+// word is a word of the text and its character offsets.
+type word struct {
+	text  string
+	start int
+	end   int
+}
+
+// Parse reads a quick add. now is the current time in the user's timezone;
+// relative dates count from it and the due date is returned in UTC. Weeks
+// start on weekStart, so "next week" is the first day of the coming week. A
+// date without a time makes an all-day todo.
+func Parse(text string, now time.Time, weekStart time.Weekday) *models.QuickAddResult {
+	p := &parser{now: now, weekStart: weekStart, read: map[string]bool{}}
+	runes := []rune(text)
+	words := splitWords(runes)
+	keys := make([]string, len(words))
+	for i, w := range words {
+		keys[i] = normalizeWord(w.text)
+	}
+
+	parts := []models.QuickAddPart{}
+	taskName := []string{}
+	for i := 0; i < len(words); {
+		n, kind, value := p.match(keys[i:])
+		if n == 0 {
+			taskName = append(taskName, words[i].text)
+			if last := len(parts) - 1; last >= 0 && parts[last].Kind == models.QuickAddText {
+				parts[last].End = words[i].end
+				parts[last].Text = string(runes[parts[last].Start:words[i].end])
+			} else {
+				parts = append(parts, models.QuickAddPart{Kind: models.QuickAddText, Text: words[i].text, Start: words[i].start, End: words[i].end})
+			}
+			i++
+			continue
+		}
+		start, end := words[i].start, words[i+n-1].end
+		parts = append(parts, models.QuickAddPart{Kind: kind, Text: string(runes[start:end]), Start: start, End: end, Value: value})
+		i += n
+	}
+
+	todo := &models.Todo{TaskName: strings.Join(taskName, " "), DueDate: p.dueDate(), Priority: p.priority, Tags: p.tags}
+	todo.DueAllDay = !todo.DueDate.IsZero() && p.clock == nil
+	if p.recurrence != nil {
+		todo.Recurrence = p.recurrence.String()
+	}
+	return &models.QuickAddResult{Todo: todo, Parts: parts}
+}
+
+// match tries the rules at the start of words.
+func (p *parser) match(words []string) (int, string, string) {
+	if words[0] == "" {
+		return 0, "", ""
+	}
+	for _, rule := range rules {
+		if p.read[rule.kind] && !rule.repeats {
+			continue
+		}
+		if n, value := rule.match(p, words); n > 0 {
+			p.read[rule.kind] = true
+			return n, rule.kind, value
+		}
+	}
+	return 0, "", ""
+}
+
+// dueDate puts the date, time and recurrence read together. A time without a
+// date is the next time the clock shows it, and a repeating todo is due on
+// its first occurrence that has not passed yet. Without a time the due date
+// is all day.
+func (p *parser) dueDate() time.Time {
+	if p.date.IsZero() && p.clock == nil && p.recurrence == nil {
+		return time.Time{}
+	}
+	due := p.date
+	if due.IsZero() {
+		due = p.today()
+	}
+	if p.clock != nil {
+		due = time.Date(due.Year(), due.Month(), due.Day(), p.clock.hour, p.clock.minute, 0, 0, due.Location())
+	}
+
+	switch {
+	case p.recurrence != nil:
+		due = p.recurrence.First(due)
+		if p.clock != nil && due.Before(p.now) {
+			due = p.recurrence.First(due.AddDate(0, 0, 1))
+		}
+	case p.date.IsZero() && due.Before(p.now):
+		due = due.AddDate(0, 0, 1)
+	}
+	if p.clock == nil {
+		return lib.AllDay(due)
+	}
+	return due.UTC()
+}
+
+// today is midnight at the start of the current day.
+func (p *parser) today() time.Time {
+	return lib.StartOfDay(p.now)
+}
+
+func splitWords(runes []rune) []word {
+	words := []word{}
+	start := -1
+	for i, r := range append(runes, ' ') {
+		space := r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		switch {
+		case space && start >= 0:
+			words = append(words, word{text: string(runes[start:i]), start: start, end: i})
+			start = -1
+		case !space && start < 0:
+			start = i
+		}
+	}
+	return words
+}
+
+// normalizeWord lower cases a word for the rules, dropping punctuation that
+// ends a phrase, as in "tomorrow, then".
+func normalizeWord(text string) string {
+	return strings.TrimRight(strings.ToLower(text), ",.;:!?")
+}
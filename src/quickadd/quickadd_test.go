@@ -0,0 +1,240 @@
+package quickadd
+
+import (
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+	// A Wednesday morning.
+	now := time.Date(2024, 11, 27, 10, 30, 0, 0, berlin)
+	at := func(year int, month time.Month, day int, hour int, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, berlin).UTC()
+	}
+	on := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		text       string
+		taskName   string
+		dueDate    time.Time
+		allDay     bool
+		recurrence string
+		tags       []string
+		priority   int
+	}{
+		// The example from the docs.
+		{text: "Pay rent every month on the 1st at 9am #finance !p1", taskName: "Pay rent", dueDate: at(2024, 12, 1, 9, 0), recurrence: "FREQ=MONTHLY;BYMONTHDAY=1", tags: []string{"finance"}, priority: 4},
+
+		// Plain text.
+		{text: "Buy milk", taskName: "Buy milk"},
+		{text: "  Buy   milk  ", taskName: "Buy milk"},
+		{text: "Read chapter 5", taskName: "Read chapter 5"},
+		{text: "Pay by card", taskName: "Pay by card"},
+		{text: "Call at 25", taskName: "Call at 25"},
+		{text: "Not a date feb 30", taskName: "Not a date feb 30"},
+		{text: "Tag # alone", taskName: "Tag # alone"},
+
+		// Relative days.
+		{text: "Buy milk today", taskName: "Buy milk", dueDate: on(2024, 11, 27), allDay: true},
+		{text: "Buy milk tomorrow", taskName: "Buy milk", dueDate: on(2024, 11, 28), allDay: true},
+		{text: "Buy milk tmrw at 5pm", taskName: "Buy milk", dueDate: at(2024, 11, 28, 17, 0)},
+		{text: "Call mom tomorrow, then email", taskName: "Call mom then email", dueDate: on(2024, 11, 28), allDay: true},
+		{text: "Log in tomorrow", taskName: "Log in", dueDate: on(2024, 11, 28), allDay: true},
+		{text: "Submit report friday", taskName: "Submit report", dueDate: on(2024, 11, 29), allDay: true},
+		{text: "Submit report Fri", taskName: "Submit report", dueDate: on(2024, 11, 29), allDay: true},
+		{text: "Standup wednesday", taskName: "Standup", dueDate: on(2024, 12, 4), allDay: true},
+		{text: "Standup this wednesday", taskName: "Standup", dueDate: on(2024, 11, 27), allDay: true},
+		{text: "Standup this friday", taskName: "Standup", dueDate: on(2024, 11, 29), allDay: true},
+		{text: "Plan sprint next monday", taskName: "Plan sprint", dueDate: on(2024, 12, 2), allDay: true},
+		{text: "Plan sprint next friday", taskName: "Plan sprint", dueDate: on(2024, 12, 6), allDay: true},
+		{text: "Plan sprint next week", taskName: "Plan sprint", dueDate: on(2024, 12, 2), allDay: true},
+		{text: "Budget next month", taskName: "Budget", dueDate: on(2024, 12, 1), allDay: true},
+		{text: "Budget next year", taskName: "Budget", dueDate: on(2025, 1, 1), allDay: true},
+		{text: "Hike this weekend", taskName: "Hike", dueDate: on(2024, 11, 30), allDay: true},
+		{text: "Hike weekend", taskName: "Hike", dueDate: on(2024, 11, 30), allDay: true},
+		{text: "Due friday: send report", taskName: "send report", dueDate: on(2024, 11, 29), allDay: true},
+		{text: "Send report due on friday", taskName: "Send report", dueDate: on(2024, 11, 29), allDay: true},
+
+		// Offsets.
+		{text: "Renew passport in 3 weeks", taskName: "Renew passport", dueDate: on(2024, 12, 18), allDay: true},
+		{text: "Review in a month", taskName: "Review", dueDate: on(2024, 12, 27), allDay: true},
+		{text: "Plant bulbs in two days", taskName: "Plant bulbs", dueDate: on(2024, 11, 29), allDay: true},
+		{text: "Renew domain in 1 year", taskName: "Renew domain", dueDate: on(2025, 11, 27), allDay: true},
+		{text: "Call back in 2 hours", taskName: "Call back", dueDate: at(2024, 11, 27, 12, 30)},
+		{text: "Check oven in 45 minutes", taskName: "Check oven", dueDate: at(2024, 11, 27, 11, 15)},
+		{text: "Wrap up in 14 hrs", taskName: "Wrap up", dueDate: at(2024, 11, 28, 0, 30)},
+		{text: "Book flights in 2 weeks at 6pm", taskName: "Book flights", dueDate: at(2024, 12, 11, 18, 0)},
+		{text: "Sign in a minute", taskName: "Sign", dueDate: at(2024, 11, 27, 10, 31)},
+
+		// Calendar dates.
+		{text: "Dentist dec 3 at 14:30", taskName: "Dentist", dueDate: at(2024, 12, 3, 14, 30)},
+		{text: "Dentist December 3rd", taskName: "Dentist", dueDate: on(2024, 12, 3), allDay: true},
+		{text: "Dentist 3 dec", taskName: "Dentist", dueDate: on(2024, 12, 3), allDay: true},
+		{text: "Dentist 3rd of december 2025", taskName: "Dentist", dueDate: on(2025, 12, 3), allDay: true},
+		{text: "Dentist dec 3, 2025", taskName: "Dentist", dueDate: on(2025, 12, 3), allDay: true},
+		{text: "Holiday jan 5", taskName: "Holiday", dueDate: on(2025, 1, 5), allDay: true},
+		{text: "Inventory nov 27", taskName: "Inventory", dueDate: on(2024, 11, 27), allDay: true},
+		{text: "Leap day feb 29", taskName: "Leap day", dueDate: on(2028, 2, 29), allDay: true},
+		{text: "Party 2024-12-24 at 8pm", taskName: "Party", dueDate: at(2024, 12, 24, 20, 0)},
+		{text: "Party 2024-13-24", taskName: "Party 2024-13-24"},
+		{text: "Pay rent on the 1st", taskName: "Pay rent", dueDate: on(2024, 12, 1), allDay: true},
+		{text: "Read the 1st chapter", taskName: "Read the 1st chapter"},
+		{text: "Deadline by dec 31 11:59pm", taskName: "Deadline", dueDate: at(2024, 12, 31, 23, 59)},
+		{text: "Meet tomorrow and friday", taskName: "Meet and friday", dueDate: on(2024, 11, 28), allDay: true},
+
+		// Times, which move to tomorrow once they have passed today.
+		{text: "Call at 9am", taskName: "Call", dueDate: at(2024, 11, 28, 9, 0)},
+		{text: "Call at noon", taskName: "Call", dueDate: at(2024, 11, 27, 12, 0)},
+		{text: "Snack at midnight", taskName: "Snack", dueDate: at(2024, 11, 28, 0, 0)},
+		{text: "Lunch 12:30", taskName: "Lunch", dueDate: at(2024, 11, 27, 12, 30)},
+		{text: "Meeting at 17", taskName: "Meeting", dueDate: at(2024, 11, 27, 17, 0)},
+		{text: "Meeting @ 4 pm", taskName: "Meeting", dueDate: at(2024, 11, 27, 16, 0)},
+		{text: "Review 9.30am", taskName: "Review", dueDate: at(2024, 11, 28, 9, 30)},
+		{text: "Dinner 7p tomorrow", taskName: "Dinner", dueDate: at(2024, 11, 28, 19, 0)},
+		{text: "Call at 13pm", taskName: "Call at 13pm"},
+
+		// Recurrence, due on the first occurrence still to come.
+		{text: "Water plants every day", taskName: "Water plants", dueDate: on(2024, 11, 27), allDay: true, recurrence: "FREQ=DAILY"},
+		{text: "Water plants daily at 8am", taskName: "Water plants", dueDate: at(2024, 11, 28, 8, 0), recurrence: "FREQ=DAILY"},
+		{text: "Water plants everyday at 8pm", taskName: "Water plants", dueDate: at(2024, 11, 27, 20, 0), recurrence: "FREQ=DAILY"},
+		{text: "Pay invoices monthly", taskName: "Pay invoices", dueDate: on(2024, 11, 27), allDay: true, recurrence: "FREQ=MONTHLY"},
+		{text: "Clean every other week", taskName: "Clean", dueDate: on(2024, 11, 27), allDay: true, recurrence: "FREQ=WEEKLY;INTERVAL=2"},
+		{text: "Backup every 3 days", taskName: "Backup", dueDate: on(2024, 11, 27), allDay: true, recurrence: "FREQ=DAILY;INTERVAL=3"},
+		{text: "Gym every mon, wed and fri at 7pm", taskName: "Gym", dueDate: at(2024, 11, 27, 19, 0), recurrence: "FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		{text: "Gym every tuesday & thursday", taskName: "Gym", dueDate: on(2024, 11, 28), allDay: true, recurrence: "FREQ=WEEKLY;BYDAY=TU,TH"},
+		{text: "Team sync every weekday at 10:00", taskName: "Team sync", dueDate: at(2024, 11, 28, 10, 0), recurrence: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{text: "Standup at 9:15 every weekday", taskName: "Standup", dueDate: at(2024, 11, 28, 9, 15), recurrence: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{text: "Stretch every weekend", taskName: "Stretch", dueDate: on(2024, 11, 30), allDay: true, recurrence: "FREQ=WEEKLY;BYDAY=SA,SU"},
+		{text: "Review goals weekly on fridays", taskName: "Review goals", dueDate: on(2024, 11, 29), allDay: true, recurrence: "FREQ=WEEKLY;BYDAY=FR"},
+		{text: "Review goals every week on monday and friday", taskName: "Review goals", dueDate: on(2024, 11, 29), allDay: true, recurrence: "FREQ=WEEKLY;BYDAY=MO,FR"},
+		{text: "Report every 15th", taskName: "Report", dueDate: on(2024, 12, 15), allDay: true, recurrence: "FREQ=MONTHLY;BYMONTHDAY=15"},
+		{text: "Invoice every last day", taskName: "Invoice", dueDate: on(2024, 11, 30), allDay: true, recurrence: "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{text: "Invoice every month on the last day", taskName: "Invoice", dueDate: on(2024, 11, 30), allDay: true, recurrence: "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{text: "Birthday every mar 14", taskName: "Birthday", dueDate: on(2025, 3, 14), allDay: true, recurrence: "FREQ=YEARLY;BYMONTHDAY=14;BYMONTH=3"},
+		{text: "Taxes every year on the 15th of april", taskName: "Taxes", dueDate: on(2025, 4, 15), allDay: true, recurrence: "FREQ=YEARLY;BYMONTHDAY=15;BYMONTH=4"},
+		{text: "Party every feb 29", taskName: "Party", dueDate: on(2028, 2, 29), allDay: true, recurrence: "FREQ=YEARLY;BYMONTHDAY=29;BYMONTH=2"},
+		{text: "Check every 2 months starting", taskName: "Check starting", dueDate: on(2024, 11, 27), allDay: true, recurrence: "FREQ=MONTHLY;INTERVAL=2"},
+		{text: "Cleanup every now and then", taskName: "Cleanup every now and then"},
+		{text: "Stand up dec 2 every day", taskName: "Stand up", dueDate: on(2024, 12, 2), allDay: true, recurrence: "FREQ=DAILY"},
+		{text: "Pay rent every month on the 1st at 9am", taskName: "Pay rent", dueDate: at(2024, 12, 1, 9, 0), recurrence: "FREQ=MONTHLY;BYMONTHDAY=1"},
+		{text: "Spring clean every year on mar 31 at 9am", taskName: "Spring clean", dueDate: at(2025, 3, 31, 9, 0), recurrence: "FREQ=YEARLY;BYMONTHDAY=31;BYMONTH=3"},
+
+		// Tags and priorities.
+		{text: "Ship it !p2 #work #release", taskName: "Ship it", tags: []string{"work", "release"}, priority: 3},
+		{text: "#Work email the team", taskName: "email the team", tags: []string{"work"}},
+		{text: "Fix bug !urgent", taskName: "Fix bug", priority: 4},
+		{text: "Fix typo !low", taskName: "Fix typo", priority: 1},
+		{text: "Fix typo !p4 !p1", taskName: "Fix typo !p1", priority: 1},
+		{text: "Say hi!", taskName: "Say hi!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			result := Parse(tt.text, now, time.Monday)
+			assert.Equal(t, tt.taskName, result.Todo.TaskName)
+			assert.Equal(t, tt.dueDate, result.Todo.DueDate)
+			assert.Equal(t, tt.allDay, result.Todo.DueAllDay)
+			assert.Equal(t, tt.recurrence, result.Todo.Recurrence)
+			assert.Equal(t, tt.tags, result.Todo.Tags)
+			assert.Equal(t, tt.priority, result.Todo.Priority)
+		})
+	}
+}
+
+func TestParseTimezones(t *testing.T) {
+	now := time.Date(2024, 11, 27, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		timezone string
+		expected time.Time
+	}{
+		// It is already the 28th in Tokyo.
+		{timezone: "Asia/Tokyo", expected: time.Date(2024, 11, 29, 0, 0, 0, 0, time.UTC)},
+		{timezone: "America/Los_Angeles", expected: time.Date(2024, 11, 28, 17, 0, 0, 0, time.UTC)},
+		{timezone: "UTC", expected: time.Date(2024, 11, 28, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			location, err := time.LoadLocation(tt.timezone)
+			if err != nil {
+				t.Fatalf("Failed to load time zone: %v", err)
+			}
+			assert.Equal(t, tt.expected, Parse("Call tomorrow at 9am", now.In(location), time.Monday).Todo.DueDate)
+		})
+	}
+}
+
+func TestParseWeekStart(t *testing.T) {
+	// A Wednesday.
+	now := time.Date(2024, 11, 27, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		text      string
+		weekStart time.Weekday
+		expected  time.Time
+	}{
+		{text: "Plan next week", weekStart: time.Monday, expected: time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC)},
+		{text: "Plan next week", weekStart: time.Sunday, expected: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{text: "Plan next week", weekStart: time.Saturday, expected: time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC)},
+		{text: "Brunch next sunday", weekStart: time.Monday, expected: time.Date(2024, 12, 8, 0, 0, 0, 0, time.UTC)},
+		{text: "Brunch next sunday", weekStart: time.Sunday, expected: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text+" "+tt.weekStart.String(), func(t *testing.T) {
+			assert.Equal(t, tt.expected, Parse(tt.text, now, tt.weekStart).Todo.DueDate)
+		})
+	}
+}
+
+func TestParseParts(t *testing.T) {
+	now := time.Date(2024, 11, 27, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		text     string
+		expected []models.QuickAddPart
+	}{
+		{
+			text: "Pay rent every month on the 1st at 9am #finance !p1",
+			expected: []models.QuickAddPart{
+				{Kind: models.QuickAddText, Text: "Pay rent", Start: 0, End: 8},
+				{Kind: models.QuickAddRecurrence, Text: "every month on the 1st", Start: 9, End: 31, Value: "FREQ=MONTHLY;BYMONTHDAY=1"},
+				{Kind: models.QuickAddTime, Text: "at 9am", Start: 32, End: 38, Value: "09:00"},
+				{Kind: models.QuickAddTag, Text: "#finance", Start: 39, End: 47, Value: "finance"},
+				{Kind: models.QuickAddPriority, Text: "!p1", Start: 48, End: 51, Value: "4"},
+			},
+		},
+		{
+			text: "Café  run tomorrow, then bakery in 2 hours",
+			expected: []models.QuickAddPart{
+				{Kind: models.QuickAddText, Text: "Café  run", Start: 0, End: 9},
+				{Kind: models.QuickAddDate, Text: "tomorrow,", Start: 10, End: 19, Value: "2024-11-28"},
+				{Kind: models.QuickAddText, Text: "then bakery in 2 hours", Start: 20, End: 42},
+			},
+		},
+		{
+			text: "Call back in 2 hours",
+			expected: []models.QuickAddPart{
+				{Kind: models.QuickAddText, Text: "Call back", Start: 0, End: 9},
+				{Kind: models.QuickAddDate, Text: "in 2 hours", Start: 10, End: 20, Value: "2024-11-27T12:30"},
+			},
+		},
+		{
+			text:     "",
+			expected: []models.QuickAddPart{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Parse(tt.text, now, time.Monday).Parts)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package realtime
+
+import (
+	"slices"
+	"sync"
+	"todo-list/src/models"
+)
+
+// subscriptionBuffer is how many events a subscriber can fall behind by
+// before it is dropped.
+const subscriptionBuffer = 64
+
+// Subscription receives the events of the todos its user can see. Events is
+// closed when the subscriber is dropped for falling behind; it should then
+// reconnect and resume from the last event it handled.
+type Subscription struct {
+	Events <-chan *models.TodoEvent
+	events chan *models.TodoEvent
+	userID int
+}
+
+// Hub fans the todo events of this server out to the streams open on it.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subscribers: map[*Subscription]struct{}{}}
+}
+
+func (h *Hub) Subscribe(userID int) *Subscription {
+	events := make(chan *models.TodoEvent, subscriptionBuffer)
+	subscription := &Subscription{Events: events, events: events, userID: userID}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers[subscription] = struct{}{}
+	return subscription
+}
+
+// Unsubscribe stops sending events to subscription. It is safe to call after
+// the subscriber was dropped.
+func (h *Hub) Unsubscribe(subscription *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[subscription]; ok {
+		delete(h.subscribers, subscription)
+		close(subscription.events)
+	}
+}
+
+// Publish sends event to the subscribers of the users it is for, without
+// waiting on any of them.
+func (h *Hub) Publish(event *models.TodoEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for subscription := range h.subscribers {
+		if !slices.Contains(event.UserIDs, subscription.userID) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			delete(h.subscribers, subscription)
+			close(subscription.events)
+		}
+	}
+}
+
+var hub = NewHub()
+
+// Subscribe subscribes a user to the events published on this server.
+func Subscribe(userID int) *Subscription {
+	return hub.Subscribe(userID)
+}
+
+func Unsubscribe(subscription *Subscription) {
+	hub.Unsubscribe(subscription)
+}
+
+// PublishHook runs for every event published on this server, whoever it is
+// for.
+type PublishHook func(event *models.TodoEvent)
+
+var publishHooks []PublishHook
+
+// OnPublish registers a hook to run for every published event. Hooks are
+// registered at startup and run in order on the publishing goroutine, so they
+// must not block.
+func OnPublish(hook PublishHook) {
+	publishHooks = append(publishHooks, hook)
+}
+
+func Publish(event *models.TodoEvent) {
+	hub.Publish(event)
+	for _, hook := range publishHooks {
+		hook(event)
+	}
+}
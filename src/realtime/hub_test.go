@@ -0,0 +1,84 @@
+package realtime
+
+import (
+	"testing"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubPublish(t *testing.T) {
+	hub := NewHub()
+	alice := hub.Subscribe(1)
+	bob := hub.Subscribe(2)
+	defer hub.Unsubscribe(alice)
+
+	shared := &models.TodoEvent{ID: 1, Type: models.EventTodoUpdated, TodoID: 5, UserIDs: []int{1, 2}}
+	private := &models.TodoEvent{ID: 2, Type: models.EventTodoCreated, TodoID: 6, UserIDs: []int{1}}
+	hub.Publish(shared)
+	hub.Publish(private)
+
+	assert.Equal(t, shared, <-alice.Events)
+	assert.Equal(t, private, <-alice.Events)
+	assert.Equal(t, shared, <-bob.Events)
+	assert.Len(t, bob.Events, 0)
+
+	hub.Unsubscribe(bob)
+	_, open := <-bob.Events
+	assert.False(t, open)
+	hub.Unsubscribe(bob)
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	hub := NewHub()
+	slow := hub.Subscribe(1)
+	for i := 0; i <= subscriptionBuffer; i++ {
+		hub.Publish(&models.TodoEvent{ID: int64(i + 1), UserIDs: []int{1}})
+	}
+
+	received := 0
+	for range slow.Events {
+		received++
+	}
+	assert.Equal(t, subscriptionBuffer, received)
+	hub.Unsubscribe(slow)
+}
+
+func TestPublishRunsHooksInOrder(t *testing.T) {
+	defer func() { publishHooks = nil }()
+
+	calls := []string{}
+	OnPublish(func(event *models.TodoEvent) { calls = append(calls, "first") })
+	OnPublish(func(event *models.TodoEvent) { calls = append(calls, "second") })
+	Publish(&models.TodoEvent{ID: 1, Type: models.EventTodoUpdated, TodoID: 5, UserIDs: []int{1}})
+
+	assert.Equal(t, []string{"first", "second"}, calls)
+}
+
+func TestHandleNotification(t *testing.T) {
+	mockStore := stores.InitMockStore()
+	stores.InitStore(mockStore)
+	subscription := Subscribe(1)
+	defer Unsubscribe(subscription)
+	defer func() { lastEventID, published = 0, map[int64]bool{} }()
+
+	first := &models.TodoEvent{ID: 107, Type: models.EventTodoCreated, TodoID: 5, UserIDs: []int{1}}
+	late := &models.TodoEvent{ID: 105, Type: models.EventTodoUpdated, TodoID: 6, UserIDs: []int{1}}
+	missed := &models.TodoEvent{ID: 108, Type: models.EventTodoDeleted, TodoID: 5, UserIDs: []int{1}}
+	mockStore.On("GetTodoEvent", int64(107)).Return(first, nil)
+	// The event logged before the last one published but committed after it
+	// is caught up on too, the ones already published are not sent again.
+	mockStore.On("GetTodoEvents", int64(7), 0, models.EventReplayLimit).Return([]*models.TodoEvent{late, first, missed}, nil)
+
+	HandleNotification(&pq.Notification{Channel: stores.TodoEventsChannel, Extra: "107"})
+	HandleNotification(nil)
+
+	assert.Equal(t, first, <-subscription.Events)
+	assert.Equal(t, late, <-subscription.Events)
+	assert.Equal(t, missed, <-subscription.Events)
+	assert.Len(t, subscription.Events, 0)
+	assert.Equal(t, int64(108), lastEventID)
+	mockStore.AssertExpectations(t)
+}
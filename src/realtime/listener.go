@@ -0,0 +1,101 @@
+package realtime
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+	"todo-list/src/models"
+	"todo-list/src/stores"
+
+	"github.com/lib/pq"
+)
+
+// ListenPostgres listens for the todo events every server logs, through
+// Postgres LISTEN on connString, and publishes them until ctx is done.
+func ListenPostgres(ctx context.Context, connString string) {
+	listener := pq.NewListener(connString, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Todo event listener: %v", err)
+		}
+	})
+	defer listener.Close()
+	if err := listener.Listen(stores.TodoEventsChannel); err != nil {
+		log.Printf("Can not listen for todo events: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			go listener.Ping()
+		case notification := <-listener.Notify:
+			HandleNotification(notification)
+		}
+	}
+}
+
+// lastEventID is the newest event published from a notification, and
+// published the IDs of the events published within models.EventResumeWindow
+// of it.
+var (
+	lastEventID int64
+	published   = map[int64]bool{}
+)
+
+// HandleNotification publishes the event of a notification. A nil
+// notification means the connection was lost and notifications may have been
+// missed, so the events logged since shortly before the last one published
+// are published instead, leaving out those already published.
+func HandleNotification(notification *pq.Notification) {
+	if notification == nil {
+		if lastEventID == 0 {
+			return
+		}
+		from := lastEventID - models.EventResumeWindow
+		if from < 0 {
+			from = 0
+		}
+		events, err := stores.GetStore().GetTodoEvents(from, 0, models.EventReplayLimit)
+		if err != nil {
+			log.Printf("Can not get missed todo events: %v", err)
+			return
+		}
+		for _, event := range events {
+			publish(event)
+		}
+		return
+	}
+
+	eventID, err := strconv.ParseInt(notification.Extra, 10, 64)
+	if err != nil {
+		log.Printf("Invalid todo event notification %q", notification.Extra)
+		return
+	}
+	event, err := stores.GetStore().GetTodoEvent(eventID)
+	if err != nil {
+		log.Printf("Can not get todo event %d: %v", eventID, err)
+		return
+	}
+	publish(event)
+}
+
+func publish(event *models.TodoEvent) {
+	if published[event.ID] {
+		return
+	}
+	Publish(event)
+	published[event.ID] = true
+	if event.ID > lastEventID {
+		lastEventID = event.ID
+		for eventID := range published {
+			if eventID <= lastEventID-models.EventResumeWindow {
+				delete(published, eventID)
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package stores
+
+import (
+	"database/sql"
+	"todo-list/src/models"
+)
+
+// CreateAppPassword saves an app password. Only a hash of its Password is
+// stored.
+func (store *DbStore) CreateAppPassword(appPassword *models.AppPassword) (*models.AppPassword, error) {
+	newAppPassword := *appPassword
+	err := store.DB.QueryRow("INSERT INTO app_passwords (user_id, name, password_hash) VALUES ($1, $2, $3) RETURNING id, created_at",
+		appPassword.UserID, appPassword.Name, hashToken(appPassword.Password)).Scan(&newAppPassword.ID, &newAppPassword.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &newAppPassword, nil
+}
+
+func (store *DbStore) GetAppPasswords(userID int) ([]*models.AppPassword, error) {
+	rows, err := store.DB.Query("SELECT id, user_id, name, created_at, last_used_at FROM app_passwords WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appPasswords := []*models.AppPassword{}
+	for rows.Next() {
+		appPassword := &models.AppPassword{}
+		if err := rows.Scan(&appPassword.ID, &appPassword.UserID, &appPassword.Name, &appPassword.CreatedAt, &appPassword.LastUsedAt); err != nil {
+			return nil, err
+		}
+		appPasswords = append(appPasswords, appPassword)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return appPasswords, nil
+}
+
+func (store *DbStore) DeleteAppPassword(appPasswordID int, userID int) error {
+	result, err := store.DB.Exec("DELETE FROM app_passwords WHERE id = $1 AND user_id = $2", appPasswordID, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AuthenticateAppPassword finds the user signing in with an app password and
+// records its use. Wrong emails or passwords give sql.ErrNoRows.
+func (store *DbStore) AuthenticateAppPassword(email string, password string) (*models.User, error) {
+	user := &models.User{}
+	err := store.DB.QueryRow("UPDATE app_passwords a SET last_used_at = NOW() FROM users u WHERE u.id = a.user_id AND u.email = $1 AND a.password_hash = $2 RETURNING u.id, u.username, u.email",
+		email, hashToken(password)).Scan(&user.ID, &user.UserName, &user.Email)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,92 @@
+package stores
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAppPassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+
+	mock.ExpectQuery("INSERT INTO app_passwords \\(user_id, name, password_hash\\) VALUES \\(\\$1, \\$2, \\$3\\) RETURNING id, created_at").
+		WithArgs(1, "Phone", hashToken("app-password")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, createdAt))
+
+	appPassword, err := store.CreateAppPassword(&models.AppPassword{UserID: 1, Name: "Phone", Password: "app-password"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.AppPassword{ID: 3, UserID: 1, Name: "Phone", Password: "app-password", CreatedAt: createdAt}, appPassword)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAppPasswords(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	lastUsedAt := createdAt.Add(time.Hour)
+
+	mock.ExpectQuery("SELECT id, user_id, name, created_at, last_used_at FROM app_passwords WHERE user_id = \\$1 ORDER BY id").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "created_at", "last_used_at"}).
+			AddRow(3, 1, "Phone", createdAt, lastUsedAt).
+			AddRow(4, 1, "Laptop", createdAt, nil))
+
+	appPasswords, err := store.GetAppPasswords(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.AppPassword{
+		{ID: 3, UserID: 1, Name: "Phone", CreatedAt: createdAt, LastUsedAt: &lastUsedAt},
+		{ID: 4, UserID: 1, Name: "Laptop", CreatedAt: createdAt},
+	}, appPasswords)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteAppPassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectExec("DELETE FROM app_passwords WHERE id = \\$1 AND user_id = \\$2").WithArgs(3, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM app_passwords").WithArgs(3, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, store.DeleteAppPassword(3, 1))
+	assert.ErrorIs(t, store.DeleteAppPassword(3, 2), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthenticateAppPassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectQuery("UPDATE app_passwords a SET last_used_at = NOW\\(\\) FROM users u WHERE u.id = a.user_id AND u.email = \\$1 AND a.password_hash = \\$2 RETURNING u.id, u.username, u.email").
+		WithArgs("test@mail.com", hashToken("app-password")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email"}).AddRow(1, "tester", "test@mail.com"))
+	mock.ExpectQuery("UPDATE app_passwords").WithArgs("test@mail.com", hashToken("wrong")).WillReturnError(sql.ErrNoRows)
+
+	user, err := store.AuthenticateAppPassword("test@mail.com", "app-password")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.User{ID: 1, UserName: "tester", Email: "test@mail.com"}, user)
+
+	_, err = store.AuthenticateAppPassword("test@mail.com", "wrong")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
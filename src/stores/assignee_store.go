@@ -0,0 +1,100 @@
+package stores
+
+import (
+	"sort"
+	"todo-list/src/models"
+)
+
+// SetAssignees makes userIDs the complete set of assignees of the todo and
+// reports who was added and removed. Callers check the users can access it.
+func (store *DbStore) SetAssignees(todoID int, userIDs []int, assignedBy int) (*models.AssigneeChanges, error) {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	rows, err := transaction.Query("SELECT user_id FROM todo_assignees WHERE todo_id = $1 FOR UPDATE", todoID)
+	if err != nil {
+		return nil, err
+	}
+	current := map[int]bool{}
+	for rows.Next() {
+		var userID int
+		if err = rows.Scan(&userID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		current[userID] = true
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	wanted := map[int]bool{}
+	changes := &models.AssigneeChanges{Assigned: []*models.User{}, Unassigned: []*models.User{}}
+	for _, userID := range userIDs {
+		wanted[userID] = true
+		if current[userID] {
+			continue
+		}
+		_, err = transaction.Exec("INSERT INTO todo_assignees (todo_id, user_id, assigned_by) VALUES ($1, $2, $3)", todoID, userID, assignedBy)
+		if err != nil {
+			return nil, err
+		}
+		var user *models.User
+		user, err = getUserByID(transaction, userID)
+		if err != nil {
+			return nil, err
+		}
+		changes.Assigned = append(changes.Assigned, user)
+	}
+
+	removed := []int{}
+	for userID := range current {
+		if !wanted[userID] {
+			removed = append(removed, userID)
+		}
+	}
+	sort.Ints(removed)
+	for _, userID := range removed {
+		_, err = transaction.Exec("DELETE FROM todo_assignees WHERE todo_id = $1 AND user_id = $2", todoID, userID)
+		if err != nil {
+			return nil, err
+		}
+		var user *models.User
+		user, err = getUserByID(transaction, userID)
+		if err != nil {
+			return nil, err
+		}
+		changes.Unassigned = append(changes.Unassigned, user)
+	}
+
+	changes.Todo = &models.Todo{}
+	err = scanTodo(transaction.QueryRow("SELECT "+todoColumns+" FROM todos t WHERE t.id = $1", todoID), changes.Todo)
+	if err != nil {
+		return nil, err
+	}
+
+	err = transaction.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func getUserByID(querier rowQuerier, userID int) (*models.User, error) {
+	user := &models.User{}
+	err := querier.QueryRow("SELECT id, username, email FROM users WHERE id = $1", userID).Scan(&user.ID, &user.UserName, &user.Email)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,69 @@
+package stores
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetAssignees(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	dueDate := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	todoRowColumns := []string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}
+	userColumns := []string{"id", "username", "email"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id FROM todo_assignees WHERE todo_id = \\$1 FOR UPDATE").WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(2).AddRow(3))
+	mock.ExpectExec("INSERT INTO todo_assignees \\(todo_id, user_id, assigned_by\\) VALUES \\(\\$1, \\$2, \\$3\\)").WithArgs(5, 4, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id, username, email FROM users WHERE id = \\$1").WithArgs(4).WillReturnRows(sqlmock.NewRows(userColumns).AddRow(4, "dave", "dave@mail.com"))
+	mock.ExpectExec("DELETE FROM todo_assignees WHERE todo_id = \\$1 AND user_id = \\$2").WithArgs(5, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id, username, email FROM users WHERE id = \\$1").WithArgs(3).WillReturnRows(sqlmock.NewRows(userColumns).AddRow(3, "carol", "carol@mail.com"))
+	mock.ExpectQuery("SELECT (.+) FROM todos t WHERE t.id = \\$1").WithArgs(5).WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(5, "Ship it", false, dueDate, dueDate, dueDate, "", 0, 0, "{2,4}", false, nil, 0, "", false, 0, nil, nil))
+	mock.ExpectCommit()
+
+	changes, err := store.SetAssignees(5, []int{2, 4}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.User{{ID: 4, UserName: "dave", Email: "dave@mail.com"}}, changes.Assigned)
+	assert.Equal(t, []*models.User{{ID: 3, UserName: "carol", Email: "carol@mail.com"}}, changes.Unassigned)
+	assert.Equal(t, []int{2, 4}, changes.Todo.AssigneeIDs)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id FROM todo_assignees").WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	mock.ExpectExec("INSERT INTO todo_assignees").WithArgs(5, 9, 1).WillReturnError(fmt.Errorf("foreign key violation"))
+	mock.ExpectRollback()
+
+	_, err = store.SetAssignees(5, []int{9}, 1)
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTodosFilteredByAssignee(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	dueDate := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) AND EXISTS \\(SELECT 1 FROM todo_assignees a WHERE a.todo_id = t.id AND a.user_id = \\$3\\) ORDER BY tp.position NULLS LAST, t.id").WithArgs(1, 4, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields", "position", "role"}).
+			AddRow(5, "Ship it", false, dueDate, dueDate, dueDate, "", 4, 3, "{1}", true, nil, 0, "", false, 0, nil, nil, "", "editor"))
+	mock.ExpectCommit()
+
+	todos, err := store.GetTodos(1, 4, &models.TodoFilter{AssigneeID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.Todo{{ID: 5, TaskName: "Ship it", DueDate: dueDate, CreatedAt: dueDate, UpdatedAt: dueDate, WorkspaceID: 4, ProjectID: 3, AssigneeIDs: []int{1}, Blocked: true, Role: "editor"}}, todos)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
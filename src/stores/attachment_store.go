@@ -0,0 +1,66 @@
+package stores
+
+import (
+	"database/sql"
+	"todo-list/src/models"
+)
+
+const attachmentColumns = "id, todo_id, user_id, file_name, content_type, size, storage_key, created_at"
+
+func scanAttachment(row rowScanner, attachment *models.Attachment) error {
+	return row.Scan(&attachment.ID, &attachment.TodoID, &attachment.UserID, &attachment.FileName, &attachment.ContentType, &attachment.Size, &attachment.StorageKey, &attachment.CreatedAt)
+}
+
+func (store *DbStore) CreateAttachment(attachment *models.Attachment) (*models.Attachment, error) {
+	newAttachment := &models.Attachment{}
+	err := scanAttachment(store.DB.QueryRow("INSERT INTO todo_attachments (todo_id, user_id, file_name, content_type, size, storage_key) VALUES ($1, $2, $3, $4, $5, $6) RETURNING "+attachmentColumns, attachment.TodoID, attachment.UserID, attachment.FileName, attachment.ContentType, attachment.Size, attachment.StorageKey), newAttachment)
+	if err != nil {
+		return nil, err
+	}
+	return newAttachment, nil
+}
+
+func (store *DbStore) GetAttachments(todoID int) ([]*models.Attachment, error) {
+	rows, err := store.DB.Query("SELECT "+attachmentColumns+" FROM todo_attachments WHERE todo_id = $1 ORDER BY created_at, id", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []*models.Attachment{}
+	for rows.Next() {
+		attachment := &models.Attachment{}
+		if err := scanAttachment(rows, attachment); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (store *DbStore) GetAttachment(attachmentID int, todoID int) (*models.Attachment, error) {
+	attachment := &models.Attachment{}
+	err := scanAttachment(store.DB.QueryRow("SELECT "+attachmentColumns+" FROM todo_attachments WHERE id = $1 AND todo_id = $2", attachmentID, todoID), attachment)
+	if err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (store *DbStore) DeleteAttachment(attachmentID int, todoID int) error {
+	result, err := store.DB.Exec("DELETE FROM todo_attachments WHERE id = $1 AND todo_id = $2", attachmentID, todoID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
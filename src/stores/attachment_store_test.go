@@ -0,0 +1,74 @@
+package stores
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var attachmentRowColumns = []string{"id", "todo_id", "user_id", "file_name", "content_type", "size", "storage_key", "created_at"}
+
+func TestCreateAttachment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC()
+	input := &models.Attachment{TodoID: 5, UserID: 1, FileName: "a.png", ContentType: "image/png", Size: 10, StorageKey: "todos/5/abc"}
+
+	mock.ExpectQuery("INSERT INTO todo_attachments").WithArgs(5, 1, "a.png", "image/png", int64(10), "todos/5/abc").WillReturnRows(sqlmock.NewRows(attachmentRowColumns).AddRow(7, 5, 1, "a.png", "image/png", 10, "todos/5/abc", createdAt))
+	attachment, err := store.CreateAttachment(input)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Attachment{ID: 7, TodoID: 5, UserID: 1, FileName: "a.png", ContentType: "image/png", Size: 10, StorageKey: "todos/5/abc", CreatedAt: createdAt}, attachment)
+
+	mock.ExpectQuery("INSERT INTO todo_attachments").WillReturnError(fmt.Errorf("some db error"))
+	_, err = store.CreateAttachment(input)
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAttachments(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC()
+	mock.ExpectQuery("SELECT (.+) FROM todo_attachments WHERE todo_id = \\$1").WithArgs(5).WillReturnRows(sqlmock.NewRows(attachmentRowColumns).
+		AddRow(7, 5, 1, "a.png", "image/png", 10, "todos/5/abc", createdAt).
+		AddRow(8, 5, 1, "b.pdf", "application/pdf", 20, "todos/5/def", createdAt))
+	attachments, err := store.GetAttachments(5)
+	assert.NoError(t, err)
+	assert.Len(t, attachments, 2)
+	assert.Equal(t, "todos/5/def", attachments[1].StorageKey)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectExec("DELETE FROM todo_attachments").WithArgs(7, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, store.DeleteAttachment(7, 5))
+
+	mock.ExpectExec("DELETE FROM todo_attachments").WithArgs(7, 6).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, store.DeleteAttachment(7, 6), sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
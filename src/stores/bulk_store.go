@@ -0,0 +1,209 @@
+package stores
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"todo-list/src/models"
+)
+
+// maxBulkItems caps the number of todos one bulk request may touch across
+// all of its operations.
+const maxBulkItems = 500
+
+var (
+	ErrBulkTooLarge     = fmt.Errorf("a bulk request can change at most %d todos", maxBulkItems)
+	errBulkTodoNotFound = errors.New("todo not found")
+)
+
+// BulkUpdateTodos applies the operations of request, in order, to the todos
+// user can see in the workspace, all in one transaction. Each item is
+// checked against the user's role on that todo. In BulkBestEffort mode failed
+// items are rolled back to a savepoint and the rest is committed; otherwise
+// the first failure rolls back the whole request.
+func (store *DbStore) BulkUpdateTodos(request *models.BulkRequest, userID int, workspaceID int) (*models.BulkResponse, error) {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	bestEffort := request.Mode == models.BulkBestEffort
+	response := &models.BulkResponse{Results: []*models.BulkResult{}}
+	for i := range request.Operations {
+		operation := &request.Operations[i]
+		var todoIDs []int
+		todoIDs, err = bulkTargets(transaction, operation, userID, workspaceID)
+		if err != nil {
+			return nil, err
+		}
+		if len(response.Results)+len(todoIDs) > maxBulkItems {
+			err = ErrBulkTooLarge
+			return nil, err
+		}
+
+		for _, todoID := range todoIDs {
+			result := &models.BulkResult{Operation: i, TodoID: todoID, Status: models.BulkOK}
+			response.Results = append(response.Results, result)
+
+			if bestEffort {
+				_, err = transaction.Exec("SAVEPOINT bulk_item")
+				if err != nil {
+					return nil, err
+				}
+			}
+			itemErr := applyBulkOperation(transaction, operation, todoID, userID, workspaceID, request.Force)
+			if itemErr == nil {
+				if bestEffort {
+					_, err = transaction.Exec("RELEASE SAVEPOINT bulk_item")
+					if err != nil {
+						return nil, err
+					}
+				}
+				continue
+			}
+
+			result.Status = models.BulkFailed
+			result.Error = itemErr.Error()
+			if bestEffort {
+				_, err = transaction.Exec("ROLLBACK TO SAVEPOINT bulk_item")
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			for _, done := range response.Results[:len(response.Results)-1] {
+				done.Status = models.BulkRolledBack
+			}
+			err = transaction.Rollback()
+			if err != nil {
+				return nil, err
+			}
+			return response, nil
+		}
+	}
+
+	err = transaction.Commit()
+	if err != nil {
+		return nil, err
+	}
+	response.Committed = true
+	return response, nil
+}
+
+// bulkTargets lists the todos an operation applies to: its IDs without
+// duplicates, or the visible todos matching its filter.
+func bulkTargets(transaction *sql.Tx, operation *models.BulkOperation, userID int, workspaceID int) ([]int, error) {
+	if operation.Filter == nil {
+		seen := map[int]bool{}
+		todoIDs := []int{}
+		for _, todoID := range operation.IDs {
+			if !seen[todoID] {
+				seen[todoID] = true
+				todoIDs = append(todoIDs, todoID)
+			}
+		}
+		return todoIDs, nil
+	}
+
+	conditions, args := filterConditions(operation.Filter, []any{userID, workspaceID})
+	rows, err := transaction.Query("SELECT t.id"+visibleTodos+conditions+fmt.Sprintf(" ORDER BY t.id LIMIT %d", maxBulkItems+1), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todoIDs := []int{}
+	for rows.Next() {
+		var todoID int
+		if err := rows.Scan(&todoID); err != nil {
+			return nil, err
+		}
+		todoIDs = append(todoIDs, todoID)
+	}
+	return todoIDs, rows.Err()
+}
+
+// applyBulkOperation runs one operation on one todo after checking the
+// user's role on it. Deleting needs the owner role, everything else editor.
+// Every other operation goes through updateTodo, so it is recorded and
+// announced like any edit.
+func applyBulkOperation(transaction *sql.Tx, operation *models.BulkOperation, todoID int, userID int, workspaceID int, allowBlocked bool) error {
+	var role string
+	err := transaction.QueryRow("SELECT "+effectiveRole+visibleTodos+" AND t.id = $3 FOR UPDATE OF t", userID, workspaceID, todoID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return errBulkTodoNotFound
+	}
+	if err != nil {
+		return err
+	}
+	minimumRole := models.RoleEditor
+	if operation.Op == models.BulkDelete {
+		minimumRole = models.RoleOwner
+	}
+	if !models.RoleAtLeast(role, minimumRole) {
+		return fmt.Errorf("this action requires the %s role", minimumRole)
+	}
+
+	switch operation.Op {
+	case models.BulkComplete, models.BulkUncomplete:
+		completed := operation.Op == models.BulkComplete
+		_, err = updateTodo(transaction, todoID, userID, allowBlocked, func(todo *models.Todo) {
+			todo.Completed = completed
+		})
+	case models.BulkSetDueDate:
+		_, err = updateTodo(transaction, todoID, userID, allowBlocked, func(todo *models.Todo) {
+			todo.DueDate = operation.DueDate
+			todo.DueAllDay = operation.DueAllDay
+		})
+	case models.BulkDelete:
+		err = deleteTodo(transaction, todoID, userID)
+	case models.BulkMove:
+		_, err = updateTodo(transaction, todoID, userID, allowBlocked, func(todo *models.Todo) {
+			// Custom field values only mean something in the project
+			// defining their fields.
+			if todo.ProjectID != *operation.ProjectID {
+				todo.ProjectID = *operation.ProjectID
+				todo.CustomFields = nil
+			}
+		})
+	case models.BulkTag:
+		_, err = updateTodo(transaction, todoID, userID, allowBlocked, func(todo *models.Todo) {
+			tags := map[string]bool{}
+			for _, tag := range todo.Tags {
+				tags[tag] = true
+			}
+			for _, tag := range operation.Tags {
+				if !tags[tag] {
+					tags[tag] = true
+					todo.Tags = append(todo.Tags, tag)
+				}
+			}
+			sort.Strings(todo.Tags)
+		})
+	case models.BulkUntag:
+		_, err = updateTodo(transaction, todoID, userID, allowBlocked, func(todo *models.Todo) {
+			removed := map[string]bool{}
+			for _, tag := range operation.Tags {
+				removed[tag] = true
+			}
+			tags := []string{}
+			for _, tag := range todo.Tags {
+				if !removed[tag] {
+					tags = append(tags, tag)
+				}
+			}
+			todo.Tags = tags
+		})
+	default:
+		err = fmt.Errorf("unknown operation %q", operation.Op)
+	}
+	return err
+}
@@ -0,0 +1,182 @@
+package stores
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkUpdateTodosBestEffort(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	dueDate := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	todoRowColumns := []string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}
+	request := &models.BulkRequest{
+		Mode:       models.BulkBestEffort,
+		Operations: []models.BulkOperation{{Op: models.BulkTag, IDs: []int{5, 6, 5}, Tags: []string{"work"}}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT CASE (.+) FROM todos t (.+) AND t.deleted_at IS NULL AND t.id = \\$3 FOR UPDATE OF t").WithArgs(1, 0, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("owner"))
+	mock.ExpectQuery("SELECT (.+) FROM todos t WHERE t.id=\\$1 AND t.deleted_at IS NULL FOR UPDATE").WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(5, "Pay rent", false, dueDate, dueDate, dueDate, "", 0, 0, nil, false, "{home}", 0, "", false, 0, nil, nil))
+	mock.ExpectExec("DELETE FROM todo_tags WHERE todo_id = \\$1 AND tag <> ALL\\(\\$2\\)").WithArgs(5, pq.StringArray{"home", "work"}).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO todo_tags \\(todo_id, tag\\) SELECT \\$1, UNNEST\\(\\$2::text\\[\\]\\) ON CONFLICT DO NOTHING").WithArgs(5, pq.StringArray{"home", "work"}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE todos t SET (.+) RETURNING").WithArgs("Pay rent", false, dueDate, false, "", 0, "", "{}", 0, 5).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(5, "Pay rent", false, dueDate, dueDate, dueDate, "", 0, 0, nil, false, "{home,work}", 0, "", false, 0, nil, nil))
+	mock.ExpectExec("INSERT INTO todo_changes").WithArgs(5, 1, "tags", "home", "home,work").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO todo_revisions").WithArgs(5, 1, "update", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO todo_events").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT CASE (.+) FOR UPDATE OF t").WithArgs(1, 0, 6).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("viewer"))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	response, err := store.BulkUpdateTodos(request, 1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.BulkResponse{Committed: true, Results: []*models.BulkResult{
+		{Operation: 0, TodoID: 5, Status: models.BulkOK},
+		{Operation: 0, TodoID: 6, Status: models.BulkFailed, Error: "this action requires the editor role"},
+	}}, response)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBulkUpdateTodosAllOrNothing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	dueDate := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	todoRowColumns := []string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}
+	completed := false
+	request := &models.BulkRequest{
+		Operations: []models.BulkOperation{{Op: models.BulkDelete, Filter: &models.TodoFilter{Completed: &completed, Tag: "old"}}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT t.id FROM todos t (.+) AND t.deleted_at IS NULL AND t.completed = \\$3 AND EXISTS \\(SELECT 1 FROM todo_tags tg WHERE tg.todo_id = t.id AND tg.tag = \\$4\\) ORDER BY t.id LIMIT 501").
+		WithArgs(1, 0, false, "old").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5).AddRow(6))
+	mock.ExpectQuery("SELECT CASE (.+) FOR UPDATE OF t").WithArgs(1, 0, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("owner"))
+	mock.ExpectQuery("UPDATE todos t SET deleted_at = NOW\\(\\) WHERE id=\\$1 AND deleted_at IS NULL RETURNING (.+)").WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(5, "Old task", false, dueDate, dueDate, dueDate, "", 0, 0, nil, false, "{old}", 0, "", false, 0, nil, nil))
+	mock.ExpectExec("INSERT INTO todo_revisions").WithArgs(5, 1, "delete", sqlmock.AnyArg(), nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO todo_events").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT CASE (.+) FOR UPDATE OF t").WithArgs(1, 0, 6).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("editor"))
+	mock.ExpectRollback()
+
+	response, err := store.BulkUpdateTodos(request, 1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.BulkResponse{Committed: false, Results: []*models.BulkResult{
+		{Operation: 0, TodoID: 5, Status: models.BulkRolledBack},
+		{Operation: 0, TodoID: 6, Status: models.BulkFailed, Error: "this action requires the owner role"},
+	}}, response)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBulkUpdateTodosMoveAndTagLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	dueDate := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	todoRowColumns := []string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}
+	fullTags := "{"
+	for i := 1; i <= maxTodoTags; i++ {
+		if i > 1 {
+			fullTags += ","
+		}
+		fullTags += fmt.Sprintf("tag%02d", i)
+	}
+	fullTags += "}"
+	projectID := 9
+	request := &models.BulkRequest{
+		Mode: models.BulkBestEffort,
+		Operations: []models.BulkOperation{
+			{Op: models.BulkMove, IDs: []int{5}, ProjectID: &projectID},
+			{Op: models.BulkTag, IDs: []int{6}, Tags: []string{"work"}},
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT CASE (.+) FOR UPDATE OF t").WithArgs(1, 4, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("editor"))
+	mock.ExpectQuery("SELECT (.+) FROM todos t WHERE t.id=\\$1 AND t.deleted_at IS NULL FOR UPDATE").WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(5, "Pay rent", false, dueDate, dueDate, dueDate, "", 4, 3, nil, false, nil, 0, "", false, 0, nil, []byte(`{"points":3}`)))
+	mock.ExpectQuery("UPDATE todos t SET (.+) RETURNING").WithArgs("Pay rent", false, dueDate, false, "", 0, "", "{}", 9, 5).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(5, "Pay rent", false, dueDate, dueDate, dueDate, "", 4, 9, nil, false, nil, 0, "", false, 0, nil, nil))
+	mock.ExpectExec("INSERT INTO todo_changes").WithArgs(5, 1, "custom_fields", `{"points":3}`, "{}").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO todo_changes").WithArgs(5, 1, "project_id", "3", "9").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO todo_revisions").WithArgs(5, 1, "update", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO todo_events").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT CASE (.+) FOR UPDATE OF t").WithArgs(1, 4, 6).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("owner"))
+	mock.ExpectQuery("SELECT (.+) FROM todos t WHERE t.id=\\$1 AND t.deleted_at IS NULL FOR UPDATE").WithArgs(6).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(6, "Plan trip", false, dueDate, dueDate, dueDate, "", 4, 0, nil, false, fullTags, 0, "", false, 0, nil, nil))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	response, err := store.BulkUpdateTodos(request, 1, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.BulkResponse{Committed: true, Results: []*models.BulkResult{
+		{Operation: 0, TodoID: 5, Status: models.BulkOK},
+		{Operation: 1, TodoID: 6, Status: models.BulkFailed, Error: "a todo can have at most 20 tags"},
+	}}, response)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBulkUpdateTodosTooLarge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	rows := sqlmock.NewRows([]string{"id"})
+	for id := 1; id <= maxBulkItems+1; id++ {
+		rows.AddRow(id)
+	}
+	request := &models.BulkRequest{Operations: []models.BulkOperation{{Op: models.BulkComplete, Filter: &models.TodoFilter{}}}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT t.id FROM todos t (.+) ORDER BY t.id LIMIT 501").WithArgs(1, 3).WillReturnRows(rows)
+	mock.ExpectRollback()
+
+	_, err = store.BulkUpdateTodos(request, 1, 3)
+	assert.ErrorIs(t, err, ErrBulkTooLarge)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
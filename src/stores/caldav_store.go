@@ -0,0 +1,131 @@
+package stores
+
+import (
+	"errors"
+	"todo-list/src/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrCalDAVNameTaken is returned when a todo of the user already has the
+// resource name a client chose for a new one.
+var ErrCalDAVNameTaken = errors.New("a todo of the user already has this resource name")
+
+// CreateCalDAVTodo creates a todo owned by the user together with the
+// resource name and UID the user's CalDAV client gave it. It fails with
+// ErrCalDAVNameTaken, creating nothing, when the name is taken.
+func (store *DbStore) CreateCalDAVTodo(todo *models.Todo, userID int, resource *models.CalDAVResource) (*models.Todo, error) {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	newTodo, err := insertTodo(transaction, todo, userID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := transaction.Exec("INSERT INTO caldav_resources (todo_id, user_id, name, uid) VALUES ($1, $2, $3, $4) ON CONFLICT (user_id, name) DO NOTHING", newTodo.ID, userID, resource.Name, resource.UID)
+	if err != nil {
+		return nil, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		err = ErrCalDAVNameTaken
+		return nil, err
+	}
+
+	err = transaction.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return newTodo, nil
+}
+
+// GetCalDAVResources returns the resources of the given todos that CalDAV
+// clients created. Todos without one are left out.
+func (store *DbStore) GetCalDAVResources(todoIDs []int) ([]*models.CalDAVResource, error) {
+	rows, err := store.DB.Query("SELECT todo_id, user_id, name, uid FROM caldav_resources WHERE todo_id = ANY($1)", pq.Array(todoIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resources := []*models.CalDAVResource{}
+	for rows.Next() {
+		resource := &models.CalDAVResource{}
+		if err := rows.Scan(&resource.TodoID, &resource.UserID, &resource.Name, &resource.UID); err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// GetCalDAVResourcesByName returns the resources of that name. Names are
+// only unique per user, so the todos of several users can have the same one.
+func (store *DbStore) GetCalDAVResourcesByName(name string) ([]*models.CalDAVResource, error) {
+	rows, err := store.DB.Query("SELECT todo_id, user_id, name, uid FROM caldav_resources WHERE name = $1 ORDER BY todo_id", name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resources := []*models.CalDAVResource{}
+	for rows.Next() {
+		resource := &models.CalDAVResource{}
+		if err := rows.Scan(&resource.TodoID, &resource.UserID, &resource.Name, &resource.UID); err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// GetSyncToken returns the oldest transaction still running, or the next one
+// when none is. Every todo change committed from now on has a sync_id at
+// least that high.
+func (store *DbStore) GetSyncToken() (int64, error) {
+	var token int64
+	err := store.DB.QueryRow("SELECT txid_snapshot_xmin(txid_current_snapshot())").Scan(&token)
+	return token, err
+}
+
+// GetChangedTodoIDs returns the todos changed from the sync token on, whether
+// or not they still exist, that have been in the project, or for project 0
+// in the personal space and shared with the user. Changes committed just
+// before the token may be returned again.
+func (store *DbStore) GetChangedTodoIDs(since int64, userID int, projectID int) ([]int, error) {
+	rows, err := store.DB.Query("SELECT todo_id FROM todo_sync WHERE sync_id >= $1 AND $3 = ANY(project_ids) AND ($3 <> 0 OR $2 = ANY(user_ids)) ORDER BY sync_id, todo_id", since, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todoIDs := []int{}
+	for rows.Next() {
+		var todoID int
+		if err := rows.Scan(&todoID); err != nil {
+			return nil, err
+		}
+		todoIDs = append(todoIDs, todoID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return todoIDs, nil
+}
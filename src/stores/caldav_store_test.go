@@ -0,0 +1,95 @@
+package stores
+
+import (
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateCalDAVTodo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	dueDate := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	expectInsert := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO todos").WithArgs("Buy milk", false, dueDate, false, "", 0, "", 0, 0, "{}").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}).
+				AddRow(4, "Buy milk", false, dueDate, dueDate, dueDate, "", 0, 0, nil, false, nil, 0, "", false, 0, nil, nil))
+		mock.ExpectQuery("SELECT COALESCE\\(MAX\\(position\\), ''\\) FROM todo_positions").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(""))
+		mock.ExpectExec("INSERT INTO users_todos").WithArgs(1, 4).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO todo_positions").WithArgs(1, 4, "V").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO todo_revisions").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO todo_events").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	expectInsert()
+	mock.ExpectExec("INSERT INTO caldav_resources \\(todo_id, user_id, name, uid\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\) ON CONFLICT \\(user_id, name\\) DO NOTHING").WithArgs(4, 1, "ABC-123.ics", "ABC-123").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectInsert()
+	mock.ExpectExec("INSERT INTO caldav_resources").WithArgs(4, 1, "ABC-123.ics", "ABC-123").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	todo, err := store.CreateCalDAVTodo(&models.Todo{TaskName: "Buy milk", DueDate: dueDate}, 1, &models.CalDAVResource{Name: "ABC-123.ics", UID: "ABC-123"})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, todo.ID)
+
+	// A name the user gave another todo creates nothing.
+	_, err = store.CreateCalDAVTodo(&models.Todo{TaskName: "Buy milk", DueDate: dueDate}, 1, &models.CalDAVResource{Name: "ABC-123.ics", UID: "ABC-123"})
+	assert.ErrorIs(t, err, ErrCalDAVNameTaken)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCalDAVResources(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectQuery("SELECT todo_id, user_id, name, uid FROM caldav_resources WHERE todo_id = ANY\\(\\$1\\)").WithArgs(pq.Array([]int{1, 4})).
+		WillReturnRows(sqlmock.NewRows([]string{"todo_id", "user_id", "name", "uid"}).AddRow(4, 1, "ABC-123.ics", "ABC-123"))
+	mock.ExpectQuery("SELECT todo_id, user_id, name, uid FROM caldav_resources WHERE name = \\$1 ORDER BY todo_id").WithArgs("ABC-123.ics").
+		WillReturnRows(sqlmock.NewRows([]string{"todo_id", "user_id", "name", "uid"}).AddRow(4, 1, "ABC-123.ics", "ABC-123").AddRow(9, 2, "ABC-123.ics", "ABC-123"))
+
+	resources, err := store.GetCalDAVResources([]int{1, 4})
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.CalDAVResource{{TodoID: 4, UserID: 1, Name: "ABC-123.ics", UID: "ABC-123"}}, resources)
+
+	resources, err = store.GetCalDAVResourcesByName("ABC-123.ics")
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.CalDAVResource{{TodoID: 4, UserID: 1, Name: "ABC-123.ics", UID: "ABC-123"}, {TodoID: 9, UserID: 2, Name: "ABC-123.ics", UID: "ABC-123"}}, resources)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSyncToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectQuery("SELECT txid_snapshot_xmin\\(txid_current_snapshot\\(\\)\\)").WillReturnRows(sqlmock.NewRows([]string{"txid_snapshot_xmin"}).AddRow(42))
+	mock.ExpectQuery("SELECT todo_id FROM todo_sync WHERE sync_id >= \\$1 AND \\$3 = ANY\\(project_ids\\) AND \\(\\$3 <> 0 OR \\$2 = ANY\\(user_ids\\)\\) ORDER BY sync_id, todo_id").WithArgs(40, 1, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"todo_id"}).AddRow(7).AddRow(3))
+
+	token, err := store.GetSyncToken()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), token)
+
+	todoIDs, err := store.GetChangedTodoIDs(40, 1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{7, 3}, todoIDs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
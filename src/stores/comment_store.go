@@ -0,0 +1,87 @@
+package stores
+
+import (
+	"todo-list/src/models"
+)
+
+const commentColumns = "id, todo_id, user_id, body, created_at, edited_at, deleted_at"
+
+func scanComment(row rowScanner, comment *models.Comment) error {
+	return row.Scan(&comment.ID, &comment.TodoID, &comment.UserID, &comment.Body, &comment.CreatedAt, &comment.EditedAt, &comment.DeletedAt)
+}
+
+func (store *DbStore) CreateComment(comment *models.Comment) (*models.Comment, error) {
+	newComment := &models.Comment{}
+	err := scanComment(store.DB.QueryRow("INSERT INTO todo_comments (todo_id, user_id, body) VALUES ($1, $2, $3) RETURNING "+commentColumns, comment.TodoID, comment.UserID, comment.Body), newComment)
+	if err != nil {
+		return nil, err
+	}
+	return newComment, nil
+}
+
+// GetComments returns every comment of a todo, oldest first. Deleted comments
+// are kept in the thread with their body cleared.
+func (store *DbStore) GetComments(todoID int) ([]*models.Comment, error) {
+	rows, err := store.DB.Query("SELECT id, todo_id, user_id, CASE WHEN deleted_at IS NULL THEN body ELSE '' END, created_at, edited_at, deleted_at FROM todo_comments WHERE todo_id = $1 ORDER BY created_at, id", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*models.Comment{}
+	for rows.Next() {
+		comment := &models.Comment{}
+		if err := scanComment(rows, comment); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (store *DbStore) GetComment(commentID int) (*models.Comment, error) {
+	comment := &models.Comment{}
+	err := scanComment(store.DB.QueryRow("SELECT "+commentColumns+" FROM todo_comments WHERE id = $1 AND deleted_at IS NULL", commentID), comment)
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (store *DbStore) UpdateComment(commentID int, body string) (*models.Comment, error) {
+	comment := &models.Comment{}
+	err := scanComment(store.DB.QueryRow("UPDATE todo_comments SET body = $1, edited_at = CURRENT_TIMESTAMP WHERE id = $2 AND deleted_at IS NULL RETURNING "+commentColumns, body, commentID), comment)
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (store *DbStore) DeleteComment(commentID int) error {
+	_, err := store.DB.Exec("UPDATE todo_comments SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL", commentID)
+	return err
+}
+
+func (store *DbStore) GetTodoChanges(todoID int) ([]*models.TodoChange, error) {
+	rows, err := store.DB.Query("SELECT id, todo_id, COALESCE(user_id, 0), field, old_value, new_value, created_at FROM todo_changes WHERE todo_id = $1 ORDER BY created_at, id", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []*models.TodoChange{}
+	for rows.Next() {
+		change := &models.TodoChange{}
+		if err := rows.Scan(&change.ID, &change.TodoID, &change.UserID, &change.Field, &change.OldValue, &change.NewValue, &change.CreatedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
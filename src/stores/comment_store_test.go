@@ -0,0 +1,115 @@
+package stores
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var commentRowColumns = []string{"id", "todo_id", "user_id", "body", "created_at", "edited_at", "deleted_at"}
+
+func TestCreateComment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC()
+
+	mock.ExpectQuery("INSERT INTO todo_comments").WithArgs(5, 1, "Looks good").WillReturnRows(sqlmock.NewRows(commentRowColumns).AddRow(3, 5, 1, "Looks good", createdAt, nil, nil))
+	comment, err := store.CreateComment(&models.Comment{TodoID: 5, UserID: 1, Body: "Looks good"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Comment{ID: 3, TodoID: 5, UserID: 1, Body: "Looks good", CreatedAt: createdAt}, comment)
+
+	mock.ExpectQuery("INSERT INTO todo_comments").WillReturnError(fmt.Errorf("some db error"))
+	_, err = store.CreateComment(&models.Comment{TodoID: 5, UserID: 1, Body: "Looks good"})
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetComments(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC()
+	deletedAt := createdAt.Add(time.Hour)
+
+	mock.ExpectQuery("SELECT (.+) FROM todo_comments WHERE todo_id = \\$1 ORDER BY created_at, id").WithArgs(5).WillReturnRows(sqlmock.NewRows(commentRowColumns).
+		AddRow(3, 5, 1, "First", createdAt, nil, nil).
+		AddRow(4, 5, 2, "", createdAt, nil, deletedAt))
+	comments, err := store.GetComments(5)
+	assert.NoError(t, err)
+	assert.Len(t, comments, 2)
+	assert.Nil(t, comments[0].DeletedAt)
+	assert.Equal(t, deletedAt, *comments[1].DeletedAt)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateComment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC()
+	editedAt := createdAt.Add(time.Minute)
+
+	mock.ExpectQuery("UPDATE todo_comments SET body = \\$1, edited_at = CURRENT_TIMESTAMP WHERE id = \\$2 AND deleted_at IS NULL").WithArgs("Edited", 3).WillReturnRows(sqlmock.NewRows(commentRowColumns).AddRow(3, 5, 1, "Edited", createdAt, editedAt, nil))
+	comment, err := store.UpdateComment(3, "Edited")
+	assert.NoError(t, err)
+	assert.Equal(t, editedAt, *comment.EditedAt)
+
+	mock.ExpectQuery("UPDATE todo_comments").WithArgs("Edited", 4).WillReturnError(sql.ErrNoRows)
+	_, err = store.UpdateComment(4, "Edited")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteComment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectExec("UPDATE todo_comments SET deleted_at = CURRENT_TIMESTAMP WHERE id = \\$1").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, store.DeleteComment(3))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTodoChanges(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC()
+
+	mock.ExpectQuery("SELECT (.+) FROM todo_changes WHERE todo_id = \\$1").WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"id", "todo_id", "user_id", "field", "old_value", "new_value", "created_at"}).
+		AddRow(1, 5, 1, "completed", "false", "true", createdAt))
+	changes, err := store.GetTodoChanges(5)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.TodoChange{{ID: 1, TodoID: 5, UserID: 1, Field: "completed", OldValue: "false", NewValue: "true", CreatedAt: createdAt}}, changes)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,188 @@
+package stores
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"todo-list/src/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrCustomFieldKeyTaken   = errors.New("the project already has a field with this key")
+	ErrCustomFieldKeyChanged = errors.New("the key of a field can not be changed")
+	ErrCustomFieldInUse      = errors.New("todos hold values the change would make invalid")
+)
+
+const customFieldColumns = "id, project_id, key, name, type, options, required, created_at"
+
+func scanCustomField(row rowScanner) (*models.CustomField, error) {
+	field := &models.CustomField{}
+	var options pq.StringArray
+	err := row.Scan(&field.ID, &field.ProjectID, &field.Key, &field.Name, &field.Type, &options, &field.Required, &field.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(options) > 0 {
+		field.Options = options
+	}
+	return field, nil
+}
+
+func scanCustomFields(rows *sql.Rows, err error) ([]*models.CustomField, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fields := []*models.CustomField{}
+	for rows.Next() {
+		field, err := scanCustomField(rows)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// fieldOptions keeps options from being stored as NULL.
+func fieldOptions(field *models.CustomField) any {
+	if field.Options == nil {
+		return pq.Array([]string{})
+	}
+	return pq.Array(field.Options)
+}
+
+// CreateCustomField adds a field to a project. It fails with
+// ErrCustomFieldKeyTaken when the project has a field with the same key.
+func (store *DbStore) CreateCustomField(field *models.CustomField) (*models.CustomField, error) {
+	created, err := scanCustomField(store.DB.QueryRow("INSERT INTO custom_fields (project_id, key, name, type, options, required) VALUES ($1, $2, $3, $4, $5, $6)"+
+		" ON CONFLICT (project_id, key) DO NOTHING RETURNING "+customFieldColumns, field.ProjectID, field.Key, field.Name, field.Type, fieldOptions(field), field.Required))
+	if err == sql.ErrNoRows {
+		return nil, ErrCustomFieldKeyTaken
+	}
+	return created, err
+}
+
+// GetCustomFields returns the fields of a project, oldest first.
+func (store *DbStore) GetCustomFields(projectID int) ([]*models.CustomField, error) {
+	return scanCustomFields(store.DB.Query("SELECT "+customFieldColumns+" FROM custom_fields WHERE project_id = $1 ORDER BY id", projectID))
+}
+
+// GetTodoCustomFields returns the fields of the project a todo is in, or none
+// for todos outside a project.
+func (store *DbStore) GetTodoCustomFields(todoID int) ([]*models.CustomField, error) {
+	return scanCustomFields(store.DB.Query("SELECT "+customFieldColumns+" FROM custom_fields WHERE project_id = (SELECT project_id FROM todos WHERE id = $1) ORDER BY id", todoID))
+}
+
+// UpdateCustomField renames a field or changes its type, options or whether
+// it is required. Changes that values already stored would not pass, such as
+// a new type for a field some todo holds or dropping an option in use, fail
+// with ErrCustomFieldInUse so no todo is left with a value its field rejects.
+// Todos in the trash count too, except for newly required fields. The key
+// never changes; giving another one fails with ErrCustomFieldKeyChanged.
+func (store *DbStore) UpdateCustomField(field *models.CustomField) (*models.CustomField, error) {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	var previous *models.CustomField
+	previous, err = scanCustomField(transaction.QueryRow("SELECT "+customFieldColumns+" FROM custom_fields WHERE id = $1 AND project_id = $2 FOR UPDATE", field.ID, field.ProjectID))
+	if err != nil {
+		return nil, err
+	}
+	if field.Key != previous.Key {
+		err = ErrCustomFieldKeyChanged
+		return nil, err
+	}
+
+	conditions := []string{}
+	args := []any{field.ProjectID, field.Key}
+	if field.Type != previous.Type {
+		conditions = append(conditions, "custom_fields ? $2")
+	} else if removed := removedOptions(previous.Options, field.Options); len(removed) > 0 {
+		args = append(args, pq.Array(removed))
+		if field.Type == models.FieldMultiSelect {
+			conditions = append(conditions, "custom_fields -> $2 ?| $3::text[]")
+		} else {
+			conditions = append(conditions, "custom_fields ->> $2 = ANY($3)")
+		}
+	}
+	if field.Required && !previous.Required {
+		conditions = append(conditions, "(deleted_at IS NULL AND NOT custom_fields ? $2)")
+	}
+	if len(conditions) > 0 {
+		var inUse bool
+		err = transaction.QueryRow("SELECT EXISTS (SELECT 1 FROM todos WHERE project_id = $1 AND ("+strings.Join(conditions, " OR ")+"))", args...).Scan(&inUse)
+		if err != nil {
+			return nil, err
+		}
+		if inUse {
+			err = ErrCustomFieldInUse
+			return nil, err
+		}
+	}
+
+	var updated *models.CustomField
+	updated, err = scanCustomField(transaction.QueryRow("UPDATE custom_fields SET name = $1, type = $2, options = $3, required = $4 WHERE id = $5 RETURNING "+customFieldColumns,
+		field.Name, field.Type, fieldOptions(field), field.Required, field.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	err = transaction.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// removedOptions lists the options of before missing from after.
+func removedOptions(before []string, after []string) []string {
+	kept := map[string]bool{}
+	for _, option := range after {
+		kept[option] = true
+	}
+	removed := []string{}
+	for _, option := range before {
+		if !kept[option] {
+			removed = append(removed, option)
+		}
+	}
+	return removed
+}
+
+// DeleteCustomField removes a field along with the values todos hold for it.
+func (store *DbStore) DeleteCustomField(fieldID int, projectID int) error {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	var key string
+	err = transaction.QueryRow("DELETE FROM custom_fields WHERE id = $1 AND project_id = $2 RETURNING key", fieldID, projectID).Scan(&key)
+	if err != nil {
+		return err
+	}
+	_, err = transaction.Exec("UPDATE todos SET custom_fields = custom_fields - $1::text WHERE project_id = $2 AND custom_fields ? $1", key, projectID)
+	if err != nil {
+		return err
+	}
+	return transaction.Commit()
+}
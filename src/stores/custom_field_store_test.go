@@ -0,0 +1,153 @@
+package stores
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomFields(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 12, 2, 9, 0, 0, 0, time.UTC)
+	columns := []string{"id", "project_id", "key", "name", "type", "options", "required", "created_at"}
+	stage := &models.CustomField{ID: 4, ProjectID: 2, Key: "stage", Name: "Stage", Type: models.FieldSelect, Options: []string{"lead", "won", "lost"}, CreatedAt: createdAt}
+
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO custom_fields (project_id, key, name, type, options, required) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (project_id, key) DO NOTHING")).
+		WithArgs(2, "stage", "Stage", "select", pq.Array([]string{"lead", "won", "lost"}), false).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(4, 2, "stage", "Stage", "select", "{lead,won,lost}", false, createdAt))
+	mock.ExpectQuery("INSERT INTO custom_fields").WithArgs(2, "stage", "Stage", "text", pq.Array([]string{}), false).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM custom_fields WHERE project_id = (SELECT project_id FROM todos WHERE id = $1) ORDER BY id")).WithArgs(9).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(4, 2, "stage", "Stage", "select", "{lead,won,lost}", false, createdAt))
+
+	created, err := store.CreateCustomField(&models.CustomField{ProjectID: 2, Key: "stage", Name: "Stage", Type: models.FieldSelect, Options: stage.Options})
+	assert.NoError(t, err)
+	assert.Equal(t, stage, created)
+
+	_, err = store.CreateCustomField(&models.CustomField{ProjectID: 2, Key: "stage", Name: "Stage", Type: models.FieldText})
+	assert.ErrorIs(t, err, ErrCustomFieldKeyTaken)
+
+	fields, err := store.GetTodoCustomFields(9)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.CustomField{stage}, fields)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateCustomField(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 12, 2, 9, 0, 0, 0, time.UTC)
+	columns := []string{"id", "project_id", "key", "name", "type", "options", "required", "created_at"}
+	expectPrevious := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("FROM custom_fields WHERE id = $1 AND project_id = $2 FOR UPDATE")).WithArgs(4, 2).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(4, 2, "stage", "Stage", "select", "{lead,won,lost}", false, createdAt))
+	}
+
+	// Dropping an option some todo holds is refused.
+	expectPrevious()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM todos WHERE project_id = $1 AND (custom_fields ->> $2 = ANY($3) OR (deleted_at IS NULL AND NOT custom_fields ? $2)))")).
+		WithArgs(2, "stage", pq.Array([]string{"lost"})).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	_, err = store.UpdateCustomField(&models.CustomField{ID: 4, ProjectID: 2, Key: "stage", Name: "Stage", Type: models.FieldSelect, Options: []string{"lead", "won"}, Required: true})
+	assert.ErrorIs(t, err, ErrCustomFieldInUse)
+
+	// So is changing the type of a field todos hold values for.
+	expectPrevious()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM todos WHERE project_id = $1 AND (custom_fields ? $2))")).
+		WithArgs(2, "stage").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	_, err = store.UpdateCustomField(&models.CustomField{ID: 4, ProjectID: 2, Key: "stage", Name: "Stage", Type: models.FieldText})
+	assert.ErrorIs(t, err, ErrCustomFieldInUse)
+
+	expectPrevious()
+	mock.ExpectRollback()
+
+	_, err = store.UpdateCustomField(&models.CustomField{ID: 4, ProjectID: 2, Key: "phase", Name: "Stage", Type: models.FieldSelect, Options: []string{"lead", "won", "lost"}})
+	assert.ErrorIs(t, err, ErrCustomFieldKeyChanged)
+
+	// Renaming and adding options never invalidates a value.
+	expectPrevious()
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE custom_fields SET name = $1, type = $2, options = $3, required = $4 WHERE id = $5")).
+		WithArgs("Deal stage", "select", pq.Array([]string{"lead", "won", "lost", "paused"}), false, 4).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(4, 2, "stage", "Deal stage", "select", "{lead,won,lost,paused}", false, createdAt))
+	mock.ExpectCommit()
+
+	updated, err := store.UpdateCustomField(&models.CustomField{ID: 4, ProjectID: 2, Key: "stage", Name: "Deal stage", Type: models.FieldSelect, Options: []string{"lead", "won", "lost", "paused"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Deal stage", updated.Name)
+	assert.Equal(t, []string{"lead", "won", "lost", "paused"}, updated.Options)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteCustomField(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("DELETE FROM custom_fields WHERE id = $1 AND project_id = $2 RETURNING key")).WithArgs(4, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("stage"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE todos SET custom_fields = custom_fields - $1::text WHERE project_id = $2 AND custom_fields ? $1")).WithArgs("stage", 2).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery("DELETE FROM custom_fields").WithArgs(5, 2).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	assert.NoError(t, store.DeleteCustomField(4, 2))
+	assert.ErrorIs(t, store.DeleteCustomField(5, 2), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTodosCustomFields(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 12, 2, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("AND t.project_id = $3 AND t.custom_fields @> $4::jsonb AND t.custom_fields @> $5::jsonb ORDER BY t.custom_fields -> $6 DESC NULLS LAST, tp.position NULLS LAST, t.id")).
+		WithArgs(1, 4, 2, `{"stage":"won"}`, `{"labels":["urgent"]}`, "estimate").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields", "position", "role"}).
+			AddRow(5, "Close the deal", false, time.Time{}, createdAt, createdAt, "", 4, 2, nil, false, nil, 0, "", false, 0, nil, []byte(`{"stage": "won", "labels": ["urgent"], "estimate": 3}`), "V", "editor").
+			AddRow(6, "Send the invoice", false, time.Time{}, createdAt, createdAt, "", 4, 2, nil, false, nil, 0, "", false, 0, nil, []byte(`{}`), "k", "editor"))
+	mock.ExpectCommit()
+
+	todos, err := store.GetTodos(1, 4, &models.TodoFilter{
+		ProjectID:      2,
+		Fields:         []models.CustomFieldMatch{{Key: "stage", Value: "won"}, {Key: "labels", Value: []string{"urgent"}}},
+		SortField:      "estimate",
+		SortDescending: true,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, todos, 2) {
+		assert.Equal(t, map[string]any{"stage": "won", "labels": []any{"urgent"}, "estimate": float64(3)}, todos[0].CustomFields)
+		assert.Nil(t, todos[1].CustomFields)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,158 @@
+package stores
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"todo-list/src/models"
+)
+
+var ErrDependencyCycle = errors.New("the dependency would create a cycle")
+
+// BlockedError is returned when completing a todo that still waits for open
+// todos.
+type BlockedError struct {
+	BlockerIDs []int
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("todo is blocked by open todos %v", e.BlockerIDs)
+}
+
+// dependencyGraph builds a CTE named graph with the ids of the todo passed as
+// param, every todo it transitively waits for and every todo transitively
+// waiting for it. Trashed todos and the edges through them are left out.
+func dependencyGraph(param string) string {
+	return "WITH RECURSIVE blockers(id) AS (" +
+		"SELECT " + param + "::int UNION SELECT d.blocked_by_id FROM todo_dependencies d JOIN blockers ON d.todo_id = blockers.id" +
+		" JOIN todos x ON x.id = d.blocked_by_id AND x.deleted_at IS NULL" +
+		"), dependents(id) AS (" +
+		"SELECT " + param + "::int UNION SELECT d.todo_id FROM todo_dependencies d JOIN dependents ON d.blocked_by_id = dependents.id" +
+		" JOIN todos x ON x.id = d.todo_id AND x.deleted_at IS NULL" +
+		"), graph(id) AS (SELECT id FROM blockers UNION SELECT id FROM dependents) "
+}
+
+// AddDependency makes todoID wait for blockedByID. It fails with
+// ErrDependencyCycle when blockedByID already waits for todoID, directly or
+// through other todos.
+func (store *DbStore) AddDependency(todoID int, blockedByID int) error {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	// Two concurrent inserts could each pass the cycle check and close a
+	// cycle together, so edges are added one at a time.
+	_, err = transaction.Exec("LOCK TABLE todo_dependencies IN SHARE ROW EXCLUSIVE MODE")
+	if err != nil {
+		return err
+	}
+
+	var cycle bool
+	err = transaction.QueryRow("WITH RECURSIVE blockers(id) AS ("+
+		"SELECT blocked_by_id FROM todo_dependencies WHERE todo_id = $1 "+
+		"UNION SELECT d.blocked_by_id FROM todo_dependencies d JOIN blockers ON d.todo_id = blockers.id"+
+		") SELECT EXISTS (SELECT 1 FROM blockers WHERE id = $2)", blockedByID, todoID).Scan(&cycle)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		err = ErrDependencyCycle
+		return err
+	}
+
+	_, err = transaction.Exec("INSERT INTO todo_dependencies (todo_id, blocked_by_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", todoID, blockedByID)
+	if err != nil {
+		return err
+	}
+
+	return transaction.Commit()
+}
+
+func (store *DbStore) RemoveDependency(todoID int, blockedByID int) error {
+	result, err := store.DB.Exec("DELETE FROM todo_dependencies WHERE todo_id = $1 AND blocked_by_id = $2", todoID, blockedByID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetDependencyGraph returns the dependency graph around the todo. Todos user
+// can not see in the workspace are reduced to hidden nodes.
+func (store *DbStore) GetDependencyGraph(todoID int, userID int, workspaceID int) (*models.DependencyGraph, error) {
+	rows, err := store.DB.Query(dependencyGraph("$3")+"SELECT n.id, n.task_name, n.completed,"+
+		" EXISTS (SELECT 1 FROM todo_dependencies d JOIN todos b ON b.id = d.blocked_by_id WHERE d.todo_id = n.id AND NOT b.completed AND b.deleted_at IS NULL),"+
+		" EXISTS (SELECT 1"+visibleTodos+" AND t.id = n.id)"+
+		" FROM graph g JOIN todos n ON n.id = g.id ORDER BY n.id", userID, workspaceID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	graph := &models.DependencyGraph{Nodes: []*models.GraphNode{}, Edges: []*models.GraphEdge{}}
+	for rows.Next() {
+		node := &models.GraphNode{}
+		var visible bool
+		if err := rows.Scan(&node.ID, &node.TaskName, &node.Completed, &node.Blocked, &visible); err != nil {
+			return nil, err
+		}
+		if !visible {
+			node = &models.GraphNode{ID: node.ID, Hidden: true}
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	edges, err := store.DB.Query(dependencyGraph("$1")+"SELECT d.todo_id, d.blocked_by_id FROM todo_dependencies d"+
+		" JOIN graph a ON a.id = d.todo_id JOIN graph b ON b.id = d.blocked_by_id ORDER BY d.todo_id, d.blocked_by_id", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer edges.Close()
+
+	for edges.Next() {
+		edge := &models.GraphEdge{}
+		if err := edges.Scan(&edge.TodoID, &edge.BlockedBy); err != nil {
+			return nil, err
+		}
+		graph.Edges = append(graph.Edges, edge)
+	}
+	if err := edges.Err(); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+// openBlockers lists the todos blocking todoID that are not completed yet.
+func openBlockers(transaction *sql.Tx, todoID int) ([]int, error) {
+	rows, err := transaction.Query("SELECT b.id FROM todo_dependencies d JOIN todos b ON b.id = d.blocked_by_id WHERE d.todo_id = $1 AND NOT b.completed AND b.deleted_at IS NULL ORDER BY b.id", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blockerIDs := []int{}
+	for rows.Next() {
+		var blockerID int
+		if err := rows.Scan(&blockerID); err != nil {
+			return nil, err
+		}
+		blockerIDs = append(blockerIDs, blockerID)
+	}
+	return blockerIDs, rows.Err()
+}
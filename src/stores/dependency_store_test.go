@@ -0,0 +1,117 @@
+package stores
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddDependency(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("LOCK TABLE todo_dependencies IN SHARE ROW EXCLUSIVE MODE").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("WITH RECURSIVE blockers").WithArgs(3, 5).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec("INSERT INTO todo_dependencies \\(todo_id, blocked_by_id\\) VALUES \\(\\$1, \\$2\\) ON CONFLICT DO NOTHING").WithArgs(5, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, store.AddDependency(5, 3))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("LOCK TABLE todo_dependencies").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("WITH RECURSIVE blockers").WithArgs(5, 3).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, store.AddDependency(3, 5), ErrDependencyCycle)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRemoveDependency(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectExec("DELETE FROM todo_dependencies WHERE todo_id = \\$1 AND blocked_by_id = \\$2").WithArgs(5, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, store.RemoveDependency(5, 3))
+
+	mock.ExpectExec("DELETE FROM todo_dependencies").WithArgs(5, 4).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, store.RemoveDependency(5, 4), sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDependencyGraph(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectQuery("WITH RECURSIVE (.+) SELECT n.id, n.task_name, n.completed, (.+) FROM graph g JOIN todos n ON n.id = g.id ORDER BY n.id").WithArgs(1, 0, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "blocked", "visible"}).
+			AddRow(3, "Design", false, false, true).
+			AddRow(5, "Build", false, true, true).
+			AddRow(8, "Secret", false, true, false))
+	mock.ExpectQuery("WITH RECURSIVE (.+) SELECT d.todo_id, d.blocked_by_id FROM todo_dependencies d").WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"todo_id", "blocked_by_id"}).AddRow(5, 3).AddRow(8, 5))
+
+	graph, err := store.GetDependencyGraph(5, 1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.DependencyGraph{
+		Nodes: []*models.GraphNode{{ID: 3, TaskName: "Design"}, {ID: 5, TaskName: "Build", Blocked: true}, {ID: 8, Hidden: true}},
+		Edges: []*models.GraphEdge{{TodoID: 5, BlockedBy: 3}, {TodoID: 8, BlockedBy: 5}},
+	}, graph)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateBlockedTodo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	dueDate := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	todoRowColumns := []string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}
+	todo := &models.Todo{TaskName: "Build", Completed: true, DueDate: dueDate}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM todos t WHERE t.id=\\$1 AND t.deleted_at IS NULL FOR UPDATE").WithArgs(5).WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(5, "Build", false, dueDate, dueDate, dueDate, "", 0, 0, nil, true, nil, 0, "", false, 0, nil, nil))
+	mock.ExpectQuery("SELECT b.id FROM todo_dependencies d JOIN todos b ON b.id = d.blocked_by_id WHERE d.todo_id = \\$1 AND NOT b.completed").WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(4))
+	mock.ExpectRollback()
+
+	_, err = store.UpdateTodo(todo, 5, 1, false)
+	var blocked *BlockedError
+	assert.ErrorAs(t, err, &blocked)
+	assert.Equal(t, []int{3, 4}, blocked.BlockerIDs)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM todos t WHERE t.id=\\$1 AND t.deleted_at IS NULL FOR UPDATE").WithArgs(5).WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(5, "Build", false, dueDate, dueDate, dueDate, "", 0, 0, nil, true, nil, 0, "", false, 0, nil, nil))
+	mock.ExpectQuery("UPDATE todos t SET (.+) RETURNING").WithArgs(todo.TaskName, true, dueDate, false, "", 0, "", "{}", 0, 5).WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(5, "Build", true, dueDate, dueDate, dueDate, "", 0, 0, nil, true, nil, 0, "", false, 0, nil, nil))
+	mock.ExpectExec("INSERT INTO todo_changes").WithArgs(5, 1, "completed", "false", "true").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO todo_revisions").WithArgs(5, 1, "update", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO todo_events").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	updated, err := store.UpdateTodo(todo, 5, 1, true)
+	assert.NoError(t, err)
+	assert.True(t, updated.Completed)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,58 @@
+package stores
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportTodos(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	dueDate := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	columns := []string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields", "position", "role"}
+	exportRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).
+			AddRow(1, "Buy milk", false, dueDate, dueDate, dueDate, "", 0, 0, "{}", false, "{home}", 2, "", false, 0, nil, nil, "F", "owner").
+			AddRow(2, "File taxes", true, dueDate, dueDate, dueDate, "", 0, 0, "{}", false, nil, 0, "", false, 0, nil, nil, "V", "owner")
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM todos t LEFT JOIN users_todos ut (.+) AND EXISTS \\(SELECT 1 FROM todo_tags tg WHERE tg.todo_id = t.id AND tg.tag = \\$3\\) ORDER BY tp.position NULLS LAST, t.id").
+		WithArgs(1, 0, "home").WillReturnRows(exportRows())
+
+	var exported []*models.Todo
+	err = store.ExportTodos(1, 0, &models.TodoFilter{Tag: "home"}, func(todo *models.Todo) error {
+		exported = append(exported, todo)
+		return nil
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, exported, 2) {
+		assert.Equal(t, "Buy milk", exported[0].TaskName)
+		assert.Equal(t, []string{"home"}, exported[0].Tags)
+		assert.Equal(t, 2, exported[0].Priority)
+		assert.True(t, exported[1].Completed)
+	}
+
+	// An error from the callback ends the export.
+	writeErr := errors.New("broken pipe")
+	mock.ExpectQuery("SELECT (.+) ORDER BY tp.position NULLS LAST, t.id").WithArgs(1, 0).WillReturnRows(exportRows()).RowsWillBeClosed()
+
+	calls := 0
+	err = store.ExportTodos(1, 0, nil, func(todo *models.Todo) error {
+		calls++
+		return writeErr
+	})
+	assert.ErrorIs(t, err, writeErr)
+	assert.Equal(t, 1, calls)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,49 @@
+package stores
+
+import (
+	"database/sql"
+	"todo-list/src/models"
+)
+
+// CreateCalendarFeed saves the feed of the user in the workspace, replacing
+// the token of an existing one so its old URL stops working. Only a hash of
+// the token is stored.
+func (store *DbStore) CreateCalendarFeed(feed *models.CalendarFeed) (*models.CalendarFeed, error) {
+	newFeed := *feed
+	err := store.DB.QueryRow("INSERT INTO calendar_feeds (user_id, workspace_id, token_hash) VALUES ($1, NULLIF($2, 0), $3)"+
+		" ON CONFLICT (user_id, COALESCE(workspace_id, 0)) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = NOW() RETURNING created_at",
+		feed.UserID, feed.WorkspaceID, hashToken(feed.Token)).Scan(&newFeed.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &newFeed, nil
+}
+
+// GetCalendarFeed finds the feed a token belongs to. Unknown tokens give
+// sql.ErrNoRows.
+func (store *DbStore) GetCalendarFeed(token string) (*models.CalendarFeed, error) {
+	feed := &models.CalendarFeed{}
+	err := store.DB.QueryRow("SELECT user_id, COALESCE(workspace_id, 0), created_at FROM calendar_feeds WHERE token_hash = $1", hashToken(token)).
+		Scan(&feed.UserID, &feed.WorkspaceID, &feed.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// DeleteCalendarFeed revokes the feed of the user in the workspace. It gives
+// sql.ErrNoRows when there is none.
+func (store *DbStore) DeleteCalendarFeed(userID int, workspaceID int) error {
+	result, err := store.DB.Exec("DELETE FROM calendar_feeds WHERE user_id = $1 AND workspace_id IS NOT DISTINCT FROM NULLIF($2, 0)", userID, workspaceID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package stores
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateCalendarFeed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+
+	mock.ExpectQuery("INSERT INTO calendar_feeds \\(user_id, workspace_id, token_hash\\) VALUES \\(\\$1, NULLIF\\(\\$2, 0\\), \\$3\\) ON CONFLICT \\(user_id, COALESCE\\(workspace_id, 0\\)\\) DO UPDATE SET token_hash = EXCLUDED.token_hash").
+		WithArgs(1, 4, hashToken("secret-token")).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+
+	feed, err := store.CreateCalendarFeed(&models.CalendarFeed{UserID: 1, WorkspaceID: 4, Token: "secret-token"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.CalendarFeed{UserID: 1, WorkspaceID: 4, Token: "secret-token", CreatedAt: createdAt}, feed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCalendarFeed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT user_id, COALESCE\\(workspace_id, 0\\), created_at FROM calendar_feeds WHERE token_hash = \\$1").WithArgs(hashToken("secret-token")).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "workspace_id", "created_at"}).AddRow(1, 0, createdAt))
+	mock.ExpectQuery("SELECT (.+) FROM calendar_feeds").WithArgs(hashToken("old-token")).WillReturnError(sql.ErrNoRows)
+
+	feed, err := store.GetCalendarFeed("secret-token")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.CalendarFeed{UserID: 1, CreatedAt: createdAt}, feed)
+
+	_, err = store.GetCalendarFeed("old-token")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteCalendarFeed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectExec("DELETE FROM calendar_feeds WHERE user_id = \\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\\(\\$2, 0\\)").WithArgs(1, 0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM calendar_feeds").WithArgs(1, 4).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, store.DeleteCalendarFeed(1, 0))
+	assert.ErrorIs(t, store.DeleteCalendarFeed(1, 4), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,66 @@
+package stores
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+	"todo-list/src/models"
+)
+
+// ClaimIdempotencyKey reserves key for a new request of the user. When the
+// key is already held by a request made after expiredBefore it returns that
+// key and false; an older key is taken over as if it never existed. The
+// fingerprint of the request is only stored with its response.
+func (store *DbStore) ClaimIdempotencyKey(userID int, key string, expiredBefore time.Time) (*models.IdempotencyKey, bool, error) {
+	var claimedKey string
+	err := store.DB.QueryRow("INSERT INTO idempotency_keys (user_id, key) VALUES ($1, $2)"+
+		" ON CONFLICT (user_id, key) DO UPDATE SET fingerprint = NULL, status_code = NULL, headers = NULL, body = NULL, created_at = NOW()"+
+		" WHERE idempotency_keys.created_at < $3 RETURNING key", userID, key, expiredBefore).Scan(&claimedKey)
+	if err == nil {
+		return nil, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	idempotencyKey := &models.IdempotencyKey{}
+	var statusCode sql.NullInt64
+	var headers []byte
+	err = store.DB.QueryRow("SELECT user_id, key, COALESCE(fingerprint, ''), status_code, headers, body, created_at FROM idempotency_keys WHERE user_id = $1 AND key = $2", userID, key).
+		Scan(&idempotencyKey.UserID, &idempotencyKey.Key, &idempotencyKey.Fingerprint, &statusCode, &headers, &idempotencyKey.Body, &idempotencyKey.CreatedAt)
+	if err != nil {
+		return nil, false, err
+	}
+	idempotencyKey.StatusCode = int(statusCode.Int64)
+	if headers != nil {
+		if err := json.Unmarshal(headers, &idempotencyKey.Header); err != nil {
+			return nil, false, err
+		}
+	}
+	return idempotencyKey, false, nil
+}
+
+// SaveIdempotentResponse stores the fingerprint and response of the request
+// holding the key so retries can replay it.
+func (store *DbStore) SaveIdempotentResponse(idempotencyKey *models.IdempotencyKey) error {
+	headers, err := json.Marshal(idempotencyKey.Header)
+	if err != nil {
+		return err
+	}
+	_, err = store.DB.Exec("UPDATE idempotency_keys SET fingerprint = $1, status_code = $2, headers = $3, body = $4 WHERE user_id = $5 AND key = $6",
+		idempotencyKey.Fingerprint, idempotencyKey.StatusCode, string(headers), idempotencyKey.Body, idempotencyKey.UserID, idempotencyKey.Key)
+	return err
+}
+
+// ReleaseIdempotencyKey forgets a key whose request did not produce a
+// response worth replaying, so a retry runs it again.
+func (store *DbStore) ReleaseIdempotencyKey(userID int, key string) error {
+	_, err := store.DB.Exec("DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND status_code IS NULL", userID, key)
+	return err
+}
+
+// PurgeIdempotencyKeys deletes the keys created before the given time.
+func (store *DbStore) PurgeIdempotencyKeys(before time.Time) error {
+	_, err := store.DB.Exec("DELETE FROM idempotency_keys WHERE created_at < $1", before)
+	return err
+}
@@ -0,0 +1,86 @@
+package stores
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	expiredBefore := time.Date(2024, 12, 1, 8, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2024, 12, 1, 20, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("INSERT INTO idempotency_keys \\(user_id, key\\) VALUES \\(\\$1, \\$2\\) ON CONFLICT \\(user_id, key\\) DO UPDATE SET fingerprint = NULL, (.+) WHERE idempotency_keys.created_at < \\$3 RETURNING key").
+		WithArgs(1, "abc", expiredBefore).WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("abc"))
+	storedKey, claimed, err := store.ClaimIdempotencyKey(1, "abc", expiredBefore)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Nil(t, storedKey)
+
+	mock.ExpectQuery("INSERT INTO idempotency_keys").WithArgs(1, "abc", expiredBefore).WillReturnRows(sqlmock.NewRows([]string{"key"}))
+	mock.ExpectQuery("SELECT user_id, key, COALESCE\\(fingerprint, ''\\), status_code, headers, body, created_at FROM idempotency_keys WHERE user_id = \\$1 AND key = \\$2").WithArgs(1, "abc").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "key", "fingerprint", "status_code", "headers", "body", "created_at"}).
+			AddRow(1, "abc", "f1", 201, []byte(`{"Content-Type":["application/json"]}`), []byte(`{"id":7}`), createdAt))
+	storedKey, claimed, err = store.ClaimIdempotencyKey(1, "abc", expiredBefore)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, &models.IdempotencyKey{
+		UserID:      1,
+		Key:         "abc",
+		Fingerprint: "f1",
+		StatusCode:  201,
+		Header:      http.Header{"Content-Type": {"application/json"}},
+		Body:        []byte(`{"id":7}`),
+		CreatedAt:   createdAt,
+	}, storedKey)
+
+	mock.ExpectQuery("INSERT INTO idempotency_keys").WithArgs(1, "def", expiredBefore).WillReturnRows(sqlmock.NewRows([]string{"key"}))
+	mock.ExpectQuery("SELECT (.+) FROM idempotency_keys").WithArgs(1, "def").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "key", "fingerprint", "status_code", "headers", "body", "created_at"}).
+			AddRow(1, "def", "", nil, nil, nil, createdAt))
+	storedKey, claimed, err = store.ClaimIdempotencyKey(1, "def", expiredBefore)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, 0, storedKey.StatusCode)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveAndReleaseIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectExec("UPDATE idempotency_keys SET fingerprint = \\$1, status_code = \\$2, headers = \\$3, body = \\$4 WHERE user_id = \\$5 AND key = \\$6").
+		WithArgs("f1", 201, `{"Content-Type":["application/json"]}`, []byte(`{"id":7}`), 1, "abc").WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, store.SaveIdempotentResponse(&models.IdempotencyKey{
+		UserID:      1,
+		Key:         "abc",
+		Fingerprint: "f1",
+		StatusCode:  201,
+		Header:      http.Header{"Content-Type": {"application/json"}},
+		Body:        []byte(`{"id":7}`),
+	}))
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE user_id = \\$1 AND key = \\$2 AND status_code IS NULL").WithArgs(1, "abc").WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, store.ReleaseIdempotencyKey(1, "abc"))
+
+	before := time.Date(2024, 12, 1, 8, 0, 0, 0, time.UTC)
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE created_at < \\$1").WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 3))
+	assert.NoError(t, store.PurgeIdempotencyKeys(before))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,65 @@
+package stores
+
+import (
+	"encoding/json"
+	"todo-list/src/models"
+)
+
+const importColumns = "id, user_id, COALESCE(workspace_id, 0), format, file_name, status, total, processed, created, failed, errors, created_at, finished_at"
+
+func scanImport(row rowScanner, imp *models.Import) error {
+	var errors []byte
+	err := row.Scan(&imp.ID, &imp.UserID, &imp.WorkspaceID, &imp.Format, &imp.FileName, &imp.Status, &imp.Total, &imp.Processed, &imp.Created, &imp.Failed, &errors, &imp.CreatedAt, &imp.FinishedAt)
+	if err != nil {
+		return err
+	}
+	imp.Errors = []models.ImportError{}
+	return json.Unmarshal(errors, &imp.Errors)
+}
+
+func (store *DbStore) CreateImport(imp *models.Import) (*models.Import, error) {
+	errors, err := importErrorsJSON(imp.Errors)
+	if err != nil {
+		return nil, err
+	}
+	newImport := &models.Import{}
+	err = scanImport(store.DB.QueryRow("INSERT INTO imports (user_id, workspace_id, format, file_name, status, total, processed, failed, errors) VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7, $8, $9) RETURNING "+importColumns,
+		imp.UserID, imp.WorkspaceID, imp.Format, imp.FileName, imp.Status, imp.Total, imp.Processed, imp.Failed, errors), newImport)
+	if err != nil {
+		return nil, err
+	}
+	return newImport, nil
+}
+
+// GetImport returns an import started by the user. It returns sql.ErrNoRows
+// for imports of other users.
+func (store *DbStore) GetImport(importID int, userID int) (*models.Import, error) {
+	imp := &models.Import{}
+	err := scanImport(store.DB.QueryRow("SELECT "+importColumns+" FROM imports WHERE id = $1 AND user_id = $2", importID, userID), imp)
+	if err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+// UpdateImport saves the progress of a running import.
+func (store *DbStore) UpdateImport(imp *models.Import) error {
+	errors, err := importErrorsJSON(imp.Errors)
+	if err != nil {
+		return err
+	}
+	_, err = store.DB.Exec("UPDATE imports SET status = $1, processed = $2, created = $3, failed = $4, errors = $5, finished_at = $6 WHERE id = $7",
+		imp.Status, imp.Processed, imp.Created, imp.Failed, errors, imp.FinishedAt, imp.ID)
+	return err
+}
+
+func importErrorsJSON(importErrors []models.ImportError) (string, error) {
+	if importErrors == nil {
+		importErrors = []models.ImportError{}
+	}
+	data, err := json.Marshal(importErrors)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
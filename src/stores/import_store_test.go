@@ -0,0 +1,51 @@
+package stores
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImports(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 12, 1, 8, 0, 0, 0, time.UTC)
+	importRowColumns := []string{"id", "user_id", "workspace_id", "format", "file_name", "status", "total", "processed", "created", "failed", "errors", "created_at", "finished_at"}
+	importErrors := `[{"line":2,"errors":{"TaskName":"This field is required"}}]`
+
+	mock.ExpectQuery("INSERT INTO imports \\(user_id, workspace_id, format, file_name, status, total, processed, failed, errors\\) VALUES \\(\\$1, NULLIF\\(\\$2, 0\\), (.+)\\) RETURNING (.+)").
+		WithArgs(1, 3, "csv", "todos.csv", "pending", 10, 1, 1, importErrors).
+		WillReturnRows(sqlmock.NewRows(importRowColumns).AddRow(4, 1, 3, "csv", "todos.csv", "pending", 10, 1, 0, 1, []byte(importErrors), createdAt, nil))
+	imp, err := store.CreateImport(&models.Import{UserID: 1, WorkspaceID: 3, Format: "csv", FileName: "todos.csv", Status: "pending", Total: 10, Processed: 1, Failed: 1,
+		Errors: []models.ImportError{{Line: 2, Errors: map[string]string{"TaskName": "This field is required"}}}})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Import{ID: 4, UserID: 1, WorkspaceID: 3, Format: "csv", FileName: "todos.csv", Status: "pending", Total: 10, Processed: 1, Failed: 1,
+		Errors: []models.ImportError{{Line: 2, Errors: map[string]string{"TaskName": "This field is required"}}}, CreatedAt: createdAt}, imp)
+
+	finishedAt := time.Date(2024, 12, 1, 8, 1, 0, 0, time.UTC)
+	mock.ExpectExec("UPDATE imports SET status = \\$1, processed = \\$2, created = \\$3, failed = \\$4, errors = \\$5, finished_at = \\$6 WHERE id = \\$7").
+		WithArgs("completed", 10, 9, 1, importErrors, &finishedAt, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	imp.Status, imp.Processed, imp.Created, imp.FinishedAt = "completed", 10, 9, &finishedAt
+	assert.NoError(t, store.UpdateImport(imp))
+
+	mock.ExpectQuery("SELECT (.+) FROM imports WHERE id = \\$1 AND user_id = \\$2").WithArgs(4, 1).
+		WillReturnRows(sqlmock.NewRows(importRowColumns).AddRow(4, 1, 0, "csv", "todos.csv", "completed", 10, 10, 10, 0, []byte("[]"), createdAt, finishedAt))
+	imp, err = store.GetImport(4, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Import{ID: 4, UserID: 1, Format: "csv", FileName: "todos.csv", Status: "completed", Total: 10, Processed: 10, Created: 10,
+		Errors: []models.ImportError{}, CreatedAt: createdAt, FinishedAt: &finishedAt}, imp)
+
+	mock.ExpectQuery("SELECT (.+) FROM imports").WithArgs(4, 2).WillReturnRows(sqlmock.NewRows(importRowColumns))
+	_, err = store.GetImport(4, 2)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,6 +1,7 @@
 package stores
 
 import (
+	"time"
 	"todo-list/src/models"
 
 	"github.com/stretchr/testify/mock"
@@ -15,23 +16,535 @@ func (m *MockStore) CreateTodo(todo *models.Todo, userID int) (*models.Todo, err
 	return rets.Get(0).(*models.Todo), rets.Error(1)
 }
 
-func (m *MockStore) GetTodos(userID int) ([]*models.Todo, error) {
-	rets := m.Called(userID)
+func (m *MockStore) GetTodos(userID int, workspaceID int, filter *models.TodoFilter) ([]*models.Todo, error) {
+	rets := m.Called(userID, workspaceID, filter)
 	return rets.Get(0).([]*models.Todo), rets.Error(1)
 }
 
-func (m *MockStore) GetTodo(todoID int, userID int) error {
+// ExportTodos feeds the todos the expectation returns to each, then returns
+// its error, so tests can fail an export part way through.
+func (m *MockStore) ExportTodos(userID int, workspaceID int, filter *models.TodoFilter, each func(*models.Todo) error) error {
+	rets := m.Called(userID, workspaceID, filter)
+	for _, todo := range rets.Get(0).([]*models.Todo) {
+		if err := each(todo); err != nil {
+			return err
+		}
+	}
+	return rets.Error(1)
+}
+
+func (m *MockStore) UpdateTodo(todo *models.Todo, todoID int, userID int, allowBlocked bool) (*models.Todo, error) {
+	rets := m.Called(todo, todoID, userID, allowBlocked)
+	return rets.Get(0).(*models.Todo), rets.Error(1)
+}
+
+func (m *MockStore) DeleteTodo(todoID int, userID int) error {
 	rets := m.Called(todoID, userID)
 	return rets.Error(0)
 }
 
-func (m *MockStore) UpdateTodo(todo *models.Todo, todoID int) (*models.Todo, error) {
-	rets := m.Called(todo, todoID)
+func (m *MockStore) GetTrash(userID int, workspaceID int) ([]*models.Todo, error) {
+	rets := m.Called(userID, workspaceID)
+	return rets.Get(0).([]*models.Todo), rets.Error(1)
+}
+
+func (m *MockStore) RestoreTodo(todoID int, userID int, workspaceID int) error {
+	rets := m.Called(todoID, userID, workspaceID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) EmptyTrash(userID int, workspaceID int) ([]*models.Attachment, error) {
+	rets := m.Called(userID, workspaceID)
+	return rets.Get(0).([]*models.Attachment), rets.Error(1)
+}
+
+func (m *MockStore) PurgeTrash(before time.Time) ([]*models.Attachment, error) {
+	rets := m.Called(before)
+	return rets.Get(0).([]*models.Attachment), rets.Error(1)
+}
+
+func (m *MockStore) MoveTodo(todoID int, userID int, move *models.TodoMove) (*models.Todo, error) {
+	rets := m.Called(todoID, userID, move)
 	return rets.Get(0).(*models.Todo), rets.Error(1)
 }
 
-func (m *MockStore) DeleteTodo(todoID int) error {
+func (m *MockStore) BulkUpdateTodos(request *models.BulkRequest, userID int, workspaceID int) (*models.BulkResponse, error) {
+	rets := m.Called(request, userID, workspaceID)
+	return rets.Get(0).(*models.BulkResponse), rets.Error(1)
+}
+
+func (m *MockStore) CreateImport(imp *models.Import) (*models.Import, error) {
+	rets := m.Called(imp)
+	return rets.Get(0).(*models.Import), rets.Error(1)
+}
+
+func (m *MockStore) GetImport(importID int, userID int) (*models.Import, error) {
+	rets := m.Called(importID, userID)
+	return rets.Get(0).(*models.Import), rets.Error(1)
+}
+
+func (m *MockStore) UpdateImport(imp *models.Import) error {
+	rets := m.Called(imp)
+	return rets.Error(0)
+}
+
+func (m *MockStore) CreateAttachment(attachment *models.Attachment) (*models.Attachment, error) {
+	rets := m.Called(attachment)
+	return rets.Get(0).(*models.Attachment), rets.Error(1)
+}
+
+func (m *MockStore) GetAttachments(todoID int) ([]*models.Attachment, error) {
 	rets := m.Called(todoID)
+	return rets.Get(0).([]*models.Attachment), rets.Error(1)
+}
+
+func (m *MockStore) GetAttachment(attachmentID int, todoID int) (*models.Attachment, error) {
+	rets := m.Called(attachmentID, todoID)
+	return rets.Get(0).(*models.Attachment), rets.Error(1)
+}
+
+func (m *MockStore) DeleteAttachment(attachmentID int, todoID int) error {
+	rets := m.Called(attachmentID, todoID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) CreateComment(comment *models.Comment) (*models.Comment, error) {
+	rets := m.Called(comment)
+	return rets.Get(0).(*models.Comment), rets.Error(1)
+}
+
+func (m *MockStore) GetComments(todoID int) ([]*models.Comment, error) {
+	rets := m.Called(todoID)
+	return rets.Get(0).([]*models.Comment), rets.Error(1)
+}
+
+func (m *MockStore) GetComment(commentID int) (*models.Comment, error) {
+	rets := m.Called(commentID)
+	return rets.Get(0).(*models.Comment), rets.Error(1)
+}
+
+func (m *MockStore) UpdateComment(commentID int, body string) (*models.Comment, error) {
+	rets := m.Called(commentID, body)
+	return rets.Get(0).(*models.Comment), rets.Error(1)
+}
+
+func (m *MockStore) DeleteComment(commentID int) error {
+	rets := m.Called(commentID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) GetTodoChanges(todoID int) ([]*models.TodoChange, error) {
+	rets := m.Called(todoID)
+	return rets.Get(0).([]*models.TodoChange), rets.Error(1)
+}
+
+func (m *MockStore) GetRevisions(todoID int) ([]*models.Revision, error) {
+	rets := m.Called(todoID)
+	return rets.Get(0).([]*models.Revision), rets.Error(1)
+}
+
+func (m *MockStore) GetRevision(todoID int, revisionID int) (*models.Revision, error) {
+	rets := m.Called(todoID, revisionID)
+	return rets.Get(0).(*models.Revision), rets.Error(1)
+}
+
+func (m *MockStore) GetUserByEmail(email string) (*models.User, error) {
+	rets := m.Called(email)
+	return rets.Get(0).(*models.User), rets.Error(1)
+}
+
+func (m *MockStore) ClaimIdempotencyKey(userID int, key string, expiredBefore time.Time) (*models.IdempotencyKey, bool, error) {
+	rets := m.Called(userID, key, expiredBefore)
+	return rets.Get(0).(*models.IdempotencyKey), rets.Bool(1), rets.Error(2)
+}
+
+func (m *MockStore) SaveIdempotentResponse(idempotencyKey *models.IdempotencyKey) error {
+	rets := m.Called(idempotencyKey)
+	return rets.Error(0)
+}
+
+func (m *MockStore) ReleaseIdempotencyKey(userID int, key string) error {
+	rets := m.Called(userID, key)
+	return rets.Error(0)
+}
+
+func (m *MockStore) PurgeIdempotencyKeys(before time.Time) error {
+	rets := m.Called(before)
+	return rets.Error(0)
+}
+
+func (m *MockStore) CreateCalendarFeed(feed *models.CalendarFeed) (*models.CalendarFeed, error) {
+	rets := m.Called(feed)
+	return rets.Get(0).(*models.CalendarFeed), rets.Error(1)
+}
+
+func (m *MockStore) GetCalendarFeed(token string) (*models.CalendarFeed, error) {
+	rets := m.Called(token)
+	return rets.Get(0).(*models.CalendarFeed), rets.Error(1)
+}
+
+func (m *MockStore) DeleteCalendarFeed(userID int, workspaceID int) error {
+	rets := m.Called(userID, workspaceID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) CreateAppPassword(appPassword *models.AppPassword) (*models.AppPassword, error) {
+	rets := m.Called(appPassword)
+	return rets.Get(0).(*models.AppPassword), rets.Error(1)
+}
+
+func (m *MockStore) GetAppPasswords(userID int) ([]*models.AppPassword, error) {
+	rets := m.Called(userID)
+	return rets.Get(0).([]*models.AppPassword), rets.Error(1)
+}
+
+func (m *MockStore) DeleteAppPassword(appPasswordID int, userID int) error {
+	rets := m.Called(appPasswordID, userID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) AuthenticateAppPassword(email string, password string) (*models.User, error) {
+	rets := m.Called(email, password)
+	return rets.Get(0).(*models.User), rets.Error(1)
+}
+
+func (m *MockStore) CreateSavedFilter(filter *models.SavedFilter) (*models.SavedFilter, error) {
+	rets := m.Called(filter)
+	return rets.Get(0).(*models.SavedFilter), rets.Error(1)
+}
+
+func (m *MockStore) GetSavedFilters(userID int) ([]*models.SavedFilter, error) {
+	rets := m.Called(userID)
+	return rets.Get(0).([]*models.SavedFilter), rets.Error(1)
+}
+
+func (m *MockStore) GetSavedFilter(filterID int, userID int) (*models.SavedFilter, error) {
+	rets := m.Called(filterID, userID)
+	return rets.Get(0).(*models.SavedFilter), rets.Error(1)
+}
+
+func (m *MockStore) UpdateSavedFilter(filter *models.SavedFilter) (*models.SavedFilter, error) {
+	rets := m.Called(filter)
+	return rets.Get(0).(*models.SavedFilter), rets.Error(1)
+}
+
+func (m *MockStore) DeleteSavedFilter(filterID int, userID int) error {
+	rets := m.Called(filterID, userID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) StartTimer(todoID int, userID int, note string) (*models.TimeEntry, error) {
+	rets := m.Called(todoID, userID, note)
+	return rets.Get(0).(*models.TimeEntry), rets.Error(1)
+}
+
+func (m *MockStore) StopTimer(todoID int, userID int) (*models.TimeEntry, error) {
+	rets := m.Called(todoID, userID)
+	return rets.Get(0).(*models.TimeEntry), rets.Error(1)
+}
+
+func (m *MockStore) GetRunningTimer(userID int) (*models.TimeEntry, error) {
+	rets := m.Called(userID)
+	return rets.Get(0).(*models.TimeEntry), rets.Error(1)
+}
+
+func (m *MockStore) CreateTimeEntry(entry *models.TimeEntry) (*models.TimeEntry, error) {
+	rets := m.Called(entry)
+	return rets.Get(0).(*models.TimeEntry), rets.Error(1)
+}
+
+func (m *MockStore) GetTimeEntries(todoID int) ([]*models.TimeEntry, error) {
+	rets := m.Called(todoID)
+	return rets.Get(0).([]*models.TimeEntry), rets.Error(1)
+}
+
+func (m *MockStore) GetTimeEntry(entryID int) (*models.TimeEntry, error) {
+	rets := m.Called(entryID)
+	return rets.Get(0).(*models.TimeEntry), rets.Error(1)
+}
+
+func (m *MockStore) UpdateTimeEntry(entry *models.TimeEntry) (*models.TimeEntry, error) {
+	rets := m.Called(entry)
+	return rets.Get(0).(*models.TimeEntry), rets.Error(1)
+}
+
+func (m *MockStore) DeleteTimeEntry(entryID int) error {
+	rets := m.Called(entryID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) GetTimeReport(userID int, query *models.TimeReportQuery) (*models.TimeReport, error) {
+	rets := m.Called(userID, query)
+	return rets.Get(0).(*models.TimeReport), rets.Error(1)
+}
+
+func (m *MockStore) GetStats(userID int, workspaceID int, query *models.StatsQuery) (*models.Stats, error) {
+	rets := m.Called(userID, workspaceID, query)
+	return rets.Get(0).(*models.Stats), rets.Error(1)
+}
+
+func (m *MockStore) CreateTemplate(template *models.Template) (*models.Template, error) {
+	rets := m.Called(template)
+	return rets.Get(0).(*models.Template), rets.Error(1)
+}
+
+func (m *MockStore) GetTemplates(userID int) ([]*models.Template, error) {
+	rets := m.Called(userID)
+	return rets.Get(0).([]*models.Template), rets.Error(1)
+}
+
+func (m *MockStore) GetTemplate(templateID int, userID int) (*models.Template, error) {
+	rets := m.Called(templateID, userID)
+	return rets.Get(0).(*models.Template), rets.Error(1)
+}
+
+func (m *MockStore) DeleteTemplate(templateID int, userID int) error {
+	rets := m.Called(templateID, userID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) CreateTodos(todos []*models.Todo, parents []int, userID int) ([]*models.Todo, error) {
+	rets := m.Called(todos, parents, userID)
+	return rets.Get(0).([]*models.Todo), rets.Error(1)
+}
+
+func (m *MockStore) CreateCalDAVTodo(todo *models.Todo, userID int, resource *models.CalDAVResource) (*models.Todo, error) {
+	rets := m.Called(todo, userID, resource)
+	return rets.Get(0).(*models.Todo), rets.Error(1)
+}
+
+func (m *MockStore) GetCalDAVResources(todoIDs []int) ([]*models.CalDAVResource, error) {
+	rets := m.Called(todoIDs)
+	return rets.Get(0).([]*models.CalDAVResource), rets.Error(1)
+}
+
+func (m *MockStore) GetCalDAVResourcesByName(name string) ([]*models.CalDAVResource, error) {
+	rets := m.Called(name)
+	return rets.Get(0).([]*models.CalDAVResource), rets.Error(1)
+}
+
+func (m *MockStore) GetSyncToken() (int64, error) {
+	rets := m.Called()
+	return rets.Get(0).(int64), rets.Error(1)
+}
+
+func (m *MockStore) GetChangedTodoIDs(since int64, userID int, projectID int) ([]int, error) {
+	rets := m.Called(since, userID, projectID)
+	return rets.Get(0).([]int), rets.Error(1)
+}
+
+func (m *MockStore) GetTodoRole(todoID int, userID int, workspaceID int) (string, error) {
+	rets := m.Called(todoID, userID, workspaceID)
+	return rets.String(0), rets.Error(1)
+}
+
+func (m *MockStore) SetAssignees(todoID int, userIDs []int, assignedBy int) (*models.AssigneeChanges, error) {
+	rets := m.Called(todoID, userIDs, assignedBy)
+	return rets.Get(0).(*models.AssigneeChanges), rets.Error(1)
+}
+
+func (m *MockStore) AddDependency(todoID int, blockedByID int) error {
+	rets := m.Called(todoID, blockedByID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) RemoveDependency(todoID int, blockedByID int) error {
+	rets := m.Called(todoID, blockedByID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) GetDependencyGraph(todoID int, userID int, workspaceID int) (*models.DependencyGraph, error) {
+	rets := m.Called(todoID, userID, workspaceID)
+	return rets.Get(0).(*models.DependencyGraph), rets.Error(1)
+}
+
+func (m *MockStore) ShareTodo(todoID int, userID int, role string) error {
+	rets := m.Called(todoID, userID, role)
+	return rets.Error(0)
+}
+
+func (m *MockStore) GetShares(todoID int) ([]*models.Share, error) {
+	rets := m.Called(todoID)
+	return rets.Get(0).([]*models.Share), rets.Error(1)
+}
+
+func (m *MockStore) RevokeShare(todoID int, userID int) error {
+	rets := m.Called(todoID, userID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) CreateWorkspace(workspace *models.Workspace, userID int) (*models.Workspace, error) {
+	rets := m.Called(workspace, userID)
+	return rets.Get(0).(*models.Workspace), rets.Error(1)
+}
+
+func (m *MockStore) GetWorkspaces(userID int) ([]*models.Workspace, error) {
+	rets := m.Called(userID)
+	return rets.Get(0).([]*models.Workspace), rets.Error(1)
+}
+
+func (m *MockStore) GetWorkspace(workspaceID int, userID int) (*models.Workspace, error) {
+	rets := m.Called(workspaceID, userID)
+	return rets.Get(0).(*models.Workspace), rets.Error(1)
+}
+
+func (m *MockStore) GetWorkspaceMembers(workspaceID int) ([]*models.WorkspaceMember, error) {
+	rets := m.Called(workspaceID)
+	return rets.Get(0).([]*models.WorkspaceMember), rets.Error(1)
+}
+
+func (m *MockStore) UpdateWorkspaceMember(workspaceID int, userID int, role string) error {
+	rets := m.Called(workspaceID, userID, role)
+	return rets.Error(0)
+}
+
+func (m *MockStore) RemoveWorkspaceMember(workspaceID int, userID int) error {
+	rets := m.Called(workspaceID, userID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) CreateInvitation(invitation *models.Invitation) (*models.Invitation, error) {
+	rets := m.Called(invitation)
+	return rets.Get(0).(*models.Invitation), rets.Error(1)
+}
+
+func (m *MockStore) AcceptInvitation(token string, user *models.User) (*models.Workspace, error) {
+	rets := m.Called(token, user)
+	return rets.Get(0).(*models.Workspace), rets.Error(1)
+}
+
+func (m *MockStore) CreateProject(project *models.Project) (*models.Project, error) {
+	rets := m.Called(project)
+	return rets.Get(0).(*models.Project), rets.Error(1)
+}
+
+func (m *MockStore) GetProjects(workspaceID int) ([]*models.Project, error) {
+	rets := m.Called(workspaceID)
+	return rets.Get(0).([]*models.Project), rets.Error(1)
+}
+
+func (m *MockStore) GetProject(projectID int, workspaceID int) (*models.Project, error) {
+	rets := m.Called(projectID, workspaceID)
+	return rets.Get(0).(*models.Project), rets.Error(1)
+}
+
+func (m *MockStore) GetMemberProjects(userID int, projectID int) ([]*models.MemberProject, error) {
+	rets := m.Called(userID, projectID)
+	return rets.Get(0).([]*models.MemberProject), rets.Error(1)
+}
+
+func (m *MockStore) DeleteProject(projectID int, workspaceID int) error {
+	rets := m.Called(projectID, workspaceID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) CreateCustomField(field *models.CustomField) (*models.CustomField, error) {
+	rets := m.Called(field)
+	return rets.Get(0).(*models.CustomField), rets.Error(1)
+}
+
+func (m *MockStore) GetCustomFields(projectID int) ([]*models.CustomField, error) {
+	rets := m.Called(projectID)
+	return rets.Get(0).([]*models.CustomField), rets.Error(1)
+}
+
+func (m *MockStore) GetTodoCustomFields(todoID int) ([]*models.CustomField, error) {
+	rets := m.Called(todoID)
+	return rets.Get(0).([]*models.CustomField), rets.Error(1)
+}
+
+func (m *MockStore) UpdateCustomField(field *models.CustomField) (*models.CustomField, error) {
+	rets := m.Called(field)
+	return rets.Get(0).(*models.CustomField), rets.Error(1)
+}
+
+func (m *MockStore) DeleteCustomField(fieldID int, projectID int) error {
+	rets := m.Called(fieldID, projectID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) CreateWebhook(webhook *models.Webhook) (*models.Webhook, error) {
+	rets := m.Called(webhook)
+	return rets.Get(0).(*models.Webhook), rets.Error(1)
+}
+
+func (m *MockStore) GetWebhooks(userID int) ([]*models.Webhook, error) {
+	rets := m.Called(userID)
+	return rets.Get(0).([]*models.Webhook), rets.Error(1)
+}
+
+func (m *MockStore) GetWebhook(webhookID int, userID int) (*models.Webhook, error) {
+	rets := m.Called(webhookID, userID)
+	return rets.Get(0).(*models.Webhook), rets.Error(1)
+}
+
+func (m *MockStore) UpdateWebhook(webhook *models.Webhook) (*models.Webhook, error) {
+	rets := m.Called(webhook)
+	return rets.Get(0).(*models.Webhook), rets.Error(1)
+}
+
+func (m *MockStore) DeleteWebhook(webhookID int, userID int) error {
+	rets := m.Called(webhookID, userID)
+	return rets.Error(0)
+}
+
+func (m *MockStore) GetWebhookDeliveries(webhookID int, limit int) ([]*models.WebhookDelivery, error) {
+	rets := m.Called(webhookID, limit)
+	return rets.Get(0).([]*models.WebhookDelivery), rets.Error(1)
+}
+
+func (m *MockStore) RedeliverWebhook(deliveryID int, webhookID int) (*models.WebhookDelivery, error) {
+	rets := m.Called(deliveryID, webhookID)
+	return rets.Get(0).(*models.WebhookDelivery), rets.Error(1)
+}
+
+func (m *MockStore) ClaimWebhookDeliveries(limit int) ([]*models.WebhookDelivery, error) {
+	rets := m.Called(limit)
+	return rets.Get(0).([]*models.WebhookDelivery), rets.Error(1)
+}
+
+func (m *MockStore) RecordWebhookAttempt(delivery *models.WebhookDelivery) error {
+	rets := m.Called(delivery)
+	return rets.Error(0)
+}
+
+func (m *MockStore) PurgeWebhookDeliveries(before time.Time) error {
+	rets := m.Called(before)
+	return rets.Error(0)
+}
+
+func (m *MockStore) GetTodoEvent(eventID int64) (*models.TodoEvent, error) {
+	rets := m.Called(eventID)
+	return rets.Get(0).(*models.TodoEvent), rets.Error(1)
+}
+
+func (m *MockStore) GetTodoEvents(afterID int64, userID int, limit int) ([]*models.TodoEvent, error) {
+	rets := m.Called(afterID, userID, limit)
+	return rets.Get(0).([]*models.TodoEvent), rets.Error(1)
+}
+
+func (m *MockStore) GetTodoEventLogStart() (int64, error) {
+	rets := m.Called()
+	return rets.Get(0).(int64), rets.Error(1)
+}
+
+func (m *MockStore) PurgeTodoEvents(before time.Time) error {
+	rets := m.Called(before)
+	return rets.Error(0)
+}
+
+func (m *MockStore) CreateStreamTicket(userID int, ticket string) (*models.StreamTicket, error) {
+	rets := m.Called(userID, ticket)
+	return rets.Get(0).(*models.StreamTicket), rets.Error(1)
+}
+
+func (m *MockStore) RedeemStreamTicket(ticket string) (int, error) {
+	rets := m.Called(ticket)
+	return rets.Int(0), rets.Error(1)
+}
+
+func (m *MockStore) PurgeStreamTickets(before time.Time) error {
+	rets := m.Called(before)
 	return rets.Error(0)
 }
 
@@ -45,6 +558,11 @@ func (m *MockStore) GetUser(user *models.User) (*models.User, error) {
 	return rets.Get(0).(*models.User), rets.Error(1)
 }
 
+func (m *MockStore) UpdateUserSettings(userID int, settings *models.UserSettings) (*models.UserSettings, error) {
+	rets := m.Called(userID, settings)
+	return rets.Get(0).(*models.UserSettings), rets.Error(1)
+}
+
 func InitMockStore() *MockStore {
 	s := new(MockStore)
 	return s
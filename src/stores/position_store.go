@@ -0,0 +1,169 @@
+package stores
+
+import (
+	"database/sql"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+)
+
+// maxPositionLength is the key length after which a user's list is rebalanced.
+// Repeatedly moving items into the same gap makes keys grow by roughly one
+// character per move, so this keeps keys short without rewriting on every move.
+const maxPositionLength = 16
+
+func (store *DbStore) MoveTodo(todoID int, userID int, move *models.TodoMove) (*models.Todo, error) {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	// Lock the user so concurrent moves cannot compute the same key.
+	_, err = transaction.Exec("SELECT id FROM users WHERE id = $1 FOR UPDATE", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Todos seen through workspace membership have no position until the
+	// user first moves something in that list; place them where they are
+	// listed so the moved todo's neighbours are the ones the user sees.
+	var workspaceID int
+	err = transaction.QueryRow("SELECT COALESCE(workspace_id, 0) FROM todos WHERE id = $1", todoID).Scan(&workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	unplaced, err := unplacedTodos(transaction, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(unplaced) > 0 {
+		err = rebalancePositions(transaction, userID, unplaced)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var after, before string
+	if move.After != 0 {
+		err = transaction.QueryRow("SELECT position FROM todo_positions WHERE user_id = $1 AND todo_id = $2", userID, move.After).Scan(&after)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if move.Before != 0 {
+		err = transaction.QueryRow("SELECT position FROM todo_positions WHERE user_id = $1 AND todo_id = $2", userID, move.Before).Scan(&before)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// With a single anchor the other neighbour is whatever currently sits next to it.
+	if move.After != 0 && move.Before == 0 {
+		err = transaction.QueryRow("SELECT COALESCE(MIN(position), '') FROM todo_positions WHERE user_id = $1 AND position > $2 AND todo_id <> $3", userID, after, todoID).Scan(&before)
+	} else if move.Before != 0 && move.After == 0 {
+		err = transaction.QueryRow("SELECT COALESCE(MAX(position), '') FROM todo_positions WHERE user_id = $1 AND position < $2 AND todo_id <> $3", userID, before, todoID).Scan(&after)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	position, err := lib.PositionBetween(after, before)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := transaction.Exec("UPDATE todo_positions SET position = $1 WHERE user_id = $2 AND todo_id = $3", position, userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		err = sql.ErrNoRows
+		return nil, err
+	}
+
+	if len(position) > maxPositionLength {
+		err = rebalancePositions(transaction, userID, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	movedTodo := &models.Todo{}
+	err = scanTodo(transaction.QueryRow("SELECT "+todoColumns+", tp.position, "+effectiveRole+visibleTodos+" AND t.id = $3", userID, workspaceID, todoID), movedTodo, &movedTodo.Position, &movedTodo.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	err = transaction.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return movedTodo, nil
+}
+
+// unplacedTodos returns the todos the user sees in the workspace's list but
+// has no position for, in the order they are listed.
+func unplacedTodos(transaction *sql.Tx, userID int, workspaceID int) ([]int, error) {
+	rows, err := transaction.Query("SELECT t.id"+visibleTodos+" AND tp.todo_id IS NULL ORDER BY t.id", userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todoIDs := []int{}
+	for rows.Next() {
+		var todoID int
+		if err := rows.Scan(&todoID); err != nil {
+			return nil, err
+		}
+		todoIDs = append(todoIDs, todoID)
+	}
+	return todoIDs, rows.Err()
+}
+
+// rebalancePositions rewrites every key in the user's list with short, evenly
+// spaced keys while keeping the current order. The unplaced todos are given
+// keys after all others.
+func rebalancePositions(transaction *sql.Tx, userID int, unplaced []int) error {
+	rows, err := transaction.Query("SELECT todo_id FROM todo_positions WHERE user_id = $1 ORDER BY position, todo_id", userID)
+	if err != nil {
+		return err
+	}
+
+	todoIDs := []int{}
+	for rows.Next() {
+		var todoID int
+		if err := rows.Scan(&todoID); err != nil {
+			rows.Close()
+			return err
+		}
+		todoIDs = append(todoIDs, todoID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	todoIDs = append(todoIDs, unplaced...)
+
+	positions, err := lib.PositionsBetween("", "", len(todoIDs))
+	if err != nil {
+		return err
+	}
+	for i, todoID := range todoIDs {
+		_, err := transaction.Exec("INSERT INTO todo_positions (user_id, todo_id, position) VALUES ($1, $2, $3) ON CONFLICT (user_id, todo_id) DO UPDATE SET position = EXCLUDED.position", userID, todoID, positions[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package stores
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoveTodo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	dueDate := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC()
+	todoColumns := []string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields", "position", "role"}
+
+	expectPlaced := func(todoID int, userID int) {
+		mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT COALESCE\\(workspace_id, 0\\) FROM todos").WithArgs(todoID).WillReturnRows(sqlmock.NewRows([]string{"workspace_id"}).AddRow(0))
+		mock.ExpectQuery("SELECT t.id FROM todos t (.+) AND tp.todo_id IS NULL ORDER BY t.id").WithArgs(userID, 0).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	}
+
+	type testCase struct {
+		name         string
+		todoID       int
+		userID       int
+		move         *models.TodoMove
+		expectedTodo *models.Todo
+		mockSetup    func(todoID int, userID int)
+		shouldError  bool
+	}
+
+	tests := []testCase{
+		{
+			name:         "Move between two todos",
+			todoID:       3,
+			userID:       1,
+			move:         &models.TodoMove{After: 1, Before: 2},
+			expectedTodo: &models.Todo{ID: 3, TaskName: "test task", DueDate: dueDate, CreatedAt: dueDate, UpdatedAt: dueDate, Position: "N", Role: "owner", AssigneeIDs: []int{2, 5}},
+			mockSetup: func(todoID int, userID int) {
+				expectPlaced(todoID, userID)
+				mock.ExpectQuery("SELECT position FROM todo_positions").WithArgs(userID, 1).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("F"))
+				mock.ExpectQuery("SELECT position FROM todo_positions").WithArgs(userID, 2).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("V"))
+				mock.ExpectExec("UPDATE todo_positions SET position").WithArgs("N", userID, todoID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT (.+) FROM todos t LEFT JOIN users_todos ut (.+) AND t.id = \\$3").WithArgs(userID, 0, todoID).WillReturnRows(sqlmock.NewRows(todoColumns).AddRow(3, "test task", false, dueDate, dueDate, dueDate, "", 0, 0, "{2,5}", false, nil, 0, "", false, 0, nil, nil, "N", "owner"))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:         "Move after the last todo",
+			todoID:       3,
+			userID:       1,
+			move:         &models.TodoMove{After: 2},
+			expectedTodo: &models.Todo{ID: 3, TaskName: "test task", DueDate: dueDate, CreatedAt: dueDate, UpdatedAt: dueDate, Position: "l", Role: "owner", AssigneeIDs: []int{2, 5}},
+			mockSetup: func(todoID int, userID int) {
+				expectPlaced(todoID, userID)
+				mock.ExpectQuery("SELECT position FROM todo_positions").WithArgs(userID, 2).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("V"))
+				mock.ExpectQuery("SELECT COALESCE\\(MIN\\(position\\), ''\\)").WithArgs(userID, "V", todoID).WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(""))
+				mock.ExpectExec("UPDATE todo_positions SET position").WithArgs("l", userID, todoID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT (.+) FROM todos t LEFT JOIN users_todos ut (.+) AND t.id = \\$3").WithArgs(userID, 0, todoID).WillReturnRows(sqlmock.NewRows(todoColumns).AddRow(3, "test task", false, dueDate, dueDate, dueDate, "", 0, 0, "{2,5}", false, nil, 0, "", false, 0, nil, nil, "l", "owner"))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:         "Long key triggers rebalance",
+			todoID:       3,
+			userID:       1,
+			move:         &models.TodoMove{After: 1, Before: 2},
+			expectedTodo: &models.Todo{ID: 3, TaskName: "test task", DueDate: dueDate, CreatedAt: dueDate, UpdatedAt: dueDate, Position: "V", Role: "owner", AssigneeIDs: []int{2, 5}},
+			mockSetup: func(todoID int, userID int) {
+				expectPlaced(todoID, userID)
+				mock.ExpectQuery("SELECT position FROM todo_positions").WithArgs(userID, 1).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("VVVVVVVVVVVVVVVV"))
+				mock.ExpectQuery("SELECT position FROM todo_positions").WithArgs(userID, 2).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("VVVVVVVVVVVVVVVW"))
+				mock.ExpectExec("UPDATE todo_positions SET position").WithArgs("VVVVVVVVVVVVVVVVV", userID, todoID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT todo_id FROM todo_positions WHERE user_id = \\$1 ORDER BY position, todo_id").WithArgs(userID).WillReturnRows(sqlmock.NewRows([]string{"todo_id"}).AddRow(1).AddRow(3).AddRow(2))
+				mock.ExpectExec("INSERT INTO todo_positions (.+) DO UPDATE SET position").WithArgs(userID, 1, "F").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO todo_positions (.+) DO UPDATE SET position").WithArgs(userID, 3, "V").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO todo_positions (.+) DO UPDATE SET position").WithArgs(userID, 2, "k").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT (.+) FROM todos t LEFT JOIN users_todos ut (.+) AND t.id = \\$3").WithArgs(userID, 0, todoID).WillReturnRows(sqlmock.NewRows(todoColumns).AddRow(3, "test task", false, dueDate, dueDate, dueDate, "", 0, 0, "{2,5}", false, nil, 0, "", false, 0, nil, nil, "V", "owner"))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:         "Project todo the user never placed",
+			todoID:       7,
+			userID:       2,
+			move:         &models.TodoMove{After: 1},
+			expectedTodo: &models.Todo{ID: 7, TaskName: "test task", DueDate: dueDate, CreatedAt: dueDate, UpdatedAt: dueDate, WorkspaceID: 3, ProjectID: 4, Position: "N", Role: "editor"},
+			mockSetup: func(todoID int, userID int) {
+				mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT COALESCE\\(workspace_id, 0\\) FROM todos").WithArgs(todoID).WillReturnRows(sqlmock.NewRows([]string{"workspace_id"}).AddRow(3))
+				mock.ExpectQuery("SELECT t.id FROM todos t (.+) AND tp.todo_id IS NULL ORDER BY t.id").WithArgs(userID, 3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6).AddRow(7))
+				mock.ExpectQuery("SELECT todo_id FROM todo_positions WHERE user_id = \\$1 ORDER BY position, todo_id").WithArgs(userID).WillReturnRows(sqlmock.NewRows([]string{"todo_id"}).AddRow(1))
+				mock.ExpectExec("INSERT INTO todo_positions (.+) DO UPDATE SET position").WithArgs(userID, 1, "F").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO todo_positions (.+) DO UPDATE SET position").WithArgs(userID, 6, "V").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO todo_positions (.+) DO UPDATE SET position").WithArgs(userID, 7, "k").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT position FROM todo_positions").WithArgs(userID, 1).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("F"))
+				mock.ExpectQuery("SELECT COALESCE\\(MIN\\(position\\), ''\\)").WithArgs(userID, "F", todoID).WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow("V"))
+				mock.ExpectExec("UPDATE todo_positions SET position").WithArgs("N", userID, todoID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT (.+) FROM todos t LEFT JOIN users_todos ut (.+) AND t.id = \\$3").WithArgs(userID, 3, todoID).WillReturnRows(sqlmock.NewRows(todoColumns).AddRow(7, "test task", false, dueDate, dueDate, dueDate, "", 3, 4, nil, false, nil, 0, "", false, 0, nil, nil, "N", "editor"))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "Anchor not in the user's list",
+			todoID: 3,
+			userID: 1,
+			move:   &models.TodoMove{After: 9},
+			mockSetup: func(todoID int, userID int) {
+				expectPlaced(todoID, userID)
+				mock.ExpectQuery("SELECT position FROM todo_positions").WithArgs(userID, 9).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			shouldError: true,
+		},
+		{
+			name:   "Todo not in the user's list",
+			todoID: 3,
+			userID: 1,
+			move:   &models.TodoMove{After: 1, Before: 2},
+			mockSetup: func(todoID int, userID int) {
+				expectPlaced(todoID, userID)
+				mock.ExpectQuery("SELECT position FROM todo_positions").WithArgs(userID, 1).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("F"))
+				mock.ExpectQuery("SELECT position FROM todo_positions").WithArgs(userID, 2).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("V"))
+				mock.ExpectExec("UPDATE todo_positions SET position").WithArgs("N", userID, todoID).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			shouldError: true,
+		},
+		{
+			name:   "Error locking list",
+			todoID: 3,
+			userID: 1,
+			move:   &models.TodoMove{After: 1},
+			mockSetup: func(todoID int, userID int) {
+				mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").WithArgs(userID).WillReturnError(fmt.Errorf("some db error"))
+				mock.ExpectRollback()
+			},
+			shouldError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock.ExpectBegin()
+			tc.mockSetup(tc.todoID, tc.userID)
+			movedTodo, err := store.MoveTodo(tc.todoID, tc.userID, tc.move)
+			if tc.shouldError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedTodo, movedTodo)
+			}
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package stores
+
+import (
+	"database/sql"
+	"encoding/json"
+	"todo-list/src/models"
+)
+
+const revisionColumns = "id, todo_id, COALESCE(user_id, 0), action, before, after, created_at"
+
+// recordRevision stores a revision of a todo inside the transaction making
+// the change, along with the webhook deliveries and the stream event it sends.
+// before or after is nil when the todo did not exist or was trashed on that
+// side of the change.
+func recordRevision(transaction *sql.Tx, todoID int, userID int, action string, before *models.Todo, after *models.Todo) error {
+	beforeJSON, err := snapshotJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := snapshotJSON(after)
+	if err != nil {
+		return err
+	}
+	_, err = transaction.Exec("INSERT INTO todo_revisions (todo_id, user_id, action, before, after) VALUES ($1, NULLIF($2, 0), $3, $4, $5)", todoID, userID, action, beforeJSON, afterJSON)
+	if err != nil {
+		return err
+	}
+	err = queueWebhookDeliveries(transaction, todoID, action, before, after)
+	if err != nil {
+		return err
+	}
+	return recordTodoEvent(transaction, todoID, action, before, after)
+}
+
+// snapshotJSON encodes the editable fields of todo, or returns nil so the
+// column is stored as NULL.
+func snapshotJSON(todo *models.Todo) (any, error) {
+	if todo == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(&models.TodoSnapshot{TaskName: todo.TaskName, Completed: todo.Completed, DueDate: todo.DueDate, DueAllDay: todo.DueAllDay, Notes: todo.Notes, Priority: todo.Priority, Recurrence: todo.Recurrence, CustomFields: todo.CustomFields})
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func scanRevision(row rowScanner, revision *models.Revision) error {
+	var before, after []byte
+	err := row.Scan(&revision.ID, &revision.TodoID, &revision.UserID, &revision.Action, &before, &after, &revision.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if revision.Before, err = parseSnapshot(before); err != nil {
+		return err
+	}
+	if revision.After, err = parseSnapshot(after); err != nil {
+		return err
+	}
+
+	revision.Changes = []models.TodoChange{}
+	if revision.Action == models.RevisionCreate || revision.Action == models.RevisionUpdate {
+		revision.Changes = diffTodos(snapshotTodo(revision.Before), snapshotTodo(revision.After))
+	}
+	return nil
+}
+
+func parseSnapshot(data []byte) (*models.TodoSnapshot, error) {
+	if data == nil {
+		return nil, nil
+	}
+	snapshot := &models.TodoSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// snapshotTodo turns a snapshot back into a todo for diffTodos. A missing
+// snapshot diffs like a todo with every field empty.
+func snapshotTodo(snapshot *models.TodoSnapshot) *models.Todo {
+	if snapshot == nil {
+		return &models.Todo{}
+	}
+	return &models.Todo{TaskName: snapshot.TaskName, Completed: snapshot.Completed, DueDate: snapshot.DueDate, DueAllDay: snapshot.DueAllDay, Notes: snapshot.Notes, Priority: snapshot.Priority, Recurrence: snapshot.Recurrence, CustomFields: snapshot.CustomFields}
+}
+
+// GetRevisions returns the revisions of a todo, oldest first.
+func (store *DbStore) GetRevisions(todoID int) ([]*models.Revision, error) {
+	rows, err := store.DB.Query("SELECT "+revisionColumns+" FROM todo_revisions WHERE todo_id = $1 ORDER BY id", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*models.Revision{}
+	for rows.Next() {
+		revision := &models.Revision{}
+		if err := scanRevision(rows, revision); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (store *DbStore) GetRevision(todoID int, revisionID int) (*models.Revision, error) {
+	revision := &models.Revision{}
+	err := scanRevision(store.DB.QueryRow("SELECT "+revisionColumns+" FROM todo_revisions WHERE id = $1 AND todo_id = $2", revisionID, todoID), revision)
+	if err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
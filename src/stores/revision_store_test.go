@@ -0,0 +1,59 @@
+package stores
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRevisions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC)
+	dueDate := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	revisionRowColumns := []string{"id", "todo_id", "user_id", "action", "before", "after", "created_at"}
+
+	mock.ExpectQuery("SELECT id, todo_id, COALESCE\\(user_id, 0\\), action, before, after, created_at FROM todo_revisions WHERE todo_id = \\$1 ORDER BY id").WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(revisionRowColumns).
+			AddRow(1, 5, 1, "create", nil, []byte(`{"task_name":"Write","completed":false,"due_date":"2024-11-30T23:59:59Z","notes":""}`), createdAt).
+			AddRow(2, 5, 2, "update", []byte(`{"task_name":"Write","completed":false,"due_date":"2024-11-30T23:59:59Z","notes":""}`), []byte(`{"task_name":"Write","completed":false,"due_date":"0001-01-01T00:00:00Z","notes":""}`), createdAt).
+			AddRow(3, 5, 0, "delete", []byte(`{"task_name":"Write","completed":false,"due_date":"0001-01-01T00:00:00Z","notes":""}`), nil, createdAt))
+
+	revisions, err := store.GetRevisions(5)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.Revision{
+		{
+			ID: 1, TodoID: 5, UserID: 1, Action: "create",
+			After:     &models.TodoSnapshot{TaskName: "Write", DueDate: dueDate},
+			Changes:   []models.TodoChange{{Field: "task_name", OldValue: "", NewValue: "Write"}, {Field: "due_date", OldValue: "", NewValue: "2024-11-30T23:59:59Z"}},
+			CreatedAt: createdAt,
+		},
+		{
+			ID: 2, TodoID: 5, UserID: 2, Action: "update",
+			Before:    &models.TodoSnapshot{TaskName: "Write", DueDate: dueDate},
+			After:     &models.TodoSnapshot{TaskName: "Write"},
+			Changes:   []models.TodoChange{{Field: "due_date", OldValue: "2024-11-30T23:59:59Z", NewValue: ""}},
+			CreatedAt: createdAt,
+		},
+		{
+			ID: 3, TodoID: 5, Action: "delete",
+			Before:    &models.TodoSnapshot{TaskName: "Write"},
+			Changes:   []models.TodoChange{},
+			CreatedAt: createdAt,
+		},
+	}, revisions)
+
+	mock.ExpectQuery("SELECT (.+) FROM todo_revisions WHERE id = \\$1 AND todo_id = \\$2").WithArgs(9, 5).WillReturnRows(sqlmock.NewRows(revisionRowColumns))
+	_, err = store.GetRevision(5, 9)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,162 @@
+package stores
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"todo-list/src/models"
+)
+
+const savedFilterColumns = "id, user_id, name, query, created_at, updated_at"
+
+func scanSavedFilter(row rowScanner) (*models.SavedFilter, error) {
+	filter := &models.SavedFilter{}
+	err := row.Scan(&filter.ID, &filter.UserID, &filter.Name, &filter.Query, &filter.CreatedAt, &filter.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func (store *DbStore) CreateSavedFilter(filter *models.SavedFilter) (*models.SavedFilter, error) {
+	return scanSavedFilter(store.DB.QueryRow("INSERT INTO saved_filters (user_id, name, query) VALUES ($1, $2, $3) RETURNING "+savedFilterColumns,
+		filter.UserID, filter.Name, filter.Query))
+}
+
+func (store *DbStore) GetSavedFilters(userID int) ([]*models.SavedFilter, error) {
+	rows, err := store.DB.Query("SELECT "+savedFilterColumns+" FROM saved_filters WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	filters := []*models.SavedFilter{}
+	for rows.Next() {
+		filter, err := scanSavedFilter(rows)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+// GetSavedFilter returns sql.ErrNoRows for filters of other users.
+func (store *DbStore) GetSavedFilter(filterID int, userID int) (*models.SavedFilter, error) {
+	return scanSavedFilter(store.DB.QueryRow("SELECT "+savedFilterColumns+" FROM saved_filters WHERE id = $1 AND user_id = $2", filterID, userID))
+}
+
+// UpdateSavedFilter renames a filter or changes its query; it returns
+// sql.ErrNoRows for filters of other users.
+func (store *DbStore) UpdateSavedFilter(filter *models.SavedFilter) (*models.SavedFilter, error) {
+	return scanSavedFilter(store.DB.QueryRow("UPDATE saved_filters SET name = $1, query = $2, updated_at = NOW() WHERE id = $3 AND user_id = $4 RETURNING "+savedFilterColumns,
+		filter.Name, filter.Query, filter.ID, filter.UserID))
+}
+
+func (store *DbStore) DeleteSavedFilter(filterID int, userID int) error {
+	result, err := store.DB.Exec("DELETE FROM saved_filters WHERE id = $1 AND user_id = $2", filterID, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+var flagConditions = map[string]string{
+	models.FilterCompleted: "t.completed",
+	models.FilterBlocked:   blockedCondition,
+	models.FilterRecurring: "t.recurrence <> ''",
+}
+
+// comparisons maps the operators of FilterPriority to SQL.
+var comparisons = map[string]string{"=": "=", "!=": "<>", "<": "<", "<=": "<=", ">": ">", ">=": ">="}
+
+var dateColumns = map[string]string{
+	models.FilterCreated: "t.created_at",
+	models.FilterUpdated: "t.updated_at",
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// compileFilter turns a filter expression into an SQL condition on the todos
+// t, with its values appended to args. Values are always passed as
+// arguments; only the shape of the tree makes it into the SQL.
+func compileFilter(node models.FilterNode, args []any) (string, []any) {
+	var condition string
+	switch node := node.(type) {
+	case models.FilterAnd:
+		var left, right string
+		left, args = compileFilter(node.Left, args)
+		right, args = compileFilter(node.Right, args)
+		condition = "(" + left + " AND " + right + ")"
+	case models.FilterOr:
+		var left, right string
+		left, args = compileFilter(node.Left, args)
+		right, args = compileFilter(node.Right, args)
+		condition = "(" + left + " OR " + right + ")"
+	case models.FilterNot:
+		var operand string
+		operand, args = compileFilter(node.Operand, args)
+		condition = "NOT " + operand
+	case models.FilterFlag:
+		if node.Name != models.FilterNoDueDate {
+			condition = flagConditions[node.Name]
+			break
+		}
+		// Todos without a due date hold the zero time.
+		args = append(args, time.Time{})
+		condition = fmt.Sprintf("t.due_date <= $%d", len(args))
+	case models.FilterTag:
+		args = append(args, node.Tag)
+		condition = fmt.Sprintf("EXISTS (SELECT 1 FROM todo_tags tg WHERE tg.todo_id = t.id AND tg.tag = $%d)", len(args))
+	case models.FilterText:
+		args = append(args, "%"+likeEscaper.Replace(node.Text)+"%")
+		condition = fmt.Sprintf("(t.task_name ILIKE $%d OR t.notes ILIKE $%d)", len(args), len(args))
+	case models.FilterProject:
+		args = append(args, node.ProjectID)
+		condition = fmt.Sprintf("COALESCE(t.project_id, 0) = $%d", len(args))
+	case models.FilterAssignee:
+		if node.UserID == 0 {
+			condition = "NOT EXISTS (SELECT 1 FROM todo_assignees a WHERE a.todo_id = t.id)"
+		} else {
+			args = append(args, node.UserID)
+			condition = fmt.Sprintf("EXISTS (SELECT 1 FROM todo_assignees a WHERE a.todo_id = t.id AND a.user_id = $%d)", len(args))
+		}
+	case models.FilterPriority:
+		args = append(args, node.Value)
+		operator, ok := comparisons[node.Operator]
+		if !ok {
+			operator = "="
+		}
+		condition = fmt.Sprintf("t.priority %s $%d", operator, len(args))
+	case models.FilterDate:
+		if node.Field == models.FilterDue {
+			return dueCondition(&node.Range, args)
+		}
+		bounds := []string{}
+		if !node.Range.From.IsZero() {
+			args = append(args, node.Range.From)
+			bounds = append(bounds, fmt.Sprintf("%s >= $%d", dateColumns[node.Field], len(args)))
+		}
+		if !node.Range.To.IsZero() {
+			args = append(args, node.Range.To)
+			bounds = append(bounds, fmt.Sprintf("%s < $%d", dateColumns[node.Field], len(args)))
+		}
+		if len(bounds) == 0 {
+			bounds = append(bounds, "TRUE")
+		}
+		condition = "(" + strings.Join(bounds, " AND ") + ")"
+	}
+	return condition, args
+}
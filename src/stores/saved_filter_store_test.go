@@ -0,0 +1,144 @@
+package stores
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavedFilters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	columns := []string{"id", "user_id", "name", "query", "created_at", "updated_at"}
+
+	mock.ExpectQuery("INSERT INTO saved_filters \\(user_id, name, query\\) VALUES \\(\\$1, \\$2, \\$3\\) RETURNING id, user_id, name, query, created_at, updated_at").
+		WithArgs(1, "Work", "tag:work").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, 1, "Work", "tag:work", createdAt, createdAt))
+	mock.ExpectQuery("SELECT id, user_id, name, query, created_at, updated_at FROM saved_filters WHERE user_id = \\$1 ORDER BY id").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, 1, "Work", "tag:work", createdAt, createdAt))
+	mock.ExpectQuery("SELECT (.+) FROM saved_filters WHERE id = \\$1 AND user_id = \\$2").WithArgs(3, 2).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("UPDATE saved_filters SET name = \\$1, query = \\$2, updated_at = NOW\\(\\) WHERE id = \\$3 AND user_id = \\$4 RETURNING").
+		WithArgs("Urgent work", "tag:work and priority >= 3", 3, 1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, 1, "Urgent work", "tag:work and priority >= 3", createdAt, createdAt.Add(time.Hour)))
+	mock.ExpectExec("DELETE FROM saved_filters WHERE id = \\$1 AND user_id = \\$2").WithArgs(3, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM saved_filters").WithArgs(3, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	filter, err := store.CreateSavedFilter(&models.SavedFilter{UserID: 1, Name: "Work", Query: "tag:work"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.SavedFilter{ID: 3, UserID: 1, Name: "Work", Query: "tag:work", CreatedAt: createdAt, UpdatedAt: createdAt}, filter)
+
+	filters, err := store.GetSavedFilters(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.SavedFilter{filter}, filters)
+
+	_, err = store.GetSavedFilter(3, 2)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	filter, err = store.UpdateSavedFilter(&models.SavedFilter{ID: 3, UserID: 1, Name: "Urgent work", Query: "tag:work and priority >= 3"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Urgent work", filter.Name)
+	assert.Equal(t, createdAt.Add(time.Hour), filter.UpdatedAt)
+
+	assert.NoError(t, store.DeleteSavedFilter(3, 1))
+	assert.ErrorIs(t, store.DeleteSavedFilter(3, 2), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompileFilter(t *testing.T) {
+	created := time.Date(2024, 11, 21, 0, 0, 0, 0, time.UTC)
+	due := time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		node         models.FilterNode
+		expected     string
+		expectedArgs []any
+	}{
+		{
+			name: "Combined",
+			node: models.FilterAnd{
+				Left:  models.FilterOr{Left: models.FilterTag{Tag: "work"}, Right: models.FilterPriority{Operator: "!=", Value: 0}},
+				Right: models.FilterNot{Operand: models.FilterFlag{Name: models.FilterCompleted}},
+			},
+			expected:     "((EXISTS (SELECT 1 FROM todo_tags tg WHERE tg.todo_id = t.id AND tg.tag = $3) OR t.priority <> $4) AND NOT t.completed)",
+			expectedArgs: []any{1, 0, "work", 0},
+		},
+		{
+			name:         "Text",
+			node:         models.FilterText{Text: `50%_off\`},
+			expected:     "(t.task_name ILIKE $3 OR t.notes ILIKE $3)",
+			expectedArgs: []any{1, 0, `%50\%\_off\\%`},
+		},
+		{
+			name:         "Without Project Or Assignee",
+			node:         models.FilterAnd{Left: models.FilterProject{}, Right: models.FilterAssignee{}},
+			expected:     "(COALESCE(t.project_id, 0) = $3 AND NOT EXISTS (SELECT 1 FROM todo_assignees a WHERE a.todo_id = t.id))",
+			expectedArgs: []any{1, 0, 0},
+		},
+		{
+			name:         "Without Due Date",
+			node:         models.FilterFlag{Name: models.FilterNoDueDate},
+			expected:     "t.due_date <= $3",
+			expectedArgs: []any{1, 0, time.Time{}},
+		},
+		{
+			name:         "Created",
+			node:         models.FilterDate{Field: models.FilterCreated, Range: models.DueRange{From: created}},
+			expected:     "(t.created_at >= $3)",
+			expectedArgs: []any{1, 0, created},
+		},
+		{
+			name:         "Due",
+			node:         models.FilterDate{Field: models.FilterDue, Range: models.DueRange{To: due}},
+			expected:     "((NOT t.due_all_day AND t.due_date > $3 AND t.due_date < $5) OR (t.due_all_day AND t.due_date > $4 AND t.due_date < $6))",
+			expectedArgs: []any{1, 0, time.Time{}, time.Time{}, due, due},
+		},
+		{
+			name:         "Blocked",
+			node:         models.FilterFlag{Name: models.FilterBlocked},
+			expected:     blockedCondition,
+			expectedArgs: []any{1, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, args := compileFilter(tt.node, []any{1, 0})
+			assert.Equal(t, tt.expected, condition)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+func TestGetTodosExpression(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("AND t.completed = $3 AND (EXISTS (SELECT 1 FROM todo_tags tg WHERE tg.todo_id = t.id AND tg.tag = $4) OR t.priority >= $5) ORDER BY")).
+		WithArgs(1, 0, false, "work", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	open := false
+	todos, err := store.GetTodos(1, 0, &models.TodoFilter{
+		Completed:  &open,
+		Expression: models.FilterOr{Left: models.FilterTag{Tag: "work"}, Right: models.FilterPriority{Operator: ">=", Value: 3}},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, todos)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,151 @@
+package stores
+
+import (
+	"errors"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+)
+
+var (
+	ErrLastOwner          = errors.New("a todo must keep at least one owner")
+	ErrNotWorkspaceMember = errors.New("the user is not a member of the todo's workspace")
+)
+
+func (store *DbStore) GetUserByEmail(email string) (*models.User, error) {
+	user := &models.User{}
+	err := store.DB.QueryRow("SELECT id, username, email FROM users WHERE email = $1", email).Scan(&user.ID, &user.UserName, &user.Email)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetTodoRole returns the role the user holds on the todo, or sql.ErrNoRows
+// when they can not see it in the workspace.
+func (store *DbStore) GetTodoRole(todoID int, userID int, workspaceID int) (string, error) {
+	var role string
+	err := store.DB.QueryRow("SELECT "+effectiveRole+visibleTodos+" AND t.id = $3", userID, workspaceID, todoID).Scan(&role)
+	if err != nil {
+		return "", err
+	}
+	return role, nil
+}
+
+// ShareTodo gives the user the role on the todo, adding it to the end of their
+// list if they did not have access yet.
+func (store *DbStore) ShareTodo(todoID int, userID int, role string) error {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	// Workspace todos are only listed inside their workspace, so someone
+	// outside it could never see a todo shared with them.
+	var member bool
+	err = transaction.QueryRow("SELECT t.workspace_id IS NULL OR EXISTS (SELECT 1 FROM workspace_members wm WHERE wm.workspace_id = t.workspace_id AND wm.user_id = $2) FROM todos t WHERE t.id = $1", todoID, userID).Scan(&member)
+	if err != nil {
+		return err
+	}
+	if !member {
+		err = ErrNotWorkspaceMember
+		return err
+	}
+
+	var lastPosition string
+	err = transaction.QueryRow("SELECT COALESCE(MAX(position), '') FROM todo_positions WHERE user_id = $1", userID).Scan(&lastPosition)
+	if err != nil {
+		return err
+	}
+	position, err := lib.PositionBetween(lastPosition, "")
+	if err != nil {
+		return err
+	}
+
+	_, err = transaction.Exec("INSERT INTO users_todos (user_id, todo_id, role) VALUES ($1, $2, $3) ON CONFLICT (user_id, todo_id) DO UPDATE SET role = EXCLUDED.role", userID, todoID, role)
+	if err != nil {
+		return err
+	}
+	_, err = transaction.Exec("INSERT INTO todo_positions (user_id, todo_id, position) VALUES ($1, $2, $3) ON CONFLICT (user_id, todo_id) DO NOTHING", userID, todoID, position)
+	if err != nil {
+		return err
+	}
+
+	err = ensureOwner(transaction, todoID)
+	if err != nil {
+		return err
+	}
+
+	return transaction.Commit()
+}
+
+func (store *DbStore) GetShares(todoID int) ([]*models.Share, error) {
+	rows, err := store.DB.Query("SELECT u.id, u.username, u.email, ut.role FROM users_todos ut JOIN users u ON u.id = ut.user_id WHERE ut.todo_id = $1 ORDER BY u.id", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []*models.Share{}
+	for rows.Next() {
+		share := &models.Share{}
+		if err := rows.Scan(&share.UserID, &share.UserName, &share.Email, &share.Role); err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+func (store *DbStore) RevokeShare(todoID int, userID int) error {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	_, err = transaction.Exec("DELETE FROM users_todos WHERE todo_id = $1 AND user_id = $2", todoID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Unassign the user unless they still see the todo through its project.
+	_, err = transaction.Exec("DELETE FROM todo_assignees a WHERE a.todo_id = $1 AND a.user_id = $2 AND NOT EXISTS (SELECT 1 FROM todos t JOIN projects p ON p.id = t.project_id JOIN workspace_members wm ON wm.workspace_id = p.workspace_id WHERE t.id = $1 AND wm.user_id = $2)", todoID, userID)
+	if err != nil {
+		return err
+	}
+
+	err = ensureOwner(transaction, todoID)
+	if err != nil {
+		return err
+	}
+
+	return transaction.Commit()
+}
+
+// ensureOwner fails with ErrLastOwner when a change left the todo without an
+// owner, so the caller's transaction is rolled back.
+func ensureOwner(transaction rowQuerier, todoID int) error {
+	var owners int
+	err := transaction.QueryRow("SELECT COUNT(*) FROM users_todos WHERE todo_id = $1 AND role = 'owner'", todoID).Scan(&owners)
+	if err != nil {
+		return err
+	}
+	if owners == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package stores
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShareTodo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	type testCase struct {
+		name        string
+		userID      int
+		role        string
+		mockSetup   func(userID int, role string)
+		expectedErr error
+		shouldError bool
+	}
+
+	tests := []testCase{
+		{
+			name:   "Share with a new collaborator",
+			userID: 2,
+			role:   "editor",
+			mockSetup: func(userID int, role string) {
+				mock.ExpectQuery("SELECT t.workspace_id IS NULL OR EXISTS (.+) FROM todos t WHERE t.id = \\$1").WithArgs(5, userID).WillReturnRows(sqlmock.NewRows([]string{"member"}).AddRow(true))
+				mock.ExpectQuery("SELECT COALESCE\\(MAX\\(position\\), ''\\) FROM todo_positions").WithArgs(userID).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("V"))
+				mock.ExpectExec("INSERT INTO users_todos (.+) ON CONFLICT \\(user_id, todo_id\\) DO UPDATE SET role = EXCLUDED.role").WithArgs(userID, 5, role).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO todo_positions (.+) ON CONFLICT \\(user_id, todo_id\\) DO NOTHING").WithArgs(userID, 5, "l").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM users_todos WHERE todo_id = \\$1 AND role = 'owner'").WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "Demoting the last owner",
+			userID: 1,
+			role:   "viewer",
+			mockSetup: func(userID int, role string) {
+				mock.ExpectQuery("SELECT t.workspace_id IS NULL OR EXISTS (.+) FROM todos t WHERE t.id = \\$1").WithArgs(5, userID).WillReturnRows(sqlmock.NewRows([]string{"member"}).AddRow(true))
+				mock.ExpectQuery("SELECT COALESCE\\(MAX\\(position\\), ''\\) FROM todo_positions").WithArgs(userID).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("V"))
+				mock.ExpectExec("INSERT INTO users_todos").WithArgs(userID, 5, role).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO todo_positions (.+) ON CONFLICT \\(user_id, todo_id\\) DO NOTHING").WithArgs(userID, 5, "l").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM users_todos").WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				mock.ExpectRollback()
+			},
+			expectedErr: ErrLastOwner,
+			shouldError: true,
+		},
+		{
+			name:   "Share a workspace todo outside the workspace",
+			userID: 3,
+			role:   "editor",
+			mockSetup: func(userID int, role string) {
+				mock.ExpectQuery("SELECT t.workspace_id IS NULL OR EXISTS (.+) FROM todos t WHERE t.id = \\$1").WithArgs(5, userID).WillReturnRows(sqlmock.NewRows([]string{"member"}).AddRow(false))
+				mock.ExpectRollback()
+			},
+			expectedErr: ErrNotWorkspaceMember,
+			shouldError: true,
+		},
+		{
+			name:   "Error inserting share",
+			userID: 2,
+			role:   "viewer",
+			mockSetup: func(userID int, role string) {
+				mock.ExpectQuery("SELECT t.workspace_id IS NULL OR EXISTS (.+) FROM todos t WHERE t.id = \\$1").WithArgs(5, userID).WillReturnRows(sqlmock.NewRows([]string{"member"}).AddRow(true))
+				mock.ExpectQuery("SELECT COALESCE\\(MAX\\(position\\), ''\\) FROM todo_positions").WithArgs(userID).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(""))
+				mock.ExpectExec("INSERT INTO users_todos").WithArgs(userID, 5, role).WillReturnError(fmt.Errorf("some db error"))
+				mock.ExpectRollback()
+			},
+			shouldError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock.ExpectBegin()
+			tc.mockSetup(tc.userID, tc.role)
+			err := store.ShareTodo(5, tc.userID, tc.role)
+			if tc.shouldError {
+				assert.Error(t, err)
+				if tc.expectedErr != nil {
+					assert.ErrorIs(t, err, tc.expectedErr)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRevokeShare(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM users_todos WHERE todo_id = \\$1 AND user_id = \\$2").WithArgs(5, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM todo_assignees a WHERE a.todo_id = \\$1 AND a.user_id = \\$2 AND NOT EXISTS").WithArgs(5, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM users_todos").WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectCommit()
+	assert.NoError(t, store.RevokeShare(5, 2))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM users_todos").WithArgs(5, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM todo_assignees").WithArgs(5, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM users_todos").WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, store.RevokeShare(5, 1), ErrLastOwner)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTodoRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectQuery("SELECT CASE (.+) FROM todos t LEFT JOIN users_todos ut (.+) WHERE t.workspace_id IS NOT DISTINCT FROM NULLIF\\(\\$2, 0\\) (.+) AND t.id = \\$3").WithArgs(2, 0, 5).WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("editor"))
+	role, err := store.GetTodoRole(5, 2, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "editor", role)
+
+	mock.ExpectQuery("SELECT CASE (.+) AND t.id = \\$3").WithArgs(2, 4, 5).WillReturnError(sql.ErrNoRows)
+	_, err = store.GetTodoRole(5, 2, 4)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,112 @@
+package stores
+
+import (
+	"fmt"
+	"todo-list/src/models"
+)
+
+// completedInRange holds for todos completed from $3 up to but not including
+// $4.
+const completedInRange = "t.completed_at >= $3 AND t.completed_at < $4"
+
+// GetStats computes the completion statistics of the todos user userID sees
+// in workspace workspaceID, where 0 is the personal space.
+func (store *DbStore) GetStats(userID int, workspaceID int, query *models.StatsQuery) (*models.Stats, error) {
+	args := []any{userID, workspaceID, query.From, query.To}
+	overdue, args := dueCondition(&models.DueRange{To: query.Now}, args)
+	counts := fmt.Sprintf("COUNT(*) FILTER (WHERE %s), COUNT(*) FILTER (WHERE NOT t.completed), COUNT(*) FILTER (WHERE NOT t.completed AND %s)", completedInRange, overdue)
+	active := fmt.Sprintf(" HAVING COUNT(*) FILTER (WHERE %s OR NOT t.completed) > 0", completedInRange)
+
+	stats := &models.Stats{}
+	err := store.DB.QueryRow(fmt.Sprintf("SELECT COUNT(*) FILTER (WHERE %[1]s), COUNT(*) FILTER (WHERE t.created_at >= $3 AND t.created_at < $4),"+
+		" COALESCE(AVG(EXTRACT(EPOCH FROM t.completed_at - t.created_at)) FILTER (WHERE %[1]s), 0)::BIGINT,"+
+		" COUNT(*) FILTER (WHERE NOT t.completed AND %[2]s)", completedInRange, overdue)+visibleTodos, args...).
+		Scan(&stats.Completed, &stats.Created, &stats.AverageLeadTimeSeconds, &stats.Overdue)
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.Days, err = store.statsDays(userID, workspaceID, query); err != nil {
+		return nil, err
+	}
+	if err := store.statsStreaks(userID, workspaceID, query, stats); err != nil {
+		return nil, err
+	}
+
+	stats.Projects, err = store.statsBreakdowns("SELECT COALESCE(p.id, 0), COALESCE(p.name, ''), "+counts+visibleTodos+
+		" GROUP BY p.id, p.name"+active+" ORDER BY 3 DESC, 4 DESC, 2", args, func(row *models.StatsBreakdown) []any {
+		return []any{&row.ProjectID, &row.ProjectName}
+	})
+	if err != nil {
+		return nil, err
+	}
+	stats.Tags, err = store.statsBreakdowns("SELECT tg.tag, "+counts+" FROM todo_tags tg JOIN todos t ON t.id = tg.todo_id"+
+		" WHERE t.id IN (SELECT t.id"+visibleTodos+") GROUP BY tg.tag"+active+" ORDER BY 2 DESC, 3 DESC, 1", args, func(row *models.StatsBreakdown) []any {
+		return []any{&row.Tag}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// statsDays lists the completions on every day of the range, with the mean
+// over the seven days ending with each.
+func (store *DbStore) statsDays(userID int, workspaceID int, query *models.StatsQuery) ([]*models.StatsDay, error) {
+	rows, err := store.DB.Query("SELECT to_char(d.day, 'YYYY-MM-DD'), COALESCE(c.completed, 0),"+
+		" ROUND(AVG(COALESCE(c.completed, 0)) OVER (ORDER BY d.day ROWS BETWEEN 6 PRECEDING AND CURRENT ROW), 2)"+
+		" FROM (SELECT generate_series(($3::timestamptz AT TIME ZONE $5)::date, ($4::timestamptz AT TIME ZONE $5)::date - 1, INTERVAL '1 day')::date AS day) d"+
+		" LEFT JOIN (SELECT (t.completed_at AT TIME ZONE $5)::date AS day, COUNT(*) AS completed"+visibleTodos+" AND "+completedInRange+" GROUP BY 1) c ON c.day = d.day"+
+		" ORDER BY d.day", userID, workspaceID, query.From, query.To, query.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []*models.StatsDay{}
+	for rows.Next() {
+		day := &models.StatsDay{}
+		if err := rows.Scan(&day.Date, &day.Completed, &day.Average); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+// statsStreaks finds the runs of consecutive days with completions: numbering
+// the days in order, the days of one run all lie the same distance from their
+// number.
+func (store *DbStore) statsStreaks(userID int, workspaceID int, query *models.StatsQuery, stats *models.Stats) error {
+	return store.DB.QueryRow("WITH days AS (SELECT DISTINCT (t.completed_at AT TIME ZONE $3)::date AS day"+visibleTodos+" AND t.completed_at IS NOT NULL),"+
+		" runs AS (SELECT MAX(day) AS last, COUNT(*) AS length FROM (SELECT day, day - ROW_NUMBER() OVER (ORDER BY day)::int AS run FROM days) d GROUP BY run)"+
+		" SELECT COALESCE(MAX(length) FILTER (WHERE last >= $4::date - 1), 0), COALESCE(MAX(length), 0) FROM runs",
+		userID, workspaceID, query.Timezone, query.Now.Format("2006-01-02")).
+		Scan(&stats.CurrentStreak, &stats.LongestStreak)
+}
+
+// statsBreakdowns runs a breakdown query, reading its leading columns into
+// the fields dest returns and the last three into the counts.
+func (store *DbStore) statsBreakdowns(query string, args []any, dest func(row *models.StatsBreakdown) []any) ([]*models.StatsBreakdown, error) {
+	rows, err := store.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	breakdowns := []*models.StatsBreakdown{}
+	for rows.Next() {
+		row := &models.StatsBreakdown{}
+		if err := rows.Scan(append(dest(row), &row.Completed, &row.Open, &row.Overdue)...); err != nil {
+			return nil, err
+		}
+		breakdowns = append(breakdowns, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return breakdowns, nil
+}
@@ -0,0 +1,73 @@
+package stores
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+	from := time.Date(2024, 11, 18, 0, 0, 0, 0, berlin)
+	to := time.Date(2024, 11, 21, 0, 0, 0, 0, berlin)
+	now := time.Date(2024, 11, 20, 15, 0, 0, 0, berlin)
+	// The counts are read for the range and for todos overdue now.
+	countArgs := []driver.Value{1, 0, from, to, time.Time{}, time.Time{}, now, time.Date(2024, 11, 20, 0, 0, 0, 0, time.UTC)}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FILTER (WHERE t.completed_at >= $3 AND t.completed_at < $4), COUNT(*) FILTER (WHERE t.created_at >= $3 AND t.created_at < $4)")).
+		WithArgs(countArgs...).
+		WillReturnRows(sqlmock.NewRows([]string{"completed", "created", "lead_time", "overdue"}).AddRow(4, 6, 86400, 2))
+	mock.ExpectQuery(regexp.QuoteMeta("ROUND(AVG(COALESCE(c.completed, 0)) OVER (ORDER BY d.day ROWS BETWEEN 6 PRECEDING AND CURRENT ROW), 2)")).
+		WithArgs(1, 0, from, to, "Europe/Berlin").
+		WillReturnRows(sqlmock.NewRows([]string{"date", "completed", "average"}).
+			AddRow("2024-11-18", 3, 3).
+			AddRow("2024-11-19", 0, 1.5).
+			AddRow("2024-11-20", 1, 1.33))
+	mock.ExpectQuery(regexp.QuoteMeta("day - ROW_NUMBER() OVER (ORDER BY day)::int AS run")).
+		WithArgs(1, 0, "Europe/Berlin", "2024-11-20").
+		WillReturnRows(sqlmock.NewRows([]string{"current", "longest"}).AddRow(1, 5))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(p.id, 0), COALESCE(p.name, ''), COUNT(*)")).
+		WithArgs(countArgs...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "completed", "open", "overdue"}).
+			AddRow(2, "Website", 3, 1, 1).
+			AddRow(0, "", 1, 5, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT tg.tag, COUNT(*)")).
+		WithArgs(countArgs...).
+		WillReturnRows(sqlmock.NewRows([]string{"tag", "completed", "open", "overdue"}).AddRow("release", 2, 0, 0))
+
+	stats, err := store.GetStats(1, 0, &models.StatsQuery{From: from, To: to, Timezone: "Europe/Berlin", Now: now})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Stats{
+		Completed:              4,
+		Created:                6,
+		AverageLeadTimeSeconds: 86400,
+		Overdue:                2,
+		CurrentStreak:          1,
+		LongestStreak:          5,
+		Days: []*models.StatsDay{
+			{Date: "2024-11-18", Completed: 3, Average: 3},
+			{Date: "2024-11-19", Completed: 0, Average: 1.5},
+			{Date: "2024-11-20", Completed: 1, Average: 1.33},
+		},
+		Projects: []*models.StatsBreakdown{
+			{ProjectID: 2, ProjectName: "Website", Completed: 3, Open: 1, Overdue: 1},
+			{Completed: 1, Open: 5, Overdue: 1},
+		},
+		Tags: []*models.StatsBreakdown{{Tag: "release", Completed: 2}},
+	}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,23 +2,206 @@ package stores
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/src/lib"
 	"todo-list/src/models"
+
+	"github.com/lib/pq"
 )
 
 type Store interface {
-	GetTodos(userID int) ([]*models.Todo, error)
+	GetTodos(userID int, workspaceID int, filter *models.TodoFilter) ([]*models.Todo, error)
+	ExportTodos(userID int, workspaceID int, filter *models.TodoFilter, each func(*models.Todo) error) error
 	CreateTodo(todo *models.Todo, userID int) (*models.Todo, error)
-	UpdateTodo(todo *models.Todo, todoID int) (*models.Todo, error)
-	GetTodo(todoID int, userID int) error
-	DeleteTodo(ID int) error
+	UpdateTodo(todo *models.Todo, todoID int, userID int, allowBlocked bool) (*models.Todo, error)
+	DeleteTodo(ID int, userID int) error
+	GetTrash(userID int, workspaceID int) ([]*models.Todo, error)
+	RestoreTodo(todoID int, userID int, workspaceID int) error
+	EmptyTrash(userID int, workspaceID int) ([]*models.Attachment, error)
+	PurgeTrash(before time.Time) ([]*models.Attachment, error)
+	MoveTodo(todoID int, userID int, move *models.TodoMove) (*models.Todo, error)
+	BulkUpdateTodos(request *models.BulkRequest, userID int, workspaceID int) (*models.BulkResponse, error)
+	CreateImport(imp *models.Import) (*models.Import, error)
+	GetImport(importID int, userID int) (*models.Import, error)
+	UpdateImport(imp *models.Import) error
+	CreateAttachment(attachment *models.Attachment) (*models.Attachment, error)
+	GetAttachments(todoID int) ([]*models.Attachment, error)
+	GetAttachment(attachmentID int, todoID int) (*models.Attachment, error)
+	DeleteAttachment(attachmentID int, todoID int) error
+	CreateComment(comment *models.Comment) (*models.Comment, error)
+	GetComments(todoID int) ([]*models.Comment, error)
+	GetComment(commentID int) (*models.Comment, error)
+	UpdateComment(commentID int, body string) (*models.Comment, error)
+	DeleteComment(commentID int) error
+	GetTodoChanges(todoID int) ([]*models.TodoChange, error)
+	GetRevisions(todoID int) ([]*models.Revision, error)
+	GetRevision(todoID int, revisionID int) (*models.Revision, error)
+	GetUserByEmail(email string) (*models.User, error)
+	ClaimIdempotencyKey(userID int, key string, expiredBefore time.Time) (*models.IdempotencyKey, bool, error)
+	SaveIdempotentResponse(idempotencyKey *models.IdempotencyKey) error
+	ReleaseIdempotencyKey(userID int, key string) error
+	PurgeIdempotencyKeys(before time.Time) error
+	CreateCalendarFeed(feed *models.CalendarFeed) (*models.CalendarFeed, error)
+	GetCalendarFeed(token string) (*models.CalendarFeed, error)
+	DeleteCalendarFeed(userID int, workspaceID int) error
+	CreateAppPassword(appPassword *models.AppPassword) (*models.AppPassword, error)
+	GetAppPasswords(userID int) ([]*models.AppPassword, error)
+	DeleteAppPassword(appPasswordID int, userID int) error
+	AuthenticateAppPassword(email string, password string) (*models.User, error)
+	CreateSavedFilter(filter *models.SavedFilter) (*models.SavedFilter, error)
+	GetSavedFilters(userID int) ([]*models.SavedFilter, error)
+	GetSavedFilter(filterID int, userID int) (*models.SavedFilter, error)
+	UpdateSavedFilter(filter *models.SavedFilter) (*models.SavedFilter, error)
+	DeleteSavedFilter(filterID int, userID int) error
+	StartTimer(todoID int, userID int, note string) (*models.TimeEntry, error)
+	StopTimer(todoID int, userID int) (*models.TimeEntry, error)
+	GetRunningTimer(userID int) (*models.TimeEntry, error)
+	CreateTimeEntry(entry *models.TimeEntry) (*models.TimeEntry, error)
+	GetTimeEntries(todoID int) ([]*models.TimeEntry, error)
+	GetTimeEntry(entryID int) (*models.TimeEntry, error)
+	UpdateTimeEntry(entry *models.TimeEntry) (*models.TimeEntry, error)
+	DeleteTimeEntry(entryID int) error
+	GetTimeReport(userID int, query *models.TimeReportQuery) (*models.TimeReport, error)
+	GetStats(userID int, workspaceID int, query *models.StatsQuery) (*models.Stats, error)
+	CreateTemplate(template *models.Template) (*models.Template, error)
+	GetTemplates(userID int) ([]*models.Template, error)
+	GetTemplate(templateID int, userID int) (*models.Template, error)
+	DeleteTemplate(templateID int, userID int) error
+	CreateTodos(todos []*models.Todo, parents []int, userID int) ([]*models.Todo, error)
+	CreateCalDAVTodo(todo *models.Todo, userID int, resource *models.CalDAVResource) (*models.Todo, error)
+	GetCalDAVResources(todoIDs []int) ([]*models.CalDAVResource, error)
+	GetCalDAVResourcesByName(name string) ([]*models.CalDAVResource, error)
+	GetSyncToken() (int64, error)
+	GetChangedTodoIDs(since int64, userID int, projectID int) ([]int, error)
+	GetTodoRole(todoID int, userID int, workspaceID int) (string, error)
+	SetAssignees(todoID int, userIDs []int, assignedBy int) (*models.AssigneeChanges, error)
+	AddDependency(todoID int, blockedByID int) error
+	RemoveDependency(todoID int, blockedByID int) error
+	GetDependencyGraph(todoID int, userID int, workspaceID int) (*models.DependencyGraph, error)
+	ShareTodo(todoID int, userID int, role string) error
+	GetShares(todoID int) ([]*models.Share, error)
+	RevokeShare(todoID int, userID int) error
+	CreateWorkspace(workspace *models.Workspace, userID int) (*models.Workspace, error)
+	GetWorkspaces(userID int) ([]*models.Workspace, error)
+	GetWorkspace(workspaceID int, userID int) (*models.Workspace, error)
+	GetWorkspaceMembers(workspaceID int) ([]*models.WorkspaceMember, error)
+	UpdateWorkspaceMember(workspaceID int, userID int, role string) error
+	RemoveWorkspaceMember(workspaceID int, userID int) error
+	CreateInvitation(invitation *models.Invitation) (*models.Invitation, error)
+	AcceptInvitation(token string, user *models.User) (*models.Workspace, error)
+	CreateProject(project *models.Project) (*models.Project, error)
+	GetProjects(workspaceID int) ([]*models.Project, error)
+	GetProject(projectID int, workspaceID int) (*models.Project, error)
+	GetMemberProjects(userID int, projectID int) ([]*models.MemberProject, error)
+	DeleteProject(projectID int, workspaceID int) error
+	CreateCustomField(field *models.CustomField) (*models.CustomField, error)
+	GetCustomFields(projectID int) ([]*models.CustomField, error)
+	GetTodoCustomFields(todoID int) ([]*models.CustomField, error)
+	UpdateCustomField(field *models.CustomField) (*models.CustomField, error)
+	DeleteCustomField(fieldID int, projectID int) error
+	CreateWebhook(webhook *models.Webhook) (*models.Webhook, error)
+	GetWebhooks(userID int) ([]*models.Webhook, error)
+	GetWebhook(webhookID int, userID int) (*models.Webhook, error)
+	UpdateWebhook(webhook *models.Webhook) (*models.Webhook, error)
+	DeleteWebhook(webhookID int, userID int) error
+	GetWebhookDeliveries(webhookID int, limit int) ([]*models.WebhookDelivery, error)
+	RedeliverWebhook(deliveryID int, webhookID int) (*models.WebhookDelivery, error)
+	ClaimWebhookDeliveries(limit int) ([]*models.WebhookDelivery, error)
+	RecordWebhookAttempt(delivery *models.WebhookDelivery) error
+	PurgeWebhookDeliveries(before time.Time) error
+	GetTodoEvent(eventID int64) (*models.TodoEvent, error)
+	GetTodoEvents(afterID int64, userID int, limit int) ([]*models.TodoEvent, error)
+	GetTodoEventLogStart() (int64, error)
+	PurgeTodoEvents(before time.Time) error
+	CreateStreamTicket(userID int, ticket string) (*models.StreamTicket, error)
+	RedeemStreamTicket(ticket string) (int, error)
+	PurgeStreamTickets(before time.Time) error
 	CreateUser(user *models.User) (*models.User, error)
 	GetUser(user *models.User) (*models.User, error)
+	UpdateUserSettings(userID int, settings *models.UserSettings) (*models.UserSettings, error)
 }
 
 type DbStore struct {
 	DB *sql.DB
 }
 
+// blockedCondition holds for todos waiting on an open todo.
+const blockedCondition = "EXISTS (SELECT 1 FROM todo_dependencies d JOIN todos b ON b.id = d.blocked_by_id WHERE d.todo_id = t.id AND NOT b.completed AND b.deleted_at IS NULL)"
+
+// todoColumns is the column list every todo query reads back, in the order
+// scanTodo expects. Queries joining users_todos select it with the "t" alias.
+const todoColumns = "t.id, t.task_name, t.completed, t.due_date, t.created_at, t.updated_at, t.notes, COALESCE(t.workspace_id, 0), COALESCE(t.project_id, 0)," +
+	" ARRAY(SELECT a.user_id FROM todo_assignees a WHERE a.todo_id = t.id ORDER BY a.user_id)," +
+	" " + blockedCondition + "," +
+	" ARRAY(SELECT tg.tag FROM todo_tags tg WHERE tg.todo_id = t.id ORDER BY tg.tag), t.priority, t.recurrence, t.due_all_day," +
+	" (SELECT COALESCE(SUM(" + entrySeconds + "), 0) FROM time_entries e WHERE e.todo_id = t.id), t.completed_at, t.custom_fields"
+
+// accessibleTodos selects the todos user $1 has access to in workspace $2,
+// where 0 is the personal space, whether or not they are in the trash. Access
+// comes either from a users_todos row or from membership of the workspace
+// owning the todo's project. tp is the todo's place in the user's list, if
+// they have one.
+const accessibleTodos = " FROM todos t" +
+	" LEFT JOIN users_todos ut ON ut.todo_id = t.id AND ut.user_id = $1" +
+	" LEFT JOIN todo_positions tp ON tp.todo_id = t.id AND tp.user_id = $1" +
+	" LEFT JOIN projects p ON p.id = t.project_id" +
+	" LEFT JOIN workspace_members wm ON wm.workspace_id = p.workspace_id AND wm.user_id = $1" +
+	" WHERE t.workspace_id IS NOT DISTINCT FROM NULLIF($2, 0) AND (ut.user_id IS NOT NULL OR wm.user_id IS NOT NULL)"
+
+// visibleTodos narrows accessibleTodos to the todos that are not trashed.
+// Every read outside the trash goes through it.
+const visibleTodos = accessibleTodos + " AND t.deleted_at IS NULL"
+
+// trashedTodos narrows accessibleTodos to the todos in the trash.
+const trashedTodos = accessibleTodos + " AND t.deleted_at IS NOT NULL"
+
+// effectiveRole is the stronger of the roles granted by visibleTodos' joins.
+const effectiveRole = "CASE WHEN 'owner' IN (ut.role, wm.role) THEN 'owner' WHEN 'editor' IN (ut.role, wm.role) THEN 'editor' ELSE 'viewer' END"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// rowQuerier is satisfied by both *sql.DB and *sql.Tx.
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// scanTodo reads the todoColumns of a row into todo, followed by any extra
+// destinations for columns selected after them.
+func scanTodo(row rowScanner, todo *models.Todo, extra ...any) error {
+	var assigneeIDs pq.Int64Array
+	var tags pq.StringArray
+	var customFields []byte
+	dest := []any{&todo.ID, &todo.TaskName, &todo.Completed, &todo.DueDate, &todo.CreatedAt, &todo.UpdatedAt, &todo.Notes, &todo.WorkspaceID, &todo.ProjectID, &assigneeIDs, &todo.Blocked, &tags, &todo.Priority, &todo.Recurrence, &todo.DueAllDay, &todo.TrackedSeconds, &todo.CompletedAt, &customFields}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return err
+	}
+	todo.AssigneeIDs = nil
+	for _, assigneeID := range assigneeIDs {
+		todo.AssigneeIDs = append(todo.AssigneeIDs, int(assigneeID))
+	}
+	todo.Tags = nil
+	if len(tags) > 0 {
+		todo.Tags = tags
+	}
+	todo.CustomFields = nil
+	if len(customFields) > 0 {
+		if err := json.Unmarshal(customFields, &todo.CustomFields); err != nil {
+			return err
+		}
+		if len(todo.CustomFields) == 0 {
+			todo.CustomFields = nil
+		}
+	}
+	return nil
+}
+
 var store Store
 
 func GetStore() Store {
@@ -37,26 +220,150 @@ func (store *DbStore) CreateTodo(todo *models.Todo, userID int) (*models.Todo, e
 		}
 	}()
 
+	lastInsertedTodo, err := insertTodo(transaction, todo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = transaction.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return lastInsertedTodo, nil
+}
+
+// insertTodo creates a todo owned by the user, with its tags, at the end of
+// their list.
+func insertTodo(transaction *sql.Tx, todo *models.Todo, userID int) (*models.Todo, error) {
+	normalizeDueDate(todo)
 	lastInsertedTodo := &models.Todo{}
-	err = transaction.QueryRow("INSERT INTO todos(task_name, completed, due_date) VALUES ($1, $2, $3) RETURNING id, task_name, completed, due_date, created_at, updated_at", todo.TaskName, todo.Completed, todo.DueDate).Scan(&lastInsertedTodo.ID, &lastInsertedTodo.TaskName, &lastInsertedTodo.Completed, &lastInsertedTodo.DueDate, &lastInsertedTodo.CreatedAt, &lastInsertedTodo.UpdatedAt)
+	err := scanTodo(transaction.QueryRow("INSERT INTO todos AS t (task_name, completed, due_date, due_all_day, notes, priority, recurrence, workspace_id, project_id, custom_fields) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, 0), NULLIF($9, 0), $10) RETURNING "+todoColumns, todo.TaskName, todo.Completed, todo.DueDate, todo.DueAllDay, todo.Notes, todo.Priority, todo.Recurrence, todo.WorkspaceID, todo.ProjectID, formatCustomFields(todo.CustomFields)), lastInsertedTodo)
 
 	if err != nil {
 		return nil, err
 	}
-	_, err = transaction.Exec("INSERT INTO users_todos (user_id, todo_id) VALUES ($1, $2)", userID, lastInsertedTodo.ID)
+	if len(todo.Tags) > 0 {
+		err = transaction.QueryRow("WITH inserted AS (INSERT INTO todo_tags (todo_id, tag) SELECT $1, UNNEST($2::text[]) ON CONFLICT DO NOTHING RETURNING tag)"+
+			" SELECT ARRAY(SELECT tag FROM inserted ORDER BY tag)", lastInsertedTodo.ID, pq.Array(todo.Tags)).Scan((*pq.StringArray)(&lastInsertedTodo.Tags))
+		if err != nil {
+			return nil, err
+		}
+	}
+	var lastPosition string
+	err = transaction.QueryRow("SELECT COALESCE(MAX(position), '') FROM todo_positions WHERE user_id = $1", userID).Scan(&lastPosition)
+	if err != nil {
+		return nil, err
+	}
+	lastInsertedTodo.Role = models.RoleOwner
+	lastInsertedTodo.Position, err = lib.PositionBetween(lastPosition, "")
 	if err != nil {
 		return nil, err
 	}
 
-	err = transaction.Commit()
+	_, err = transaction.Exec("INSERT INTO users_todos (user_id, todo_id, role) VALUES ($1, $2, 'owner')", userID, lastInsertedTodo.ID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = transaction.Exec("INSERT INTO todo_positions (user_id, todo_id, position) VALUES ($1, $2, $3)", userID, lastInsertedTodo.ID, lastInsertedTodo.Position)
 	if err != nil {
 		return nil, err
 	}
 
+	err = recordRevision(transaction, lastInsertedTodo.ID, userID, models.RevisionCreate, nil, lastInsertedTodo)
+	if err != nil {
+		return nil, err
+	}
 	return lastInsertedTodo, nil
 }
 
-func (store *DbStore) GetTodos(userID int) ([]*models.Todo, error) {
+// filterConditions turns filter into conditions appended to a visibleTodos
+// query, numbering its placeholders after the ones already in args.
+func filterConditions(filter *models.TodoFilter, args []any) (string, []any) {
+	conditions := ""
+	if filter == nil {
+		return conditions, args
+	}
+	if filter.AssigneeID != 0 {
+		args = append(args, filter.AssigneeID)
+		conditions += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM todo_assignees a WHERE a.todo_id = t.id AND a.user_id = $%d)", len(args))
+	}
+	if filter.Completed != nil {
+		args = append(args, *filter.Completed)
+		conditions += fmt.Sprintf(" AND t.completed = $%d", len(args))
+	}
+	if filter.ProjectID != 0 {
+		args = append(args, filter.ProjectID)
+		conditions += fmt.Sprintf(" AND t.project_id = $%d", len(args))
+	}
+	if filter.Tag != "" {
+		args = append(args, filter.Tag)
+		conditions += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM todo_tags tg WHERE tg.todo_id = t.id AND tg.tag = $%d)", len(args))
+	}
+	if filter.IDs != nil {
+		args = append(args, pq.Array(filter.IDs))
+		conditions += fmt.Sprintf(" AND t.id = ANY($%d)", len(args))
+	}
+	if filter.Due != nil {
+		var condition string
+		condition, args = dueCondition(filter.Due, args)
+		conditions += " AND " + condition
+	}
+	if filter.Expression != nil {
+		var condition string
+		condition, args = compileFilter(filter.Expression, args)
+		conditions += " AND " + condition
+	}
+	for _, match := range filter.Fields {
+		// Containment is served by the GIN index on custom_fields.
+		args = append(args, formatCustomFields(map[string]any{match.Key: match.Value}))
+		conditions += fmt.Sprintf(" AND t.custom_fields @> $%d::jsonb", len(args))
+	}
+	return conditions, args
+}
+
+// dueCondition matches the todos due within due: timed todos by instant and
+// all-day todos by calendar date. Todos without a due date hold the zero
+// time, so an open lower bound still starts just after it.
+func dueCondition(due *models.DueRange, args []any) (string, []any) {
+	timed := []string{"NOT t.due_all_day"}
+	allDay := []string{"t.due_all_day"}
+	bound := func(operator string, value time.Time) {
+		args = append(args, value)
+		timed = append(timed, fmt.Sprintf("t.due_date %s $%d", operator, len(args)))
+		args = append(args, lib.AllDay(value))
+		allDay = append(allDay, fmt.Sprintf("t.due_date %s $%d", operator, len(args)))
+	}
+	if due.From.IsZero() {
+		bound(">", time.Time{})
+	} else {
+		bound(">=", due.From)
+	}
+	if !due.To.IsZero() {
+		bound("<", due.To)
+	}
+	return "((" + strings.Join(timed, " AND ") + ") OR (" + strings.Join(allDay, " AND ") + "))", args
+}
+
+// listTodosQuery selects the todos GET /todos lists, in list order. Project
+// todos the user never placed in their own list come last. Sorting by a
+// custom field compares the JSON values, so numbers sort as numbers and dates
+// as dates without casts that a stray value could make fail.
+func listTodosQuery(userID int, workspaceID int, filter *models.TodoFilter) (string, []any) {
+	conditions, args := filterConditions(filter, []any{userID, workspaceID})
+	order := ""
+	if filter != nil && filter.SortField != "" {
+		args = append(args, filter.SortField)
+		direction := "ASC"
+		if filter.SortDescending {
+			direction = "DESC"
+		}
+		order = fmt.Sprintf("t.custom_fields -> $%d %s NULLS LAST, ", len(args), direction)
+	}
+	return "SELECT " + todoColumns + ", COALESCE(tp.position, ''), " + effectiveRole + visibleTodos + conditions + " ORDER BY " + order + "tp.position NULLS LAST, t.id", args
+}
+
+func (store *DbStore) GetTodos(userID int, workspaceID int, filter *models.TodoFilter) ([]*models.Todo, error) {
 	transaction, err := store.DB.Begin()
 	if err != nil {
 		return nil, err
@@ -69,7 +376,8 @@ func (store *DbStore) GetTodos(userID int) ([]*models.Todo, error) {
 			transaction.Commit()
 		}
 	}()
-	rows, err := transaction.Query("SELECT t.* from todos t JOIN users_todos ut ON t.id = ut.todo_id WHERE ut.user_id = $1", userID)
+	query, args := listTodosQuery(userID, workspaceID, filter)
+	rows, err := transaction.Query(query, args...)
 
 	if err != nil {
 		return nil, err
@@ -80,49 +388,230 @@ func (store *DbStore) GetTodos(userID int) ([]*models.Todo, error) {
 	todos := []*models.Todo{}
 	for rows.Next() {
 		todo := &models.Todo{}
-		if err := rows.Scan(&todo.TaskName, &todo.Completed, &todo.DueDate); err != nil {
+		if err := scanTodo(rows, todo, &todo.Position, &todo.Role); err != nil {
 			return nil, err
 		}
 		todos = append(todos, todo)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 	return todos, nil
 }
 
-func (store *DbStore) GetTodo(todoID int, userID int) error {
-	transaction, err := store.DB.Begin()
+// ExportTodos calls each for every todo GetTodos would return, reading them
+// one row at a time so exports of any size never sit in memory. It stops at
+// the first error each returns.
+func (store *DbStore) ExportTodos(userID int, workspaceID int, filter *models.TodoFilter, each func(*models.Todo) error) error {
+	query, args := listTodosQuery(userID, workspaceID, filter)
+	rows, err := store.DB.Query(query, args...)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
+
+	for rows.Next() {
+		todo := &models.Todo{}
+		if err := scanTodo(rows, todo, &todo.Position, &todo.Role); err != nil {
+			return err
+		}
+		if err := each(todo); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// UpdateTodo saves the user editable fields of a todo and records what
+// changed. Completing a todo with open blockers fails with a *BlockedError
+// unless allowBlocked is set.
+func (store *DbStore) UpdateTodo(todo *models.Todo, todoID int, userID int, allowBlocked bool) (*models.Todo, error) {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
 	defer func() {
 		if err != nil {
 			transaction.Rollback()
 		}
 	}()
 
-	todo := &models.Todo{}
-	err = transaction.QueryRow("SELECT t.* from todos t JOIN users_todos ut ON t.id = ut.todo_id WHERE ut.user_id = $1 AND t.id = $2", userID, todoID).Scan(&todo.ID, &todo.TaskName, &todo.Completed, &todo.DueDate, &todo.CreatedAt, &todo.UpdatedAt)
+	var updatedTodo *models.Todo
+	updatedTodo, err = updateTodo(transaction, todoID, userID, allowBlocked, func(editable *models.Todo) {
+		editable.TaskName = todo.TaskName
+		editable.Completed = todo.Completed
+		editable.DueDate = todo.DueDate
+		editable.DueAllDay = todo.DueAllDay
+		editable.Notes = todo.Notes
+		editable.Priority = todo.Priority
+		editable.Recurrence = todo.Recurrence
+		if todo.CustomFields != nil {
+			editable.CustomFields = todo.CustomFields
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
 
+	err = transaction.Commit()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+
+	return updatedTodo, nil
 }
 
-func (store *DbStore) UpdateTodo(todo *models.Todo, todoID int) (*models.Todo, error) {
+// maxTodoTags is the limit on models.Todo.Tags.
+const maxTodoTags = 20
+
+var errTooManyTags = fmt.Errorf("a todo can have at most %d tags", maxTodoTags)
+
+// updateTodo locks the todo, lets edit change its editable fields, project
+// and tags and saves them inside transaction, recording the changes and a
+// revision. Edits have to keep the tags sorted.
+func updateTodo(transaction *sql.Tx, todoID int, userID int, allowBlocked bool, edit func(todo *models.Todo)) (*models.Todo, error) {
+	previousTodo := &models.Todo{}
+	err := scanTodo(transaction.QueryRow("SELECT "+todoColumns+" FROM todos t WHERE t.id=$1 AND t.deleted_at IS NULL FOR UPDATE", todoID), previousTodo)
+	if err != nil {
+		return nil, err
+	}
+	todo := *previousTodo
+	edit(&todo)
+	normalizeDueDate(&todo)
+
+	if todo.Completed && !previousTodo.Completed && !allowBlocked {
+		blockerIDs, err := openBlockers(transaction, todoID)
+		if err != nil {
+			return nil, err
+		}
+		if len(blockerIDs) > 0 {
+			return nil, &BlockedError{BlockerIDs: blockerIDs}
+		}
+	}
+
+	if strings.Join(todo.Tags, ",") != strings.Join(previousTodo.Tags, ",") {
+		if len(todo.Tags) > maxTodoTags {
+			return nil, errTooManyTags
+		}
+		tags := pq.StringArray{}
+		tags = append(tags, todo.Tags...)
+		_, err = transaction.Exec("DELETE FROM todo_tags WHERE todo_id = $1 AND tag <> ALL($2)", todoID, tags)
+		if err != nil {
+			return nil, err
+		}
+		_, err = transaction.Exec("INSERT INTO todo_tags (todo_id, tag) SELECT $1, UNNEST($2::text[]) ON CONFLICT DO NOTHING", todoID, tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	updatedTodo := &models.Todo{}
-	err := store.DB.QueryRow("UPDATE todos SET task_name=$1, completed=$2, due_date=$3 WHERE id=$4 RETURNING id, task_name, completed, due_date, created_at, updated_at", todo.TaskName, todo.Completed, todo.DueDate, todoID).Scan(&updatedTodo.ID, &updatedTodo.TaskName, &updatedTodo.Completed, &updatedTodo.DueDate, &updatedTodo.CreatedAt, &updatedTodo.UpdatedAt)
+	err = scanTodo(transaction.QueryRow("UPDATE todos t SET task_name=$1, completed=$2, due_date=$3, due_all_day=$4, notes=$5, priority=$6, recurrence=$7, custom_fields=$8, project_id=NULLIF($9, 0) WHERE id=$10 RETURNING "+todoColumns, todo.TaskName, todo.Completed, todo.DueDate, todo.DueAllDay, todo.Notes, todo.Priority, todo.Recurrence, formatCustomFields(todo.CustomFields), todo.ProjectID, todoID), updatedTodo)
 	if err != nil {
 		return nil, err
 	}
+
+	changes := diffTodos(previousTodo, updatedTodo)
+	for _, change := range changes {
+		_, err = transaction.Exec("INSERT INTO todo_changes (todo_id, user_id, field, old_value, new_value) VALUES ($1, $2, $3, $4, $5)", todoID, userID, change.Field, change.OldValue, change.NewValue)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(changes) > 0 {
+		err = recordRevision(transaction, todoID, userID, models.RevisionUpdate, previousTodo, updatedTodo)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return updatedTodo, nil
 }
 
-func (store *DbStore) DeleteTodo(ID int) error {
-	_, err := store.DB.Exec("DELETE FROM todos WHERE id=$1", ID)
+// diffTodos lists the user editable fields that differ between two versions
+// of a todo, formatted the way they are stored in todo_changes.
+func diffTodos(before *models.Todo, after *models.Todo) []models.TodoChange {
+	changes := []models.TodoChange{}
+	add := func(field string, oldValue string, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, models.TodoChange{Field: field, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	add("task_name", before.TaskName, after.TaskName)
+	add("completed", strconv.FormatBool(before.Completed), strconv.FormatBool(after.Completed))
+	add("due_date", formatDueDate(before.DueDate, before.DueAllDay), formatDueDate(after.DueDate, after.DueAllDay))
+	add("notes", before.Notes, after.Notes)
+	add("priority", strconv.Itoa(before.Priority), strconv.Itoa(after.Priority))
+	add("recurrence", before.Recurrence, after.Recurrence)
+	add("custom_fields", formatCustomFields(before.CustomFields), formatCustomFields(after.CustomFields))
+	add("project_id", strconv.Itoa(before.ProjectID), strconv.Itoa(after.ProjectID))
+	add("tags", strings.Join(before.Tags, ","), strings.Join(after.Tags, ","))
+	return changes
+}
+
+// formatDueDate writes all-day due dates as a plain date, so switching a todo
+// between all day and a time shows up as a change.
+func formatDueDate(dueDate time.Time, allDay bool) string {
+	if dueDate.IsZero() {
+		return ""
+	}
+	if allDay {
+		return dueDate.Format("2006-01-02")
+	}
+	return dueDate.UTC().Format(time.RFC3339)
+}
+
+// formatCustomFields encodes the custom field values of a todo as a JSON
+// object, keys sorted. The values were decoded from JSON, so encoding them
+// again can not fail.
+func formatCustomFields(values map[string]any) string {
+	if len(values) == 0 {
+		return "{}"
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// normalizeDueDate stores an all-day due date as its calendar date at
+// midnight UTC, whatever time and offset it was given with. A todo without a
+// due date is never all day.
+func normalizeDueDate(todo *models.Todo) {
+	if todo.DueDate.IsZero() {
+		todo.DueAllDay = false
+	} else if todo.DueAllDay {
+		todo.DueDate = lib.AllDay(todo.DueDate)
+	}
+}
+
+// DeleteTodo moves a todo to the trash. It stays there, hidden from every
+// other read, until it is restored or purged.
+func (store *DbStore) DeleteTodo(ID int, userID int) error {
+	transaction, err := store.DB.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	err = deleteTodo(transaction, ID, userID)
+	if err != nil {
+		return err
+	}
+
+	return transaction.Commit()
+}
+
+func deleteTodo(transaction *sql.Tx, todoID int, userID int) error {
+	deletedTodo := &models.Todo{}
+	err := scanTodo(transaction.QueryRow("UPDATE todos t SET deleted_at = NOW() WHERE id=$1 AND deleted_at IS NULL RETURNING "+todoColumns, todoID), deletedTodo)
+	if err != nil {
+		return err
+	}
+	return recordRevision(transaction, todoID, userID, models.RevisionDelete, deletedTodo, nil)
 }
 
 func (store *DbStore) CreateUser(user *models.User) (*models.User, error) {
@@ -136,15 +625,25 @@ func (store *DbStore) CreateUser(user *models.User) (*models.User, error) {
 }
 
 func (store *DbStore) GetUser(user *models.User) (*models.User, error) {
-	row := store.DB.QueryRow("SELECT id, username, email, password FROM users WHERE email=$1 AND password=$2", user.Email, user.Password)
-	userData := &models.User{}
-	err := row.Scan(&userData.ID, &userData.UserName, &userData.Email, &userData.Password)
+	row := store.DB.QueryRow("SELECT id, username, email, password, timezone, week_start, locale FROM users WHERE email=$1 AND password=$2", user.Email, user.Password)
+	userData := &models.User{Settings: &models.UserSettings{}}
+	err := row.Scan(&userData.ID, &userData.UserName, &userData.Email, &userData.Password, &userData.Settings.Timezone, &userData.Settings.WeekStart, &userData.Settings.Locale)
 	if err != nil {
 		return nil, err
 	}
 	return userData, nil
 }
 
+// UpdateUserSettings replaces the settings of a user.
+func (store *DbStore) UpdateUserSettings(userID int, settings *models.UserSettings) (*models.UserSettings, error) {
+	updated := &models.UserSettings{}
+	err := store.DB.QueryRow("UPDATE users SET timezone = $1, week_start = $2, locale = $3 WHERE id = $4 RETURNING timezone, week_start, locale", settings.Timezone, settings.WeekStart, settings.Locale, userID).Scan(&updated.Timezone, &updated.WeekStart, &updated.Locale)
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
 func InitStore(s Store) {
 	store = s
 }
@@ -1,12 +1,14 @@
 package stores
 
 import (
+	"database/sql"
 	"fmt"
 	"testing"
 	"time"
 	"todo-list/src/models"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -42,12 +44,55 @@ func TestCreateTodo(t *testing.T) {
 				DueDate:   time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC(),
 				CreatedAt: time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC(),
 				UpdatedAt: time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC(),
+				Position:  "V",
+				Role:      "owner",
 			},
 			userID: 1,
 			mockSetup: func(todoInput *models.Todo, userID int, expectedTodo *models.Todo) {
-				mock.ExpectQuery("INSERT INTO todos").WithArgs(todoInput.TaskName, todoInput.Completed, todoInput.DueDate).WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at"}).AddRow(1, expectedTodo.TaskName, expectedTodo.Completed, expectedTodo.DueDate, expectedTodo.DueDate, expectedTodo.DueDate))
+				mock.ExpectQuery("INSERT INTO todos").WithArgs(todoInput.TaskName, todoInput.Completed, todoInput.DueDate, false, todoInput.Notes, 0, "", 0, 0, "{}").WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}).AddRow(1, expectedTodo.TaskName, expectedTodo.Completed, expectedTodo.DueDate, expectedTodo.DueDate, expectedTodo.DueDate, expectedTodo.Notes, 0, 0, nil, false, nil, 0, "", false, 0, nil, nil))
 
+				mock.ExpectQuery("SELECT COALESCE\\(MAX\\(position\\), ''\\) FROM todo_positions").WithArgs(userID).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(""))
 				mock.ExpectExec("INSERT INTO users_todos").WithArgs(userID, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO todo_positions").WithArgs(userID, 1, "V").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO todo_revisions \\(todo_id, user_id, action, before, after\\) VALUES \\(\\$1, NULLIF\\(\\$2, 0\\), \\$3, \\$4, \\$5\\)").
+					WithArgs(1, userID, "create", nil, `{"task_name":"test task","completed":false,"due_date":"2024-11-30T23:59:59Z","notes":""}`).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO todo_events").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			shouldError: false,
+		},
+		{
+			name: "Todo creation with tags and priority",
+			todoInput: &models.Todo{
+				TaskName: "test task",
+				DueDate:  time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC(),
+				Priority: 3,
+				Tags:     []string{"work", "home", "work"},
+			},
+			expectedTodo: &models.Todo{
+				ID:        1,
+				TaskName:  "test task",
+				DueDate:   time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC(),
+				CreatedAt: time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC(),
+				UpdatedAt: time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC(),
+				Priority:  3,
+				Tags:      []string{"home", "work"},
+				Position:  "V",
+				Role:      "owner",
+			},
+			userID: 1,
+			mockSetup: func(todoInput *models.Todo, userID int, expectedTodo *models.Todo) {
+				mock.ExpectQuery("INSERT INTO todos").WithArgs(todoInput.TaskName, false, todoInput.DueDate, false, "", 3, "", 0, 0, "{}").WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}).AddRow(1, expectedTodo.TaskName, false, expectedTodo.DueDate, expectedTodo.DueDate, expectedTodo.DueDate, "", 0, 0, nil, false, nil, 3, "", false, 0, nil, nil))
+				mock.ExpectQuery("WITH inserted AS \\(INSERT INTO todo_tags \\(todo_id, tag\\) SELECT \\$1, UNNEST\\(\\$2::text\\[\\]\\) ON CONFLICT DO NOTHING RETURNING tag\\) SELECT ARRAY").WithArgs(1, pq.Array(todoInput.Tags)).WillReturnRows(sqlmock.NewRows([]string{"array"}).AddRow("{home,work}"))
+
+				mock.ExpectQuery("SELECT COALESCE\\(MAX\\(position\\), ''\\) FROM todo_positions").WithArgs(userID).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(""))
+				mock.ExpectExec("INSERT INTO users_todos").WithArgs(userID, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO todo_positions").WithArgs(userID, 1, "V").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO todo_revisions").
+					WithArgs(1, userID, "create", nil, `{"task_name":"test task","completed":false,"due_date":"2024-11-30T23:59:59Z","notes":"","priority":3}`).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO todo_events").WillReturnResult(sqlmock.NewResult(0, 0))
 				mock.ExpectCommit()
 			},
 			shouldError: false,
@@ -62,7 +107,7 @@ func TestCreateTodo(t *testing.T) {
 			expectedTodo: nil,
 			userID:       1,
 			mockSetup: func(todoInput *models.Todo, userID int, expectedTodo *models.Todo) {
-				mock.ExpectQuery("INSERT INTO todos").WithArgs(todoInput.TaskName, todoInput.Completed, todoInput.DueDate).WillReturnError(fmt.Errorf("error inserting into todos"))
+				mock.ExpectQuery("INSERT INTO todos").WithArgs(todoInput.TaskName, todoInput.Completed, todoInput.DueDate, false, todoInput.Notes, 0, "", 0, 0, "{}").WillReturnError(fmt.Errorf("error inserting into todos"))
 				mock.ExpectRollback()
 			},
 			shouldError: true,
@@ -84,8 +129,9 @@ func TestCreateTodo(t *testing.T) {
 			},
 			userID: 1,
 			mockSetup: func(todoInput *models.Todo, userID int, expectedTodo *models.Todo) {
-				mock.ExpectQuery("INSERT INTO todos").WithArgs(todoInput.TaskName, todoInput.Completed, todoInput.DueDate).WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at"}).AddRow(1, expectedTodo.TaskName, expectedTodo.Completed, expectedTodo.DueDate, expectedTodo.DueDate, expectedTodo.DueDate))
+				mock.ExpectQuery("INSERT INTO todos").WithArgs(todoInput.TaskName, todoInput.Completed, todoInput.DueDate, false, todoInput.Notes, 0, "", 0, 0, "{}").WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}).AddRow(1, expectedTodo.TaskName, expectedTodo.Completed, expectedTodo.DueDate, expectedTodo.DueDate, expectedTodo.DueDate, expectedTodo.Notes, 0, 0, nil, false, nil, 0, "", false, 0, nil, nil))
 
+				mock.ExpectQuery("SELECT COALESCE\\(MAX\\(position\\), ''\\) FROM todo_positions").WithArgs(userID).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("V"))
 				mock.ExpectExec("INSERT INTO users_todos").WithArgs(userID, 1).WillReturnError(fmt.Errorf("some db error"))
 				mock.ExpectRollback()
 
@@ -146,7 +192,14 @@ func TestUpdateTodo(t *testing.T) {
 			},
 			todoID: 1,
 			mockSetup: func(todoInput *models.Todo, todoID int, expectedTodo *models.Todo) {
-				mock.ExpectQuery("UPDATE todos SET task_name=\\$1, completed=\\$2, due_date=\\$3 WHERE id=\\$4 RETURNING id, task_name, completed, due_date, created_at, updated_at").WithArgs(todoInput.TaskName, todoInput.Completed, todoInput.DueDate, todoID).WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "due_date", "completed", "created_at", "updated_at"}).AddRow(expectedTodo.ID, expectedTodo.TaskName, expectedTodo.Completed, expectedTodo.DueDate, expectedTodo.CreatedAt, expectedTodo.UpdatedAt))
+				mock.ExpectQuery("SELECT (.+) FROM todos t WHERE t.id=\\$1 AND t.deleted_at IS NULL FOR UPDATE").WithArgs(todoID).WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}).AddRow(expectedTodo.ID, "test task", false, expectedTodo.DueDate, expectedTodo.CreatedAt, expectedTodo.UpdatedAt, expectedTodo.Notes, 0, 0, nil, false, nil, 0, "", false, 0, nil, nil))
+				mock.ExpectQuery("UPDATE todos t SET task_name=\\$1, completed=\\$2, due_date=\\$3, due_all_day=\\$4, notes=\\$5, priority=\\$6, recurrence=\\$7, custom_fields=\\$8, project_id=NULLIF\\(\\$9, 0\\) WHERE id=\\$10 RETURNING (.+)").WithArgs(todoInput.TaskName, todoInput.Completed, todoInput.DueDate, false, todoInput.Notes, 0, "", "{}", 0, todoID).WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}).AddRow(expectedTodo.ID, expectedTodo.TaskName, expectedTodo.Completed, expectedTodo.DueDate, expectedTodo.CreatedAt, expectedTodo.UpdatedAt, expectedTodo.Notes, 0, 0, nil, false, nil, 0, "", false, 0, nil, nil))
+				mock.ExpectExec("INSERT INTO todo_changes").WithArgs(todoID, 2, "task_name", "test task", "updated test task").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO todo_changes").WithArgs(todoID, 2, "completed", "false", "true").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO todo_revisions").WithArgs(todoID, 2, "update", `{"task_name":"test task","completed":false,"due_date":"2024-11-30T23:59:59Z","notes":""}`, `{"task_name":"updated test task","completed":true,"due_date":"2024-11-30T23:59:59Z","notes":""}`).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO todo_events").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
 			},
 			shouldError: false,
 		},
@@ -160,15 +213,18 @@ func TestUpdateTodo(t *testing.T) {
 			expectedTodo: nil,
 			todoID:       1,
 			mockSetup: func(todoInput *models.Todo, todoID int, expectedTodo *models.Todo) {
-				mock.ExpectQuery("UPDATE todos SET task_name=\\$1, completed=\\$2, due_date=\\$3 WHERE id=\\$4 RETURNING id, task_name, completed, due_date, created_at, updated_at").WithArgs(todoInput.TaskName, todoInput.Completed, todoInput.DueDate, todoID).WillReturnError(fmt.Errorf("some db error"))
+				mock.ExpectQuery("SELECT (.+) FROM todos t WHERE t.id=\\$1 AND t.deleted_at IS NULL FOR UPDATE").WithArgs(todoID).WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}).AddRow(todoID, "test task", false, todoInput.DueDate, todoInput.DueDate, todoInput.DueDate, "", 0, 0, nil, false, nil, 0, "", false, 0, nil, nil))
+				mock.ExpectQuery("UPDATE todos t SET task_name=\\$1, completed=\\$2, due_date=\\$3, due_all_day=\\$4, notes=\\$5, priority=\\$6, recurrence=\\$7, custom_fields=\\$8, project_id=NULLIF\\(\\$9, 0\\) WHERE id=\\$10 RETURNING (.+)").WithArgs(todoInput.TaskName, todoInput.Completed, todoInput.DueDate, false, todoInput.Notes, 0, "", "{}", 0, todoID).WillReturnError(fmt.Errorf("some db error"))
+				mock.ExpectRollback()
 			},
 			shouldError: true,
 		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			mock.ExpectBegin()
 			tc.mockSetup(tc.todoInput, tc.todoID, tc.expectedTodo)
-			updatedTodo, err := store.UpdateTodo(tc.todoInput, tc.todoID)
+			updatedTodo, err := store.UpdateTodo(tc.todoInput, tc.todoID, 2, false)
 			if tc.shouldError {
 				assert.Error(t, err)
 			} else {
@@ -202,16 +258,16 @@ func TestGetTodos(t *testing.T) {
 			name:   "Successful Get todos",
 			userID: 1,
 			expectedTodos: []*models.Todo{
-				{TaskName: "test task 1", Completed: false, DueDate: time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC()},
-				{TaskName: "test task 2", Completed: true, DueDate: time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC()},
-				{TaskName: "test task 3", Completed: false, DueDate: time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC()},
+				{TaskName: "test task 1", Completed: false, DueDate: time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC(), Position: "F"},
+				{TaskName: "test task 2", Completed: true, DueDate: time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC(), Position: "V"},
+				{TaskName: "test task 3", Completed: false, DueDate: time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC).UTC(), Position: "k"},
 			},
 			mockSetup: func(userID int, expectedTodos []*models.Todo) {
-				rows := sqlmock.NewRows([]string{"task_name", "completed", "due_date"})
-				for _, todo := range expectedTodos {
-					rows.AddRow(todo.TaskName, todo.Completed, todo.DueDate)
+				rows := sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields", "position", "role"})
+				for i, todo := range expectedTodos {
+					rows.AddRow(i+1, todo.TaskName, todo.Completed, todo.DueDate, todo.DueDate, todo.DueDate, todo.Notes, 0, 0, "{}", false, nil, 0, "", false, 0, nil, nil, todo.Position, "owner")
 				}
-				mock.ExpectQuery("SELECT t.* from todos t JOIN users_todos ut ON t.id = ut.todo_id WHERE ut.user_id = \\$1").WithArgs(userID).WillReturnRows(rows)
+				mock.ExpectQuery("SELECT (.+) FROM todos t LEFT JOIN users_todos ut (.+) ORDER BY tp.position NULLS LAST, t.id").WithArgs(userID, 0).WillReturnRows(rows)
 				mock.ExpectCommit()
 			},
 			shouldError: false,
@@ -221,7 +277,7 @@ func TestGetTodos(t *testing.T) {
 			userID:        1,
 			expectedTodos: nil,
 			mockSetup: func(userID int, expectedTodos []*models.Todo) {
-				mock.ExpectQuery("SELECT t.* from todos t JOIN users_todos ut ON t.id = ut.todo_id WHERE ut.user_id = \\$1").WithArgs(userID).WillReturnError(fmt.Errorf("some db error"))
+				mock.ExpectQuery("SELECT (.+) FROM todos t LEFT JOIN users_todos ut (.+) ORDER BY tp.position NULLS LAST, t.id").WithArgs(userID, 0).WillReturnError(fmt.Errorf("some db error"))
 				mock.ExpectRollback()
 			},
 			shouldError: true,
@@ -231,7 +287,7 @@ func TestGetTodos(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mock.ExpectBegin()
 			tc.mockSetup(tc.userID, tc.expectedTodos)
-			todos, err := store.GetTodos(tc.userID)
+			todos, err := store.GetTodos(tc.userID, 0, nil)
 			if tc.shouldError {
 				assert.Error(t, err)
 			} else {
@@ -244,6 +300,105 @@ func TestGetTodos(t *testing.T) {
 	}
 }
 
+func TestGetTodosDue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+	today := time.Date(2024, 11, 28, 0, 0, 0, 0, tokyo)
+	tomorrow := today.AddDate(0, 0, 1)
+	allDay := time.Date(2024, 11, 28, 0, 0, 0, 0, time.UTC)
+	timed := time.Date(2024, 11, 27, 23, 30, 0, 0, time.UTC)
+
+	// Timed todos are compared with midnight in Tokyo, all-day ones with the
+	// dates.
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) AND \\(\\(NOT t.due_all_day AND t.due_date >= \\$3 AND t.due_date < \\$5\\) OR \\(t.due_all_day AND t.due_date >= \\$4 AND t.due_date < \\$6\\)\\) ORDER BY").
+		WithArgs(1, 0, today, allDay, tomorrow, allDay.AddDate(0, 0, 1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields", "position", "role"}).
+			AddRow(5, "Ship it", false, allDay, timed, timed, "", 0, 0, nil, false, nil, 0, "", true, 0, nil, nil, "V", "owner").
+			AddRow(6, "Call back", false, timed, timed, timed, "", 0, 0, nil, false, nil, 0, "", false, 0, nil, nil, "k", "owner"))
+	mock.ExpectCommit()
+
+	todos, err := store.GetTodos(1, 0, &models.TodoFilter{Due: &models.DueRange{From: today, To: tomorrow}})
+	assert.NoError(t, err)
+	if assert.Len(t, todos, 2) {
+		assert.True(t, todos[0].DueAllDay)
+		assert.False(t, todos[1].DueAllDay)
+	}
+
+	// Without a lower bound todos without a due date still do not match.
+	now := time.Date(2024, 11, 28, 9, 0, 0, 0, tokyo)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) AND t.completed = \\$3 AND \\(\\(NOT t.due_all_day AND t.due_date > \\$4 AND t.due_date < \\$6\\) OR \\(t.due_all_day AND t.due_date > \\$5 AND t.due_date < \\$7\\)\\) ORDER BY").
+		WithArgs(1, 0, false, time.Time{}, time.Time{}, now, allDay).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	open := false
+	todos, err = store.GetTodos(1, 0, &models.TodoFilter{Completed: &open, Due: &models.DueRange{To: now}})
+	assert.NoError(t, err)
+	assert.Empty(t, todos)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateAllDayTodo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	allDay := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	// The date is kept as written, even though it is still November in UTC.
+	todo := &models.Todo{TaskName: "Pay rent", DueDate: time.Date(2024, 12, 1, 0, 30, 0, 0, time.FixedZone("CET", 3600)), DueAllDay: true}
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO todos").WithArgs("Pay rent", false, allDay, true, "", 0, "", 0, 0, "{}").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}).
+			AddRow(1, "Pay rent", false, allDay, allDay, allDay, "", 0, 0, nil, false, nil, 0, "", true, 0, nil, nil))
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(position\\), ''\\) FROM todo_positions").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(""))
+	mock.ExpectExec("INSERT INTO users_todos").WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO todo_positions").WithArgs(1, 1, "V").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO todo_revisions").WithArgs(1, 1, "create", nil, `{"task_name":"Pay rent","completed":false,"due_date":"2024-12-01T00:00:00Z","due_all_day":true,"notes":""}`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO todo_events").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	created, err := store.CreateTodo(todo, 1)
+	assert.NoError(t, err)
+	assert.True(t, created.DueAllDay)
+	assert.Equal(t, allDay, created.DueDate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateUserSettings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	settings := &models.UserSettings{Timezone: "Asia/Tokyo", WeekStart: "sunday", Locale: "ja-JP"}
+
+	mock.ExpectQuery("UPDATE users SET timezone = \\$1, week_start = \\$2, locale = \\$3 WHERE id = \\$4 RETURNING timezone, week_start, locale").WithArgs("Asia/Tokyo", "sunday", "ja-JP", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"timezone", "week_start", "locale"}).AddRow("Asia/Tokyo", "sunday", "ja-JP"))
+	updated, err := store.UpdateUserSettings(1, settings)
+	assert.NoError(t, err)
+	assert.Equal(t, settings, updated)
+
+	mock.ExpectQuery("UPDATE users SET").WithArgs("Asia/Tokyo", "sunday", "ja-JP", 2).WillReturnError(sql.ErrNoRows)
+	_, err = store.UpdateUserSettings(2, settings)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestCreateUser(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -0,0 +1,39 @@
+package stores
+
+import (
+	"time"
+	"todo-list/src/models"
+)
+
+// streamTicketLifetime is how long a stream ticket can be used after it was
+// issued, as a Postgres interval. Clients open the stream right away.
+const streamTicketLifetime = "30 seconds"
+
+// CreateStreamTicket saves a ticket that opens one stream of the user within
+// streamTicketLifetime. Only a hash of the ticket is stored.
+func (store *DbStore) CreateStreamTicket(userID int, ticket string) (*models.StreamTicket, error) {
+	streamTicket := &models.StreamTicket{Ticket: ticket}
+	err := store.DB.QueryRow("INSERT INTO stream_tickets (token_hash, user_id, expires_at) VALUES ($1, $2, NOW() + $3::interval) RETURNING expires_at",
+		hashToken(ticket), userID, streamTicketLifetime).Scan(&streamTicket.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return streamTicket, nil
+}
+
+// RedeemStreamTicket uses up a ticket and returns the user it was issued to,
+// or sql.ErrNoRows when it is unknown, used or expired.
+func (store *DbStore) RedeemStreamTicket(ticket string) (int, error) {
+	var userID int
+	err := store.DB.QueryRow("DELETE FROM stream_tickets WHERE token_hash = $1 AND expires_at > NOW() RETURNING user_id", hashToken(ticket)).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+// PurgeStreamTickets deletes the tickets that expired before the time given.
+func (store *DbStore) PurgeStreamTickets(before time.Time) error {
+	_, err := store.DB.Exec("DELETE FROM stream_tickets WHERE expires_at < $1", before)
+	return err
+}
@@ -0,0 +1,43 @@
+package stores
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamTickets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	expiresAt := time.Date(2024, 12, 2, 9, 0, 30, 0, time.UTC)
+
+	mock.ExpectQuery("INSERT INTO stream_tickets \\(token_hash, user_id, expires_at\\) VALUES \\(\\$1, \\$2, NOW\\(\\) \\+ \\$3::interval\\) RETURNING expires_at").
+		WithArgs(hashToken("ticket"), 1, "30 seconds").WillReturnRows(sqlmock.NewRows([]string{"expires_at"}).AddRow(expiresAt))
+	streamTicket, err := store.CreateStreamTicket(1, "ticket")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.StreamTicket{Ticket: "ticket", ExpiresAt: expiresAt}, streamTicket)
+
+	mock.ExpectQuery("DELETE FROM stream_tickets WHERE token_hash = \\$1 AND expires_at > NOW\\(\\) RETURNING user_id").
+		WithArgs(hashToken("ticket")).WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	userID, err := store.RedeemStreamTicket("ticket")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, userID)
+
+	// A ticket opens a single stream.
+	mock.ExpectQuery("DELETE FROM stream_tickets").WithArgs(hashToken("ticket")).WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	_, err = store.RedeemStreamTicket("ticket")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	mock.ExpectExec("DELETE FROM stream_tickets WHERE expires_at < \\$1").WithArgs(expiresAt).WillReturnResult(sqlmock.NewResult(0, 2))
+	assert.NoError(t, store.PurgeStreamTickets(expiresAt))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,114 @@
+package stores
+
+import (
+	"database/sql"
+	"encoding/json"
+	"todo-list/src/models"
+)
+
+const templateColumns = "id, user_id, name, items, created_at"
+
+func scanTemplate(row rowScanner) (*models.Template, error) {
+	template := &models.Template{}
+	var items []byte
+	err := row.Scan(&template.ID, &template.UserID, &template.Name, &items, &template.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	template.Items = []*models.TemplateItem{}
+	if err := json.Unmarshal(items, &template.Items); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (store *DbStore) CreateTemplate(template *models.Template) (*models.Template, error) {
+	items, err := json.Marshal(template.Items)
+	if err != nil {
+		return nil, err
+	}
+	return scanTemplate(store.DB.QueryRow("INSERT INTO templates (user_id, name, items) VALUES ($1, $2, $3) RETURNING "+templateColumns,
+		template.UserID, template.Name, string(items)))
+}
+
+func (store *DbStore) GetTemplates(userID int) ([]*models.Template, error) {
+	rows, err := store.DB.Query("SELECT "+templateColumns+" FROM templates WHERE user_id = $1 ORDER BY name, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []*models.Template{}
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// GetTemplate returns one of the user's templates, or sql.ErrNoRows.
+func (store *DbStore) GetTemplate(templateID int, userID int) (*models.Template, error) {
+	return scanTemplate(store.DB.QueryRow("SELECT "+templateColumns+" FROM templates WHERE id = $1 AND user_id = $2", templateID, userID))
+}
+
+func (store *DbStore) DeleteTemplate(templateID int, userID int) error {
+	result, err := store.DB.Exec("DELETE FROM templates WHERE id = $1 AND user_id = $2", templateID, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CreateTodos creates the todos of an instantiated template in one
+// transaction, so either all of them exist afterwards or none. parents[i] is
+// the index of the todo that todos[i] blocks, or -1.
+func (store *DbStore) CreateTodos(todos []*models.Todo, parents []int, userID int) ([]*models.Todo, error) {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	created := make([]*models.Todo, len(todos))
+	for i, todo := range todos {
+		created[i], err = insertTodo(transaction, todo, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// The todos are all new, so their edges can not close a cycle.
+	for i, parent := range parents {
+		if parent < 0 {
+			continue
+		}
+		_, err = transaction.Exec("INSERT INTO todo_dependencies (todo_id, blocked_by_id) VALUES ($1, $2)", created[parent].ID, created[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		created[parent].Blocked = true
+	}
+
+	err = transaction.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
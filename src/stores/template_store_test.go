@@ -0,0 +1,102 @@
+package stores
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 11, 30, 9, 0, 0, 0, time.UTC)
+	items := `[{"task_name":"Release {{version}}","children":[{"task_name":"Write release notes","due_offset_days":-1}]}]`
+	offset := -1
+	release := &models.Template{ID: 3, UserID: 1, Name: "Release", CreatedAt: createdAt, Items: []*models.TemplateItem{
+		{TaskName: "Release {{version}}", Children: []*models.TemplateItem{{TaskName: "Write release notes", DueOffsetDays: &offset}}},
+	}}
+	columns := []string{"id", "user_id", "name", "items", "created_at"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO templates (user_id, name, items) VALUES ($1, $2, $3) RETURNING id, user_id, name, items, created_at")).
+		WithArgs(1, "Release", items).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, 1, "Release", []byte(items), createdAt))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM templates WHERE user_id = $1 ORDER BY name, id")).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, 1, "Release", []byte(items), createdAt))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM templates WHERE id = $1 AND user_id = $2")).WithArgs(3, 2).WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM templates WHERE id = $1 AND user_id = $2")).WithArgs(3, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM templates").WithArgs(3, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	created, err := store.CreateTemplate(&models.Template{UserID: 1, Name: "Release", Items: release.Items})
+	assert.NoError(t, err)
+	assert.Equal(t, release, created)
+
+	templates, err := store.GetTemplates(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.Template{release}, templates)
+
+	_, err = store.GetTemplate(3, 2)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.NoError(t, store.DeleteTemplate(3, 1))
+	assert.ErrorIs(t, store.DeleteTemplate(3, 1), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateTodos(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	dueDate := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	todoRow := func(id int, taskName string, due time.Time) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}).
+			AddRow(id, taskName, false, due, dueDate, dueDate, "", 0, 0, nil, false, nil, 0, "", !due.IsZero(), 0, nil, nil)
+	}
+	expectInsert := func(id int, taskName string, due time.Time, position string) {
+		mock.ExpectQuery("INSERT INTO todos").WillReturnRows(todoRow(id, taskName, due))
+		mock.ExpectQuery("SELECT COALESCE\\(MAX\\(position\\), ''\\) FROM todo_positions").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(position))
+		mock.ExpectExec("INSERT INTO users_todos").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO todo_positions").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO todo_revisions").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO todo_events").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	mock.ExpectBegin()
+	expectInsert(7, "Release 1.4", time.Time{}, "")
+	expectInsert(8, "Write release notes", dueDate, "V")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO todo_dependencies (todo_id, blocked_by_id) VALUES ($1, $2)")).WithArgs(7, 8).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	todos, err := store.CreateTodos([]*models.Todo{
+		{TaskName: "Release 1.4"},
+		{TaskName: "Write release notes", DueDate: dueDate, DueAllDay: true},
+	}, []int{-1, 0}, 1)
+	assert.NoError(t, err)
+	assert.Len(t, todos, 2)
+	assert.Equal(t, 7, todos[0].ID)
+	assert.True(t, todos[0].Blocked)
+	assert.Greater(t, todos[1].Position, todos[0].Position)
+
+	// A failing todo leaves none of them behind.
+	mock.ExpectBegin()
+	expectInsert(9, "Release 1.5", time.Time{}, "")
+	mock.ExpectQuery("INSERT INTO todos").WillReturnError(fmt.Errorf("some db error"))
+	mock.ExpectRollback()
+
+	_, err = store.CreateTodos([]*models.Todo{{TaskName: "Release 1.5"}, {TaskName: "Write release notes"}}, []int{-1, 0}, 1)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,175 @@
+package stores
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"todo-list/src/models"
+)
+
+var ErrTimerRunning = errors.New("a timer is already running")
+
+// entrySeconds is the length of time entry e in whole seconds, up to now for
+// running timers.
+const entrySeconds = "EXTRACT(EPOCH FROM COALESCE(e.ended_at, NOW()) - e.started_at)::BIGINT"
+
+const timeEntryColumns = "e.id, e.todo_id, e.user_id, e.started_at, e.ended_at, e.note, " + entrySeconds
+
+func scanTimeEntry(row rowScanner) (*models.TimeEntry, error) {
+	entry := &models.TimeEntry{}
+	err := row.Scan(&entry.ID, &entry.TodoID, &entry.UserID, &entry.StartedAt, &entry.EndedAt, &entry.Note, &entry.Seconds)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// StartTimer starts a timer for the user on a todo. The database keeps at
+// most one running timer per user; while another runs it returns
+// ErrTimerRunning.
+func (store *DbStore) StartTimer(todoID int, userID int, note string) (*models.TimeEntry, error) {
+	entry, err := scanTimeEntry(store.DB.QueryRow("INSERT INTO time_entries AS e (todo_id, user_id, started_at, note) VALUES ($1, $2, NOW(), $3)"+
+		" ON CONFLICT (user_id) WHERE ended_at IS NULL DO NOTHING RETURNING "+timeEntryColumns, todoID, userID, note))
+	if err == sql.ErrNoRows {
+		return nil, ErrTimerRunning
+	}
+	return entry, err
+}
+
+// StopTimer stops the user's timer on a todo. It returns sql.ErrNoRows when
+// none is running there.
+func (store *DbStore) StopTimer(todoID int, userID int) (*models.TimeEntry, error) {
+	return scanTimeEntry(store.DB.QueryRow("UPDATE time_entries e SET ended_at = NOW() WHERE e.todo_id = $1 AND e.user_id = $2 AND e.ended_at IS NULL RETURNING "+timeEntryColumns,
+		todoID, userID))
+}
+
+// GetRunningTimer returns the user's running timer, or sql.ErrNoRows.
+func (store *DbStore) GetRunningTimer(userID int) (*models.TimeEntry, error) {
+	return scanTimeEntry(store.DB.QueryRow("SELECT "+timeEntryColumns+" FROM time_entries e WHERE e.user_id = $1 AND e.ended_at IS NULL", userID))
+}
+
+// CreateTimeEntry logs time that was not tracked with a timer.
+func (store *DbStore) CreateTimeEntry(entry *models.TimeEntry) (*models.TimeEntry, error) {
+	return scanTimeEntry(store.DB.QueryRow("INSERT INTO time_entries AS e (todo_id, user_id, started_at, ended_at, note) VALUES ($1, $2, $3, $4, $5) RETURNING "+timeEntryColumns,
+		entry.TodoID, entry.UserID, entry.StartedAt, entry.EndedAt, entry.Note))
+}
+
+func (store *DbStore) GetTimeEntries(todoID int) ([]*models.TimeEntry, error) {
+	rows, err := store.DB.Query("SELECT "+timeEntryColumns+" FROM time_entries e WHERE e.todo_id = $1 ORDER BY e.started_at, e.id", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.TimeEntry{}
+	for rows.Next() {
+		entry, err := scanTimeEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (store *DbStore) GetTimeEntry(entryID int) (*models.TimeEntry, error) {
+	return scanTimeEntry(store.DB.QueryRow("SELECT "+timeEntryColumns+" FROM time_entries e WHERE e.id = $1", entryID))
+}
+
+// UpdateTimeEntry changes the times and note of an entry. Giving a running
+// timer an end stops it.
+func (store *DbStore) UpdateTimeEntry(entry *models.TimeEntry) (*models.TimeEntry, error) {
+	return scanTimeEntry(store.DB.QueryRow("UPDATE time_entries e SET started_at = $1, ended_at = $2, note = $3 WHERE e.id = $4 RETURNING "+timeEntryColumns,
+		entry.StartedAt, entry.EndedAt, entry.Note, entry.ID))
+}
+
+func (store *DbStore) DeleteTimeEntry(entryID int) error {
+	result, err := store.DB.Exec("DELETE FROM time_entries WHERE id = $1", entryID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// timeGroups are the columns a time report can be grouped by, with the
+// fields of TimeReportRow they are read into. Days are read in the timezone
+// passed as $4.
+var timeGroups = map[string]struct {
+	columns []string
+	dest    func(row *models.TimeReportRow) []any
+}{
+	models.TimeByProject: {
+		columns: []string{"COALESCE(p.id, 0)", "COALESCE(p.name, '')"},
+		dest:    func(row *models.TimeReportRow) []any { return []any{&row.ProjectID, &row.ProjectName} },
+	},
+	models.TimeByTag: {
+		columns: []string{"COALESCE(tg.tag, '')"},
+		dest:    func(row *models.TimeReportRow) []any { return []any{&row.Tag} },
+	},
+	models.TimeByDay: {
+		columns: []string{"to_char(e.started_at AT TIME ZONE $4, 'YYYY-MM-DD')"},
+		dest:    func(row *models.TimeReportRow) []any { return []any{&row.Day} },
+	},
+}
+
+// GetTimeReport sums the time the user logged in the query's range. The
+// total counts every entry once, however the rows are grouped.
+func (store *DbStore) GetTimeReport(userID int, query *models.TimeReportQuery) (*models.TimeReport, error) {
+	const entries = " FROM time_entries e JOIN todos t ON t.id = e.todo_id LEFT JOIN projects p ON p.id = t.project_id"
+	const inRange = " WHERE e.user_id = $1 AND e.started_at >= $2 AND e.started_at < $3"
+	args := []any{userID, query.From, query.To}
+
+	report := &models.TimeReport{Rows: []*models.TimeReportRow{}}
+	err := store.DB.QueryRow("SELECT COALESCE(SUM("+entrySeconds+"), 0)"+entries+inRange, args...).Scan(&report.TotalSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := []string{}
+	joins := ""
+	for _, group := range query.GroupBy {
+		columns = append(columns, timeGroups[group].columns...)
+		switch group {
+		case models.TimeByTag:
+			joins = " LEFT JOIN todo_tags tg ON tg.todo_id = t.id"
+		case models.TimeByDay:
+			args = append(args, query.Timezone)
+		}
+	}
+	if len(columns) == 0 {
+		return report, nil
+	}
+	groups := strings.Join(columns, ", ")
+	rows, err := store.DB.Query(fmt.Sprintf("SELECT %s, SUM(%s)%s%s%s GROUP BY %s ORDER BY %s", groups, entrySeconds, entries, joins, inRange, groups, groups), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row := &models.TimeReportRow{}
+		dest := []any{}
+		for _, group := range query.GroupBy {
+			dest = append(dest, timeGroups[group].dest(row)...)
+		}
+		if err := rows.Scan(append(dest, &row.Seconds)...); err != nil {
+			return nil, err
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
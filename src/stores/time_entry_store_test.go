@@ -0,0 +1,140 @@
+package stores
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var timeEntryRowColumns = []string{"id", "todo_id", "user_id", "started_at", "ended_at", "note", "seconds"}
+
+func TestStartTimer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	startedAt := time.Date(2024, 11, 30, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO time_entries AS e (todo_id, user_id, started_at, note) VALUES ($1, $2, NOW(), $3) ON CONFLICT (user_id) WHERE ended_at IS NULL DO NOTHING RETURNING e.id")).
+		WithArgs(5, 1, "Design review").
+		WillReturnRows(sqlmock.NewRows(timeEntryRowColumns).AddRow(3, 5, 1, startedAt, nil, "Design review", 0))
+	// The partial unique index turns a second timer into a conflict.
+	mock.ExpectQuery("INSERT INTO time_entries").WithArgs(6, 1, "").WillReturnRows(sqlmock.NewRows(timeEntryRowColumns))
+
+	entry, err := store.StartTimer(5, 1, "Design review")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.TimeEntry{ID: 3, TodoID: 5, UserID: 1, StartedAt: startedAt, Note: "Design review"}, entry)
+
+	_, err = store.StartTimer(6, 1, "")
+	assert.ErrorIs(t, err, ErrTimerRunning)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStopTimer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	startedAt := time.Date(2024, 11, 30, 9, 0, 0, 0, time.UTC)
+	endedAt := startedAt.Add(90 * time.Minute)
+
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE time_entries e SET ended_at = NOW() WHERE e.todo_id = $1 AND e.user_id = $2 AND e.ended_at IS NULL RETURNING")).
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows(timeEntryRowColumns).AddRow(3, 5, 1, startedAt, endedAt, "", 5400))
+	mock.ExpectQuery("UPDATE time_entries").WithArgs(5, 1).WillReturnError(sql.ErrNoRows)
+
+	entry, err := store.StopTimer(5, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.TimeEntry{ID: 3, TodoID: 5, UserID: 1, StartedAt: startedAt, EndedAt: &endedAt, Seconds: 5400}, entry)
+
+	_, err = store.StopTimer(5, 1)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTimeEntries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	startedAt := time.Date(2024, 11, 30, 9, 0, 0, 0, time.UTC)
+	endedAt := startedAt.Add(time.Hour)
+
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO time_entries AS e (todo_id, user_id, started_at, ended_at, note) VALUES ($1, $2, $3, $4, $5) RETURNING")).
+		WithArgs(5, 1, startedAt, &endedAt, "Call").
+		WillReturnRows(sqlmock.NewRows(timeEntryRowColumns).AddRow(4, 5, 1, startedAt, endedAt, "Call", 3600))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM time_entries e WHERE e.todo_id = $1 ORDER BY e.started_at, e.id")).WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(timeEntryRowColumns).AddRow(4, 5, 1, startedAt, endedAt, "Call", 3600))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE time_entries e SET started_at = $1, ended_at = $2, note = $3 WHERE e.id = $4 RETURNING")).
+		WithArgs(startedAt, &endedAt, "Client call", 4).
+		WillReturnRows(sqlmock.NewRows(timeEntryRowColumns).AddRow(4, 5, 1, startedAt, endedAt, "Client call", 3600))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM time_entries WHERE id = $1")).WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM time_entries").WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	entry, err := store.CreateTimeEntry(&models.TimeEntry{TodoID: 5, UserID: 1, StartedAt: startedAt, EndedAt: &endedAt, Note: "Call"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3600), entry.Seconds)
+
+	entries, err := store.GetTimeEntries(5)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.TimeEntry{entry}, entries)
+
+	entry.Note = "Client call"
+	updated, err := store.UpdateTimeEntry(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "Client call", updated.Note)
+
+	assert.NoError(t, store.DeleteTimeEntry(4))
+	assert.ErrorIs(t, store.DeleteTimeEntry(4), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTimeReport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	from := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(EXTRACT(EPOCH FROM COALESCE(e.ended_at, NOW()) - e.started_at)::BIGINT), 0) FROM time_entries e JOIN todos t ON t.id = e.todo_id LEFT JOIN projects p ON p.id = t.project_id WHERE e.user_id = $1 AND e.started_at >= $2 AND e.started_at < $3")).
+		WithArgs(1, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(9000))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(p.id, 0), COALESCE(p.name, ''), to_char(e.started_at AT TIME ZONE $4, 'YYYY-MM-DD'), SUM(")).
+		WithArgs(1, from, to, "Europe/Berlin").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "day", "sum"}).
+			AddRow(0, "", "2024-11-04", 1800).
+			AddRow(2, "Website", "2024-11-04", 7200))
+
+	report, err := store.GetTimeReport(1, &models.TimeReportQuery{From: from, To: to, Timezone: "Europe/Berlin", GroupBy: []string{models.TimeByProject, models.TimeByDay}})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.TimeReport{TotalSeconds: 9000, Rows: []*models.TimeReportRow{
+		{Day: "2024-11-04", Seconds: 1800},
+		{ProjectID: 2, ProjectName: "Website", Day: "2024-11-04", Seconds: 7200},
+	}}, report)
+
+	// Grouping by tag joins the tags, so a todo counts towards each of them.
+	mock.ExpectQuery("SELECT COALESCE").WithArgs(1, from, to).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(3600))
+	mock.ExpectQuery(regexp.QuoteMeta("LEFT JOIN todo_tags tg ON tg.todo_id = t.id WHERE e.user_id = $1 AND e.started_at >= $2 AND e.started_at < $3 GROUP BY COALESCE(tg.tag, '') ORDER BY")).
+		WithArgs(1, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"tag", "sum"}).AddRow("billable", 3600).AddRow("client", 3600))
+
+	report, err = store.GetTimeReport(1, &models.TimeReportQuery{From: from, To: to, Timezone: "UTC", GroupBy: []string{models.TimeByTag}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3600), report.TotalSeconds)
+	assert.Len(t, report.Rows, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
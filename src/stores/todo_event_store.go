@@ -0,0 +1,109 @@
+package stores
+
+import (
+	"database/sql"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/lib/pq"
+)
+
+// TodoEventsChannel is the channel the ID of every todo event is notified on
+// when the transaction logging it commits.
+const TodoEventsChannel = "todo_events"
+
+const todoEventColumns = "id, type, todo_id, COALESCE(workspace_id, 0), todo, created_at, user_ids"
+
+// recordTodoEventQuery logs event $2 of todo $1, carrying the todo $3 as JSON,
+// for the users who can see the todo: those it is shared with and the members
+// of the workspace owning its project. The notification is only sent if the
+// transaction commits.
+const recordTodoEventQuery = "WITH event AS (INSERT INTO todo_events (todo_id, workspace_id, type, todo, user_ids)" +
+	" SELECT t.id, t.workspace_id, $2, $3::jsonb, ARRAY(SELECT ut.user_id FROM users_todos ut WHERE ut.todo_id = t.id" +
+	" UNION SELECT wm.user_id FROM projects p JOIN workspace_members wm ON wm.workspace_id = p.workspace_id WHERE p.id = t.project_id)" +
+	" FROM todos t WHERE t.id = $1 RETURNING id)" +
+	" SELECT pg_notify('" + TodoEventsChannel + "', id::text) FROM event"
+
+func scanTodoEvent(row rowScanner) (*models.TodoEvent, error) {
+	event := &models.TodoEvent{}
+	var todo []byte
+	var userIDs pq.Int64Array
+	err := row.Scan(&event.ID, &event.Type, &event.TodoID, &event.WorkspaceID, &todo, &event.CreatedAt, &userIDs)
+	if err != nil {
+		return nil, err
+	}
+	event.Todo = todo
+	for _, userID := range userIDs {
+		event.UserIDs = append(event.UserIDs, int(userID))
+	}
+	return event, nil
+}
+
+// todoEventType is the type of the event streamed for a change recorded as a
+// revision. Completing a todo is an update like any other.
+func todoEventType(action string) string {
+	switch action {
+	case models.RevisionCreate, models.RevisionRestore:
+		return models.EventTodoCreated
+	case models.RevisionDelete:
+		return models.EventTodoDeleted
+	}
+	return models.EventTodoUpdated
+}
+
+// recordTodoEvent logs the change to a todo for the change streams inside the
+// transaction making it.
+func recordTodoEvent(transaction *sql.Tx, todoID int, action string, before *models.Todo, after *models.Todo) error {
+	todo := after
+	if todo == nil {
+		todo = before
+	}
+	data, err := sharedTodoJSON(todo)
+	if err != nil {
+		return err
+	}
+	_, err = transaction.Exec(recordTodoEventQuery, todoID, todoEventType(action), data)
+	return err
+}
+
+func (store *DbStore) GetTodoEvent(eventID int64) (*models.TodoEvent, error) {
+	return scanTodoEvent(store.DB.QueryRow("SELECT "+todoEventColumns+" FROM todo_events WHERE id = $1", eventID))
+}
+
+// GetTodoEvents returns up to limit events logged after afterID, oldest
+// first. With a user, only the events sent to them are returned.
+func (store *DbStore) GetTodoEvents(afterID int64, userID int, limit int) ([]*models.TodoEvent, error) {
+	rows, err := store.DB.Query("SELECT "+todoEventColumns+" FROM todo_events WHERE id > $1 AND ($2 = 0 OR $2 = ANY(user_ids)) ORDER BY id LIMIT $3", afterID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*models.TodoEvent{}
+	for rows.Next() {
+		event, err := scanTodoEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetTodoEventLogStart returns the ID of the oldest event still logged, or 0
+// when none ever was. Events before it were purged.
+func (store *DbStore) GetTodoEventLogStart() (int64, error) {
+	var start int64
+	err := store.DB.QueryRow("SELECT COALESCE(MIN(id), 0) FROM todo_events").Scan(&start)
+	return start, err
+}
+
+// PurgeTodoEvents deletes the events logged before the given time. The
+// newest event is always kept, so the log tells which events were purged.
+func (store *DbStore) PurgeTodoEvents(before time.Time) error {
+	_, err := store.DB.Exec("DELETE FROM todo_events WHERE created_at < $1 AND id < (SELECT MAX(id) FROM todo_events)", before)
+	return err
+}
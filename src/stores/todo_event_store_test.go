@@ -0,0 +1,54 @@
+package stores
+
+import (
+	"regexp"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordTodoEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	todo := &models.Todo{ID: 5, TaskName: "Pay rent", Completed: true, Position: "V", Role: "owner"}
+	todoJSON := `{"id":5,"task_name":"Pay rent","completed":true,"due_date":"0001-01-01T00:00:00Z","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","notes":"","blocked":false}`
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(recordTodoEventQuery)).WithArgs(5, "todo.updated", todoJSON).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_notify('todo_events', id::text) FROM event")).WithArgs(5, "todo.deleted", todoJSON).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO todo_events").WithArgs(5, "todo.created", todoJSON).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	transaction, err := db.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, recordTodoEvent(transaction, 5, models.RevisionUpdate, todo, todo))
+	assert.NoError(t, recordTodoEvent(transaction, 5, models.RevisionDelete, todo, nil))
+	assert.NoError(t, recordTodoEvent(transaction, 5, models.RevisionRestore, nil, todo))
+	assert.NoError(t, transaction.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTodoEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 12, 2, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM todo_events WHERE id > $1 AND ($2 = 0 OR $2 = ANY(user_ids)) ORDER BY id LIMIT $3")).WithArgs(40, 1, 1001).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "todo_id", "workspace_id", "todo", "created_at", "user_ids"}).
+			AddRow(41, "todo.updated", 5, 4, []byte(`{"id":5}`), createdAt, "{1,2}"))
+
+	events, err := store.GetTodoEvents(40, 1, models.EventReplayLimit+1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.TodoEvent{{ID: 41, Type: "todo.updated", TodoID: 5, WorkspaceID: 4, Todo: []byte(`{"id":5}`), CreatedAt: createdAt, UserIDs: []int{1, 2}}}, events)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,99 @@
+package stores
+
+import (
+	"time"
+	"todo-list/src/models"
+)
+
+// ownedTrash selects the trashed todos user $1 owns in workspace $2. Only
+// owners can delete a todo, so only they can see it in the trash.
+const ownedTrash = "SELECT t.id" + trashedTodos + " AND " + effectiveRole + " = 'owner'"
+
+// GetTrash lists the trashed todos of the user in the workspace, most
+// recently deleted first.
+func (store *DbStore) GetTrash(userID int, workspaceID int) ([]*models.Todo, error) {
+	rows, err := store.DB.Query("SELECT "+todoColumns+", "+effectiveRole+", t.deleted_at"+trashedTodos+
+		" AND "+effectiveRole+" = 'owner' ORDER BY t.deleted_at DESC, t.id", userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []*models.Todo{}
+	for rows.Next() {
+		todo := &models.Todo{}
+		if err := scanTodo(rows, todo, &todo.Role, &todo.DeletedAt); err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// RestoreTodo takes a todo out of the trash. It returns sql.ErrNoRows when
+// the user has no such todo in the trash of the workspace.
+func (store *DbStore) RestoreTodo(todoID int, userID int, workspaceID int) error {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	restoredTodo := &models.Todo{}
+	err = scanTodo(transaction.QueryRow("UPDATE todos t SET deleted_at = NULL WHERE id = $3 AND id IN ("+ownedTrash+") RETURNING "+todoColumns, userID, workspaceID, todoID), restoredTodo)
+	if err != nil {
+		return err
+	}
+
+	err = recordRevision(transaction, todoID, userID, models.RevisionRestore, nil, restoredTodo)
+	if err != nil {
+		return err
+	}
+
+	return transaction.Commit()
+}
+
+// EmptyTrash permanently deletes the user's trashed todos in the workspace.
+// It returns the attachments that went with them so their files can be
+// removed from the blob store.
+func (store *DbStore) EmptyTrash(userID int, workspaceID int) ([]*models.Attachment, error) {
+	return store.purgeTodos("id IN ("+ownedTrash+")", userID, workspaceID)
+}
+
+// PurgeTrash permanently deletes every todo trashed before the given time,
+// returning their attachments like EmptyTrash.
+func (store *DbStore) PurgeTrash(before time.Time) ([]*models.Attachment, error) {
+	return store.purgeTodos("deleted_at < $1", before)
+}
+
+// purgeTodos deletes the todos matching condition. The attachment rows are
+// read in the same statement, from the snapshot taken before the cascade.
+func (store *DbStore) purgeTodos(condition string, args ...any) ([]*models.Attachment, error) {
+	rows, err := store.DB.Query("WITH purged AS (DELETE FROM todos WHERE deleted_at IS NOT NULL AND "+condition+" RETURNING id)"+
+		" SELECT "+attachmentColumns+" FROM todo_attachments WHERE todo_id IN (SELECT id FROM purged) ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []*models.Attachment{}
+	for rows.Next() {
+		attachment := &models.Attachment{}
+		if err := scanAttachment(rows, attachment); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
@@ -0,0 +1,116 @@
+package stores
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteTodoMovesToTrash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	dueDate := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	todoRowColumns := []string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE todos t SET deleted_at = NOW\\(\\) WHERE id=\\$1 AND deleted_at IS NULL RETURNING (.+)").WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(5, "Old task", false, dueDate, dueDate, dueDate, "", 0, 0, nil, false, nil, 0, "", false, 0, nil, nil))
+	mock.ExpectExec("INSERT INTO todo_revisions").WithArgs(5, 2, "delete", `{"task_name":"Old task","completed":false,"due_date":"2024-11-30T23:59:59Z","notes":""}`, nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO todo_events").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	assert.NoError(t, store.DeleteTodo(5, 2))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE todos t SET deleted_at = NOW\\(\\)").WithArgs(6).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	assert.ErrorIs(t, store.DeleteTodo(6, 2), sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTrash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	dueDate := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	deletedAt := time.Date(2024, 12, 2, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT (.+), t.deleted_at FROM todos t (.+) AND t.deleted_at IS NOT NULL AND (.+) = 'owner' ORDER BY t.deleted_at DESC, t.id").WithArgs(1, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields", "role", "deleted_at"}).
+			AddRow(5, "Old task", false, dueDate, dueDate, dueDate, "", 0, 0, "{}", false, nil, 0, "", false, 0, nil, nil, "owner", deletedAt))
+
+	todos, err := store.GetTrash(1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.Todo{{ID: 5, TaskName: "Old task", DueDate: dueDate, CreatedAt: dueDate, UpdatedAt: dueDate, Role: "owner", DeletedAt: &deletedAt}}, todos)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreTodo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	dueDate := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	todoRowColumns := []string{"id", "task_name", "completed", "due_date", "created_at", "updated_at", "notes", "workspace_id", "project_id", "assignee_ids", "blocked", "tags", "priority", "recurrence", "due_all_day", "tracked_seconds", "completed_at", "custom_fields"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE todos t SET deleted_at = NULL WHERE id = \\$3 AND id IN \\(SELECT t.id FROM todos t (.+) AND t.deleted_at IS NOT NULL AND (.+) = 'owner'\\) RETURNING (.+)").WithArgs(1, 0, 5).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(5, "Old task", false, dueDate, dueDate, dueDate, "", 0, 0, nil, false, nil, 0, "", false, 0, nil, nil))
+	mock.ExpectExec("INSERT INTO todo_revisions").WithArgs(5, 1, "restore", nil, `{"task_name":"Old task","completed":false,"due_date":"2024-11-30T23:59:59Z","notes":""}`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO todo_events").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	assert.NoError(t, store.RestoreTodo(5, 1, 0))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE todos t SET deleted_at = NULL").WithArgs(1, 0, 6).WillReturnRows(sqlmock.NewRows(todoRowColumns))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, store.RestoreTodo(6, 1, 0), sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeTodos(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	attachmentRowColumns := []string{"id", "todo_id", "user_id", "file_name", "content_type", "size", "storage_key", "created_at"}
+
+	mock.ExpectQuery("WITH purged AS \\(DELETE FROM todos WHERE deleted_at IS NOT NULL AND id IN \\(SELECT t.id FROM todos t (.+)\\) RETURNING id\\) SELECT (.+) FROM todo_attachments WHERE todo_id IN \\(SELECT id FROM purged\\)").WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows(attachmentRowColumns).AddRow(2, 5, 1, "a.txt", "text/plain", 1, "todos/5/a.txt", createdAt))
+
+	attachments, err := store.EmptyTrash(1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.Attachment{{ID: 2, TodoID: 5, UserID: 1, FileName: "a.txt", ContentType: "text/plain", Size: 1, StorageKey: "todos/5/a.txt", CreatedAt: createdAt}}, attachments)
+
+	before := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("WITH purged AS \\(DELETE FROM todos WHERE deleted_at IS NOT NULL AND deleted_at < \\$1 RETURNING id\\)").WithArgs(before).
+		WillReturnRows(sqlmock.NewRows(attachmentRowColumns))
+
+	attachments, err = store.PurgeTrash(before)
+	assert.NoError(t, err)
+	assert.Empty(t, attachments)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
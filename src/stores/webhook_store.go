@@ -0,0 +1,258 @@
+package stores
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/lib/pq"
+)
+
+const webhookColumns = "id, user_id, COALESCE(workspace_id, 0), url, secret, events, active, failure_count, disabled_at, created_at"
+
+const webhookDeliveryColumns = "d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, COALESCE(d.response_status, 0), d.error, d.next_attempt_at, d.delivered_at, d.created_at"
+
+// webhookLease is how long a claimed delivery is kept from other workers. A
+// worker that dies while sending leaves it to be tried again after that.
+const webhookLease = "5 minutes"
+
+// queueWebhookDeliveriesQuery queues a delivery of each of the events $2 of
+// todo $1 for every active webhook subscribed to it in the todo's space whose
+// user can see the todo. $3 is the todo as JSON.
+const queueWebhookDeliveriesQuery = "INSERT INTO webhook_deliveries (webhook_id, event, payload)" +
+	" SELECT w.id, e.event, jsonb_build_object('event', e.event, 'todo', $3::jsonb)" +
+	" FROM webhooks w CROSS JOIN UNNEST($2::text[]) AS e(event) JOIN todos t ON t.id = $1" +
+	" WHERE w.active AND e.event = ANY(w.events) AND w.workspace_id IS NOT DISTINCT FROM t.workspace_id" +
+	" AND (EXISTS (SELECT 1 FROM users_todos ut WHERE ut.todo_id = t.id AND ut.user_id = w.user_id)" +
+	" OR EXISTS (SELECT 1 FROM projects p JOIN workspace_members wm ON wm.workspace_id = p.workspace_id WHERE p.id = t.project_id AND wm.user_id = w.user_id))"
+
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	var events pq.StringArray
+	err := row.Scan(&webhook.ID, &webhook.UserID, &webhook.WorkspaceID, &webhook.URL, &webhook.Secret, &events, &webhook.Active, &webhook.FailureCount, &webhook.DisabledAt, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Events = events
+	return webhook, nil
+}
+
+// scanWebhookDelivery reads webhookDeliveryColumns, followed by any extra
+// destinations for columns selected after them.
+func scanWebhookDelivery(row rowScanner, extra ...any) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{}
+	var payload []byte
+	dest := []any{&delivery.ID, &delivery.WebhookID, &delivery.Event, &payload, &delivery.Status, &delivery.Attempts, &delivery.ResponseStatus, &delivery.Error, &delivery.NextAttemptAt, &delivery.DeliveredAt, &delivery.CreatedAt}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = payload
+	return delivery, nil
+}
+
+// webhookEvents lists the events of a change recorded as a revision, along
+// with the todo they carry.
+func webhookEvents(action string, before *models.Todo, after *models.Todo) ([]string, *models.Todo) {
+	switch action {
+	case models.RevisionCreate, models.RevisionRestore:
+		return []string{models.EventTodoCreated}, after
+	case models.RevisionUpdate:
+		if after.Completed && !before.Completed {
+			return []string{models.EventTodoUpdated, models.EventTodoCompleted}, after
+		}
+		return []string{models.EventTodoUpdated}, after
+	case models.RevisionDelete:
+		return []string{models.EventTodoDeleted}, before
+	}
+	return nil, nil
+}
+
+// queueWebhookDeliveries queues the webhook deliveries of a change to a todo
+// inside the transaction making it, so they are sent if and only if it
+// commits.
+func queueWebhookDeliveries(transaction *sql.Tx, todoID int, action string, before *models.Todo, after *models.Todo) error {
+	events, todo := webhookEvents(action, before, after)
+	if len(events) == 0 {
+		return nil
+	}
+	data, err := sharedTodoJSON(todo)
+	if err != nil {
+		return err
+	}
+	_, err = transaction.Exec(queueWebhookDeliveriesQuery, todoID, pq.Array(events), data)
+	return err
+}
+
+// sharedTodoJSON encodes a todo as sent to everyone who can see it, without
+// the position and role of the user who changed it.
+func sharedTodoJSON(todo *models.Todo) (string, error) {
+	shared := *todo
+	shared.Position = ""
+	shared.Role = ""
+	data, err := json.Marshal(&shared)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (store *DbStore) CreateWebhook(webhook *models.Webhook) (*models.Webhook, error) {
+	return scanWebhook(store.DB.QueryRow("INSERT INTO webhooks (user_id, workspace_id, url, secret, events) VALUES ($1, NULLIF($2, 0), $3, $4, $5) RETURNING "+webhookColumns,
+		webhook.UserID, webhook.WorkspaceID, webhook.URL, webhook.Secret, pq.Array(webhook.Events)))
+}
+
+// GetWebhooks returns the webhooks of a user in every space.
+func (store *DbStore) GetWebhooks(userID int) ([]*models.Webhook, error) {
+	rows, err := store.DB.Query("SELECT "+webhookColumns+" FROM webhooks WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// GetWebhook returns sql.ErrNoRows for webhooks of other users.
+func (store *DbStore) GetWebhook(webhookID int, userID int) (*models.Webhook, error) {
+	return scanWebhook(store.DB.QueryRow("SELECT "+webhookColumns+" FROM webhooks WHERE id = $1 AND user_id = $2", webhookID, userID))
+}
+
+// UpdateWebhook changes the URL, events and whether a webhook is active.
+// Activating a webhook clears its failures, so one disabled for failing gets
+// a fresh start; its pending deliveries are sent again from then on.
+func (store *DbStore) UpdateWebhook(webhook *models.Webhook) (*models.Webhook, error) {
+	return scanWebhook(store.DB.QueryRow("UPDATE webhooks SET url = $1, events = $2, active = $3,"+
+		" failure_count = CASE WHEN $3 AND NOT active THEN 0 ELSE failure_count END,"+
+		" disabled_at = CASE WHEN $3 THEN NULL ELSE COALESCE(disabled_at, NOW()) END"+
+		" WHERE id = $4 AND user_id = $5 RETURNING "+webhookColumns,
+		webhook.URL, pq.Array(webhook.Events), webhook.Active, webhook.ID, webhook.UserID))
+}
+
+// DeleteWebhook removes a webhook along with its deliveries.
+func (store *DbStore) DeleteWebhook(webhookID int, userID int) error {
+	result, err := store.DB.Exec("DELETE FROM webhooks WHERE id = $1 AND user_id = $2", webhookID, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetWebhookDeliveries returns the latest deliveries of a webhook, newest
+// first.
+func (store *DbStore) GetWebhookDeliveries(webhookID int, limit int) ([]*models.WebhookDelivery, error) {
+	rows, err := store.DB.Query("SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries d WHERE d.webhook_id = $1 ORDER BY d.id DESC LIMIT $2", webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RedeliverWebhook queues a delivery again as a new one with the same
+// payload. It returns sql.ErrNoRows for deliveries of other webhooks.
+func (store *DbStore) RedeliverWebhook(deliveryID int, webhookID int) (*models.WebhookDelivery, error) {
+	return scanWebhookDelivery(store.DB.QueryRow("INSERT INTO webhook_deliveries AS d (webhook_id, event, payload)"+
+		" SELECT webhook_id, event, payload FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2 RETURNING "+webhookDeliveryColumns, deliveryID, webhookID))
+}
+
+// ClaimWebhookDeliveries takes up to limit pending deliveries of active
+// webhooks that are due, oldest first, along with the URL and secret to send
+// them with. Claimed deliveries are leased for webhookLease, so concurrent
+// workers on other servers never send the same one.
+func (store *DbStore) ClaimWebhookDeliveries(limit int) ([]*models.WebhookDelivery, error) {
+	rows, err := store.DB.Query("UPDATE webhook_deliveries d SET next_attempt_at = NOW() + INTERVAL '"+webhookLease+"' FROM webhooks w"+
+		" WHERE w.id = d.webhook_id AND d.id IN (SELECT pd.id FROM webhook_deliveries pd JOIN webhooks pw ON pw.id = pd.webhook_id"+
+		" WHERE pd.status = 'pending' AND pd.next_attempt_at <= NOW() AND pw.active ORDER BY pd.next_attempt_at, pd.id LIMIT $1 FOR UPDATE OF pd SKIP LOCKED)"+
+		" RETURNING "+webhookDeliveryColumns+", w.url, w.secret", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		var url, secret string
+		delivery, err := scanWebhookDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, err
+		}
+		delivery.URL = url
+		delivery.Secret = secret
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RecordWebhookAttempt saves the outcome of sending a delivery: its status,
+// attempts, response and next attempt. A delivered one clears the failures of
+// its webhook; any other adds one, and the webhook is disabled once it failed
+// models.WebhookDisableAfter times in a row.
+func (store *DbStore) RecordWebhookAttempt(delivery *models.WebhookDelivery) error {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	delivered := delivery.Status == models.DeliveryDelivered
+	_, err = transaction.Exec("UPDATE webhook_deliveries SET status = $1, attempts = $2, response_status = NULLIF($3, 0), error = $4, next_attempt_at = $5,"+
+		" delivered_at = CASE WHEN $6 THEN NOW() END WHERE id = $7",
+		delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.Error, delivery.NextAttemptAt, delivered, delivery.ID)
+	if err != nil {
+		return err
+	}
+	_, err = transaction.Exec("UPDATE webhooks SET failure_count = CASE WHEN $1 THEN 0 ELSE failure_count + 1 END,"+
+		" active = active AND ($1 OR failure_count + 1 < $2),"+
+		" disabled_at = CASE WHEN active AND NOT $1 AND failure_count + 1 >= $2 THEN NOW() ELSE disabled_at END WHERE id = $3",
+		delivered, models.WebhookDisableAfter, delivery.WebhookID)
+	if err != nil {
+		return err
+	}
+	return transaction.Commit()
+}
+
+// PurgeWebhookDeliveries deletes the delivered and failed deliveries created
+// before the given time.
+func (store *DbStore) PurgeWebhookDeliveries(before time.Time) error {
+	_, err := store.DB.Exec("DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < $1", before)
+	return err
+}
@@ -0,0 +1,88 @@
+package stores
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueueWebhookDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	before := &models.Todo{ID: 5, TaskName: "Pay rent", Position: "V", Role: "owner"}
+	after := &models.Todo{ID: 5, TaskName: "Pay rent", Completed: true, Position: "V", Role: "owner"}
+	todoJSON := `{"id":5,"task_name":"Pay rent","completed":true,"due_date":"0001-01-01T00:00:00Z","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","notes":"","blocked":false}`
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(queueWebhookDeliveriesQuery)).
+		WithArgs(5, pq.Array([]string{"todo.updated", "todo.completed"}), todoJSON).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO webhook_deliveries").
+		WithArgs(5, pq.Array([]string{"todo.deleted"}), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	transaction, err := db.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, queueWebhookDeliveries(transaction, 5, models.RevisionUpdate, before, after))
+	assert.NoError(t, queueWebhookDeliveries(transaction, 5, models.RevisionDelete, after, nil))
+	assert.NoError(t, transaction.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimWebhookDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 12, 2, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("LIMIT $1 FOR UPDATE OF pd SKIP LOCKED)")).WithArgs(50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id", "event", "payload", "status", "attempts", "response_status", "error", "next_attempt_at", "delivered_at", "created_at", "url", "secret"}).
+			AddRow(7, 3, "todo.created", []byte(`{"event":"todo.created"}`), "pending", 1, 500, "500 Internal Server Error", createdAt, nil, createdAt, "https://example.com/hook", "s3cret"))
+
+	deliveries, err := store.ClaimWebhookDeliveries(50)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.WebhookDelivery{{
+		ID: 7, WebhookID: 3, Event: "todo.created", Payload: []byte(`{"event":"todo.created"}`), Status: "pending", Attempts: 1, ResponseStatus: 500,
+		Error: "500 Internal Server Error", NextAttemptAt: createdAt, CreatedAt: createdAt, URL: "https://example.com/hook", Secret: "s3cret",
+	}}, deliveries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordWebhookAttempt(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	next := time.Date(2024, 12, 2, 9, 4, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE webhook_deliveries SET status = $1")).
+		WithArgs("pending", 3, 502, "502 Bad Gateway", next, false, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE webhooks SET failure_count = CASE WHEN $1 THEN 0 ELSE failure_count + 1 END")).
+		WithArgs(false, models.WebhookDisableAfter, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE webhook_deliveries").WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	assert.NoError(t, store.RecordWebhookAttempt(&models.WebhookDelivery{ID: 7, WebhookID: 3, Status: "pending", Attempts: 3, ResponseStatus: 502, Error: "502 Bad Gateway", NextAttemptAt: next}))
+	assert.ErrorIs(t, store.RecordWebhookAttempt(&models.WebhookDelivery{ID: 7, WebhookID: 3, Status: "delivered", Attempts: 4}), sql.ErrConnDone)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,336 @@
+package stores
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"todo-list/src/models"
+)
+
+// invitationLifetime is how long an emailed invitation can be accepted, as a
+// Postgres interval.
+const invitationLifetime = "7 days"
+
+var (
+	ErrLastWorkspaceOwner = errors.New("a workspace must keep at least one owner")
+	ErrInvitationExpired  = errors.New("the invitation has expired or was already used")
+	ErrInvitationEmail    = errors.New("the invitation was sent to a different email address")
+)
+
+func (store *DbStore) CreateWorkspace(workspace *models.Workspace, userID int) (*models.Workspace, error) {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	newWorkspace := &models.Workspace{Role: models.RoleOwner}
+	err = transaction.QueryRow("INSERT INTO workspaces (name) VALUES ($1) RETURNING id, name, created_at", workspace.Name).Scan(&newWorkspace.ID, &newWorkspace.Name, &newWorkspace.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = transaction.Exec("INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, 'owner')", newWorkspace.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = transaction.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return newWorkspace, nil
+}
+
+func (store *DbStore) GetWorkspaces(userID int) ([]*models.Workspace, error) {
+	rows, err := store.DB.Query("SELECT w.id, w.name, wm.role, w.created_at FROM workspaces w JOIN workspace_members wm ON wm.workspace_id = w.id WHERE wm.user_id = $1 ORDER BY w.name, w.id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := []*models.Workspace{}
+	for rows.Next() {
+		workspace := &models.Workspace{}
+		if err := rows.Scan(&workspace.ID, &workspace.Name, &workspace.Role, &workspace.CreatedAt); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, workspace)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+// GetWorkspace returns the workspace with the user's role in it, or
+// sql.ErrNoRows when they are not a member.
+func (store *DbStore) GetWorkspace(workspaceID int, userID int) (*models.Workspace, error) {
+	workspace := &models.Workspace{}
+	err := store.DB.QueryRow("SELECT w.id, w.name, wm.role, w.created_at FROM workspaces w JOIN workspace_members wm ON wm.workspace_id = w.id WHERE w.id = $1 AND wm.user_id = $2", workspaceID, userID).Scan(&workspace.ID, &workspace.Name, &workspace.Role, &workspace.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+func (store *DbStore) GetWorkspaceMembers(workspaceID int) ([]*models.WorkspaceMember, error) {
+	rows, err := store.DB.Query("SELECT u.id, u.username, u.email, wm.role FROM workspace_members wm JOIN users u ON u.id = wm.user_id WHERE wm.workspace_id = $1 ORDER BY u.id", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []*models.WorkspaceMember{}
+	for rows.Next() {
+		member := &models.WorkspaceMember{}
+		if err := rows.Scan(&member.UserID, &member.UserName, &member.Email, &member.Role); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// UpdateWorkspaceMember changes the role of an existing member. It returns
+// sql.ErrNoRows when the user is not a member.
+func (store *DbStore) UpdateWorkspaceMember(workspaceID int, userID int, role string) error {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	result, err := transaction.Exec("UPDATE workspace_members SET role = $1 WHERE workspace_id = $2 AND user_id = $3", role, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		err = sql.ErrNoRows
+		return err
+	}
+
+	err = ensureWorkspaceOwner(transaction, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	return transaction.Commit()
+}
+
+func (store *DbStore) RemoveWorkspaceMember(workspaceID int, userID int) error {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	_, err = transaction.Exec("DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2", workspaceID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Former members keep the todos shared with them directly, so only
+	// assignments on todos they can no longer see are dropped.
+	_, err = transaction.Exec("DELETE FROM todo_assignees a USING todos t WHERE a.todo_id = t.id AND t.workspace_id = $1 AND a.user_id = $2 AND NOT EXISTS (SELECT 1 FROM users_todos ut WHERE ut.todo_id = t.id AND ut.user_id = $2)", workspaceID, userID)
+	if err != nil {
+		return err
+	}
+
+	err = ensureWorkspaceOwner(transaction, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	return transaction.Commit()
+}
+
+// ensureWorkspaceOwner fails with ErrLastWorkspaceOwner when a change left the
+// workspace without an owner, so the caller's transaction is rolled back.
+func ensureWorkspaceOwner(transaction rowQuerier, workspaceID int) error {
+	var owners int
+	err := transaction.QueryRow("SELECT COUNT(*) FROM workspace_members WHERE workspace_id = $1 AND role = 'owner'", workspaceID).Scan(&owners)
+	if err != nil {
+		return err
+	}
+	if owners == 0 {
+		return ErrLastWorkspaceOwner
+	}
+	return nil
+}
+
+// CreateInvitation saves an invitation that can be accepted with its Token
+// for invitationLifetime. Only a hash of the token is stored.
+func (store *DbStore) CreateInvitation(invitation *models.Invitation) (*models.Invitation, error) {
+	newInvitation := *invitation
+	err := store.DB.QueryRow("INSERT INTO workspace_invitations (workspace_id, email, role, token_hash, invited_by, expires_at) VALUES ($1, $2, $3, $4, $5, NOW() + $6::interval) RETURNING id, expires_at, created_at",
+		invitation.WorkspaceID, invitation.Email, invitation.Role, hashToken(invitation.Token), invitation.InvitedBy, invitationLifetime).Scan(&newInvitation.ID, &newInvitation.ExpiresAt, &newInvitation.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &newInvitation, nil
+}
+
+// AcceptInvitation adds the user to the workspace the token invites them to.
+// Unknown tokens give sql.ErrNoRows. Members who already belong to the
+// workspace keep their current role.
+func (store *DbStore) AcceptInvitation(token string, user *models.User) (*models.Workspace, error) {
+	transaction, err := store.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			transaction.Rollback()
+		}
+	}()
+
+	var invitationID, workspaceID int
+	var email, role string
+	var usable bool
+	err = transaction.QueryRow("SELECT id, workspace_id, email, role, accepted_at IS NULL AND expires_at > NOW() FROM workspace_invitations WHERE token_hash = $1 FOR UPDATE", hashToken(token)).Scan(&invitationID, &workspaceID, &email, &role, &usable)
+	if err != nil {
+		return nil, err
+	}
+	if !usable {
+		err = ErrInvitationExpired
+		return nil, err
+	}
+	if !strings.EqualFold(email, user.Email) {
+		err = ErrInvitationEmail
+		return nil, err
+	}
+
+	_, err = transaction.Exec("INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3) ON CONFLICT (workspace_id, user_id) DO NOTHING", workspaceID, user.ID, role)
+	if err != nil {
+		return nil, err
+	}
+	_, err = transaction.Exec("UPDATE workspace_invitations SET accepted_at = NOW() WHERE id = $1", invitationID)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace := &models.Workspace{}
+	err = transaction.QueryRow("SELECT w.id, w.name, wm.role, w.created_at FROM workspaces w JOIN workspace_members wm ON wm.workspace_id = w.id WHERE w.id = $1 AND wm.user_id = $2", workspaceID, user.ID).Scan(&workspace.ID, &workspace.Name, &workspace.Role, &workspace.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = transaction.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (store *DbStore) CreateProject(project *models.Project) (*models.Project, error) {
+	newProject := &models.Project{}
+	err := store.DB.QueryRow("INSERT INTO projects (workspace_id, name) VALUES ($1, $2) RETURNING id, workspace_id, name, created_at", project.WorkspaceID, project.Name).Scan(&newProject.ID, &newProject.WorkspaceID, &newProject.Name, &newProject.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return newProject, nil
+}
+
+func (store *DbStore) GetProjects(workspaceID int) ([]*models.Project, error) {
+	rows, err := store.DB.Query("SELECT id, workspace_id, name, created_at FROM projects WHERE workspace_id = $1 ORDER BY name, id", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []*models.Project{}
+	for rows.Next() {
+		project := &models.Project{}
+		if err := rows.Scan(&project.ID, &project.WorkspaceID, &project.Name, &project.CreatedAt); err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (store *DbStore) GetProject(projectID int, workspaceID int) (*models.Project, error) {
+	project := &models.Project{}
+	err := store.DB.QueryRow("SELECT id, workspace_id, name, created_at FROM projects WHERE id = $1 AND workspace_id = $2", projectID, workspaceID).Scan(&project.ID, &project.WorkspaceID, &project.Name, &project.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// GetMemberProjects returns the projects of every workspace the user is a
+// member of, only the one of projectID unless it is 0.
+func (store *DbStore) GetMemberProjects(userID int, projectID int) ([]*models.MemberProject, error) {
+	rows, err := store.DB.Query("SELECT p.id, p.workspace_id, p.name, p.created_at, w.name, wm.role FROM projects p JOIN workspaces w ON w.id = p.workspace_id"+
+		" JOIN workspace_members wm ON wm.workspace_id = w.id WHERE wm.user_id = $1 AND ($2 = 0 OR p.id = $2) ORDER BY w.name, w.id, p.name, p.id", userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []*models.MemberProject{}
+	for rows.Next() {
+		project := &models.MemberProject{}
+		if err := rows.Scan(&project.ID, &project.WorkspaceID, &project.Name, &project.CreatedAt, &project.WorkspaceName, &project.Role); err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// DeleteProject removes the project. Its todos stay in the workspace, visible
+// only to the users they were created by or shared with.
+func (store *DbStore) DeleteProject(projectID int, workspaceID int) error {
+	result, err := store.DB.Exec("DELETE FROM projects WHERE id = $1 AND workspace_id = $2", projectID, workspaceID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package stores
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"todo-list/src/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateWorkspace(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO workspaces \\(name\\) VALUES \\(\\$1\\) RETURNING id, name, created_at").WithArgs("Team").WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at"}).AddRow(4, "Team", createdAt))
+	mock.ExpectExec("INSERT INTO workspace_members \\(workspace_id, user_id, role\\) VALUES \\(\\$1, \\$2, 'owner'\\)").WithArgs(4, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	workspace, err := store.CreateWorkspace(&models.Workspace{Name: "Team"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Workspace{ID: 4, Name: "Team", Role: "owner", CreatedAt: createdAt}, workspace)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateWorkspaceMember(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	type testCase struct {
+		name        string
+		mockSetup   func()
+		expectedErr error
+	}
+
+	tests := []testCase{
+		{
+			name: "Change a member's role",
+			mockSetup: func() {
+				mock.ExpectExec("UPDATE workspace_members SET role = \\$1 WHERE workspace_id = \\$2 AND user_id = \\$3").WithArgs("editor", 4, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM workspace_members WHERE workspace_id = \\$1 AND role = 'owner'").WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Not a member",
+			mockSetup: func() {
+				mock.ExpectExec("UPDATE workspace_members").WithArgs("editor", 4, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			expectedErr: sql.ErrNoRows,
+		},
+		{
+			name: "Demoting the last owner",
+			mockSetup: func() {
+				mock.ExpectExec("UPDATE workspace_members").WithArgs("editor", 4, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM workspace_members").WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				mock.ExpectRollback()
+			},
+			expectedErr: ErrLastWorkspaceOwner,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock.ExpectBegin()
+			tc.mockSetup()
+			err := store.UpdateWorkspaceMember(4, 2, "editor")
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreateInvitation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+
+	mock.ExpectQuery("INSERT INTO workspace_invitations (.+) NOW\\(\\) \\+ \\$6::interval\\) RETURNING id, expires_at, created_at").
+		WithArgs(4, "friend@mail.com", "editor", hashToken("secret-token"), 1, "7 days").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "expires_at", "created_at"}).AddRow(9, createdAt.AddDate(0, 0, 7), createdAt))
+
+	invitation, err := store.CreateInvitation(&models.Invitation{WorkspaceID: 4, Email: "friend@mail.com", Role: "editor", InvitedBy: 1, Token: "secret-token"})
+	assert.NoError(t, err)
+	assert.Equal(t, 9, invitation.ID)
+	assert.Equal(t, createdAt.AddDate(0, 0, 7), invitation.ExpiresAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 11, 30, 23, 59, 59, 0, time.UTC)
+	user := &models.User{ID: 2, Email: "Friend@Mail.com"}
+	invitationColumns := []string{"id", "workspace_id", "email", "role", "usable"}
+
+	type testCase struct {
+		name              string
+		mockSetup         func()
+		expectedWorkspace *models.Workspace
+		expectedErr       error
+	}
+
+	tests := []testCase{
+		{
+			name: "Accept a pending invitation",
+			mockSetup: func() {
+				mock.ExpectQuery("SELECT (.+) FROM workspace_invitations WHERE token_hash = \\$1 FOR UPDATE").WithArgs(hashToken("secret-token")).WillReturnRows(sqlmock.NewRows(invitationColumns).AddRow(9, 4, "friend@mail.com", "editor", true))
+				mock.ExpectExec("INSERT INTO workspace_members (.+) ON CONFLICT \\(workspace_id, user_id\\) DO NOTHING").WithArgs(4, 2, "editor").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE workspace_invitations SET accepted_at = NOW\\(\\) WHERE id = \\$1").WithArgs(9).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT w.id, w.name, wm.role, w.created_at FROM workspaces w").WithArgs(4, 2).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "role", "created_at"}).AddRow(4, "Team", "editor", createdAt))
+				mock.ExpectCommit()
+			},
+			expectedWorkspace: &models.Workspace{ID: 4, Name: "Team", Role: "editor", CreatedAt: createdAt},
+		},
+		{
+			name: "Unknown token",
+			mockSetup: func() {
+				mock.ExpectQuery("SELECT (.+) FROM workspace_invitations").WithArgs(hashToken("secret-token")).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedErr: sql.ErrNoRows,
+		},
+		{
+			name: "Expired or used invitation",
+			mockSetup: func() {
+				mock.ExpectQuery("SELECT (.+) FROM workspace_invitations").WithArgs(hashToken("secret-token")).WillReturnRows(sqlmock.NewRows(invitationColumns).AddRow(9, 4, "friend@mail.com", "editor", false))
+				mock.ExpectRollback()
+			},
+			expectedErr: ErrInvitationExpired,
+		},
+		{
+			name: "Invitation for someone else",
+			mockSetup: func() {
+				mock.ExpectQuery("SELECT (.+) FROM workspace_invitations").WithArgs(hashToken("secret-token")).WillReturnRows(sqlmock.NewRows(invitationColumns).AddRow(9, 4, "other@mail.com", "editor", true))
+				mock.ExpectRollback()
+			},
+			expectedErr: ErrInvitationEmail,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock.ExpectBegin()
+			tc.mockSetup()
+			workspace, err := store.AcceptInvitation("secret-token", user)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedWorkspace, workspace)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteProject(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectExec("DELETE FROM projects WHERE id = \\$1 AND workspace_id = \\$2").WithArgs(3, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, store.DeleteProject(3, 4))
+
+	mock.ExpectExec("DELETE FROM projects WHERE id = \\$1 AND workspace_id = \\$2").WithArgs(3, 5).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, store.DeleteProject(3, 5), sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMemberProjects(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+	createdAt := time.Date(2024, 12, 1, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT (.+) FROM projects p JOIN workspaces w (.+) WHERE wm.user_id = \\$1 AND \\(\\$2 = 0 OR p.id = \\$2\\)").WithArgs(1, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workspace_id", "name", "created_at", "name", "role"}).AddRow(7, 4, "Launch", createdAt, "Acme", "editor"))
+
+	projects, err := store.GetMemberProjects(1, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.MemberProject{{Project: models.Project{ID: 7, WorkspaceID: 4, Name: "Launch", CreatedAt: createdAt}, WorkspaceName: "Acme", Role: "editor"}}, projects)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRemoveWorkspaceMember(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	store := &DbStore{DB: db}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM workspace_members WHERE workspace_id = \\$1 AND user_id = \\$2").WithArgs(4, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM todo_assignees a USING todos t WHERE (.+) NOT EXISTS").WithArgs(4, 2).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM workspace_members").WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, store.RemoveWorkspaceMember(4, 2))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,31 @@
+package validations
+
+import (
+	"fmt"
+	"strconv"
+	"todo-list/src/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func ValidateAppPassword(appPassword *models.AppPassword) map[string]string {
+	errors := make(map[string]string)
+	err := validate.Struct(appPassword)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			var errorMessage string
+
+			switch err.Tag() {
+			case "required":
+				errorMessage = "This field is required"
+			case "max":
+				maxValue, _ := strconv.Atoi(err.Param())
+				errorMessage = fmt.Sprintf("This field must be at most %d characters", maxValue)
+			default:
+				errorMessage = fmt.Sprintf("failed on the '%s' tag", err.Tag())
+			}
+			errors[err.Field()] = errorMessage
+		}
+	}
+	return errors
+}
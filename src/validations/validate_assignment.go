@@ -0,0 +1,30 @@
+package validations
+
+import (
+	"fmt"
+	"strconv"
+	"todo-list/src/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func ValidateAssignment(assignment *models.Assignment) map[string]string {
+	errors := make(map[string]string)
+	err := validate.Struct(assignment)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			var errorMessage string
+			switch err.Tag() {
+			case "max":
+				maxValue, _ := strconv.Atoi(err.Param())
+				errorMessage = fmt.Sprintf("At most %d assignees are allowed", maxValue)
+			case "gt":
+				errorMessage = "Must be a valid user id"
+			default:
+				errorMessage = fmt.Sprintf("failed on the '%s' tag", err.Tag())
+			}
+			errors[err.Field()] = errorMessage
+		}
+	}
+	return errors
+}
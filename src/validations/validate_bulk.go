@@ -0,0 +1,63 @@
+package validations
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"todo-list/src/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidateBulk checks a bulk request. Errors are keyed by the path of the
+// offending field, e.g. "Operations[1].Tags".
+func ValidateBulk(request *models.BulkRequest) map[string]string {
+	errors := make(map[string]string)
+	err := validate.Struct(request)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			var errorMessage string
+			switch err.Tag() {
+			case "required":
+				errorMessage = "This field is required"
+			case "min":
+				errorMessage = fmt.Sprintf("At least %s items are required", err.Param())
+			case "max":
+				maxValue, _ := strconv.Atoi(err.Param())
+				if err.Kind() == reflect.Slice {
+					errorMessage = fmt.Sprintf("At most %d items are allowed", maxValue)
+				} else {
+					errorMessage = fmt.Sprintf("This field must be at most %d characters", maxValue)
+				}
+			case "oneof":
+				errorMessage = fmt.Sprintf("Must be one of: %s", strings.ReplaceAll(err.Param(), " ", ", "))
+			case "gt", "gte":
+				errorMessage = "Must be a valid id"
+			case "excludesall":
+				errorMessage = "Tags can not contain commas"
+			default:
+				errorMessage = fmt.Sprintf("failed on the '%s' tag", err.Tag())
+			}
+			errors[strings.TrimPrefix(err.Namespace(), "BulkRequest.")] = errorMessage
+		}
+	}
+
+	for i, operation := range request.Operations {
+		field := fmt.Sprintf("Operations[%d]", i)
+		if (len(operation.IDs) == 0) == (operation.Filter == nil && operation.Query == "") {
+			errors[field] = "Either ids or a filter or query is required"
+		}
+		switch operation.Op {
+		case models.BulkMove:
+			if operation.ProjectID == nil {
+				errors[field+".ProjectID"] = "This field is required"
+			}
+		case models.BulkTag, models.BulkUntag:
+			if len(operation.Tags) == 0 {
+				errors[field+".Tags"] = "This field is required"
+			}
+		}
+	}
+	return errors
+}
@@ -0,0 +1,150 @@
+package validations
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/src/models"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	maxTextFieldLength = 1000
+	maxURLFieldLength  = 2000
+)
+
+// ValidateCustomField checks the definition of a custom field. Select and
+// multi-select fields need options; other fields may not have any.
+func ValidateCustomField(field *models.CustomField) map[string]string {
+	errors := make(map[string]string)
+	err := validate.Struct(field)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			var errorMessage string
+			switch err.Tag() {
+			case "required":
+				errorMessage = "This field is required"
+			case "max":
+				maxValue, _ := strconv.Atoi(err.Param())
+				if err.Kind() == reflect.Slice {
+					errorMessage = fmt.Sprintf("At most %d items are allowed", maxValue)
+				} else {
+					errorMessage = fmt.Sprintf("This field must be at most %d characters", maxValue)
+				}
+			case "fieldkey":
+				errorMessage = "Must start with a lower case letter followed by lower case letters, digits or underscores"
+			case "oneof":
+				errorMessage = "Must be one of: text, number, date, select, multi_select, url"
+			default:
+				errorMessage = fmt.Sprintf("failed on the '%s' tag", err.Tag())
+			}
+			errors[strings.TrimPrefix(err.Namespace(), "CustomField.")] = errorMessage
+		}
+	}
+
+	selectField := field.Type == models.FieldSelect || field.Type == models.FieldMultiSelect
+	if selectField && len(field.Options) == 0 {
+		errors["Options"] = "Select fields need at least one option"
+	} else if !selectField && len(field.Options) > 0 {
+		errors["Options"] = "Only select fields have options"
+	} else if hasDuplicates(field.Options) {
+		errors["Options"] = "Options must be unique"
+	}
+	return errors
+}
+
+// ValidateCustomFieldValues checks the custom field values of a todo against
+// the fields of its project. Errors are keyed like "CustomFields.estimate".
+func ValidateCustomFieldValues(values map[string]any, fields []*models.CustomField) map[string]string {
+	errors := make(map[string]string)
+	defined := map[string]bool{}
+	for _, field := range fields {
+		defined[field.Key] = true
+		value, ok := values[field.Key]
+		if !ok || value == nil {
+			if field.Required {
+				errors["CustomFields."+field.Key] = "This field is required"
+			}
+			continue
+		}
+		if message := customFieldError(field, value); message != "" {
+			errors["CustomFields."+field.Key] = message
+		}
+	}
+	for key := range values {
+		if !defined[key] {
+			errors["CustomFields."+key] = "Unknown field"
+		}
+	}
+	return errors
+}
+
+// customFieldError describes what is wrong with value for field, or returns
+// an empty string. Values are as decoded from JSON: numbers are float64 and
+// lists []any.
+func customFieldError(field *models.CustomField, value any) string {
+	text, isText := value.(string)
+	switch field.Type {
+	case models.FieldText:
+		if !isText || utf8.RuneCountInString(text) > maxTextFieldLength {
+			return fmt.Sprintf("Must be text of at most %d characters", maxTextFieldLength)
+		}
+	case models.FieldNumber:
+		if _, ok := value.(float64); !ok {
+			return "Must be a number"
+		}
+	case models.FieldDate:
+		if _, err := time.Parse("2006-01-02", text); !isText || err != nil {
+			return "Must be a date such as 2024-05-01"
+		}
+	case models.FieldSelect:
+		if !isText || !hasOption(field, text) {
+			return "Must be one of: " + strings.Join(field.Options, ", ")
+		}
+	case models.FieldMultiSelect:
+		list, ok := value.([]any)
+		chosen := map[string]bool{}
+		for _, item := range list {
+			option, isOption := item.(string)
+			if !isOption || !hasOption(field, option) || chosen[option] {
+				ok = false
+				break
+			}
+			chosen[option] = true
+		}
+		if !ok {
+			return "Must be a list of different options from: " + strings.Join(field.Options, ", ")
+		}
+	case models.FieldURL:
+		link, err := url.Parse(text)
+		if !isText || err != nil || len(text) > maxURLFieldLength || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+			return fmt.Sprintf("Must be an http or https URL of at most %d characters", maxURLFieldLength)
+		}
+	}
+	return ""
+}
+
+func hasOption(field *models.CustomField, option string) bool {
+	for _, candidate := range field.Options {
+		if candidate == option {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDuplicates(values []string) bool {
+	seen := map[string]bool{}
+	for _, value := range values {
+		if seen[value] {
+			return true
+		}
+		seen[value] = true
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package validations
+
+import (
+	"fmt"
+	"strconv"
+	"todo-list/src/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func ValidateQuickAdd(quickAdd *models.QuickAdd) map[string]string {
+	errors := make(map[string]string)
+	err := validate.Struct(quickAdd)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			var errorMessage string
+
+			switch err.Tag() {
+			case "required":
+				errorMessage = "This field is required"
+			case "max":
+				maxValue, _ := strconv.Atoi(err.Param())
+				errorMessage = fmt.Sprintf("This field must be at most %d characters", maxValue)
+			case "timezone":
+				errorMessage = "Must be an IANA time zone such as Europe/Berlin"
+			default:
+				errorMessage = fmt.Sprintf("failed on the '%s' tag", err.Tag())
+			}
+			errors[err.Field()] = errorMessage
+		}
+	}
+	return errors
+}
@@ -0,0 +1,33 @@
+package validations
+
+import (
+	"fmt"
+	"strconv"
+	"todo-list/src/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidateSavedFilter checks the name and the length of the query; whether
+// the query reads is up to filterexpr.Parse, whose errors point at the token.
+func ValidateSavedFilter(filter *models.SavedFilter) map[string]string {
+	errors := make(map[string]string)
+	err := validate.Struct(filter)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			var errorMessage string
+
+			switch err.Tag() {
+			case "required":
+				errorMessage = "This field is required"
+			case "max":
+				maxValue, _ := strconv.Atoi(err.Param())
+				errorMessage = fmt.Sprintf("This field must be at most %d characters", maxValue)
+			default:
+				errorMessage = fmt.Sprintf("failed on the '%s' tag", err.Tag())
+			}
+			errors[err.Field()] = errorMessage
+		}
+	}
+	return errors
+}
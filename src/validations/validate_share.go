@@ -0,0 +1,31 @@
+package validations
+
+import (
+	"fmt"
+	"strings"
+	"todo-list/src/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func ValidateShare(share *models.ShareRequest) map[string]string {
+	errors := make(map[string]string)
+	err := validate.Struct(share)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			var errorMessage string
+			switch err.Tag() {
+			case "required":
+				errorMessage = "This field is required"
+			case "email":
+				errorMessage = "Not a valid email address"
+			case "oneof":
+				errorMessage = fmt.Sprintf("Must be one of: %s", strings.ReplaceAll(err.Param(), " ", ", "))
+			default:
+				errorMessage = fmt.Sprintf("failed on the '%s' tag", err.Tag())
+			}
+			errors[err.Field()] = errorMessage
+		}
+	}
+	return errors
+}
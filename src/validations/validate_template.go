@@ -0,0 +1,95 @@
+package validations
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"todo-list/src/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidateTemplate checks a template and every item in its tree. Errors are
+// keyed by the path of the offending field, e.g. "Items[0].Children[2].Tags".
+func ValidateTemplate(template *models.Template) map[string]string {
+	errors := make(map[string]string)
+	err := validate.Struct(template)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			var errorMessage string
+			switch err.Tag() {
+			case "required":
+				errorMessage = "This field is required"
+			case "min":
+				errorMessage = fmt.Sprintf("At least %s items are required", err.Param())
+			case "max":
+				maxValue, _ := strconv.Atoi(err.Param())
+				if err.Kind() == reflect.Slice {
+					errorMessage = fmt.Sprintf("At most %d items are allowed", maxValue)
+				} else {
+					errorMessage = fmt.Sprintf("This field must be at most %d characters", maxValue)
+				}
+			case "excludesall":
+				errorMessage = "Tags can not contain commas"
+			case "gte", "lte":
+				if err.Field() == "Priority" {
+					errorMessage = "Must be between 0 and 4"
+				} else {
+					errorMessage = "Must be at most 3650 days from the start"
+				}
+			default:
+				errorMessage = fmt.Sprintf("failed on the '%s' tag", err.Tag())
+			}
+			errors[strings.TrimPrefix(err.Namespace(), "Template.")] = errorMessage
+		}
+	}
+
+	count, depth := templateSize(template.Items)
+	if count > models.TemplateMaxTodos || depth > models.TemplateMaxDepth {
+		errors["Items"] = fmt.Sprintf("At most %d todos nested %d deep are allowed", models.TemplateMaxTodos, models.TemplateMaxDepth)
+	}
+	return errors
+}
+
+// templateSize counts the items of a tree and the levels they nest in.
+func templateSize(items []*models.TemplateItem) (int, int) {
+	count, depth := len(items), 0
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		childCount, childDepth := templateSize(item.Children)
+		count += childCount
+		depth = max(depth, childDepth)
+	}
+	if len(items) > 0 {
+		depth++
+	}
+	return count, depth
+}
+
+// ValidateTemplateInstantiation checks the variables given to fill in a
+// template.
+func ValidateTemplateInstantiation(instantiation *models.TemplateInstantiation) map[string]string {
+	errors := make(map[string]string)
+	err := validate.Struct(instantiation)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			var errorMessage string
+			switch err.Tag() {
+			case "max":
+				maxValue, _ := strconv.Atoi(err.Param())
+				if err.Kind() == reflect.Map {
+					errorMessage = fmt.Sprintf("At most %d variables are allowed", maxValue)
+				} else {
+					errorMessage = fmt.Sprintf("This field must be at most %d characters", maxValue)
+				}
+			default:
+				errorMessage = fmt.Sprintf("failed on the '%s' tag", err.Tag())
+			}
+			errors[strings.TrimPrefix(err.Namespace(), "TemplateInstantiation.")] = errorMessage
+		}
+	}
+	return errors
+}
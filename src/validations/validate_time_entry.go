@@ -0,0 +1,42 @@
+package validations
+
+import (
+	"fmt"
+	"strconv"
+	"todo-list/src/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidateTimeEntry checks an entry logged by hand, which unlike a running
+// timer needs an end.
+func ValidateTimeEntry(entry *models.TimeEntry) map[string]string {
+	return timeErrors(validate.Struct(entry))
+}
+
+func ValidateTimerStart(start *models.TimerStart) map[string]string {
+	return timeErrors(validate.Struct(start))
+}
+
+func timeErrors(err error) map[string]string {
+	errors := make(map[string]string)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			var errorMessage string
+
+			switch err.Tag() {
+			case "required":
+				errorMessage = "This field is required"
+			case "max":
+				maxValue, _ := strconv.Atoi(err.Param())
+				errorMessage = fmt.Sprintf("This field must be at most %d characters", maxValue)
+			case "gtfield":
+				errorMessage = "Must be after the start"
+			default:
+				errorMessage = fmt.Sprintf("failed on the '%s' tag", err.Tag())
+			}
+			errors[err.Field()] = errorMessage
+		}
+	}
+	return errors
+}
@@ -2,18 +2,35 @@ package validations
 
 import (
 	"fmt"
+	"reflect"
+	"regexp"
 	"strconv"
+	"todo-list/src/lib"
 	"todo-list/src/models"
 
 	"github.com/go-playground/validator/v10"
 )
 
-var validate *validator.Validate
+var validate = newValidator()
 
-func init() {
-	validate = validator.New()
+// newValidator sets up the validator shared by every model, with the custom
+// tags they use.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterValidation("rrule", func(fl validator.FieldLevel) bool {
+		_, err := lib.ParseRecurrence(fl.Field().String())
+		return err == nil
+	})
+	v.RegisterValidation("fieldkey", func(fl validator.FieldLevel) bool {
+		return fieldKeyPattern.MatchString(fl.Field().String())
+	})
+	return v
 }
 
+// fieldKeyPattern matches the keys of custom fields, such as "estimate" or
+// "due_quarter".
+var fieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
 func ValidateTodo(todo *models.Todo) map[string]string {
 	errors := make(map[string]string)
 	err := validate.Struct(todo)
@@ -27,6 +44,19 @@ func ValidateTodo(todo *models.Todo) map[string]string {
 			case "min":
 				minValue, _ := strconv.Atoi(err.Param())
 				errorMessage = fmt.Sprintf("This field must be longer than %d characters", minValue)
+			case "max":
+				maxValue, _ := strconv.Atoi(err.Param())
+				if err.Kind() == reflect.Slice {
+					errorMessage = fmt.Sprintf("At most %d items are allowed", maxValue)
+				} else {
+					errorMessage = fmt.Sprintf("This field must be at most %d characters", maxValue)
+				}
+			case "excludesall":
+				errorMessage = "Tags can not contain commas"
+			case "gte", "lte":
+				errorMessage = "Must be between 0 and 4"
+			case "rrule":
+				errorMessage = "Must be a recurrence rule such as FREQ=WEEKLY;BYDAY=MO"
 			default:
 				errorMessage = fmt.Sprintf("failed on the '%s' tag", err.Tag())
 			}
@@ -8,10 +8,6 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
-// var validateUser *validator.Validate
-func init() {
-	validate = validator.New()
-}
 func ValidateUser(user *models.User) map[string]string {
 	errors := make(map[string]string)
 	err := validate.Struct(user)
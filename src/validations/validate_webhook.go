@@ -0,0 +1,60 @@
+package validations
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"todo-list/src/lib"
+	"todo-list/src/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidateWebhook checks the URL and events of a webhook. Only http and https
+// URLs are accepted, and not ones naming a local or private address. Host
+// names are checked again on every delivery, against the addresses they
+// resolve to then.
+func ValidateWebhook(webhook *models.Webhook) map[string]string {
+	errors := make(map[string]string)
+	err := validate.Struct(webhook)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			var errorMessage string
+
+			switch err.Tag() {
+			case "required":
+				errorMessage = "This field is required"
+			case "url":
+				errorMessage = "Must be a URL"
+			case "min":
+				errorMessage = fmt.Sprintf("At least %s items are required", err.Param())
+			case "max":
+				maxValue, _ := strconv.Atoi(err.Param())
+				if err.Kind() == reflect.Slice {
+					errorMessage = fmt.Sprintf("At most %d items are allowed", maxValue)
+				} else {
+					errorMessage = fmt.Sprintf("This field must be at most %d characters", maxValue)
+				}
+			case "oneof":
+				errorMessage = "Must be one of: " + strings.Join(strings.Fields(err.Param()), ", ")
+			default:
+				errorMessage = fmt.Sprintf("failed on the '%s' tag", err.Tag())
+			}
+			errors[strings.TrimPrefix(err.Namespace(), "Webhook.")] = errorMessage
+		}
+	}
+
+	if _, ok := errors["URL"]; !ok {
+		if parsed, err := url.Parse(webhook.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			errors["URL"] = "Must be an http or https URL"
+		} else if host := strings.ToLower(parsed.Hostname()); host == "localhost" || strings.HasSuffix(host, ".localhost") {
+			errors["URL"] = "Must not be a local or private address"
+		} else if ip := net.ParseIP(host); ip != nil && !lib.PublicAddress(ip) {
+			errors["URL"] = "Must not be a local or private address"
+		}
+	}
+	return errors
+}
@@ -0,0 +1,53 @@
+package validations
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"todo-list/src/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func ValidateWorkspace(workspace *models.Workspace) map[string]string {
+	return workspaceErrors(workspace)
+}
+
+func ValidateWorkspaceMember(member *models.WorkspaceMember) map[string]string {
+	return workspaceErrors(member)
+}
+
+func ValidateInvitation(invitation *models.Invitation) map[string]string {
+	return workspaceErrors(invitation)
+}
+
+func ValidateProject(project *models.Project) map[string]string {
+	return workspaceErrors(project)
+}
+
+// workspaceErrors validates any of the workspace models, which share the same
+// set of tags.
+func workspaceErrors(value interface{}) map[string]string {
+	errors := make(map[string]string)
+	err := validate.Struct(value)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			var errorMessage string
+			switch err.Tag() {
+			case "required":
+				errorMessage = "This field is required"
+			case "max":
+				maxValue, _ := strconv.Atoi(err.Param())
+				errorMessage = fmt.Sprintf("This field must be at most %d characters", maxValue)
+			case "email":
+				errorMessage = "Not a valid email address"
+			case "oneof":
+				errorMessage = fmt.Sprintf("Must be one of: %s", strings.ReplaceAll(err.Param(), " ", ", "))
+			default:
+				errorMessage = fmt.Sprintf("failed on the '%s' tag", err.Tag())
+			}
+			errors[err.Field()] = errorMessage
+		}
+	}
+	return errors
+}